@@ -10,13 +10,14 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
-	"runtime"
+	"sync"
 	"time"
 
 	"github.com/czechbol/lumeon/app/config"
 	"github.com/czechbol/lumeon/core"
 	"github.com/czechbol/lumeon/core/hardware"
 	"github.com/czechbol/lumeon/core/hardware/i2c"
+	"github.com/czechbol/lumeon/core/notify"
 	"github.com/czechbol/lumeon/core/resources"
 	"gitlab.com/greyxor/slogor"
 )
@@ -39,7 +40,15 @@ type App interface {
 // CoreApp implements App interface.
 type CoreApp struct {
 	config       config.Config
+	platform     platform
 	coreServices *core.CoreServices
+
+	// fanBus is the bus the fan board is looked for on once Run starts, nil
+	// if it was found or there is no bus.
+	fanBus i2c.I2CBus
+	// mutex guards coreServices.FanService, which is set late when the fan
+	// board is found after startup.
+	mutex sync.Mutex
 }
 
 // NewCoreApp constructs App.
@@ -63,45 +72,123 @@ func (app *CoreApp) Init() {
 
 	slog.Info(fmt.Sprintf("starting %s", serviceName), "version", version, "commit", gitCommit, "buildDate", buildDate)
 
-	archCheck()
+	app.platform = newPlatform(app.config)
+	i2cBus, oled, fan := app.initHardware()
 
-	i2cBus, err := i2c.NewBus("")
-	if err != nil {
-		slog.Error("failed to initialize i2c bus", "error", err)
-		os.Exit(1)
+	drivesConfig := app.config.DrivesConfig()
+	selfTests := make([]resources.SelfTestSchedule, 0, len(drivesConfig.SelfTests()))
+	for _, st := range drivesConfig.SelfTests() {
+		schedule, err := resources.NewSelfTestSchedule(st.Drive, st.Type, st.Schedule)
+		if err != nil {
+			slog.Error("invalid drive self-test schedule", "drive", st.Drive, "error", err)
+			os.Exit(1)
+		}
+		selfTests = append(selfTests, schedule)
 	}
 
-	oled, err := hardware.NewOLED(i2cBus)
-	if err != nil {
-		slog.Error("failed to initialize OLED display", "error", err)
-		os.Exit(1)
+	drives := app.platform.drives(resources.HDDOptions{
+		Bays:        drivesConfig.Bays(),
+		StateDir:    drivesConfig.StateDir(),
+		AcceptAfter: drivesConfig.AcceptAfter(),
+		SelfTests:   selfTests,
+	})
+	alerts := core.NewAlertManager()
+
+	notifyConfig := app.config.NotifyConfig()
+	sinks := make([]notify.Sink, 0, len(notifyConfig.Sinks()))
+	for _, sc := range notifyConfig.Sinks() {
+		sink, err := notify.New(notify.Options{
+			Name:          sc.Name,
+			Type:          sc.Type,
+			URL:           sc.URL,
+			Token:         sc.Token,
+			Host:          sc.Host,
+			Port:          sc.Port,
+			Username:      sc.Username,
+			Password:      sc.Password,
+			From:          sc.From,
+			To:            sc.To,
+			TLS:           sc.TLS,
+			TitleTemplate: sc.Title,
+			BodyTemplate:  sc.Body,
+		})
+		if err != nil {
+			slog.Error("invalid notification sink", "sink", sc.Name, "error", err)
+			os.Exit(1)
+		}
+		sinks = append(sinks, sink)
 	}
 
-	cpu := resources.NewCPU()
-	drives := resources.NewHDD()
+	fsConfig := app.config.FilesystemsConfig()
+	upsConfig := app.config.UPSConfig()
+	sampler := core.NewSampler(core.Probers{
+		CPU:     app.platform.cpu(),
+		Memory:  resources.NewMemory(),
+		Network: resources.NewNetwork(),
+		DiskIO:  resources.NewDiskIO(),
+		Drives:  drives,
+		Filesystems: resources.NewFilesystems(resources.FilesystemOptions{
+			Include: fsConfig.Include(),
+			Exclude: fsConfig.Exclude(),
+		}),
+		Storage:    resources.NewStorage(),
+		System:     resources.NewSystem(),
+		Containers: resources.NewContainers(app.config.ContainersConfig().Socket()),
+		Systemd:    resources.NewSystemd(app.config.SystemdConfig().Units()),
+		UPS: resources.NewUPS(resources.UPSOptions{
+			Address: upsConfig.Address(),
+			Name:    upsConfig.Name(),
+		}),
+	}, app.config.SamplerConfig())
 
+	system := app.platform.newSystem(i2cBus, app.config.FanConfig().Address())
 	services := &core.CoreServices{
-		FanService: core.NewFanService(
-			hardware.NewFan(i2cBus),
-			cpu,
-			drives,
-			app.config.FanConfig(),
-		),
-		DisplayService: core.NewDisplayService(
-			oled,
-			cpu,
-			resources.NewMemory(),
-			resources.NewNetwork(),
-			drives,
-			app.config.DisplayConfig(),
+		Sampler:       sampler,
+		HealthService: core.NewHealthService(alerts, sampler, drives, float64(drivesConfig.TemperatureMax())),
+		UPSService: core.NewUPSService(
+			system,
+			sampler,
+			alerts,
+			upsConfig,
 		),
+		NotifierService: core.NewNotifierService(alerts, sinks, notifyConfig),
+		Alerts:          alerts,
+	}
+	// Each of the services below runs without the others, so a missing
+	// display or fan board only takes its own service down.
+	if fan != nil {
+		services.FanService = core.NewFanService(fan, sampler, alerts, app.config.FanConfig())
+	} else if i2cBus != nil {
+		app.fanBus = i2cBus
+	}
+	if oled != nil {
+		services.DisplayService = core.NewDisplayService(oled, system, sampler, alerts, app.config.DisplayConfig())
 	}
 
-	button, err := hardware.NewButton()
+	mqttService, err := core.NewMQTTService(
+		services.FanService,
+		services.DisplayService,
+		sampler,
+		alerts,
+		app.config.MQTTConfig(),
+		version,
+	)
 	if err != nil {
-		slog.Warn("button not available, skipping button service", "error", err)
-	} else {
-		services.ButtonService = core.NewButtonService(button, services.DisplayService)
+		slog.Error("failed to set up MQTT", "error", err)
+		os.Exit(1)
+	}
+	services.MQTTService = mqttService
+
+	// The API and the button only drive the display.
+	if services.DisplayService != nil {
+		services.APIService = core.NewAPIService(services.DisplayService, app.config.APIConfig())
+
+		button, err := app.platform.newButton()
+		if err != nil {
+			slog.Warn("button not available, skipping button service", "error", err)
+		} else {
+			services.ButtonService = core.NewButtonService(button, services.DisplayService)
+		}
 	}
 
 	app.coreServices = services
@@ -109,36 +196,107 @@ func (app *CoreApp) Init() {
 
 // Run the App.
 func (app *CoreApp) Run(ctx context.Context) error {
-	if err := app.coreServices.FanService.Start(ctx); err != nil {
+	// Start the notifier first so it sees every alert.
+	if err := app.coreServices.NotifierService.Start(ctx); err != nil {
 		return err
 	}
-	if err := app.coreServices.DisplayService.Start(ctx); err != nil {
+	if err := app.coreServices.Sampler.Start(ctx); err != nil {
 		return err
 	}
+	if app.coreServices.FanService != nil {
+		if err := app.coreServices.FanService.Start(ctx); err != nil {
+			return err
+		}
+	}
+	if app.coreServices.DisplayService != nil {
+		if err := app.coreServices.DisplayService.Start(ctx); err != nil {
+			return err
+		}
+	}
 	if app.coreServices.ButtonService != nil {
 		if err := app.coreServices.ButtonService.Start(ctx); err != nil {
 			return err
 		}
 	}
+	if err := app.coreServices.HealthService.Start(ctx); err != nil {
+		return err
+	}
+	if err := app.coreServices.UPSService.Start(ctx); err != nil {
+		return err
+	}
+	if err := app.coreServices.MQTTService.Start(ctx); err != nil {
+		return err
+	}
+	if app.coreServices.APIService != nil {
+		if err := app.coreServices.APIService.Start(ctx); err != nil {
+			return err
+		}
+	}
+
+	if app.fanBus != nil {
+		if err := app.startLateFan(ctx); err != nil {
+			return err
+		}
+	}
 
 	<-ctx.Done()
 
 	return nil
 }
 
+// startLateFan waits for the fan board that did not answer at startup and
+// starts the fan service once it does. MQTT was set up without it, so the fan
+// is not controllable from there until a restart.
+func (app *CoreApp) startLateFan(ctx context.Context) error {
+	fanConfig := app.config.FanConfig()
+	if !waitForFanBoard(ctx, app.fanBus, fanConfig.Address(), fanBoardRetryInterval, app.coreServices.Alerts) {
+		return nil
+	}
+	slog.Info("fan board found, starting fan control", "address", fmt.Sprintf("%#02x", fanConfig.Address()))
+
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+	if ctx.Err() != nil {
+		return nil
+	}
+	fanService := core.NewFanService(hardware.NewFan(app.fanBus, fanConfig.Address()), app.coreServices.Sampler,
+		app.coreServices.Alerts, fanConfig)
+	app.coreServices.FanService = fanService
+	return fanService.Start(ctx)
+}
+
 // Shutdown the App.
 func (app *CoreApp) Shutdown(ctx context.Context) error {
 	ctx, cancel := context.WithTimeout(ctx, shutdownTimeoutSec*time.Second)
 	defer cancel()
 
-	slog.Info("stopping fan loop")
-	if err := app.coreServices.FanService.Shutdown(ctx); err != nil {
-		slog.Error("failed to stop fan loop", "error", err)
+	if app.coreServices.APIService != nil {
+		slog.Info("stopping API")
+		if err := app.coreServices.APIService.Shutdown(ctx); err != nil {
+			slog.Error("failed to stop API", "error", err)
+		}
+	}
+
+	slog.Info("stopping MQTT client")
+	if err := app.coreServices.MQTTService.Shutdown(ctx); err != nil {
+		slog.Error("failed to stop MQTT client", "error", err)
 	}
 
-	slog.Info("stopping display service")
-	if err := app.coreServices.DisplayService.Shutdown(ctx); err != nil {
-		slog.Error("failed to stop display service", "error", err)
+	app.mutex.Lock()
+	fanService := app.coreServices.FanService
+	app.mutex.Unlock()
+	if fanService != nil {
+		slog.Info("stopping fan loop")
+		if err := fanService.Shutdown(ctx); err != nil {
+			slog.Error("failed to stop fan loop", "error", err)
+		}
+	}
+
+	if app.coreServices.DisplayService != nil {
+		slog.Info("stopping display service")
+		if err := app.coreServices.DisplayService.Shutdown(ctx); err != nil {
+			slog.Error("failed to stop display service", "error", err)
+		}
 	}
 
 	if app.coreServices.ButtonService != nil {
@@ -148,13 +306,25 @@ func (app *CoreApp) Shutdown(ctx context.Context) error {
 		}
 	}
 
-	return nil
-}
+	slog.Info("stopping health service")
+	if err := app.coreServices.HealthService.Shutdown(ctx); err != nil {
+		slog.Error("failed to stop health service", "error", err)
+	}
 
-func archCheck() {
-	// Check if the architecture is supported
-	if runtime.GOARCH != "arm64" && runtime.GOARCH != "arm" {
-		slog.Error("this application is only supported on arm64 and arm architectures")
-		os.Exit(1)
+	slog.Info("stopping UPS monitor")
+	if err := app.coreServices.UPSService.Shutdown(ctx); err != nil {
+		slog.Error("failed to stop UPS monitor", "error", err)
+	}
+
+	slog.Info("stopping notifier")
+	if err := app.coreServices.NotifierService.Shutdown(ctx); err != nil {
+		slog.Error("failed to stop notifier", "error", err)
 	}
+
+	slog.Info("stopping sampler")
+	if err := app.coreServices.Sampler.Shutdown(ctx); err != nil {
+		slog.Error("failed to stop sampler", "error", err)
+	}
+
+	return nil
 }
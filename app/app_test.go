@@ -0,0 +1,122 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/czechbol/lumeon/app/config"
+	"github.com/czechbol/lumeon/core"
+	"github.com/czechbol/lumeon/core/hardware/i2c"
+	i2cmock "github.com/czechbol/lumeon/core/hardware/i2c/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var errNoFanBoard = errors.New("no fan board")
+
+// AppTestSuite runs the whole daemon on the simulated hardware.
+type AppTestSuite struct {
+	suite.Suite
+}
+
+func TestAppTestSuite(t *testing.T) {
+	suite.Run(t, new(AppTestSuite))
+}
+
+func (s *AppTestSuite) config() config.Config {
+	second := time.Second
+	return config.NewConfig(
+		slog.LevelWarn,
+		config.NewFanConfig(true, 0x1A, []config.FanCurvePoint{config.NewFanCurvePoint(0, 30)}, nil,
+			config.FanIOBoost{}),
+		config.NewDisplayConfig(true, second,
+			config.DisplayPanelConfig{Controller: "ssd1306", Width: 128, Height: 64, Address: 0x3C},
+			config.DisplayImageConfig{Dither: "threshold", Scale: "fit", Gamma: 1},
+			config.DisplaySplashConfig{}),
+		config.NewDrivesConfig(nil, s.T().TempDir(), 7*24*time.Hour, 55, nil),
+		config.NewFilesystemsConfig(nil, nil),
+		config.NewSamplerConfig(config.SamplerIntervals{
+			CPU: second, Memory: second, Network: second, DiskIO: second, Drives: second, Filesystems: second,
+			Pools: second, System: second, Containers: second, Systemd: second, UPS: second,
+		}),
+		config.NewContainersConfig(""),
+		config.NewSystemdConfig(nil),
+		config.NewUPSConfig("", "", 0, 0),
+		config.NewNotifyConfig(nil, nil, 0, 0),
+		config.NewMQTTConfig("", "", "", "", "", "", second, config.MQTTTLSConfig{}),
+		config.NewAPIConfig("", ""),
+		config.NewI2CConfig("", 2, time.Millisecond),
+		config.NewHardwareConfig(config.BackendSim, config.SimConfig{Ambient: 25, Load: 20}),
+	)
+}
+
+func (s *AppTestSuite) TestSimulated() {
+	app := NewApp(s.config())
+	app.Init()
+	s.Require().NotNil(app.platform.sim)
+	s.Require().NotNil(app.coreServices.FanService)
+	s.Require().NotNil(app.coreServices.DisplayService)
+	s.Require().NotNil(app.coreServices.ButtonService)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- app.Run(ctx) }()
+
+	simulator := app.platform.sim
+	s.Eventually(func() bool {
+		_, dataBytes := simulator.Display.Written()
+		return simulator.Board.Duty() > 0 && dataBytes > 0
+	}, 10*time.Second, 50*time.Millisecond)
+
+	cancel()
+	s.Require().NoError(<-done)
+	s.Require().NoError(app.Shutdown(context.Background()))
+	s.False(simulator.Board.Halted())
+}
+
+func (s *AppTestSuite) TestProbe() {
+	var out bytes.Buffer
+	s.Equal(0, Probe(s.config(), &out))
+	s.Contains(out.String(), "fan board: 0x1a found")
+	s.Contains(out.String(), "errors:    none\n")
+}
+
+func (s *AppTestSuite) TestBusErrors() {
+	s.Equal("none", busErrors(map[uint16]i2c.AddressStats{0x1A: {Transactions: 4}}))
+	s.Equal("0x1a 1 of 4 transfers, 0 failed after retries; 0x3c 3 of 3 transfers, 1 failed after retries",
+		busErrors(map[uint16]i2c.AddressStats{
+			0x3C: {Transactions: 3, Errors: 3, Failures: 1},
+			0x1A: {Transactions: 4, Errors: 1},
+		}))
+}
+
+func (s *AppTestSuite) TestWaitForFanBoard() {
+	alerts := core.NewAlertManager()
+	answers := 0
+	bus := &i2cmock.I2CBus{TxHandler: func(addr uint16, _, _ []byte) error {
+		s.Equal(uint16(0x1A), addr)
+		if answers++; answers < 3 {
+			return errNoFanBoard
+		}
+		s.Len(alerts.Active(), 1, "the alert is raised while the board is missing")
+		return nil
+	}}
+
+	s.True(waitForFanBoard(context.Background(), bus, 0x1A, time.Millisecond, alerts))
+	s.Equal(3, bus.TxCalled)
+	s.Empty(alerts.Active())
+}
+
+func (s *AppTestSuite) TestWaitForFanBoardCancelled() {
+	alerts := core.NewAlertManager()
+	bus := &i2cmock.I2CBus{TxHandler: func(uint16, []byte, []byte) error { return errNoFanBoard }}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	s.False(waitForFanBoard(ctx, bus, 0x1A, time.Millisecond, alerts))
+	s.Require().Len(alerts.Active(), 1)
+	s.Equal(fanMissingAlert, alerts.Active()[0].Key)
+}
@@ -9,19 +9,67 @@ type Config interface {
 	LogLevel() slog.Level
 	FanConfig() FanConfig
 	DisplayConfig() DisplayConfig
+	DrivesConfig() DrivesConfig
+	FilesystemsConfig() FilesystemsConfig
+	SamplerConfig() SamplerConfig
+	ContainersConfig() ContainersConfig
+	SystemdConfig() SystemdConfig
+	UPSConfig() UPSConfig
+	NotifyConfig() NotifyConfig
+	MQTTConfig() MQTTConfig
+	APIConfig() APIConfig
+	I2CConfig() I2CConfig
+	HardwareConfig() HardwareConfig
 }
 
 type configImpl struct {
 	logLevel      slog.Level
 	fanConfig     FanConfig
 	displayConfig DisplayConfig
+	drivesConfig  DrivesConfig
+	fsConfig      FilesystemsConfig
+	samplerConfig SamplerConfig
+	containers    ContainersConfig
+	systemd       SystemdConfig
+	ups           UPSConfig
+	notify        NotifyConfig
+	mqtt          MQTTConfig
+	api           APIConfig
+	i2c           I2CConfig
+	hardware      HardwareConfig
 }
 
-func NewConfig(logLevel slog.Level, fanConfig FanConfig, displayConfig DisplayConfig) Config {
+func NewConfig(
+	logLevel slog.Level,
+	fanConfig FanConfig,
+	displayConfig DisplayConfig,
+	drivesConfig DrivesConfig,
+	fsConfig FilesystemsConfig,
+	samplerConfig SamplerConfig,
+	containers ContainersConfig,
+	systemd SystemdConfig,
+	ups UPSConfig,
+	notify NotifyConfig,
+	mqtt MQTTConfig,
+	api APIConfig,
+	i2c I2CConfig,
+	hardware HardwareConfig,
+) Config {
 	return &configImpl{
 		logLevel:      logLevel,
 		fanConfig:     fanConfig,
 		displayConfig: displayConfig,
+		drivesConfig:  drivesConfig,
+		fsConfig:      fsConfig,
+		samplerConfig: samplerConfig,
+		containers:    containers,
+		systemd:       systemd,
+		ups:           ups,
+		notify:        notify,
+		mqtt:          mqtt,
+		api:           api,
+		i2c:           i2c,
+		hardware:      hardware,
 	}
 }
 
@@ -37,20 +85,82 @@ func (c *configImpl) DisplayConfig() DisplayConfig {
 	return c.displayConfig
 }
 
+func (c *configImpl) DrivesConfig() DrivesConfig {
+	return c.drivesConfig
+}
+
+func (c *configImpl) FilesystemsConfig() FilesystemsConfig {
+	return c.fsConfig
+}
+
+func (c *configImpl) SamplerConfig() SamplerConfig {
+	return c.samplerConfig
+}
+
+func (c *configImpl) ContainersConfig() ContainersConfig {
+	return c.containers
+}
+
+func (c *configImpl) SystemdConfig() SystemdConfig {
+	return c.systemd
+}
+
+func (c *configImpl) UPSConfig() UPSConfig {
+	return c.ups
+}
+
+func (c *configImpl) NotifyConfig() NotifyConfig {
+	return c.notify
+}
+
+func (c *configImpl) MQTTConfig() MQTTConfig {
+	return c.mqtt
+}
+
+func (c *configImpl) APIConfig() APIConfig {
+	return c.api
+}
+
+func (c *configImpl) I2CConfig() I2CConfig {
+	return c.i2c
+}
+
+func (c *configImpl) HardwareConfig() HardwareConfig {
+	return c.hardware
+}
+
 type DisplayConfig interface {
 	Enabled() bool
 	Interval() time.Duration
+	// Panel is the display module: its controller, size and orientation.
+	Panel() DisplayPanelConfig
+	// Image is how images and GIFs are converted for the display.
+	Image() DisplayImageConfig
+	// Splash holds the splash, wake and shutdown screens.
+	Splash() DisplaySplashConfig
 }
 
 type displayConfigImpl struct {
 	enabled  bool
 	interval time.Duration
+	panel    DisplayPanelConfig
+	image    DisplayImageConfig
+	splash   DisplaySplashConfig
 }
 
-func NewDisplayConfig(enabled bool, interval time.Duration) DisplayConfig {
+func NewDisplayConfig(
+	enabled bool,
+	interval time.Duration,
+	panel DisplayPanelConfig,
+	image DisplayImageConfig,
+	splash DisplaySplashConfig,
+) DisplayConfig {
 	return &displayConfigImpl{
 		enabled:  enabled,
 		interval: interval,
+		panel:    panel,
+		image:    image,
+		splash:   splash,
 	}
 }
 
@@ -62,23 +172,567 @@ func (d *displayConfigImpl) Interval() time.Duration {
 	return d.interval
 }
 
+func (d *displayConfigImpl) Panel() DisplayPanelConfig {
+	return d.panel
+}
+
+func (d *displayConfigImpl) Image() DisplayImageConfig {
+	return d.image
+}
+
+func (d *displayConfigImpl) Splash() DisplaySplashConfig {
+	return d.splash
+}
+
+// DisplayPanelConfig holds the display module settings.
+type DisplayPanelConfig struct {
+	// Controller is ssd1306, sh1106 or ssd1309.
+	Controller string
+	Width      int
+	Height     int
+	// Rotation is 0 or 180 degrees.
+	Rotation         int
+	MirrorHorizontal bool
+	MirrorVertical   bool
+	// Address is the 7-bit I2C address of the display.
+	Address uint16
+}
+
+// DisplayImageConfig holds the image conversion settings of the display.
+type DisplayImageConfig struct {
+	// Dither is threshold, floyd-steinberg, atkinson or bayer.
+	Dither string
+	// Scale is fit, fill or stretch, for images that are not the size of the display.
+	Scale string
+	// Gamma the images are encoded with; 1 treats them as linear.
+	Gamma float64
+}
+
+// DisplaySplashConfig holds the splash, wake and shutdown screens of the display.
+type DisplaySplashConfig struct {
+	// Enabled shows the splash on start and when the display wakes.
+	Enabled bool
+	// Duration is how long the startup splash is shown.
+	Duration time.Duration
+	// Image, WakeImage and ShutdownImage are PNG, JPEG or GIF files that
+	// replace the built-in screens; empty keeps the built-in ones.
+	Image         string
+	WakeImage     string
+	ShutdownImage string
+}
+
+type DrivesConfig interface {
+	// Bays maps a /dev/disk/by-path name or drive serial number to a friendly label.
+	Bays() map[string]string
+	// StateDir is where SMART snapshots are persisted between restarts. Empty
+	// keeps them in memory only.
+	StateDir() string
+	// AcceptAfter is how long the SMART counters of a degraded drive have to
+	// stay the same before they become its new baseline. Zero never accepts them.
+	AcceptAfter() time.Duration
+	// TemperatureMax is the drive temperature in °C above which a drive is reported as overheating.
+	TemperatureMax() uint8
+	// SelfTests are the SMART self-test schedules.
+	SelfTests() []SelfTestConfig
+}
+
+type drivesConfigImpl struct {
+	bays           map[string]string
+	stateDir       string
+	acceptAfter    time.Duration
+	temperatureMax uint8
+	selfTests      []SelfTestConfig
+}
+
+func NewDrivesConfig(
+	bays map[string]string,
+	stateDir string,
+	acceptAfter time.Duration,
+	temperatureMax uint8,
+	selfTests []SelfTestConfig,
+) DrivesConfig {
+	return &drivesConfigImpl{
+		bays:           bays,
+		stateDir:       stateDir,
+		acceptAfter:    acceptAfter,
+		temperatureMax: temperatureMax,
+		selfTests:      selfTests,
+	}
+}
+
+func (d *drivesConfigImpl) Bays() map[string]string {
+	return d.bays
+}
+
+func (d *drivesConfigImpl) StateDir() string {
+	return d.stateDir
+}
+
+func (d *drivesConfigImpl) AcceptAfter() time.Duration {
+	return d.acceptAfter
+}
+
+func (d *drivesConfigImpl) TemperatureMax() uint8 {
+	return d.temperatureMax
+}
+
+func (d *drivesConfigImpl) SelfTests() []SelfTestConfig {
+	return d.selfTests
+}
+
+// SelfTestConfig schedules a SMART self-test.
+type SelfTestConfig struct {
+	Drive    string // bay label, serial, by-path name or device name; "*" for all drives
+	Type     string // "short" or "long"
+	Schedule string // five-field cron expression
+}
+
+type SamplerConfig interface {
+	// Intervals are how often each resource prober is sampled.
+	Intervals() SamplerIntervals
+}
+
+type samplerConfigImpl struct {
+	intervals SamplerIntervals
+}
+
+func NewSamplerConfig(intervals SamplerIntervals) SamplerConfig {
+	return &samplerConfigImpl{
+		intervals: intervals,
+	}
+}
+
+func (s *samplerConfigImpl) Intervals() SamplerIntervals {
+	return s.intervals
+}
+
+// SamplerIntervals holds the sampling interval of each resource prober.
+type SamplerIntervals struct {
+	CPU         time.Duration
+	Memory      time.Duration
+	Network     time.Duration
+	DiskIO      time.Duration
+	Drives      time.Duration
+	Filesystems time.Duration
+	Pools       time.Duration
+	System      time.Duration
+	Containers  time.Duration
+	Systemd     time.Duration
+	UPS         time.Duration
+}
+
+type ContainersConfig interface {
+	// Socket is the Docker or Podman API socket; empty means auto-detect.
+	Socket() string
+}
+
+type containersConfigImpl struct {
+	socket string
+}
+
+func NewContainersConfig(socket string) ContainersConfig {
+	return &containersConfigImpl{
+		socket: socket,
+	}
+}
+
+func (c *containersConfigImpl) Socket() string {
+	return c.socket
+}
+
+type SystemdConfig interface {
+	// Units lists the systemd units to watch; names without a type are services.
+	Units() []string
+}
+
+type systemdConfigImpl struct {
+	units []string
+}
+
+func NewSystemdConfig(units []string) SystemdConfig {
+	return &systemdConfigImpl{
+		units: units,
+	}
+}
+
+func (s *systemdConfigImpl) Units() []string {
+	return s.units
+}
+
+type UPSConfig interface {
+	// Address is the host[:port] of the NUT upsd; empty disables UPS monitoring.
+	Address() string
+	// Name is the UPS name as configured in NUT.
+	Name() string
+	// ShutdownAfter is how long to run on battery before shutting down; 0 never shuts down on time.
+	ShutdownAfter() time.Duration
+	// ShutdownCharge is the battery charge in percent at or below which to shut down; 0 disables it.
+	ShutdownCharge() uint8
+}
+
+type upsConfigImpl struct {
+	address        string
+	name           string
+	shutdownAfter  time.Duration
+	shutdownCharge uint8
+}
+
+func NewUPSConfig(address, name string, shutdownAfter time.Duration, shutdownCharge uint8) UPSConfig {
+	return &upsConfigImpl{
+		address:        address,
+		name:           name,
+		shutdownAfter:  shutdownAfter,
+		shutdownCharge: shutdownCharge,
+	}
+}
+
+func (u *upsConfigImpl) Address() string {
+	return u.address
+}
+
+func (u *upsConfigImpl) Name() string {
+	return u.name
+}
+
+func (u *upsConfigImpl) ShutdownAfter() time.Duration {
+	return u.shutdownAfter
+}
+
+func (u *upsConfigImpl) ShutdownCharge() uint8 {
+	return u.shutdownCharge
+}
+
+type NotifyConfig interface {
+	// Sinks are the destinations notifications can be sent to.
+	Sinks() []NotifySinkConfig
+	// Rules choose which alerts go to which sinks; without rules every alert goes to every sink.
+	Rules() []NotifyRuleConfig
+	// RateLimit is the most notifications sent to one sink per hour; 0 means unlimited.
+	RateLimit() int
+	// DedupWindow is how long a repeat of the same alert is not notified again.
+	DedupWindow() time.Duration
+}
+
+type notifyConfigImpl struct {
+	sinks       []NotifySinkConfig
+	rules       []NotifyRuleConfig
+	rateLimit   int
+	dedupWindow time.Duration
+}
+
+func NewNotifyConfig(
+	sinks []NotifySinkConfig,
+	rules []NotifyRuleConfig,
+	rateLimit int,
+	dedupWindow time.Duration,
+) NotifyConfig {
+	return &notifyConfigImpl{
+		sinks:       sinks,
+		rules:       rules,
+		rateLimit:   rateLimit,
+		dedupWindow: dedupWindow,
+	}
+}
+
+func (n *notifyConfigImpl) Sinks() []NotifySinkConfig {
+	return n.sinks
+}
+
+func (n *notifyConfigImpl) Rules() []NotifyRuleConfig {
+	return n.rules
+}
+
+func (n *notifyConfigImpl) RateLimit() int {
+	return n.rateLimit
+}
+
+func (n *notifyConfigImpl) DedupWindow() time.Duration {
+	return n.dedupWindow
+}
+
+// NotifySinkConfig is one notification destination.
+type NotifySinkConfig struct {
+	Name  string
+	Type  string // "webhook", "ntfy", "gotify" or "smtp"
+	URL   string
+	Token string
+
+	// SMTP only.
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+	TLS      bool
+
+	// Title and Body are text/template sources; empty means the global or built-in template.
+	Title string
+	Body  string
+}
+
+// NotifyRuleConfig routes matching alerts to Sinks.
+type NotifyRuleConfig struct {
+	Sinks []string
+	// Sources limits the rule to alerts from these subsystems, e.g. "drive"; empty matches all.
+	Sources []string
+	// Critical limits the rule to critical alerts.
+	Critical bool
+	// Resolved also sends a notification when a matching alert is resolved.
+	Resolved bool
+}
+
+type MQTTConfig interface {
+	// Broker is the broker URL, e.g. tcp://host:1883 or ssl://host:8883; empty disables MQTT.
+	Broker() string
+	// ClientID identifies the connection to the broker.
+	ClientID() string
+	Username() string
+	Password() string
+	// TopicPrefix is prepended to the state and command topics.
+	TopicPrefix() string
+	// DiscoveryPrefix is the Home Assistant discovery prefix; empty disables discovery.
+	DiscoveryPrefix() string
+	// Interval is how often state is published.
+	Interval() time.Duration
+	TLS() MQTTTLSConfig
+}
+
+type mqttConfigImpl struct {
+	broker          string
+	clientID        string
+	username        string
+	password        string
+	topicPrefix     string
+	discoveryPrefix string
+	interval        time.Duration
+	tls             MQTTTLSConfig
+}
+
+func NewMQTTConfig(
+	broker, clientID, username, password, topicPrefix, discoveryPrefix string,
+	interval time.Duration,
+	tls MQTTTLSConfig,
+) MQTTConfig {
+	return &mqttConfigImpl{
+		broker:          broker,
+		clientID:        clientID,
+		username:        username,
+		password:        password,
+		topicPrefix:     topicPrefix,
+		discoveryPrefix: discoveryPrefix,
+		interval:        interval,
+		tls:             tls,
+	}
+}
+
+func (m *mqttConfigImpl) Broker() string {
+	return m.broker
+}
+
+func (m *mqttConfigImpl) ClientID() string {
+	return m.clientID
+}
+
+func (m *mqttConfigImpl) Username() string {
+	return m.username
+}
+
+func (m *mqttConfigImpl) Password() string {
+	return m.password
+}
+
+func (m *mqttConfigImpl) TopicPrefix() string {
+	return m.topicPrefix
+}
+
+func (m *mqttConfigImpl) DiscoveryPrefix() string {
+	return m.discoveryPrefix
+}
+
+func (m *mqttConfigImpl) Interval() time.Duration {
+	return m.interval
+}
+
+func (m *mqttConfigImpl) TLS() MQTTTLSConfig {
+	return m.tls
+}
+
+// MQTTTLSConfig holds the TLS settings used with ssl:// brokers.
+type MQTTTLSConfig struct {
+	// CAFile is a PEM bundle to verify the broker with; empty uses the system roots.
+	CAFile string
+	// CertFile and KeyFile are a client certificate, if the broker requires one.
+	CertFile string
+	KeyFile  string
+	// Insecure skips verification of the broker's certificate.
+	Insecure bool
+}
+
+type APIConfig interface {
+	// Listen is the host:port the HTTP API listens on; empty disables the API.
+	Listen() string
+	// Token, if set, must be sent as a bearer token with every request.
+	Token() string
+}
+
+type apiConfigImpl struct {
+	listen string
+	token  string
+}
+
+func NewAPIConfig(listen, token string) APIConfig {
+	return &apiConfigImpl{
+		listen: listen,
+		token:  token,
+	}
+}
+
+func (a *apiConfigImpl) Listen() string {
+	return a.listen
+}
+
+func (a *apiConfigImpl) Token() string {
+	return a.token
+}
+
+// Hardware backends.
+const (
+	// BackendPeriph drives the Argon EON hardware through periph.io.
+	BackendPeriph = "periph"
+	// BackendSim simulates the hardware.
+	BackendSim = "sim"
+)
+
+type HardwareConfig interface {
+	// Backend is BackendPeriph or BackendSim.
+	Backend() string
+	// Sim configures the simulation of the sim backend.
+	Sim() SimConfig
+}
+
+// SimConfig configures the simulated hardware.
+type SimConfig struct {
+	// Ambient is the air temperature around the case, in °C.
+	Ambient float64
+	// Load is the CPU load, in percent.
+	Load int
+	// ButtonPresses are the times after start the button is pressed.
+	ButtonPresses []time.Duration
+}
+
+type hardwareConfigImpl struct {
+	backend string
+	sim     SimConfig
+}
+
+func NewHardwareConfig(backend string, sim SimConfig) HardwareConfig {
+	return &hardwareConfigImpl{
+		backend: backend,
+		sim:     sim,
+	}
+}
+
+func (h *hardwareConfigImpl) Backend() string {
+	return h.backend
+}
+
+func (h *hardwareConfigImpl) Sim() SimConfig {
+	return h.sim
+}
+
+type I2CConfig interface {
+	// Bus is the name or number of the I2C bus; empty means the first one.
+	Bus() string
+	// Retries is how many times a failed transaction is tried again.
+	Retries() int
+	// RetryBackoff is the wait before the first retry, doubled before each next.
+	RetryBackoff() time.Duration
+}
+
+type i2cConfigImpl struct {
+	bus          string
+	retries      int
+	retryBackoff time.Duration
+}
+
+func NewI2CConfig(bus string, retries int, retryBackoff time.Duration) I2CConfig {
+	return &i2cConfigImpl{
+		bus:          bus,
+		retries:      retries,
+		retryBackoff: retryBackoff,
+	}
+}
+
+func (i *i2cConfigImpl) Bus() string {
+	return i.bus
+}
+
+func (i *i2cConfigImpl) Retries() int {
+	return i.retries
+}
+
+func (i *i2cConfigImpl) RetryBackoff() time.Duration {
+	return i.retryBackoff
+}
+
+type FilesystemsConfig interface {
+	// Include lists mountpoint glob patterns to report; empty means all real filesystems.
+	Include() []string
+	// Exclude lists mountpoint glob patterns to hide.
+	Exclude() []string
+}
+
+type filesystemsConfigImpl struct {
+	include []string
+	exclude []string
+}
+
+func NewFilesystemsConfig(include, exclude []string) FilesystemsConfig {
+	return &filesystemsConfigImpl{
+		include: include,
+		exclude: exclude,
+	}
+}
+
+func (f *filesystemsConfigImpl) Include() []string {
+	return f.include
+}
+
+func (f *filesystemsConfigImpl) Exclude() []string {
+	return f.exclude
+}
+
 type FanConfig interface {
 	Enabled() bool
+	// Address is the I2C address of the fan board.
+	Address() uint16
 	CPUCurve() []FanCurvePoint
 	HDDCurve() []FanCurvePoint
+	// IOBoost raises the fan while drives are busy; disabled when Utilization is 0.
+	IOBoost() FanIOBoost
 }
 
 type fanConfigImpl struct {
 	enabled  bool
+	address  uint16
 	cpuCurve []FanCurvePoint
 	hddCurve []FanCurvePoint
+	ioBoost  FanIOBoost
 }
 
-func NewFanConfig(enabled bool, cpuCurve, hddCurve []FanCurvePoint) FanConfig {
+func NewFanConfig(
+	enabled bool,
+	address uint16,
+	cpuCurve, hddCurve []FanCurvePoint,
+	ioBoost FanIOBoost,
+) FanConfig {
 	return &fanConfigImpl{
 		enabled:  enabled,
+		address:  address,
 		cpuCurve: cpuCurve,
 		hddCurve: hddCurve,
+		ioBoost:  ioBoost,
 	}
 }
 
@@ -86,6 +740,10 @@ func (f *fanConfigImpl) Enabled() bool {
 	return f.enabled
 }
 
+func (f *fanConfigImpl) Address() uint16 {
+	return f.address
+}
+
 func (f *fanConfigImpl) CPUCurve() []FanCurvePoint {
 	return f.cpuCurve
 }
@@ -94,6 +752,17 @@ func (f *fanConfigImpl) HDDCurve() []FanCurvePoint {
 	return f.hddCurve
 }
 
+func (f *fanConfigImpl) IOBoost() FanIOBoost {
+	return f.ioBoost
+}
+
+// FanIOBoost sets a minimum fan speed while any drive is busier than
+// Utilization percent, averaged over one fan interval.
+type FanIOBoost struct {
+	Utilization uint8
+	Speed       uint8
+}
+
 type FanCurvePoint struct {
 	Temperature uint8
 	Speed       uint8
@@ -2,9 +2,14 @@ package settings
 
 import (
 	"log/slog"
+	"net"
+	"net/url"
 	"os"
+	"path"
+	"slices"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/czechbol/lumeon/app/config"
@@ -14,22 +19,146 @@ import (
 
 // Settings is the struct that holds the configuration for the application.
 type Settings struct {
-	LogLevel        string
-	FanSettings     FanSettings
-	DisplaySettings DisplaySettings
+	LogLevel         string
+	FanSettings      FanSettings
+	DisplaySettings  DisplaySettings
+	DrivesSettings   DrivesSettings
+	FsSettings       FilesystemsSettings
+	SamplerSettings  SamplerSettings
+	I2CSettings      I2CSettings
+	HardwareSettings HardwareSettings
+}
+
+// HardwareSettings is the struct that holds the hardware backend and its
+// simulation.
+type HardwareSettings struct {
+	Backend          string // periph or sim
+	SimAmbient       float64
+	SimLoad          int   // percent
+	SimButtonPresses []int // seconds after start
+}
+
+// I2CSettings is the struct that holds the I2C bus the Argon devices are on.
+type I2CSettings struct {
+	Bus          string // name or number, empty for the first bus
+	Retries      int
+	RetryBackoff int // milliseconds, doubled after each retry
 }
 
 // FanSettings is the struct that holds the configuration for the fan.
 type FanSettings struct {
 	Enabled  bool
+	Address  uint16
 	CPUCurve map[uint8]uint8
 	HDDCurve map[uint8]uint8
+	IOBoost  FanIOBoostSettings
+}
+
+// FanIOBoostSettings is the struct that holds the I/O-driven fan boost.
+type FanIOBoostSettings struct {
+	Utilization uint8 // percent, 0 disables
+	Speed       uint8 // percent
 }
 
 // DisplaySettings is the struct that holds the configuration for the OLED display.
 type DisplaySettings struct {
 	Enabled  bool
 	Interval int // seconds per page
+
+	Controller       string
+	Size             string // WIDTHxHEIGHT
+	Rotation         int    // degrees
+	MirrorHorizontal bool
+	MirrorVertical   bool
+	Address          uint16
+
+	Dither string
+	Scale  string
+	Gamma  float64
+
+	Splash         bool
+	SplashDuration int // seconds
+	SplashImage    string
+	WakeImage      string
+	ShutdownImage  string
+}
+
+// DrivesSettings is the struct that holds the configuration for the storage drives.
+type DrivesSettings struct {
+	Bays           map[string]string // by-path name or serial number -> label
+	StateDir       string            // empty keeps SMART snapshots in memory only
+	AcceptAfter    int               // days
+	TemperatureMax uint8
+	SelfTests      []SelfTestSettings
+}
+
+// SamplerSettings is the struct that holds the sampling interval of each prober, in seconds.
+type SamplerSettings struct {
+	CPU         int
+	Memory      int
+	Network     int
+	DiskIO      int
+	Drives      int
+	Filesystems int
+	Pools       int
+	System      int
+	Containers  int
+	Systemd     int
+	UPS         int
+}
+
+// defaultSamplerSettings are the intervals of the probers left out of the
+// [sampler] section.
+var defaultSamplerSettings = SamplerSettings{
+	CPU:         2,
+	Memory:      5,
+	Network:     2,
+	DiskIO:      2,
+	Drives:      60,
+	Filesystems: 30,
+	Pools:       60,
+	System:      5,
+	Containers:  10,
+	Systemd:     30,
+	UPS:         5,
+}
+
+// FilesystemsSettings is the struct that holds the disk space reporting filters.
+type FilesystemsSettings struct {
+	Include []string // mountpoint glob patterns
+	Exclude []string
+}
+
+// NotifySinkSettings is the struct that holds one notification destination.
+type NotifySinkSettings struct {
+	Name     string
+	Type     string
+	URL      string
+	Token    string
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+	TLS      bool
+	Title    string
+	Body     string
+}
+
+// NotifyRuleSettings is the struct that holds one notification routing rule.
+type NotifyRuleSettings struct {
+	Sinks    []string
+	Sources  []string
+	Severity string // minimum severity, "warning" or "critical"
+	Resolved *bool  // defaults to true
+}
+
+// SelfTestSettings is the struct that holds one SMART self-test schedule.
+type SelfTestSettings struct {
+	Drive    string
+	Type     string
+	Schedule string
 }
 
 func init() {
@@ -42,6 +171,7 @@ func init() {
 // LoadSettings loads the settings from the configuration file.
 func GetConfig() config.Config {
 	verbosityFlag := pflag.CountP("verbosity", "v", "verbosity level")
+	simulateFlag := pflag.Bool("simulate", false, "simulate the hardware")
 	pflag.Parse()
 
 	err := viper.ReadInConfig()
@@ -65,21 +195,487 @@ func GetConfig() config.Config {
 	if displayInterval <= 0 {
 		displayInterval = 5
 	}
+	displayPanel := displayPanelSettings()
+	displayImage := displayImageSettings()
+	displaySplash := displaySplashSettings()
+
+	ioBoostUtilization := viper.GetInt("fan.ioBoost.utilization")
+	ioBoostSpeed := viper.GetInt("fan.ioBoost.speed")
+	if ioBoostUtilization < 0 || ioBoostUtilization > 100 || ioBoostSpeed < 0 || ioBoostSpeed > 100 {
+		slog.Error("fan I/O boost utilization and speed must be between 0 and 100",
+			"utilization", ioBoostUtilization, "speed", ioBoostSpeed)
+		os.Exit(1)
+	}
+
+	// An explicitly empty state directory keeps the SMART snapshots in memory.
+	drivesStateDir := "/var/lib/lumeon"
+	if viper.IsSet("drives.stateDir") {
+		drivesStateDir = viper.GetString("drives.stateDir")
+	}
+
+	drivesAcceptAfter := 7
+	if viper.IsSet("drives.acceptAfter") {
+		drivesAcceptAfter = viper.GetInt("drives.acceptAfter")
+	}
+	if drivesAcceptAfter < 0 {
+		slog.Error("drive acceptAfter must not be negative", "acceptAfter", drivesAcceptAfter)
+		os.Exit(1)
+	}
+
+	drivesTemperatureMax := 60
+	if viper.IsSet("drives.temperatureMax") {
+		drivesTemperatureMax = viper.GetInt("drives.temperatureMax")
+	}
+	if drivesTemperatureMax < 0 || drivesTemperatureMax > 255 {
+		slog.Error("drive temperature maximum must be between 0 and 255", "temp", drivesTemperatureMax)
+		os.Exit(1)
+	}
+
+	var selfTestSettings []SelfTestSettings
+	if err := viper.UnmarshalKey("drives.selfTests", &selfTestSettings); err != nil {
+		slog.Error("invalid drive self-test schedules", "error", err)
+		os.Exit(1)
+	}
+	selfTests := make([]config.SelfTestConfig, 0, len(selfTestSettings))
+	for _, st := range selfTestSettings {
+		selfTests = append(selfTests, config.SelfTestConfig{Drive: st.Drive, Type: st.Type, Schedule: st.Schedule})
+	}
+
+	fsInclude := viper.GetStringSlice("filesystems.include")
+	fsExclude := viper.GetStringSlice("filesystems.exclude")
+	for _, pattern := range append(slices.Clone(fsInclude), fsExclude...) {
+		if _, err := path.Match(pattern, "/"); err != nil {
+			slog.Error("invalid filesystem mountpoint pattern", "pattern", pattern, "error", err)
+			os.Exit(1)
+		}
+	}
+
+	samplerIntervals := config.SamplerIntervals{
+		CPU:         samplerInterval("cpu", defaultSamplerSettings.CPU),
+		Memory:      samplerInterval("memory", defaultSamplerSettings.Memory),
+		Network:     samplerInterval("network", defaultSamplerSettings.Network),
+		DiskIO:      samplerInterval("diskio", defaultSamplerSettings.DiskIO),
+		Drives:      samplerInterval("drives", defaultSamplerSettings.Drives),
+		Filesystems: samplerInterval("filesystems", defaultSamplerSettings.Filesystems),
+		Pools:       samplerInterval("pools", defaultSamplerSettings.Pools),
+		System:      samplerInterval("system", defaultSamplerSettings.System),
+		Containers:  samplerInterval("containers", defaultSamplerSettings.Containers),
+		Systemd:     samplerInterval("systemd", defaultSamplerSettings.Systemd),
+		UPS:         samplerInterval("ups", defaultSamplerSettings.UPS),
+	}
+
+	upsName := viper.GetString("ups.name")
+	if upsName == "" {
+		upsName = "ups"
+	}
+	upsShutdownAfter := viper.GetInt("ups.shutdownAfter")
+	if upsShutdownAfter < 0 {
+		slog.Error("UPS shutdown delay must not be negative", "shutdownAfter", upsShutdownAfter)
+		os.Exit(1)
+	}
+	upsShutdownCharge := viper.GetInt("ups.shutdownCharge")
+	if upsShutdownCharge < 0 || upsShutdownCharge > 100 {
+		slog.Error("UPS shutdown charge must be between 0 and 100", "shutdownCharge", upsShutdownCharge)
+		os.Exit(1)
+	}
+
+	notifyConfig := notifySettings()
+	mqttConfig := mqttSettings()
+
+	apiListen := "127.0.0.1:8630"
+	if viper.IsSet("api.listen") {
+		apiListen = viper.GetString("api.listen")
+	}
+	if apiListen != "" {
+		if _, _, err := net.SplitHostPort(apiListen); err != nil {
+			slog.Error("API listen address must be host:port", "listen", apiListen, "error", err)
+			os.Exit(1)
+		}
+	}
 
 	return config.NewConfig(
 		convertLogLevel(logLevel),
 		config.NewFanConfig(
 			viper.GetBool("fan.enabled"),
+			i2cAddressSetting("fan.address", 0x1A),
 			stringMapStringToPointSlice(viper.GetStringMapString("fan.cpuCurve")),
 			stringMapStringToPointSlice(viper.GetStringMapString("fan.hddCurve")),
+			config.FanIOBoost{
+				Utilization: uint8(ioBoostUtilization), //nolint:gosec // bounds checked above (0–100)
+				Speed:       uint8(ioBoostSpeed),       //nolint:gosec // bounds checked above (0–100)
+			},
 		),
 		config.NewDisplayConfig(
 			viper.GetBool("display.enabled"),
 			time.Duration(displayInterval)*time.Second,
+			displayPanel,
+			displayImage,
+			displaySplash,
 		),
+		config.NewDrivesConfig(
+			viper.GetStringMapString("drives.bays"),
+			drivesStateDir,
+			time.Duration(drivesAcceptAfter)*24*time.Hour,
+			uint8(drivesTemperatureMax), //nolint:gosec // bounds checked above (0–255)
+			selfTests,
+		),
+		config.NewFilesystemsConfig(fsInclude, fsExclude),
+		config.NewSamplerConfig(samplerIntervals),
+		config.NewContainersConfig(viper.GetString("containers.socket")),
+		config.NewSystemdConfig(viper.GetStringSlice("systemd.units")),
+		config.NewUPSConfig(
+			viper.GetString("ups.address"),
+			upsName,
+			time.Duration(upsShutdownAfter)*time.Second,
+			uint8(upsShutdownCharge), //nolint:gosec // bounds checked above (0–100)
+		),
+		notifyConfig,
+		mqttConfig,
+		config.NewAPIConfig(apiListen, viper.GetString("api.token")),
+		i2cSettings(),
+		hardwareSettings(*simulateFlag),
 	)
 }
 
+// hardwareSettings reads and validates the [hardware] section. simulate
+// selects the sim backend whatever the config says.
+func hardwareSettings(simulate bool) config.HardwareConfig {
+	backend := config.BackendPeriph
+	if viper.IsSet("hardware.backend") {
+		backend = viper.GetString("hardware.backend")
+	}
+	if simulate {
+		backend = config.BackendSim
+	}
+	if backend != config.BackendPeriph && backend != config.BackendSim {
+		slog.Error("hardware backend must be periph or sim", "backend", backend)
+		os.Exit(1)
+	}
+
+	sim := config.SimConfig{Ambient: 25, Load: 20}
+	if viper.IsSet("hardware.sim.ambient") {
+		sim.Ambient = viper.GetFloat64("hardware.sim.ambient")
+	}
+	if viper.IsSet("hardware.sim.load") {
+		sim.Load = viper.GetInt("hardware.sim.load")
+	}
+	if sim.Load < 0 || sim.Load > 100 {
+		slog.Error("simulated CPU load must be between 0 and 100", "load", sim.Load)
+		os.Exit(1)
+	}
+	for _, seconds := range viper.GetIntSlice("hardware.sim.buttonPresses") {
+		if seconds < 0 {
+			slog.Error("simulated button presses must not be negative", "seconds", seconds)
+			os.Exit(1)
+		}
+		sim.ButtonPresses = append(sim.ButtonPresses, time.Duration(seconds)*time.Second)
+	}
+	return config.NewHardwareConfig(backend, sim)
+}
+
+// i2cSettings reads and validates the [i2c] section.
+func i2cSettings() config.I2CConfig {
+	retries := 2
+	if viper.IsSet("i2c.retries") {
+		retries = viper.GetInt("i2c.retries")
+	}
+	if retries < 0 || retries > 10 {
+		slog.Error("i2c retries must be between 0 and 10", "retries", retries)
+		os.Exit(1)
+	}
+	retryBackoff := 5
+	if viper.IsSet("i2c.retryBackoff") {
+		retryBackoff = viper.GetInt("i2c.retryBackoff")
+	}
+	if retryBackoff < 0 || retryBackoff > 1000 {
+		slog.Error("i2c retry backoff must be between 0 and 1000 milliseconds", "retryBackoff", retryBackoff)
+		os.Exit(1)
+	}
+	return config.NewI2CConfig(viper.GetString("i2c.bus"), retries, time.Duration(retryBackoff)*time.Millisecond)
+}
+
+// i2cAddressSetting reads the I2C address at key, or returns def if it is not
+// set.
+func i2cAddressSetting(key string, def uint16) uint16 {
+	if !viper.IsSet(key) {
+		return def
+	}
+	address := viper.GetInt(key)
+	if address < 0x08 || address > 0x77 {
+		slog.Error("I2C address must be a 7-bit address between 0x08 and 0x77", "key", key, "address", address)
+		os.Exit(1)
+	}
+	return uint16(address) //nolint:gosec // bounds checked above (0x08–0x77)
+}
+
+// displayPanelSettings reads and validates the display module settings of the
+// [display] section. The defaults are the Argon EON display.
+func displayPanelSettings() config.DisplayPanelConfig {
+	panel := config.DisplayPanelConfig{
+		Controller:       "ssd1306",
+		Width:            128,
+		Height:           64,
+		MirrorHorizontal: true,
+		Address:          0x3C,
+	}
+	if viper.IsSet("display.controller") {
+		panel.Controller = viper.GetString("display.controller")
+	}
+	if viper.IsSet("display.size") {
+		size := viper.GetString("display.size")
+		switch size {
+		case "128x64":
+			panel.Height = 64
+		case "128x32":
+			panel.Height = 32
+		default:
+			slog.Error("display size must be 128x64 or 128x32", "size", size)
+			os.Exit(1)
+		}
+	}
+	if viper.IsSet("display.rotation") {
+		panel.Rotation = viper.GetInt("display.rotation")
+	}
+	if viper.IsSet("display.mirrorHorizontal") {
+		panel.MirrorHorizontal = viper.GetBool("display.mirrorHorizontal")
+	}
+	panel.MirrorVertical = viper.GetBool("display.mirrorVertical")
+	panel.Address = i2cAddressSetting("display.address", panel.Address)
+
+	if !slices.Contains([]string{"ssd1306", "sh1106", "ssd1309"}, panel.Controller) {
+		slog.Error("display controller must be ssd1306, sh1106 or ssd1309", "controller", panel.Controller)
+		os.Exit(1)
+	}
+	if panel.Rotation != 0 && panel.Rotation != 180 {
+		slog.Error("display rotation must be 0 or 180", "rotation", panel.Rotation)
+		os.Exit(1)
+	}
+
+	return panel
+}
+
+// displayImageSettings reads and validates the image conversion settings of
+// the [display] section.
+func displayImageSettings() config.DisplayImageConfig {
+	image := config.DisplayImageConfig{Dither: "floyd-steinberg", Scale: "fit", Gamma: 2.2}
+	if viper.IsSet("display.dither") {
+		image.Dither = viper.GetString("display.dither")
+	}
+	if viper.IsSet("display.scale") {
+		image.Scale = viper.GetString("display.scale")
+	}
+	if viper.IsSet("display.gamma") {
+		image.Gamma = viper.GetFloat64("display.gamma")
+	}
+
+	if !slices.Contains([]string{"threshold", "floyd-steinberg", "atkinson", "bayer"}, image.Dither) {
+		slog.Error("display dither must be threshold, floyd-steinberg, atkinson or bayer", "dither", image.Dither)
+		os.Exit(1)
+	}
+	if !slices.Contains([]string{"fit", "fill", "stretch"}, image.Scale) {
+		slog.Error("display scale must be fit, fill or stretch", "scale", image.Scale)
+		os.Exit(1)
+	}
+	if image.Gamma < 0.1 || image.Gamma > 5 {
+		slog.Error("display gamma must be between 0.1 and 5", "gamma", image.Gamma)
+		os.Exit(1)
+	}
+
+	return image
+}
+
+// displaySplashSettings reads and validates the splash screen settings of the
+// [display] section.
+func displaySplashSettings() config.DisplaySplashConfig {
+	splash := config.DisplaySplashConfig{
+		Enabled:       true,
+		Duration:      5 * time.Second,
+		Image:         viper.GetString("display.splashImage"),
+		WakeImage:     viper.GetString("display.wakeImage"),
+		ShutdownImage: viper.GetString("display.shutdownImage"),
+	}
+	if viper.IsSet("display.splash") {
+		splash.Enabled = viper.GetBool("display.splash")
+	}
+	if viper.IsSet("display.splashDuration") {
+		duration := viper.GetInt("display.splashDuration")
+		if duration < 0 || duration > 60 {
+			slog.Error("display splash duration must be between 0 and 60 seconds", "splashDuration", duration)
+			os.Exit(1)
+		}
+		splash.Duration = time.Duration(duration) * time.Second
+	}
+
+	for _, image := range []string{splash.Image, splash.WakeImage, splash.ShutdownImage} {
+		if image == "" {
+			continue
+		}
+		if _, err := os.Stat(image); err != nil {
+			slog.Error("display image not found", "image", image, "error", err)
+			os.Exit(1)
+		}
+	}
+
+	return splash
+}
+
+// mqttSettings reads and validates the [mqtt] section.
+func mqttSettings() config.MQTTConfig {
+	broker := viper.GetString("mqtt.broker")
+	if broker != "" {
+		brokerURL, err := url.Parse(broker)
+		if err != nil || brokerURL.Host == "" ||
+			!slices.Contains([]string{"tcp", "mqtt", "ssl", "tls", "mqtts", "ws", "wss"}, brokerURL.Scheme) {
+			slog.Error("MQTT broker must be a URL such as tcp://host:1883 or ssl://host:8883", "broker", broker)
+			os.Exit(1)
+		}
+	}
+
+	clientID := viper.GetString("mqtt.clientID")
+	if clientID == "" {
+		hostname, _ := os.Hostname()
+		clientID = "lumeon-" + hostname
+	}
+
+	topicPrefix := strings.Trim(viper.GetString("mqtt.topicPrefix"), "/")
+	if topicPrefix == "" {
+		topicPrefix = "lumeon"
+	}
+	discoveryPrefix := "homeassistant"
+	if viper.IsSet("mqtt.discoveryPrefix") {
+		discoveryPrefix = strings.Trim(viper.GetString("mqtt.discoveryPrefix"), "/")
+	}
+	if strings.ContainsAny(topicPrefix+discoveryPrefix, "#+") {
+		slog.Error("MQTT topic prefixes must not contain wildcards",
+			"topicPrefix", topicPrefix, "discoveryPrefix", discoveryPrefix)
+		os.Exit(1)
+	}
+
+	interval := 30
+	if viper.IsSet("mqtt.interval") {
+		interval = viper.GetInt("mqtt.interval")
+	}
+	if interval <= 0 {
+		slog.Error("MQTT publish interval must be positive", "interval", interval)
+		os.Exit(1)
+	}
+
+	tls := config.MQTTTLSConfig{
+		CAFile:   viper.GetString("mqtt.tls.caFile"),
+		CertFile: viper.GetString("mqtt.tls.certFile"),
+		KeyFile:  viper.GetString("mqtt.tls.keyFile"),
+		Insecure: viper.GetBool("mqtt.tls.insecure"),
+	}
+	if (tls.CertFile == "") != (tls.KeyFile == "") {
+		slog.Error("MQTT client certificate needs both certFile and keyFile")
+		os.Exit(1)
+	}
+
+	return config.NewMQTTConfig(
+		broker,
+		clientID,
+		viper.GetString("mqtt.username"),
+		viper.GetString("mqtt.password"),
+		topicPrefix,
+		discoveryPrefix,
+		time.Duration(interval)*time.Second,
+		tls,
+	)
+}
+
+// notifySettings reads and validates the [notify] section.
+func notifySettings() config.NotifyConfig {
+	var sinkSettings []NotifySinkSettings
+	if err := viper.UnmarshalKey("notify.sinks", &sinkSettings); err != nil {
+		slog.Error("invalid notification sinks", "error", err)
+		os.Exit(1)
+	}
+	var ruleSettings []NotifyRuleSettings
+	if err := viper.UnmarshalKey("notify.rules", &ruleSettings); err != nil {
+		slog.Error("invalid notification rules", "error", err)
+		os.Exit(1)
+	}
+
+	// Sinks without their own templates use the [notify] ones.
+	title := viper.GetString("notify.title")
+	body := viper.GetString("notify.body")
+
+	names := make(map[string]struct{}, len(sinkSettings))
+	sinks := make([]config.NotifySinkConfig, 0, len(sinkSettings))
+	for _, sink := range sinkSettings {
+		if !slices.Contains([]string{"webhook", "ntfy", "gotify", "smtp"}, sink.Type) {
+			slog.Error("notification sink type must be webhook, ntfy, gotify or smtp",
+				"sink", sink.Name, "type", sink.Type)
+			os.Exit(1)
+		}
+		if _, ok := names[sink.Name]; ok || sink.Name == "" {
+			slog.Error("notification sinks need a unique name", "sink", sink.Name)
+			os.Exit(1)
+		}
+		names[sink.Name] = struct{}{}
+		if sink.Title == "" {
+			sink.Title = title
+		}
+		if sink.Body == "" {
+			sink.Body = body
+		}
+		sinks = append(sinks, config.NotifySinkConfig(sink))
+	}
+
+	rules := make([]config.NotifyRuleConfig, 0, len(ruleSettings))
+	for _, rule := range ruleSettings {
+		for _, name := range rule.Sinks {
+			if _, ok := names[name]; !ok {
+				slog.Error("notification rule refers to an unknown sink", "sink", name)
+				os.Exit(1)
+			}
+		}
+		if rule.Severity != "" && rule.Severity != "warning" && rule.Severity != "critical" {
+			slog.Error("notification rule severity must be warning or critical", "severity", rule.Severity)
+			os.Exit(1)
+		}
+		rules = append(rules, config.NotifyRuleConfig{
+			Sinks:    rule.Sinks,
+			Sources:  rule.Sources,
+			Critical: rule.Severity == "critical",
+			Resolved: rule.Resolved == nil || *rule.Resolved,
+		})
+	}
+
+	rateLimit := 20
+	if viper.IsSet("notify.rateLimit") {
+		rateLimit = viper.GetInt("notify.rateLimit")
+	}
+	if rateLimit < 0 {
+		slog.Error("notification rate limit must not be negative", "rateLimit", rateLimit)
+		os.Exit(1)
+	}
+	dedupWindow := 900
+	if viper.IsSet("notify.dedupWindow") {
+		dedupWindow = viper.GetInt("notify.dedupWindow")
+	}
+	if dedupWindow < 0 {
+		slog.Error("notification dedup window must not be negative", "dedupWindow", dedupWindow)
+		os.Exit(1)
+	}
+
+	return config.NewNotifyConfig(sinks, rules, rateLimit, time.Duration(dedupWindow)*time.Second)
+}
+
+// samplerInterval reads sampler.<prober> in seconds, falling back to def when unset.
+func samplerInterval(prober string, def int) time.Duration {
+	key := "sampler." + prober
+	seconds := def
+	if viper.IsSet(key) {
+		seconds = viper.GetInt(key)
+	}
+	if seconds <= 0 {
+		slog.Error("sampler interval must be a positive number of seconds", "prober", prober, "interval", seconds)
+		os.Exit(1)
+	}
+	return time.Duration(seconds) * time.Second
+}
+
 func stringMapStringToPointSlice(input map[string]string) []config.FanCurvePoint {
 	output := make([]config.FanCurvePoint, 0, len(input))
 	for k, v := range input {
@@ -0,0 +1,240 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/czechbol/lumeon/app/config"
+	"github.com/czechbol/lumeon/core"
+	"github.com/czechbol/lumeon/core/hardware"
+	"github.com/czechbol/lumeon/core/hardware/i2c"
+	"github.com/czechbol/lumeon/core/hardware/sim"
+	"github.com/czechbol/lumeon/core/resources"
+)
+
+const (
+	// fanBoardRetryInterval is how often a fan board that did not answer at
+	// startup is looked for again.
+	fanBoardRetryInterval = 30 * time.Second
+	// fanMissingAlert is raised while the fan board is looked for.
+	fanMissingAlert = "fan:missing"
+)
+
+// platform is where the hardware comes from: the host through periph.io, or
+// a simulation of it.
+type platform struct {
+	// sim is the simulated hardware, nil on the periph backend.
+	sim *sim.Simulator
+}
+
+// newPlatform sets up the configured hardware backend.
+func newPlatform(cfg config.Config) platform {
+	hardwareConfig := cfg.HardwareConfig()
+	if hardwareConfig.Backend() != config.BackendSim {
+		archCheck()
+		return platform{}
+	}
+
+	simConfig := hardwareConfig.Sim()
+	slog.Warn("simulating the hardware", "ambient", simConfig.Ambient, "load", simConfig.Load)
+	return platform{sim: sim.New(sim.Options{
+		DisplayAddress: cfg.DisplayConfig().Panel().Address,
+		FanAddress:     cfg.FanConfig().Address(),
+		Ambient:        simConfig.Ambient,
+		Load:           float64(simConfig.Load) / 100,
+		ButtonPresses:  simConfig.ButtonPresses,
+	})}
+}
+
+// archCheck warns that the periph backend will not find the Argon hardware
+// on anything but a Raspberry Pi.
+func archCheck() {
+	if runtime.GOARCH != "arm64" && runtime.GOARCH != "arm" {
+		slog.Warn("the Argon EON hardware is only found on arm64 and arm, use --simulate to simulate it",
+			"arch", runtime.GOARCH)
+	}
+}
+
+func (p platform) openBus(opts i2c.Options) (i2c.I2CBus, error) {
+	if p.sim != nil {
+		return i2c.NewBusOn(p.sim.Bus, opts), nil
+	}
+	return i2c.NewBus(opts)
+}
+
+func (p platform) newSystem(bus i2c.I2CBus, address uint16) hardware.System {
+	if p.sim != nil {
+		return sim.NewSystem(bus, address)
+	}
+	return hardware.NewSystem(bus, address)
+}
+
+func (p platform) newButton() (hardware.Button, error) {
+	if p.sim != nil {
+		return p.sim.Button, nil
+	}
+	return hardware.NewButton()
+}
+
+// cpu returns the CPU prober: the host's, or the simulated CPU.
+func (p platform) cpu() resources.CPU {
+	if p.sim != nil {
+		return p.sim.CPU()
+	}
+	return resources.NewCPU()
+}
+
+// drives returns the drive prober: the host's drives set up with opts, or the
+// simulated drives.
+func (p platform) drives(opts resources.HDDOptions) resources.HDD {
+	if p.sim != nil {
+		return p.sim.Drives()
+	}
+	return resources.NewHDD(opts)
+}
+
+// initHardware opens the I2C bus and sets up the display and the fan board
+// found on it. Whatever is missing is nil: the daemon keeps monitoring, and
+// runs the fan without a display or the display without a fan board. A fan
+// board that did not answer is looked for again by Run.
+func (app *CoreApp) initHardware() (i2c.I2CBus, hardware.OLED, hardware.Fan) {
+	bus, err := app.platform.openBus(busOptions(app.config.I2CConfig()))
+	if err != nil {
+		slog.Error("i2c bus not available, running without display and fan", "bus", app.config.I2CConfig().Bus(),
+			"error", err)
+		return nil, nil, nil
+	}
+
+	panel := displayPanel(app.config.DisplayConfig())
+	fanAddress := app.config.FanConfig().Address()
+	devices := hardware.Probe(bus, panel.Address, fanAddress)
+	slog.Info("probed i2c bus", "bus", devices.Bus,
+		"display", devices.Display.Found, "fanBoard", devices.FanBoard.Found)
+
+	var oled hardware.OLED
+	if devices.Display.Found {
+		imageConfig := app.config.DisplayConfig().Image()
+		oled, err = hardware.NewOLED(bus, panel, hardware.ConvertOptions{
+			Dither: hardware.Dither(imageConfig.Dither),
+			Scale:  hardware.Scale(imageConfig.Scale),
+			Gamma:  imageConfig.Gamma,
+		})
+		if err != nil {
+			slog.Error("failed to initialize OLED display, running without it", "error", err)
+			oled = nil
+		}
+	} else {
+		slog.Warn("no display found, running without it", "address", fmt.Sprintf("%#02x", panel.Address))
+	}
+
+	if !devices.FanBoard.Found {
+		slog.Warn("no fan board found, running without fan control until it answers",
+			"address", fmt.Sprintf("%#02x", devices.FanBoard.Address), "retry", fanBoardRetryInterval)
+		return bus, oled, nil
+	}
+
+	return bus, oled, hardware.NewFan(bus, fanAddress)
+}
+
+// waitForFanBoard looks for the fan board every interval until it answers,
+// and keeps an alert raised meanwhile. It returns false if ctx is done first.
+func waitForFanBoard(
+	ctx context.Context,
+	bus i2c.I2CBus,
+	address uint16,
+	interval time.Duration,
+	alerts core.AlertManager,
+) bool {
+	alerts.Raise(core.Alert{
+		Key:      fanMissingAlert,
+		Source:   "fan",
+		Severity: core.AlertWarning,
+		Title:    "Fan board not found",
+		Message:  fmt.Sprintf("no fan board at %#02x, the fan speed is not controlled", address),
+	})
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+		if hardware.ProbeFanBoard(bus, address).Found {
+			alerts.Resolve(fanMissingAlert)
+			return true
+		}
+	}
+}
+
+// busOptions maps the [i2c] settings to the bus options.
+func busOptions(i2cConfig config.I2CConfig) i2c.Options {
+	return i2c.Options{
+		Bus:     i2cConfig.Bus(),
+		Retries: i2cConfig.Retries(),
+		Backoff: i2cConfig.RetryBackoff(),
+	}
+}
+
+// displayPanel maps the display module settings to the hardware panel.
+func displayPanel(displayConfig config.DisplayConfig) hardware.Panel {
+	panelConfig := displayConfig.Panel()
+	return hardware.Panel{
+		Controller:       hardware.Controller(panelConfig.Controller),
+		Width:            panelConfig.Width,
+		Height:           panelConfig.Height,
+		Rotation:         panelConfig.Rotation,
+		MirrorHorizontal: panelConfig.MirrorHorizontal,
+		MirrorVertical:   panelConfig.MirrorVertical,
+		Address:          panelConfig.Address,
+	}
+}
+
+// Probe prints the Argon devices found on the configured I2C bus to w, and the
+// transfers that failed while looking for them. It returns the exit code: 1
+// if the bus cannot be opened, 0 otherwise.
+func Probe(cfg config.Config, w io.Writer) int {
+	bus, err := newPlatform(cfg).openBus(busOptions(cfg.I2CConfig()))
+	if err != nil {
+		fmt.Fprintf(w, "cannot open i2c bus %q: %v\n", cfg.I2CConfig().Bus(), err)
+		return 1
+	}
+
+	devices := hardware.Probe(bus, cfg.DisplayConfig().Panel().Address, cfg.FanConfig().Address())
+	fmt.Fprintf(w, "i2c bus:   %s\n", devices.Bus)
+	fmt.Fprintf(w, "display:   %#02x %s\n", devices.Display.Address, found(devices.Display.Found))
+	fmt.Fprintf(w, "fan board: %#02x %s\n", devices.FanBoard.Address, found(devices.FanBoard.Found))
+	fmt.Fprintf(w, "errors:    %s\n", busErrors(bus.Stats()))
+	return 0
+}
+
+// busErrors describes the failed transfers of each address in stats, or
+// returns "none".
+func busErrors(stats map[uint16]i2c.AddressStats) string {
+	var parts []string
+	for _, addr := range slices.Sorted(maps.Keys(stats)) {
+		if s := stats[addr]; s.Errors > 0 {
+			parts = append(parts, fmt.Sprintf("%#02x %d of %d transfers, %d failed after retries", addr, s.Errors,
+				s.Transactions, s.Failures))
+		}
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, "; ")
+}
+
+func found(ok bool) string {
+	if ok {
+		return "found"
+	}
+	return "not found"
+}
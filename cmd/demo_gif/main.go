@@ -1,9 +1,9 @@
 // Package main generates a demo GIF showing all OLED display pages with mock data.
-// Run: go run ./cmd/demo_gif/ [-o demo.gif] [-scale 4]
+// Run: go run ./cmd/demo_gif/ [-o demo.gif] [-scale 4] [-height 32]
 //
 // The tool starts the full DisplayService with a mock OLED that captures every
-// DrawImage call as a frame. It runs for ~8 seconds (5 s animated splash +
-// ~3 s for one full page cycle at 200 ms/page), then assembles the captured
+// DrawImage call as a frame. It runs for ~18 seconds (5 s animated splash +
+// one full page cycle at 200 ms per page and subpage), then assembles the captured
 // frames into a looping GIF, skipping the splash frames.
 package main
 
@@ -22,6 +22,7 @@ import (
 	"github.com/czechbol/lumeon/app/config"
 	"github.com/czechbol/lumeon/core"
 	"github.com/czechbol/lumeon/core/hardware"
+	hwmock "github.com/czechbol/lumeon/core/hardware/mock"
 	"github.com/czechbol/lumeon/core/hardware/types"
 	"github.com/czechbol/lumeon/core/resources"
 	resmock "github.com/czechbol/lumeon/core/resources/mock"
@@ -35,13 +36,14 @@ type capturedFrame struct {
 }
 
 type capturingOLED struct {
+	bounds image.Rectangle
 	mu     sync.Mutex
 	frames []capturedFrame
 }
 
-// copyImage makes a deep copy so the source canvas can be reused.
-func copyImage(src image.Image) image.Image {
-	b := src.Bounds()
+// copyImage makes a deep copy of the b part of src so the source canvas can be
+// reused.
+func copyImage(src image.Image, b image.Rectangle) image.Image {
 	dst := image.NewRGBA(b)
 	for y := b.Min.Y; y < b.Max.Y; y++ {
 		for x := b.Min.X; x < b.Max.X; x++ {
@@ -51,28 +53,54 @@ func copyImage(src image.Image) image.Image {
 	return dst
 }
 
+func (c *capturingOLED) Bounds() image.Rectangle {
+	return c.bounds
+}
+
+// DrawImage captures img cropped to the display, as the splash images are
+// drawn at 128×64 whatever its size.
 func (c *capturingOLED) DrawImage(img image.Image) error {
-	cp := copyImage(img)
+	cp := copyImage(img, c.bounds)
 	c.mu.Lock()
 	c.frames = append(c.frames, capturedFrame{img: cp, when: time.Now()})
 	c.mu.Unlock()
 	return nil
 }
 
-func (c *capturingOLED) DrawGIF(g *gif.GIF) error {
+func (c *capturingOLED) DrawGIF(_ context.Context, g *gif.GIF) error {
 	for _, frame := range g.Image {
 		_ = c.DrawImage(frame)
 	}
 	return nil
 }
 
-func (c *capturingOLED) Invert(bool) error                                             { return nil }
-func (c *capturingOLED) SetContrast(uint8) error                                       { return nil }
-func (c *capturingOLED) Clear() error                                                  { return nil }
-func (c *capturingOLED) DrawText(string, int, int) error                               { return nil }
-func (c *capturingOLED) DrawLines([]string) error                                      { return nil }
-func (c *capturingOLED) DrawImageWithText(image.Image, int, int, string) error         { return nil }
-func (c *capturingOLED) DrawGIFWithText(*gif.GIF, int, int, string) error              { return nil }
+// PlayGIF captures every frame once and returns a finished animation.
+func (c *capturingOLED) PlayGIF(ctx context.Context, g *gif.GIF) hardware.Animation {
+	_ = c.DrawGIF(ctx, g)
+	return finishedAnimation{}
+}
+
+type finishedAnimation struct{}
+
+func (finishedAnimation) Pause()      {}
+func (finishedAnimation) Resume()     {}
+func (finishedAnimation) Stop()       {}
+func (finishedAnimation) Wait() error { return nil }
+func (finishedAnimation) Done() <-chan struct{} {
+	done := make(chan struct{})
+	close(done)
+	return done
+}
+
+func (c *capturingOLED) Invert(bool) error                                     { return nil }
+func (c *capturingOLED) SetContrast(uint8) error                               { return nil }
+func (c *capturingOLED) Clear() error                                          { return nil }
+func (c *capturingOLED) DrawText(string, int, int) error                       { return nil }
+func (c *capturingOLED) DrawLines([]string) error                              { return nil }
+func (c *capturingOLED) DrawImageWithText(image.Image, int, int, string) error { return nil }
+func (c *capturingOLED) DrawGIFWithText(context.Context, *gif.GIF, int, int, string) error {
+	return nil
+}
 func (c *capturingOLED) Scroll(types.ScrollDirection, types.FrameRate, int, int) error { return nil }
 func (c *capturingOLED) StopScroll() error                                             { return nil }
 
@@ -82,18 +110,97 @@ var _ hardware.OLED = (*capturingOLED)(nil)
 
 func buildCPU() resources.CPU {
 	return &resmock.CPUMock{
-		GetAverageTempHandler: func() (float64, error) { return 52, nil },
-		GetStatsHandler: func() (*resources.CPUStats, error) {
+		GetAverageTempHandler: func(context.Context) (float64, error) { return 52, nil },
+		GetStatsHandler: func(context.Context) (*resources.CPUStats, error) {
 			return &resources.CPUStats{
 				UsagePercent:   42,
 				AvgTemperature: 52,
 				CoreCount:      4,
 				Cores: []resources.CoreStats{
-					{ID: 0, UsagePercent: 38, MaxFrequency: 1800},
-					{ID: 1, UsagePercent: 45, MaxFrequency: 1800},
-					{ID: 2, UsagePercent: 52, MaxFrequency: 1800},
-					{ID: 3, UsagePercent: 34, MaxFrequency: 1800},
+					{ID: 0, UsagePercent: 38, MaxFrequency: 1800, CurFrequency: 1800, Governor: "ondemand"},
+					{ID: 1, UsagePercent: 45, MaxFrequency: 1800, CurFrequency: 1800, Governor: "ondemand"},
+					{ID: 2, UsagePercent: 52, MaxFrequency: 1800, CurFrequency: 1500, Governor: "ondemand"},
+					{ID: 3, UsagePercent: 34, MaxFrequency: 1800, CurFrequency: 1500, Governor: "ondemand"},
+				},
+				ThrottleKnown: true,
+			}, nil
+		},
+	}
+}
+
+func buildSystem() resources.System {
+	const mb = 1 << 20
+	return &resmock.SystemMock{
+		GetStatsHandler: func(context.Context) (*resources.SystemStats, error) {
+			return &resources.SystemStats{
+				Hostname:     "nas",
+				Kernel:       "6.6.51+rpt-rpi-2712",
+				Uptime:       12*24*time.Hour + 4*time.Hour + 13*time.Minute,
+				Load1:        0.52,
+				Load5:        0.48,
+				Load15:       0.41,
+				RunningProcs: 2,
+				TotalProcs:   213,
+				TopCPU: []resources.ProcessStats{
+					{PID: 812, Name: "smbd", CPUPercent: 23.5, RSS: 48 * mb},
+					{PID: 1204, Name: "jellyfin", CPUPercent: 11.2, RSS: 412 * mb},
+					{PID: 990, Name: "lumeond", CPUPercent: 1.4, RSS: 18 * mb},
 				},
+				TopMemory: []resources.ProcessStats{
+					{PID: 1204, Name: "jellyfin", CPUPercent: 11.2, RSS: 412 * mb},
+					{PID: 655, Name: "dockerd", CPUPercent: 0.3, RSS: 96 * mb},
+					{PID: 812, Name: "smbd", CPUPercent: 23.5, RSS: 48 * mb},
+				},
+			}, nil
+		},
+	}
+}
+
+func buildContainers() resources.Containers {
+	const mb = 1 << 20
+	return &resmock.ContainersMock{
+		GetStatsHandler: func(context.Context) ([]resources.ContainerStats, error) {
+			return []resources.ContainerStats{
+				{Name: "jellyfin", State: "running", Health: "healthy", CPUPercent: 11.2, MemoryUsage: 412 * mb},
+				{Name: "nextcloud", State: "running", Health: "unhealthy", CPUPercent: 2.5, MemoryUsage: 180 * mb},
+				{Name: "postgres", State: "running", CPUPercent: 0.8, MemoryUsage: 96 * mb},
+				{Name: "backup", State: "exited"},
+			}, nil
+		},
+	}
+}
+
+func buildSystemd() resources.Systemd {
+	return &resmock.SystemdMock{
+		GetStatsHandler: func(context.Context) (*resources.SystemdStats, error) {
+			return &resources.SystemdStats{
+				FailedUnits: 1,
+				Units: []resources.UnitStatus{
+					{Name: "smbd.service", LoadState: "loaded", ActiveState: "active", SubState: "running"},
+					{Name: "nfs-server.service", LoadState: "loaded", ActiveState: "active", SubState: "exited"},
+					{Name: "backup.timer", LoadState: "loaded", ActiveState: "active", SubState: "waiting"},
+					{
+						Name: "backup.service", LoadState: "loaded", ActiveState: "failed", SubState: "failed",
+						Result: "exit-code", ExitStatus: 3,
+					},
+				},
+			}, nil
+		},
+	}
+}
+
+func buildUPS() resources.UPS {
+	return &resmock.UPSMock{
+		GetStatsHandler: func(context.Context) (*resources.UPSStats, error) {
+			return &resources.UPSStats{
+				Name:         "ups",
+				Status:       "OL CHRG",
+				Charge:       94,
+				ChargeKnown:  true,
+				Runtime:      38 * time.Minute,
+				RuntimeKnown: true,
+				Load:         21,
+				LoadKnown:    true,
 			}, nil
 		},
 	}
@@ -102,7 +209,7 @@ func buildCPU() resources.CPU {
 func buildMemory() resources.Memory {
 	const gb = 1 << 30
 	return &resmock.MemoryMock{
-		GetStatsHandler: func() (*resources.MemoryStats, error) {
+		GetStatsHandler: func(context.Context) (*resources.MemoryStats, error) {
 			total := uint64(8) * gb
 			used := uint64(32) * gb / 10 // 3.2 GB
 			avail := uint64(9) * gb / 2  // 4.5 GB
@@ -114,7 +221,21 @@ func buildMemory() resources.Memory {
 				Available:    avail,
 				SwapTotal:    swapTotal,
 				SwapUsed:     swapUsed,
+				Buffers:      gb / 10,
+				Cached:       3 * gb / 2,
+				SReclaimable: gb / 5,
+				Shmem:        64 << 20,
+				Dirty:        12 << 20,
+				ZFSArcSize:   2 * gb,
 				UsagePercent: float64(used) / float64(total) * 100,
+				Pressure: &resources.PressureStats{
+					CPU:    resources.Pressure{Some: resources.PressureAverages{Avg10: 1.2}},
+					Memory: resources.Pressure{Some: resources.PressureAverages{Avg10: 0.4}},
+					IO: resources.Pressure{
+						Some: resources.PressureAverages{Avg10: 8.5},
+						Full: resources.PressureAverages{Avg10: 3.1},
+					},
+				},
 			}, nil
 		},
 	}
@@ -124,10 +245,10 @@ func buildNetwork() resources.Network {
 	const mb = 1 << 20
 	const gb = 1 << 30
 	return &resmock.NetworkMock{
-		GetInterfaceStatsHandler: func(_ string) (*resources.NetworkStats, error) {
+		GetInterfaceStatsHandler: func(context.Context, string) (*resources.NetworkStats, error) {
 			return nil, nil
 		},
-		GetAllInterfaceStatsHandler: func() (map[string]*resources.NetworkStats, error) {
+		GetAllInterfaceStatsHandler: func(context.Context) (map[string]*resources.NetworkStats, error) {
 			return map[string]*resources.NetworkStats{
 				"eth0": {
 					Interface:     "eth0",
@@ -148,14 +269,37 @@ func buildNetwork() resources.Network {
 	}
 }
 
+func buildDiskIO() resources.DiskIO {
+	const mb = 1 << 20
+	return &resmock.DiskIOMock{
+		GetDeviceStatsHandler: func(context.Context, string) (*resources.DiskIOStats, error) {
+			return nil, nil
+		},
+		GetAllDeviceStatsHandler: func(context.Context) (map[string]*resources.DiskIOStats, error) {
+			return map[string]*resources.DiskIOStats{
+				"sda": {
+					Device:      "sda",
+					ReadSpeed:   48 * mb,
+					WriteSpeed:  1.5 * mb,
+					ReadIOPS:    380,
+					WriteIOPS:   24,
+					Utilization: 62,
+					Await:       7.4,
+				},
+			}, nil
+		},
+	}
+}
+
 func buildHDD() resources.HDD {
 	const gb = 1 << 30
 	return &resmock.HDDMock{
-		GetAverageTempHandler: func() (float64, error) { return 32, nil },
-		GetStatsHandler: func() ([]resources.HDDStats, error) {
+		GetAverageTempHandler: func(context.Context) (float64, error) { return 32, nil },
+		GetStatsHandler: func(context.Context) ([]resources.HDDStats, error) {
 			return []resources.HDDStats{
 				{
 					DeviceName:  "sda",
+					Label:       "Bay 1",
 					Temperature: 32,
 					TotalSize:   uint64(1000) * gb,
 					SmartStatus: resources.SmartStatus{
@@ -186,6 +330,63 @@ func buildHDD() resources.HDD {
 	}
 }
 
+func buildStorage() resources.Storage {
+	const gb = 1 << 30
+	return &resmock.StorageMock{
+		GetDevicesHandler: func(context.Context) ([]resources.StackDevice, error) { return nil, nil },
+		GetPoolsHandler: func(context.Context) ([]resources.Pool, error) {
+			return []resources.Pool{
+				{
+					Name:       "md0",
+					Type:       resources.PoolMD,
+					Level:      "raid1",
+					State:      "active",
+					Healthy:    true,
+					Mountpoint: "/srv",
+					Total:      uint64(1800) * gb,
+					Used:       uint64(700) * gb,
+					Available:  uint64(1100) * gb,
+					Devices: []resources.PoolDevice{
+						{Name: "sdb1", State: "active", Healthy: true},
+						{Name: "sdc1", State: "active", Healthy: true},
+					},
+				},
+			}, nil
+		},
+	}
+}
+
+func buildFilesystems() resources.Filesystems {
+	const gb = 1 << 30
+	return &resmock.FilesystemsMock{
+		GetStatsHandler: func(context.Context) ([]resources.FilesystemStats, error) {
+			return []resources.FilesystemStats{
+				{
+					Source:      "/dev/sda1",
+					Mountpoint:  "/",
+					FsType:      "ext4",
+					Total:       uint64(120) * gb,
+					Used:        uint64(24) * gb,
+					Available:   uint64(90) * gb,
+					UsedPercent: 21,
+					Inodes:      7864320,
+					InodesUsed:  412000,
+				},
+				{
+					Source:      "nas:/export/media",
+					Mountpoint:  "/srv/media",
+					FsType:      "nfs4",
+					Remote:      true,
+					Total:       uint64(4000) * gb,
+					Used:        uint64(2600) * gb,
+					Available:   uint64(1400) * gb,
+					UsedPercent: 65,
+				},
+			}, nil
+		},
+	}
+}
+
 // ---- GIF assembly ---------------------------------------------------------
 
 var oledPalette = color.Palette{
@@ -268,7 +469,7 @@ func buildGIF(frames []capturedFrame, outputPath string, scale int) {
 	}
 
 	fmt.Printf("wrote %d frames to %s (%dx%d pixels)\n",
-		len(gifFrames), outputPath, 128*scale, 64*scale)
+		len(gifFrames), outputPath, gifFrames[0].Rect.Dx(), gifFrames[0].Rect.Dy())
 }
 
 // ---- main -----------------------------------------------------------------
@@ -276,23 +477,57 @@ func buildGIF(frames []capturedFrame, outputPath string, scale int) {
 func main() {
 	output := flag.String("o", "docs/demo.gif", "output GIF file path")
 	scale := flag.Int("scale", 6, "pixel scale factor (default 6 → 768×384)")
+	height := flag.Int("height", 64, "display height: 64, or 32 for the compact layout")
 	flag.Parse()
 
-	oled := &capturingOLED{}
-	dispCfg := config.NewDisplayConfig(true, 200*time.Millisecond)
+	oled := &capturingOLED{bounds: image.Rect(0, 0, 128, *height)}
+	dispCfg := config.NewDisplayConfig(true, 200*time.Millisecond, config.DisplayPanelConfig{},
+		config.DisplayImageConfig{},
+		config.DisplaySplashConfig{Enabled: true, Duration: 5 * time.Second})
+
+	sampler := core.NewSampler(core.Probers{
+		CPU:         buildCPU(),
+		Memory:      buildMemory(),
+		Network:     buildNetwork(),
+		DiskIO:      buildDiskIO(),
+		Drives:      buildHDD(),
+		Filesystems: buildFilesystems(),
+		Storage:     buildStorage(),
+		System:      buildSystem(),
+		Containers:  buildContainers(),
+		Systemd:     buildSystemd(),
+		UPS:         buildUPS(),
+	}, config.NewSamplerConfig(config.SamplerIntervals{
+		CPU:         time.Second,
+		Memory:      time.Second,
+		Network:     time.Second,
+		DiskIO:      time.Second,
+		Drives:      time.Second,
+		Filesystems: time.Second,
+		Pools:       time.Second,
+		System:      time.Second,
+		Containers:  time.Second,
+		Systemd:     time.Second,
+		UPS:         time.Second,
+	}))
 
 	svc := core.NewDisplayService(
 		oled,
-		buildCPU(),
-		buildMemory(),
-		buildNetwork(),
-		buildHDD(),
+		&hwmock.SystemMock{PowerTransitionHandler: func() (hardware.PowerTransition, error) {
+			return hardware.PowerRunning, nil
+		}},
+		sampler,
+		core.NewAlertManager(),
 		dispCfg,
 	)
 
 	// Run long enough for the 5 s animated splash and one full page cycle.
-	ctx, cancel := context.WithTimeout(context.Background(), 9*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 18*time.Second)
 
+	if err := sampler.Start(ctx); err != nil {
+		cancel()
+		log.Fatalf("starting sampler: %v", err)
+	}
 	if err := svc.Start(ctx); err != nil {
 		cancel()
 		log.Fatalf("starting display service: %v", err)
@@ -1,15 +1,25 @@
 package main
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/czechbol/lumeon/app"
 	"github.com/czechbol/lumeon/app/config/settings"
+	"github.com/spf13/pflag"
 )
 
 func main() {
-	application := app.NewApp(settings.GetConfig())
-	exitCode := app.RunAndManageApp(application)
+	cfg := settings.GetConfig()
 
-	os.Exit(exitCode)
+	switch pflag.Arg(0) {
+	case "":
+		os.Exit(app.RunAndManageApp(app.NewApp(cfg)))
+	case "probe":
+		// Print the Argon devices found on the I2C bus and exit.
+		os.Exit(app.Probe(cfg, os.Stdout))
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q; usage: lumeond [-v] [--simulate] [probe]\n", pflag.Arg(0))
+		os.Exit(2)
+	}
 }
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// AlertSeverity ranks how urgent an alert is.
+type AlertSeverity int
+
+const (
+	AlertWarning AlertSeverity = iota
+	AlertCritical
+)
+
+func (s AlertSeverity) String() string {
+	switch s {
+	case AlertWarning:
+		return "warning"
+	case AlertCritical:
+		return "critical"
+	}
+	return "unknown"
+}
+
+// Alert is an active condition that needs the operator's attention.
+type Alert struct {
+	Key      string // stable identity, e.g. "drive:WD-WCC4N1234567:degraded"
+	Source   string // subsystem that raised the alert, e.g. "drive"
+	Severity AlertSeverity
+	Title    string // short summary that fits on the display, e.g. "Bay 2 degraded"
+	Message  string // full detail for logs
+	Since    time.Time
+}
+
+// AlertEvent is delivered to subscribers when an alert is raised or resolved.
+type AlertEvent struct {
+	Alert    Alert
+	Resolved bool
+}
+
+// AlertManager tracks active alerts and fans out changes to subscribers.
+type AlertManager interface {
+	// Raise activates an alert. Raising an already active key updates it and
+	// only notifies subscribers if the severity changed.
+	Raise(alert Alert)
+	// Resolve deactivates an alert. Resolving an inactive key is a no-op.
+	Resolve(key string)
+	// Active returns the active alerts, most severe first.
+	Active() []Alert
+	// Subscribe registers fn to be called on every raise or resolve.
+	Subscribe(fn func(AlertEvent))
+}
+
+type alertManagerImpl struct {
+	mutex       sync.RWMutex
+	active      map[string]Alert
+	subscribers []func(AlertEvent)
+}
+
+func NewAlertManager() AlertManager {
+	return &alertManagerImpl{
+		active: make(map[string]Alert),
+	}
+}
+
+func (am *alertManagerImpl) Raise(alert Alert) {
+	am.mutex.Lock()
+	existing, ok := am.active[alert.Key]
+	if ok {
+		alert.Since = existing.Since
+	} else if alert.Since.IsZero() {
+		alert.Since = time.Now()
+	}
+	am.active[alert.Key] = alert
+	subscribers := am.subscribers
+	am.mutex.Unlock()
+
+	if ok && existing.Severity == alert.Severity {
+		return
+	}
+
+	level := slog.LevelWarn
+	if alert.Severity == AlertCritical {
+		level = slog.LevelError
+	}
+	slog.Log(context.Background(), level, "alert raised",
+		"key", alert.Key, "severity", alert.Severity, "title", alert.Title, "message", alert.Message)
+
+	for _, fn := range subscribers {
+		fn(AlertEvent{Alert: alert})
+	}
+}
+
+func (am *alertManagerImpl) Resolve(key string) {
+	am.mutex.Lock()
+	alert, ok := am.active[key]
+	delete(am.active, key)
+	subscribers := am.subscribers
+	am.mutex.Unlock()
+
+	if !ok {
+		return
+	}
+
+	slog.Info("alert resolved", "key", key, "title", alert.Title)
+
+	for _, fn := range subscribers {
+		fn(AlertEvent{Alert: alert, Resolved: true})
+	}
+}
+
+func (am *alertManagerImpl) Active() []Alert {
+	am.mutex.RLock()
+	alerts := make([]Alert, 0, len(am.active))
+	for _, alert := range am.active {
+		alerts = append(alerts, alert)
+	}
+	am.mutex.RUnlock()
+
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].Severity != alerts[j].Severity {
+			return alerts[i].Severity > alerts[j].Severity
+		}
+		return alerts[i].Since.Before(alerts[j].Since)
+	})
+	return alerts
+}
+
+func (am *alertManagerImpl) Subscribe(fn func(AlertEvent)) {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+	am.subscribers = append(am.subscribers, fn)
+}
@@ -0,0 +1,281 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	_ "image/jpeg" // register JPEG decoder
+	"io"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/czechbol/lumeon/app/config"
+)
+
+const (
+	// apiMaxBody limits request bodies, images included.
+	apiMaxBody = 1 << 20
+	// apiMaxPixels and apiMaxGIFPixels limit image sizes before decoding;
+	// every GIF frame is decoded at full size.
+	apiMaxPixels    = 2048 * 2048
+	apiMaxGIFPixels = 512 * 512
+
+	apiDefaultDuration = 30 * time.Second
+	apiMaxDuration     = 24 * time.Hour
+	apiReadTimeout     = 10 * time.Second
+)
+
+var ErrImageTooLarge = errors.New("image is too large")
+
+// APIService serves the local HTTP API, which queues messages for the display.
+type APIService interface {
+	IsRunning() bool
+	Start(ctx context.Context) error
+	Shutdown(ctx context.Context) error
+}
+
+type apiServiceImpl struct {
+	mutex        sync.RWMutex
+	running      bool
+	display      DisplayService
+	apiConfig    config.APIConfig
+	server       *http.Server
+	shutdownChan chan struct{}
+}
+
+func NewAPIService(display DisplayService, apiConfig config.APIConfig) APIService {
+	as := &apiServiceImpl{
+		display:      display,
+		apiConfig:    apiConfig,
+		shutdownChan: make(chan struct{}),
+	}
+	as.server = &http.Server{
+		Handler:           as.handler(),
+		ReadHeaderTimeout: apiReadTimeout,
+		ReadTimeout:       apiReadTimeout,
+	}
+	return as
+}
+
+func (as *apiServiceImpl) IsRunning() bool {
+	as.mutex.RLock()
+	defer as.mutex.RUnlock()
+	return as.running
+}
+
+func (as *apiServiceImpl) Start(ctx context.Context) error {
+	as.mutex.Lock()
+	if as.running {
+		as.mutex.Unlock()
+		return nil
+	}
+	as.running = true
+	as.mutex.Unlock()
+
+	if as.apiConfig.Listen() == "" {
+		slog.Info("no API listen address configured, API disabled")
+		close(as.shutdownChan)
+		return nil
+	}
+
+	var lc net.ListenConfig
+	listener, err := lc.Listen(ctx, "tcp", as.apiConfig.Listen())
+	if err != nil {
+		// The rest of the daemon works without the API.
+		slog.Error("failed to start API, continuing without it", "listen", as.apiConfig.Listen(), "error", err)
+		close(as.shutdownChan)
+		return nil
+	}
+
+	slog.Info("starting API", "listen", listener.Addr().String())
+
+	go func() {
+		defer close(as.shutdownChan)
+		if err := as.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("API server failed", "error", err)
+		}
+	}()
+
+	return nil
+}
+
+func (as *apiServiceImpl) Shutdown(ctx context.Context) error {
+	if err := as.server.Shutdown(ctx); err != nil {
+		slog.Warn("shutdown context expired before API could stop", "error", err)
+	}
+
+	select {
+	case <-as.shutdownChan:
+		slog.Info("API stopped gracefully")
+	case <-ctx.Done():
+		slog.Warn("shutdown context expired before API could stop")
+	}
+
+	as.mutex.Lock()
+	as.running = false
+	as.mutex.Unlock()
+
+	return nil
+}
+
+func (as *apiServiceImpl) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/message", as.postMessage)
+	mux.HandleFunc("DELETE /api/v1/message", as.deleteMessages)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := as.apiConfig.Token()
+		if token != "" {
+			if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+				writeAPIError(w, http.StatusUnauthorized, "missing or wrong bearer token")
+				return
+			}
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// messageRequest is the JSON body of a text message.
+type messageRequest struct {
+	Text     string `json:"text"`
+	Priority int    `json:"priority"`
+	Duration int    `json:"duration"` // seconds
+}
+
+// postMessage queues a message. A JSON body is a text message; a PNG, JPEG
+// or GIF body is an image, with priority and duration in the query string.
+func (as *apiServiceImpl) postMessage(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, apiMaxBody)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var msg DisplayMessage
+	var duration int
+	var err error
+	switch mediaType {
+	case "application/json":
+		var req messageRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeAPIError(w, requestErrorStatus(err), "invalid JSON: "+err.Error())
+			return
+		}
+		msg.Text, msg.Priority, duration = req.Text, req.Priority, req.Duration
+	case "image/png", "image/jpeg", "image/gif":
+		if msg.Priority, duration, err = messageQuery(r); err != nil {
+			writeAPIError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if msg.Image, msg.GIF, err = decodeImage(r.Body); err != nil {
+			writeAPIError(w, requestErrorStatus(err), "invalid image: "+err.Error())
+			return
+		}
+	default:
+		writeAPIError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json, image/png, "+
+			"image/jpeg or image/gif")
+		return
+	}
+
+	msg.Duration = apiDefaultDuration
+	if duration != 0 {
+		msg.Duration = time.Duration(duration) * time.Second
+	}
+	if msg.Duration < 0 || msg.Duration > apiMaxDuration {
+		writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("duration must be between 1 and %d seconds",
+			int(apiMaxDuration.Seconds())))
+		return
+	}
+	if !msg.valid() {
+		writeAPIError(w, http.StatusBadRequest, ErrMessageInvalid.Error())
+		return
+	}
+
+	if err := as.display.ShowMessage(msg); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrMessageQueueFull) {
+			status = http.StatusServiceUnavailable
+		}
+		writeAPIError(w, status, err.Error())
+		return
+	}
+	slog.Info("message queued for display", "priority", msg.Priority, "duration", msg.Duration,
+		"remote", r.RemoteAddr)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// deleteMessages removes the current and queued messages.
+func (as *apiServiceImpl) deleteMessages(w http.ResponseWriter, _ *http.Request) {
+	as.display.ClearMessages()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// messageQuery reads the priority and duration query parameters of an image.
+func messageQuery(r *http.Request) (priority, duration int, err error) {
+	query := r.URL.Query()
+	if value := query.Get("priority"); value != "" {
+		if priority, err = strconv.Atoi(value); err != nil {
+			return 0, 0, fmt.Errorf("invalid priority %q", value)
+		}
+	}
+	if value := query.Get("duration"); value != "" {
+		if duration, err = strconv.Atoi(value); err != nil {
+			return 0, 0, fmt.Errorf("invalid duration %q", value)
+		}
+	}
+	return priority, duration, nil
+}
+
+// decodeImage decodes a PNG, JPEG or GIF image. An animated GIF is returned
+// as a GIF, anything else as a still image.
+func decodeImage(r io.Reader) (image.Image, *gif.GIF, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	maxPixels := apiMaxPixels
+	if format == "gif" {
+		maxPixels = apiMaxGIFPixels
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, nil, fmt.Errorf("%dx%d: %w", cfg.Width, cfg.Height, ErrImageTooLarge)
+	}
+
+	if format == "gif" {
+		g, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(g.Image) > 1 {
+			return nil, g, nil
+		}
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, nil, err
+}
+
+// requestErrorStatus maps an error reading the body to a status code.
+func requestErrorStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) || errors.Is(err, ErrImageTooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
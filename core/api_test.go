@@ -0,0 +1,159 @@
+package core
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/czechbol/lumeon/app/config"
+	"github.com/stretchr/testify/suite"
+)
+
+type APIServiceTestSuite struct {
+	suite.Suite
+	display *fakeDisplay
+	server  *httptest.Server
+}
+
+func TestAPIServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(APIServiceTestSuite))
+}
+
+func (s *APIServiceTestSuite) SetupTest() {
+	s.newServer("")
+}
+
+func (s *APIServiceTestSuite) newServer(token string) {
+	s.display = &fakeDisplay{}
+	service, ok := NewAPIService(s.display, config.NewAPIConfig("127.0.0.1:0", token)).(*apiServiceImpl)
+	s.Require().True(ok)
+	s.server = httptest.NewServer(service.handler())
+	s.T().Cleanup(s.server.Close)
+}
+
+func (s *APIServiceTestSuite) post(path, contentType string, body []byte, header ...string) *http.Response {
+	req, err := http.NewRequest(http.MethodPost, s.server.URL+path, bytes.NewReader(body))
+	s.Require().NoError(err)
+	req.Header.Set("Content-Type", contentType)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	resp.Body.Close()
+	return resp
+}
+
+func encodePNG(width, height int) []byte {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+func encodeGIF(frames int) []byte {
+	g := &gif.GIF{}
+	palette := color.Palette{color.Black, color.White}
+	for range frames {
+		g.Image = append(g.Image, image.NewPaletted(image.Rect(0, 0, 128, 64), palette))
+		g.Delay = append(g.Delay, 10)
+	}
+	var buf bytes.Buffer
+	_ = gif.EncodeAll(&buf, g)
+	return buf.Bytes()
+}
+
+func (s *APIServiceTestSuite) TestTextMessage() {
+	resp := s.post("/api/v1/message", "application/json",
+		[]byte(`{"text": "Backup finished", "priority": 2, "duration": 60}`))
+	s.Equal(http.StatusAccepted, resp.StatusCode)
+
+	s.Require().Len(s.display.messages, 1)
+	s.Equal(DisplayMessage{Text: "Backup finished", Priority: 2, Duration: time.Minute}, s.display.messages[0])
+
+	resp = s.post("/api/v1/message", "application/json; charset=utf-8", []byte(`{"text": "Rebuilding RAID"}`))
+	s.Equal(http.StatusAccepted, resp.StatusCode)
+	s.Require().Len(s.display.messages, 2)
+	s.Equal(apiDefaultDuration, s.display.messages[1].Duration)
+}
+
+func (s *APIServiceTestSuite) TestImageMessages() {
+	resp := s.post("/api/v1/message?priority=1&duration=10", "image/png", encodePNG(64, 64))
+	s.Equal(http.StatusAccepted, resp.StatusCode)
+
+	resp = s.post("/api/v1/message", "image/gif", encodeGIF(3))
+	s.Equal(http.StatusAccepted, resp.StatusCode)
+
+	// A GIF with one frame is a still image.
+	resp = s.post("/api/v1/message", "image/gif", encodeGIF(1))
+	s.Equal(http.StatusAccepted, resp.StatusCode)
+
+	s.Require().Len(s.display.messages, 3)
+	s.NotNil(s.display.messages[0].Image)
+	s.Equal(1, s.display.messages[0].Priority)
+	s.Equal(10*time.Second, s.display.messages[0].Duration)
+	s.Require().NotNil(s.display.messages[1].GIF)
+	s.Len(s.display.messages[1].GIF.Image, 3)
+	s.Nil(s.display.messages[2].GIF)
+	s.NotNil(s.display.messages[2].Image)
+}
+
+func (s *APIServiceTestSuite) TestRejectedMessages() {
+	for _, tc := range []struct {
+		name        string
+		path        string
+		contentType string
+		body        []byte
+		status      int
+	}{
+		{"bad JSON", "/api/v1/message", "application/json", []byte(`{"text":`), http.StatusBadRequest},
+		{"empty text", "/api/v1/message", "application/json", []byte(`{}`), http.StatusBadRequest},
+		{"too long", "/api/v1/message", "application/json", []byte(`{"text": "x", "duration": 864000}`),
+			http.StatusBadRequest},
+		{"negative", "/api/v1/message", "application/json", []byte(`{"text": "x", "duration": -1}`),
+			http.StatusBadRequest},
+		{"bad query", "/api/v1/message?priority=high", "image/png", encodePNG(1, 1), http.StatusBadRequest},
+		{"not an image", "/api/v1/message", "image/png", []byte("hello"), http.StatusBadRequest},
+		{"huge image", "/api/v1/message", "image/png", encodePNG(4096, 4096), http.StatusRequestEntityTooLarge},
+		{"huge body", "/api/v1/message", "application/json",
+			[]byte(`{"text": "` + strings.Repeat("x", apiMaxBody) + `"}`), http.StatusRequestEntityTooLarge},
+		{"plain text", "/api/v1/message", "text/plain", []byte("hello"), http.StatusUnsupportedMediaType},
+	} {
+		resp := s.post(tc.path, tc.contentType, tc.body)
+		s.Equal(tc.status, resp.StatusCode, tc.name)
+	}
+	s.Empty(s.display.messages)
+
+	s.display.err = ErrMessageQueueFull
+	resp := s.post("/api/v1/message", "application/json", []byte(`{"text": "x"}`))
+	s.Equal(http.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func (s *APIServiceTestSuite) TestClearMessages() {
+	req, err := http.NewRequest(http.MethodDelete, s.server.URL+"/api/v1/message", nil)
+	s.Require().NoError(err)
+	resp, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	resp.Body.Close()
+	s.Equal(http.StatusNoContent, resp.StatusCode)
+	s.Equal(1, s.display.cleared)
+}
+
+func (s *APIServiceTestSuite) TestToken() {
+	s.newServer("secret")
+
+	resp := s.post("/api/v1/message", "application/json", []byte(`{"text": "x"}`))
+	s.Equal(http.StatusUnauthorized, resp.StatusCode)
+	resp = s.post("/api/v1/message", "application/json", []byte(`{"text": "x"}`), "Authorization", "Bearer wrong")
+	s.Equal(http.StatusUnauthorized, resp.StatusCode)
+	resp = s.post("/api/v1/message", "application/json", []byte(`{"text": "x"}`), "Authorization", "Bearer secret")
+	s.Equal(http.StatusAccepted, resp.StatusCode)
+	s.Len(s.display.messages, 1)
+}
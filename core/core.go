@@ -1,7 +1,14 @@
 package core
 
 type CoreServices struct {
-	FanService     FanService
-	DisplayService DisplayService
-	ButtonService  ButtonService
+	Sampler         Sampler
+	FanService      FanService
+	DisplayService  DisplayService
+	ButtonService   ButtonService
+	HealthService   HealthService
+	UPSService      UPSService
+	NotifierService NotifierService
+	MQTTService     MQTTService
+	APIService      APIService
+	Alerts          AlertManager
 }
@@ -17,12 +17,16 @@ import (
 	"github.com/czechbol/lumeon/app/config"
 	"github.com/czechbol/lumeon/core/hardware"
 	"github.com/czechbol/lumeon/core/resources"
+	"periph.io/x/devices/v3/ssd1306/image1bit"
 )
 
 const (
-	displayPageCount      = 5
+	displayPageCount      = 12
+	displayContainersPage = 8  // only shown when there are containers
+	displayServicesPage   = 9  // only shown when units are watched or have failed
+	displayUPSPage        = 10 // only shown when a UPS is configured
+	displayAlertsPage     = 11 // only shown while alerts are active
 	displaySleepTimeout   = 2 * time.Minute
-	displaySplashDuration = 5 * time.Second
 
 	// Smooth-scroll animation for multi-subpage pages.
 	scrollStep  = 4                     // pixels advanced per animation frame
@@ -34,25 +38,42 @@ type DisplayService interface {
 	Start(ctx context.Context) error
 	Shutdown(ctx context.Context) error
 	Wake()
+	// ShowMessage queues msg to be shown in place of the pages. Messages are
+	// shown by priority, and a higher priority message interrupts the current one.
+	ShowMessage(msg DisplayMessage) error
+	// ClearMessages removes the current and queued messages.
+	ClearMessages()
 }
 
-// linesPerPage is the number of data rows that fit below the header.
-const linesPerPage = (canvasH - headerHeight) / lineHeight // = 3
-
 type displayServiceImpl struct {
 	mutex         sync.RWMutex
 	running       bool
 	sleeping      bool
 	oled          hardware.OLED
-	cpu           resources.CPU
-	mem           resources.Memory
-	net           resources.Network
-	drives        resources.HDD
+	system        hardware.System
+	sampler       Sampler
+	alerts        AlertManager
 	displayConfig config.DisplayConfig
+	layout        layout
 	ctx           context.Context
 	cancel        context.CancelFunc
 	shutdownChan  chan struct{}
 	wakeChan      chan struct{}
+	messageChan   chan struct{}
+	screens       screens
+
+	// messages are waiting to be shown, highest priority first; message is
+	// shown instead of the pages until messageUntil. Guarded by mutex.
+	messages     []*DisplayMessage
+	message      *DisplayMessage
+	messageUntil time.Time
+	// messageCleared is set when the shown message is cleared, until the
+	// display loop draws the pages again. Guarded by mutex.
+	messageCleared bool
+
+	// animation is the GIF message playing, if any. Only the display loop
+	// touches it.
+	animation hardware.Animation
 
 	// cpuCoreOffset scrolls core pairs on the CPU page.
 	cpuCoreOffset int
@@ -60,21 +81,23 @@ type displayServiceImpl struct {
 
 func NewDisplayService(
 	oled hardware.OLED,
-	cpu resources.CPU,
-	mem resources.Memory,
-	net resources.Network,
-	drives resources.HDD,
+	system hardware.System,
+	sampler Sampler,
+	alerts AlertManager,
 	displayConfig config.DisplayConfig,
 ) DisplayService {
+	splash := displayConfig.Splash()
 	return &displayServiceImpl{
 		oled:          oled,
-		cpu:           cpu,
-		mem:           mem,
-		net:           net,
-		drives:        drives,
+		system:        system,
+		sampler:       sampler,
+		alerts:        alerts,
 		displayConfig: displayConfig,
+		layout:        newLayout(oled.Bounds()),
 		shutdownChan:  make(chan struct{}),
 		wakeChan:      make(chan struct{}, 1),
+		messageChan:   make(chan struct{}, 1),
+		screens:       loadScreens(splash.Image, splash.WakeImage, splash.ShutdownImage),
 	}
 }
 
@@ -103,6 +126,13 @@ func (ds *displayServiceImpl) Start(ctx context.Context) error {
 
 	slog.Info("starting display loop")
 
+	// Light up the display when something goes wrong so the alert page is seen.
+	ds.alerts.Subscribe(func(event AlertEvent) {
+		if !event.Resolved {
+			ds.Wake()
+		}
+	})
+
 	go ds.displayLoop()
 
 	return nil
@@ -118,7 +148,18 @@ func (ds *displayServiceImpl) Shutdown(ctx context.Context) error {
 		slog.Warn("shutdown context expired before display loop could stop")
 	}
 
-	if err := ds.oled.Clear(); err != nil {
+	// Leave a notice up while the system goes down, so nobody pulls the power
+	// too early. Otherwise blank the display.
+	transition, err := ds.system.PowerTransition()
+	if err != nil {
+		slog.Warn("failed to check whether the system is going down", "error", err)
+	}
+	if transition != hardware.PowerRunning {
+		slog.Info("system is going down, showing shutdown screen", "reboot", transition == hardware.PowerReboot)
+		if err := ds.renderShutdownScreen(ctx, transition); err != nil {
+			slog.Error("failed to render shutdown screen", "error", err)
+		}
+	} else if err := ds.oled.Clear(); err != nil {
 		slog.Error("failed to clear display on shutdown", "error", err)
 	}
 
@@ -131,10 +172,7 @@ func (ds *displayServiceImpl) Shutdown(ctx context.Context) error {
 
 func (ds *displayServiceImpl) displayLoop() {
 	defer close(ds.shutdownChan)
-
-	// Start continuous CPU polling immediately so the cache is warm by the
-	// time the first page renders, and stays fresh throughout the cycle.
-	ds.cpu.Poll(ds.ctx)
+	defer ds.stopAnimation()
 
 	if !ds.showStartupSplash() {
 		return
@@ -151,7 +189,7 @@ func (ds *displayServiceImpl) displayLoop() {
 	if err := ds.renderPage(page); err != nil {
 		slog.Error("failed to render display page", "page", page, "error", err)
 	}
-	page = (page + 1) % displayPageCount
+	page = ds.nextPage(page)
 
 	ticker := time.NewTicker(ds.displayConfig.Interval())
 	defer ticker.Stop()
@@ -171,27 +209,48 @@ func (ds *displayServiceImpl) displayLoop() {
 			default:
 			}
 		case <-sleepTimer.C:
+			if ds.messageShowing(time.Now()) {
+				sleepTimer.Reset(displaySleepTimeout)
+				continue
+			}
 			ds.handleSleep()
 		case <-ds.wakeChan:
-			ds.handleWake(ticker, sleepTimer)
+			if ds.handleWake(ticker, sleepTimer) {
+				page = ds.handleTick(page)
+			}
+		case <-ds.messageChan:
+			if ds.handleMessage(ticker, sleepTimer) {
+				page = ds.handleTick(page)
+			}
 		}
 	}
 }
 
-// showStartupSplash renders the animated splash, warms the CPU cache, and waits
-// for the splash duration. Returns false if the context was cancelled.
+// showStartupSplash renders the animated splash, or the custom one, warms the
+// CPU cache, and waits for the splash duration. Returns false if the context
+// was cancelled.
 func (ds *displayServiceImpl) showStartupSplash() bool {
+	splash := ds.displayConfig.Splash()
+	if !splash.Enabled {
+		return true
+	}
 	slog.Info("showing startup splash")
 
 	start := time.Now()
-	if err := ds.renderAnimatedSplash(); err != nil {
-		slog.Error("failed to render animated splash, trying static", "error", err)
-	}
-	// Show static splash for the remainder of the splash duration.
-	if err := ds.renderSplash(); err != nil {
-		slog.Error("failed to render startup splash", "error", err)
+	if ds.screens.splash != nil {
+		if err := ds.renderScreen(ds.ctx, ds.screens.splash); err != nil {
+			slog.Error("failed to render startup splash", "error", err)
+		}
+	} else {
+		if err := ds.renderAnimatedSplash(); err != nil {
+			slog.Error("failed to render animated splash, trying static", "error", err)
+		}
+		// Show static splash for the remainder of the splash duration.
+		if err := ds.renderSplash(); err != nil {
+			slog.Error("failed to render startup splash", "error", err)
+		}
 	}
-	remaining := displaySplashDuration - time.Since(start)
+	remaining := splash.Duration - time.Since(start)
 	if remaining <= 0 {
 		return true
 	}
@@ -208,28 +267,69 @@ func (ds *displayServiceImpl) handleTick(page int) int {
 	sleeping := ds.sleeping
 	ds.mutex.RUnlock()
 
+	// Pages resume where they left off once the messages expire.
+	if ds.messageShowing(time.Now()) {
+		return page
+	}
+	ds.stopAnimation()
+	if msg := ds.nextMessage(time.Now()); msg != nil {
+		ds.renderMessage(msg)
+		return page
+	}
+
 	if !sleeping {
 		if err := ds.renderPage(page); err != nil {
 			slog.Error("failed to render display page", "page", page, "error", err)
 		}
-		page = (page + 1) % displayPageCount
+		page = ds.nextPage(page)
 	}
 
 	return page
 }
 
+// nextPage returns the page after page, skipping pages with nothing to show.
+func (ds *displayServiceImpl) nextPage(page int) int {
+	next := (page + 1) % displayPageCount
+	for next != 0 && ds.pageHidden(next) {
+		next = (next + 1) % displayPageCount
+	}
+	return next
+}
+
+// pageHidden reports whether page is skipped: the containers page without
+// containers, the services page with nothing to report, the UPS page without
+// a UPS and the alerts page without active alerts.
+func (ds *displayServiceImpl) pageHidden(page int) bool {
+	switch page {
+	case displayContainersPage:
+		return len(ds.sampler.Snapshot().Containers.Value) == 0
+	case displayServicesPage:
+		stats := ds.sampler.Snapshot().Systemd.Value
+		return stats == nil || (len(stats.Units) == 0 && stats.FailedUnits == 0)
+	case displayUPSPage:
+		return ds.sampler.Snapshot().UPS.Value == nil
+	case displayAlertsPage:
+		return len(ds.alerts.Active()) == 0
+	}
+	return false
+}
+
 func (ds *displayServiceImpl) handleSleep() {
 	slog.Info("display going to sleep")
 	ds.mutex.Lock()
 	ds.sleeping = true
 	ds.mutex.Unlock()
 
+	ds.stopAnimation()
 	if err := ds.oled.Clear(); err != nil {
 		slog.Error("failed to clear display for sleep", "error", err)
 	}
 }
 
-func (ds *displayServiceImpl) handleWake(ticker *time.Ticker, sleepTimer *time.Timer) {
+// handleWake wakes the display and resets the sleep timer. It reports whether
+// the display woke without a splash, in which case the caller renders a page
+// straight away.
+func (ds *displayServiceImpl) handleWake(ticker *time.Ticker, sleepTimer *time.Timer) bool {
 	ds.mutex.Lock()
 	wasSleeping := ds.sleeping
 	ds.sleeping = false
@@ -243,19 +343,25 @@ func (ds *displayServiceImpl) handleWake(ticker *time.Ticker, sleepTimer *time.T
 	}
 	sleepTimer.Reset(displaySleepTimeout)
 
-	if wasSleeping {
-		slog.Info("display waking up")
-		if err := ds.renderSplash(); err != nil {
-			slog.Error("failed to render splash on wake", "error", err)
-		}
-		// Reset ticker so the splash is visible for a full interval
-		// before data pages begin rendering.
-		ticker.Reset(ds.displayConfig.Interval())
-		select {
-		case <-ticker.C:
-		default:
-		}
+	if !wasSleeping {
+		return false
 	}
+	slog.Info("display waking up")
+	ds.stopAnimation()
+	if !ds.displayConfig.Splash().Enabled {
+		return true
+	}
+	if err := ds.renderWakeScreen(); err != nil {
+		slog.Error("failed to render splash on wake", "error", err)
+	}
+	// Reset ticker so the splash is visible for a full interval
+	// before data pages begin rendering.
+	ticker.Reset(ds.displayConfig.Interval())
+	select {
+	case <-ticker.C:
+	default:
+	}
+	return false
 }
 
 // pageSlice returns the [start, end) window into a list of `total` items for the
@@ -282,22 +388,22 @@ func pageSlice(total, offset, pageSize int) (start, end, next int) {
 
 // scrollFrame composites the fixed header with a scrolled content slice and sends it to the OLED.
 // scrollOff is how many pixels of curr have scrolled off the top; next fills the gap from below.
-func (ds *displayServiceImpl) scrollFrame(icon image.Image, title string, curr, next image.Image, scrollOff int) error {
-	frame := newCanvas()
-	drawIcon(frame, icon, 0, 0)
-	drawText(frame, title, iconSize+2, 0)
+func (ds *displayServiceImpl) scrollFrame(header, curr, next image.Image, scrollOff int) error {
+	frame := image1bit.NewVerticalLSB(image.Rect(0, 0, ds.layout.width, ds.layout.height))
+	draw.Draw(frame, image.Rect(0, 0, ds.layout.width, headerHeight), header, image.Point{}, draw.Src)
 
+	contentH := ds.layout.contentHeight()
 	showCurr := contentH - scrollOff
 	if showCurr > 0 {
 		draw.Draw(frame,
-			image.Rect(0, headerHeight, canvasW, headerHeight+showCurr),
+			image.Rect(0, headerHeight, ds.layout.width, headerHeight+showCurr),
 			curr,
 			image.Point{0, scrollOff},
 			draw.Src)
 	}
 	if next != nil && scrollOff > 0 {
 		draw.Draw(frame,
-			image.Rect(0, headerHeight+showCurr, canvasW, headerHeight+contentH),
+			image.Rect(0, headerHeight+showCurr, ds.layout.width, headerHeight+contentH),
 			next,
 			image.Point{0, 0},
 			draw.Src)
@@ -306,9 +412,9 @@ func (ds *displayServiceImpl) scrollFrame(icon image.Image, title string, curr,
 }
 
 // animateScroll smoothly scrolls the content area from curr to next over scrollStep-pixel increments.
-func (ds *displayServiceImpl) animateScroll(icon image.Image, title string, curr, next image.Image) error {
-	for off := scrollStep; off <= contentH; off += scrollStep {
-		if err := ds.scrollFrame(icon, title, curr, next, off); err != nil {
+func (ds *displayServiceImpl) animateScroll(header, curr, next image.Image) error {
+	for off := scrollStep; off <= ds.layout.contentHeight(); off += scrollStep {
+		if err := ds.scrollFrame(header, curr, next, off); err != nil {
 			return err
 		}
 		select {
@@ -321,37 +427,55 @@ func (ds *displayServiceImpl) animateScroll(icon image.Image, title string, curr
 }
 
 // scrollPage renders a page with a fixed header and a vertically-scrolling content area.
-// subpages is a list of draw functions, each filling a 128×48 content canvas.
+// subpages is a list of draw functions, each filling a content canvas.
 // The first subpage is shown immediately; subsequent subpages scroll in from below
 // with a smooth animation. The method blocks until all subpages have been displayed,
-// advancing one subpage per display interval, or until the context is cancelled.
+// advancing one subpage per display interval, until a message is queued or until
+// the context is cancelled.
 func (ds *displayServiceImpl) scrollPage(iconData []byte, title string, subpages []func(draw.Image)) error {
 	if len(subpages) == 0 {
 		return nil
 	}
 
-	contents := make([]image.Image, len(subpages))
+	header := ds.layout.newCanvas()
+	drawHeader(header, iconData, title)
+
+	contents := make([]*image1bit.VerticalLSB, len(subpages))
 	for i, fn := range subpages {
-		c := newContentCanvas()
+		c := ds.layout.newContentCanvas()
 		fn(c)
 		contents[i] = c
 	}
+	return ds.scrollContent(header, contents)
+}
 
-	icon := decodeIcon(iconData)
+// scrollContent shows the contents below header one after the other, each
+// for a display interval. On a compact layout the rows of each content are
+// stepped through instead, sharing its interval. A queued message stops it
+// between contents and is left for the display loop to show.
+func (ds *displayServiceImpl) scrollContent(header image.Image, contents []*image1bit.VerticalLSB) error {
+	var windows []*image1bit.VerticalLSB
+	for _, content := range contents {
+		windows = append(windows, ds.layout.windows(content)...)
+	}
+	dwell := ds.displayConfig.Interval() * time.Duration(ds.layout.rows) / time.Duration(ds.layout.lines)
 
-	if err := ds.scrollFrame(icon, title, contents[0], nil, 0); err != nil {
+	if err := ds.scrollFrame(header, windows[0], nil, 0); err != nil {
 		return err
 	}
 
-	for i := range contents {
+	for i := range windows {
 		select {
 		case <-ds.ctx.Done():
 			return nil
-		case <-time.After(ds.displayConfig.Interval()):
+		case <-ds.messageChan:
+			ds.signalMessage()
+			return nil
+		case <-time.After(dwell):
 		}
 
-		if i+1 < len(contents) {
-			if err := ds.animateScroll(icon, title, contents[i], contents[i+1]); err != nil {
+		if i+1 < len(windows) {
+			if err := ds.animateScroll(header, windows[i], windows[i+1]); err != nil {
 				return err
 			}
 		}
@@ -360,42 +484,71 @@ func (ds *displayServiceImpl) scrollPage(iconData []byte, title string, subpages
 	return nil
 }
 
+// present draws a page canvas. On a compact layout the header stays and the
+// rows below it are stepped through as scrollContent does, which blocks.
+func (ds *displayServiceImpl) present(canvas *image1bit.VerticalLSB) error {
+	if !ds.layout.compact() {
+		return ds.oled.DrawImage(canvas)
+	}
+	content := ds.layout.newContentCanvas()
+	draw.Draw(content, content.Rect, canvas, image.Pt(0, headerHeight), draw.Src)
+	return ds.scrollContent(canvas, []*image1bit.VerticalLSB{content})
+}
+
 func (ds *displayServiceImpl) renderPage(page int) error {
 	switch page {
 	case 0:
 		return ds.renderCPUPage()
 	case 1:
-		return ds.renderMemoryPage()
+		return ds.renderSystemPage()
 	case 2:
-		return ds.renderNetworkPage()
+		return ds.renderMemoryPage()
 	case 3:
-		return ds.renderHDDSMARTPage()
+		return ds.renderNetworkPage()
 	case 4:
+		return ds.renderDiskIOPage()
+	case 5:
+		return ds.renderHDDSMARTPage()
+	case 6:
 		return ds.renderHDDSpacePage()
+	case 7:
+		return ds.renderPoolsPage()
+	case displayContainersPage:
+		return ds.renderContainersPage()
+	case displayServicesPage:
+		return ds.renderServicesPage()
+	case displayUPSPage:
+		return ds.renderUPSPage()
+	case displayAlertsPage:
+		return ds.renderAlertsPage()
 	}
 	return nil
 }
 
 func (ds *displayServiceImpl) renderCPUPage() error {
-	stats, err := ds.cpu.GetStats()
+	snap := ds.sampler.Snapshot()
+	stats, err := sampleValue(&snap.CPU)
 	if err != nil {
 		return fmt.Errorf("getting cpu stats: %w", err)
 	}
 
-	canvas := newCanvas()
-	y := drawHeader(canvas, iconCPUPNG,
-		fmt.Sprintf("CPU %.0f\u00b0C", stats.AvgTemperature))
+	canvas := ds.layout.newCanvas()
+	title := fmt.Sprintf("CPU %.0f\u00b0C", stats.AvgTemperature)
+	if indicator := throttleIndicator(stats); indicator != "" {
+		title += " " + indicator
+	}
+	y := drawHeader(canvas, iconCPUPNG, title)
 
 	// Usage bar + percentage on the same row
 	pctText := fmt.Sprintf(" %.0f%%", stats.UsagePercent)
-	barW := canvasW - textWidth(pctText) - 2
+	barW := ds.layout.width - textWidth(pctText) - 2
 	drawProgressBar(canvas, 0, y, barW, stats.UsagePercent)
 	drawText(canvas, pctText, barW+2, y)
 	y += lineHeight
 
 	// Core usage pairs (2 per line); scroll when pairs exceed available lines.
-	// One row is consumed by the bar, so linesPerPage-1 pairs fit.
-	const coreLinesPerPage = linesPerPage - 1
+	// One row is consumed by the bar, so the other lines fit the pairs.
+	coreLinesPerPage := ds.layout.lines - 1
 	pairs := (len(stats.Cores) + 1) / 2
 	offset, end, next := pageSlice(pairs, ds.cpuCoreOffset, coreLinesPerPage)
 	ds.cpuCoreOffset = next
@@ -406,7 +559,7 @@ func (ds *displayServiceImpl) renderCPUPage() error {
 			"C%d:%.0f%%%.1fG",
 			stats.Cores[i].ID,
 			stats.Cores[i].UsagePercent,
-			stats.Cores[i].MaxFrequency/1000,
+			coreFrequency(&stats.Cores[i])/1000,
 		)
 		drawText(canvas, left, 0, y)
 		if i+1 < len(stats.Cores) {
@@ -414,18 +567,96 @@ func (ds *displayServiceImpl) renderCPUPage() error {
 				"C%d:%.0f%%%.1fG",
 				stats.Cores[i+1].ID,
 				stats.Cores[i+1].UsagePercent,
-				stats.Cores[i+1].MaxFrequency/1000,
+				coreFrequency(&stats.Cores[i+1])/1000,
 			)
-			drawText(canvas, right, canvasW/2, y)
+			drawText(canvas, right, ds.layout.width/2, y)
 		}
 		y += lineHeight
 	}
 
-	return ds.oled.DrawImage(canvas)
+	return ds.present(canvas)
+}
+
+// throttleIndicator returns a short marker for the CPU page header: the most
+// serious active throttling condition, or lowercase "uv" if undervoltage only
+// occurred earlier since boot.
+func throttleIndicator(stats *resources.CPUStats) string {
+	if !stats.ThrottleKnown {
+		return ""
+	}
+	switch flags := stats.Throttle; {
+	case flags.Has(resources.ThrottleUndervoltage):
+		return "UV"
+	case flags.Has(resources.ThrottleThrottled), flags.Has(resources.ThrottleFreqCapped):
+		return "THR"
+	case flags.Has(resources.ThrottleSoftTempLimit):
+		return "SOFT"
+	case flags.Has(resources.ThrottleUndervoltageOccurred):
+		return "uv"
+	}
+	return ""
+}
+
+// coreFrequency returns the current frequency of core in MHz, falling back to
+// the maximum where cpufreq is not available.
+func coreFrequency(core *resources.CoreStats) float64 {
+	if core.CurFrequency > 0 {
+		return core.CurFrequency
+	}
+	return core.MaxFrequency
+}
+
+func (ds *displayServiceImpl) renderSystemPage() error {
+	snap := ds.sampler.Snapshot()
+	stats, err := sampleValue(&snap.System)
+	if err != nil {
+		return fmt.Errorf("getting system stats: %w", err)
+	}
+
+	// First subpage: uptime and task counts, load averages, kernel.
+	subpages := []func(draw.Image){
+		func(content draw.Image) {
+			y := 0
+			tasks := fmt.Sprintf("%d/%d", stats.RunningProcs, stats.TotalProcs)
+			drawText(content, "up "+formatUptime(stats.Uptime), 0, y)
+			drawText(content, tasks, rightAlignX(content, tasks), y)
+			y += lineHeight
+
+			drawText(content, fmt.Sprintf("ld %.2f %.2f %.2f", stats.Load1, stats.Load5, stats.Load15), 0, y)
+			y += lineHeight
+
+			drawText(content, truncateToFit(stats.Kernel, ds.layout.width), 0, y)
+		},
+	}
+
+	// Then the busiest processes by CPU (shown as %) and by resident memory.
+	processRows := func(processes []resources.ProcessStats, value func(p *resources.ProcessStats) string) {
+		if len(processes) == 0 {
+			return
+		}
+		processes = processes[:min(len(processes), ds.layout.lines)]
+		subpages = append(subpages, func(content draw.Image) {
+			for i := range processes {
+				text := value(&processes[i])
+				x := rightAlignX(content, text)
+				drawText(content, truncateToFit(processes[i].Name, x-6), 0, i*lineHeight)
+				drawText(content, text, x, i*lineHeight)
+			}
+		})
+	}
+	processRows(stats.TopCPU, func(p *resources.ProcessStats) string {
+		return fmt.Sprintf("%.0f%%", p.CPUPercent)
+	})
+	processRows(stats.TopMemory, func(p *resources.ProcessStats) string {
+		return formatBytes(p.RSS)
+	})
+
+	return ds.scrollPage(iconSystemPNG, truncateToFit(stats.Hostname, ds.layout.width-iconSize-2), subpages)
 }
 
 func (ds *displayServiceImpl) renderMemoryPage() error {
-	stats, err := ds.mem.GetStats()
+	snap := ds.sampler.Snapshot()
+	stats, err := sampleValue(&snap.Memory)
 	if err != nil {
 		return fmt.Errorf("getting memory stats: %w", err)
 	}
@@ -436,28 +667,56 @@ func (ds *displayServiceImpl) renderMemoryPage() error {
 	swapUsedGB := float64(stats.SwapUsed) / gb
 	swapTotalGB := float64(stats.SwapTotal) / gb
 
-	canvas := newCanvas()
-	y := drawHeader(canvas, iconMemoryPNG, "Memory")
+	subpages := []func(draw.Image){
+		// Usage bar, used/available and swap.
+		func(content draw.Image) {
+			y := 0
+			pctText := fmt.Sprintf(" %.0f%%", stats.UsagePercent)
+			barW := ds.layout.width - textWidth(pctText) - 2
+			drawProgressBar(content, 0, y, barW, stats.UsagePercent)
+			drawText(content, pctText, barW+2, y)
+			y += lineHeight
 
-	// RAM bar + percentage on the same row
-	pctText := fmt.Sprintf(" %.0f%%", stats.UsagePercent)
-	barW := canvasW - textWidth(pctText) - 2
-	drawProgressBar(canvas, 0, y, barW, stats.UsagePercent)
-	drawText(canvas, pctText, barW+2, y)
-	y += lineHeight
+			drawText(content, fmt.Sprintf("Used %.1f  Avail %.1fG", usedGB, availGB), 0, y)
+			y += lineHeight
 
-	// RAM: used by apps + reclaimable-inclusive available
-	drawText(canvas, fmt.Sprintf("Used %.1f  Avail %.1fG", usedGB, availGB), 0, y)
-	y += lineHeight
+			drawText(content, fmt.Sprintf("Swap %.1f / %.1f GB", swapUsedGB, swapTotalGB), 0, y)
+		},
+		// Cache breakdown and pending writes.
+		func(content draw.Image) {
+			y := 0
+			drawText(content, fmt.Sprintf("Cache %s Shm %s",
+				formatBytes(stats.Buffers+stats.Cached+stats.SReclaimable), formatBytes(stats.Shmem)), 0, y)
+			y += lineHeight
+
+			pending := fmt.Sprintf("Dirty %s WB %s", formatBytes(stats.Dirty), formatBytes(stats.Writeback))
+			drawText(content, pending, 0, y)
+			y += lineHeight
+
+			if stats.ZFSArcSize > 0 {
+				drawText(content, "ZFS ARC "+formatBytes(stats.ZFSArcSize), 0, y)
+			}
+		},
+	}
 
-	// Swap usage
-	drawText(canvas, fmt.Sprintf("Swap %.1f / %.1f GB", swapUsedGB, swapTotalGB), 0, y)
+	// Pressure stall over the last 10 seconds: share of time some (and all) tasks waited.
+	if p := stats.Pressure; p != nil {
+		subpages = append(subpages, func(content draw.Image) {
+			y := 0
+			drawText(content, fmt.Sprintf("PSI cpu %.1f%%", p.CPU.Some.Avg10), 0, y)
+			y += lineHeight
+			drawText(content, fmt.Sprintf("mem %.1f%% full %.1f%%", p.Memory.Some.Avg10, p.Memory.Full.Avg10), 0, y)
+			y += lineHeight
+			drawText(content, fmt.Sprintf("io  %.1f%% full %.1f%%", p.IO.Some.Avg10, p.IO.Full.Avg10), 0, y)
+		})
+	}
 
-	return ds.oled.DrawImage(canvas)
+	return ds.scrollPage(iconMemoryPNG, "Memory", subpages)
 }
 
 func (ds *displayServiceImpl) renderNetworkPage() error {
-	allStats, err := ds.net.GetAllInterfaceStats()
+	snap := ds.sampler.Snapshot()
+	allStats, err := sampleValue(&snap.Network)
 	if err != nil {
 		return fmt.Errorf("getting network stats: %w", err)
 	}
@@ -474,10 +733,10 @@ func (ds *displayServiceImpl) renderNetworkPage() error {
 	sort.Strings(ifaces)
 
 	if len(ifaces) == 0 {
-		canvas := newCanvas()
+		canvas := ds.layout.newCanvas()
 		drawHeader(canvas, iconNetworkPNG, "Network")
 		drawText(canvas, "No interfaces", 0, headerHeight)
-		return ds.oled.DrawImage(canvas)
+		return ds.present(canvas)
 	}
 
 	// One subpage per interface: row1 speeds, row2 cumulative totals, row3 errors.
@@ -487,13 +746,13 @@ func (ds *displayServiceImpl) renderNetworkPage() error {
 		subpages[i] = func(content draw.Image) {
 			y := 0
 			speeds := fmt.Sprintf("\u2193%s \u2191%s", formatSpeed(stat.ReceiveSpeed), formatSpeed(stat.SendSpeed))
-			speedsX := rightAlignX(speeds)
+			speedsX := rightAlignX(content, speeds)
 			drawText(content, truncateToFit(iface, speedsX-6), 0, y)
 			drawText(content, speeds, speedsX, y)
 			y += lineHeight
 
 			totals := fmt.Sprintf("\u2193%s \u2191%s", formatBytes(stat.BytesReceived), formatBytes(stat.BytesSent))
-			totalsX := rightAlignX(totals)
+			totalsX := rightAlignX(content, totals)
 			drawText(content, "tot", 0, y)
 			drawText(content, totals, totalsX, y)
 			y += lineHeight
@@ -504,40 +763,98 @@ func (ds *displayServiceImpl) renderNetworkPage() error {
 	return ds.scrollPage(iconNetworkPNG, "Network", subpages)
 }
 
+func (ds *displayServiceImpl) renderDiskIOPage() error {
+	snap := ds.sampler.Snapshot()
+	allStats, err := sampleValue(&snap.DiskIO)
+	if err != nil {
+		return fmt.Errorf("getting disk io stats: %w", err)
+	}
+
+	devices := make([]string, 0, len(allStats))
+	for device := range allStats {
+		// Device-mapper names (dm-0) mean nothing on a small screen; their
+		// traffic is already counted on the disks below them.
+		if strings.HasPrefix(device, "dm-") {
+			continue
+		}
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
+
+	if len(devices) == 0 {
+		canvas := ds.layout.newCanvas()
+		drawHeader(canvas, iconIOPNG, "I/O")
+		drawText(canvas, "No devices", 0, headerHeight)
+		return ds.present(canvas)
+	}
+
+	// Show bay labels where the drive has one.
+	labels := make(map[string]string)
+	for i := range snap.Drives.Value {
+		labels[snap.Drives.Value[i].DeviceName] = snap.Drives.Value[i].DisplayName()
+	}
+
+	// One subpage per device: row1 name+utilization, row2 read/write speeds, row3 IOPS and await.
+	subpages := make([]func(draw.Image), len(devices))
+	for i, device := range devices {
+		stat := allStats[device]
+		name := device
+		if label, ok := labels[device]; ok {
+			name = label
+		}
+		subpages[i] = func(content draw.Image) {
+			y := 0
+			util := fmt.Sprintf("%.0f%% busy", stat.Utilization)
+			utilX := rightAlignX(content, util)
+			drawText(content, truncateToFit(name, utilX-6), 0, y)
+			drawText(content, util, utilX, y)
+			y += lineHeight
+
+			speeds := fmt.Sprintf("R %s W %s", formatSpeed(stat.ReadSpeed), formatSpeed(stat.WriteSpeed))
+			drawText(content, speeds, 0, y)
+			y += lineHeight
+
+			drawText(content, fmt.Sprintf("%.0f/%.0f IOPS %.0fms", stat.ReadIOPS, stat.WriteIOPS, stat.Await), 0, y)
+		}
+	}
+	return ds.scrollPage(iconIOPNG, "I/O", subpages)
+}
+
 func (ds *displayServiceImpl) renderHDDSMARTPage() error {
-	allStats, err := ds.drives.GetStats()
+	snap := ds.sampler.Snapshot()
+	allStats, err := sampleValue(&snap.Drives)
 	if err != nil {
 		return fmt.Errorf("getting hdd stats: %w", err)
 	}
 
 	if len(allStats) == 0 {
-		canvas := newCanvas()
+		canvas := ds.layout.newCanvas()
 		drawHeader(canvas, iconHDDPNG, "Storage")
 		drawText(canvas, "No drives", 0, headerHeight)
-		return ds.oled.DrawImage(canvas)
+		return ds.present(canvas)
 	}
 
-	// One subpage per drive: row1 name+temp+health, row2 POH+TBW, row3 error counters.
+	// One subpage per drive: row1 name+temp+health, row2 POH+TBW or self-test progress, row3 error counters.
 	subpages := make([]func(draw.Image), len(allStats))
 	for i, stat := range allStats {
 		subpages[i] = func(content draw.Image) {
 			y := 0
-			health := "PASS"
-			if !stat.SmartStatus.HealthOK {
-				health = "FAIL"
-			}
-			detail := fmt.Sprintf("%.0f\u00b0C %s", stat.Temperature, health)
-			detailX := rightAlignX(detail)
-			drawText(content, truncateToFit(stat.DeviceName, detailX-6), 0, y)
+			detail := fmt.Sprintf("%.0f\u00b0C %s", stat.Temperature, stat.Health())
+			detailX := rightAlignX(content, detail)
+			drawText(content, truncateToFit(stat.DisplayName(), detailX-6), 0, y)
 			drawText(content, detail, detailX, y)
 			y += lineHeight
 
-			drawText(
-				content,
-				fmt.Sprintf("POH:%dh TBW:%dT", stat.SmartStatus.PowerOnHours, stat.SmartStatus.TerabytesWritten),
-				0,
-				y,
-			)
+			if stat.SelfTest.Running {
+				drawText(content, fmt.Sprintf("Testing %d%%", stat.SelfTest.Progress), 0, y)
+			} else {
+				drawText(
+					content,
+					fmt.Sprintf("POH:%dh TBW:%dT", stat.SmartStatus.PowerOnHours, stat.SmartStatus.TerabytesWritten),
+					0,
+					y,
+				)
+			}
 			y += lineHeight
 
 			drawText(
@@ -557,45 +874,291 @@ func (ds *displayServiceImpl) renderHDDSMARTPage() error {
 }
 
 func (ds *displayServiceImpl) renderHDDSpacePage() error {
-	allStats, err := ds.drives.GetStats()
+	snap := ds.sampler.Snapshot()
+	allStats, err := sampleValue(&snap.Filesystems)
 	if err != nil {
-		return fmt.Errorf("getting hdd stats: %w", err)
-	}
-
-	// Collect all mounted partitions across all drives.
-	var allParts []resources.Partition
-	for _, stat := range allStats {
-		allParts = append(allParts, stat.Partitions...)
+		return fmt.Errorf("getting filesystem stats: %w", err)
 	}
 
-	if len(allParts) == 0 {
-		canvas := newCanvas()
+	if len(allStats) == 0 {
+		canvas := ds.layout.newCanvas()
 		drawHeader(canvas, iconHDDPNG, "Disk Space")
-		drawText(canvas, "No partitions", 0, headerHeight)
-		return ds.oled.DrawImage(canvas)
+		drawText(canvas, "No filesystems", 0, headerHeight)
+		return ds.present(canvas)
 	}
 
-	// One subpage per partition: row1 mountpoint, row2 usage bar+%, row3 free/total.
-	subpages := make([]func(draw.Image), len(allParts))
-	for i, part := range allParts {
+	// One subpage per filesystem: row1 mountpoint+type, row2 usage bar+%, row3 free/total and inode usage.
+	subpages := make([]func(draw.Image), len(allStats))
+	for i, stat := range allStats {
 		subpages[i] = func(content draw.Image) {
 			y := 0
-			drawText(content, truncateToFit(part.Mountpoint, canvasW), 0, y)
+			typeX := rightAlignX(content, stat.FsType)
+			drawText(content, truncateToFit(stat.Mountpoint, typeX-6), 0, y)
+			drawText(content, stat.FsType, typeX, y)
 			y += lineHeight
 
-			usedPct := 100.0 * float64(part.Total-part.Free) / float64(part.Total)
-			pctText := fmt.Sprintf(" %.0f%%", usedPct)
-			barW := canvasW - textWidth(pctText) - 2
-			drawProgressBar(content, 0, y, barW, usedPct)
+			if stat.Unavailable {
+				drawText(content, "Not responding", 0, y)
+				return
+			}
+
+			pctText := fmt.Sprintf(" %.0f%%", stat.UsedPercent)
+			barW := ds.layout.width - textWidth(pctText) - 2
+			drawProgressBar(content, 0, y, barW, stat.UsedPercent)
 			drawText(content, pctText, barW+2, y)
 			y += lineHeight
 
-			drawText(content, fmt.Sprintf("%s free / %s", formatBytes(part.Free), formatBytes(part.Total)), 0, y)
+			space := fmt.Sprintf("%s free / %s", formatBytes(stat.Available), formatBytes(stat.Total))
+			drawText(content, space, 0, y)
+			if stat.Inodes > 0 {
+				inodes := fmt.Sprintf("i%.0f%%", stat.InodesPercent())
+				if inodesX := rightAlignX(content, inodes); inodesX > textWidth(space)+4 {
+					drawText(content, inodes, inodesX, y)
+				}
+			}
 		}
 	}
 	return ds.scrollPage(iconHDDPNG, "Disk Space", subpages)
 }
 
+func (ds *displayServiceImpl) renderPoolsPage() error {
+	snap := ds.sampler.Snapshot()
+	pools, err := sampleValue(&snap.Pools)
+	if err != nil {
+		return fmt.Errorf("getting storage pools: %w", err)
+	}
+
+	if len(pools) == 0 {
+		canvas := ds.layout.newCanvas()
+		drawHeader(canvas, iconPoolPNG, "Pools")
+		drawText(canvas, "No pools", 0, headerHeight)
+		return ds.present(canvas)
+	}
+
+	// One subpage per pool: row1 name+level+health, row2 sync progress or usage bar, row3 devices+errors.
+	subpages := make([]func(draw.Image), len(pools))
+	for i, pool := range pools {
+		subpages[i] = func(content draw.Image) {
+			y := 0
+			health := "OK"
+			if !pool.Healthy {
+				health = "DEGR"
+			}
+			detail := health
+			if pool.Level != "" {
+				detail = pool.Level + " " + health
+			}
+			detailX := rightAlignX(content, detail)
+			drawText(content, truncateToFit(pool.Name, detailX-6), 0, y)
+			drawText(content, detail, detailX, y)
+			y += lineHeight
+
+			switch {
+			case pool.SyncAction != "":
+				drawText(content, fmt.Sprintf("%s %.1f%%", pool.SyncAction, pool.SyncProgress), 0, y)
+			case pool.Total > 0:
+				usedPct := 100.0 * float64(pool.Used) / float64(pool.Total)
+				pctText := fmt.Sprintf(" %.0f%%", usedPct)
+				barW := ds.layout.width - textWidth(pctText) - 2
+				drawProgressBar(content, 0, y, barW, usedPct)
+				drawText(content, pctText, barW+2, y)
+			default:
+				drawText(content, truncateToFit(pool.State, ds.layout.width), 0, y)
+			}
+			y += lineHeight
+
+			failed := 0
+			for _, device := range pool.Devices {
+				if !device.Healthy {
+					failed++
+				}
+			}
+			drawText(
+				content,
+				fmt.Sprintf("%s dev:%d/%d err:%d", pool.Type, len(pool.Devices)-failed, len(pool.Devices), pool.Errors),
+				0,
+				y,
+			)
+		}
+	}
+	return ds.scrollPage(iconPoolPNG, "Pools", subpages)
+}
+
+func (ds *displayServiceImpl) renderContainersPage() error {
+	snap := ds.sampler.Snapshot()
+	containers, err := sampleValue(&snap.Containers)
+	if err != nil {
+		return fmt.Errorf("getting containers: %w", err)
+	}
+
+	// Rows are name and status: problem containers first, then running
+	// containers with their usage.
+	var problems, usage [][2]string
+	for i := range containers {
+		container := &containers[i]
+		switch {
+		case container.State == "restarting":
+			problems = append(problems, [2]string{container.Name, "RESTART"})
+		case container.Unhealthy():
+			problems = append(problems, [2]string{container.Name, "UNHLTHY"})
+		case container.State == "dead":
+			problems = append(problems, [2]string{container.Name, "DEAD"})
+		}
+		if container.Running() {
+			text := fmt.Sprintf("%.0f%% %s", container.CPUPercent, formatBytes(container.MemoryUsage))
+			usage = append(usage, [2]string{container.Name, text})
+		}
+	}
+	title := fmt.Sprintf("Containers %d/%d", len(usage), len(containers))
+
+	rows := problems
+	if len(rows) == 0 {
+		rows = append(rows, [2]string{"All healthy", ""})
+	}
+	rows = append(rows, usage...)
+
+	var subpages []func(draw.Image)
+	for start := 0; start < len(rows); start += ds.layout.lines {
+		page := rows[start:min(start+ds.layout.lines, len(rows))]
+		subpages = append(subpages, func(content draw.Image) {
+			for i, row := range page {
+				x := rightAlignX(content, row[1])
+				drawText(content, truncateToFit(row[0], x-6), 0, i*lineHeight)
+				drawText(content, row[1], x, i*lineHeight)
+			}
+		})
+	}
+	return ds.scrollPage(iconContainerPNG, title, subpages)
+}
+
+func (ds *displayServiceImpl) renderServicesPage() error {
+	snap := ds.sampler.Snapshot()
+	stats, err := sampleValue(&snap.Systemd)
+	if err != nil {
+		return fmt.Errorf("getting systemd units: %w", err)
+	}
+
+	title := "Services"
+	if stats.FailedUnits > 0 {
+		title = fmt.Sprintf("Services %d failed", stats.FailedUnits)
+	}
+
+	if len(stats.Units) == 0 {
+		canvas := ds.layout.newCanvas()
+		drawHeader(canvas, iconServicePNG, title)
+		drawText(canvas, "systemctl --failed", 0, headerHeight)
+		return ds.present(canvas)
+	}
+
+	// One row per watched unit: name without the .service suffix and a marker.
+	var subpages []func(draw.Image)
+	for start := 0; start < len(stats.Units); start += ds.layout.lines {
+		units := stats.Units[start:min(start+ds.layout.lines, len(stats.Units))]
+		subpages = append(subpages, func(content draw.Image) {
+			for i := range units {
+				marker := unitMarker(&units[i])
+				x := rightAlignX(content, marker)
+				name := strings.TrimSuffix(units[i].Name, ".service")
+				drawText(content, truncateToFit(name, x-6), 0, i*lineHeight)
+				drawText(content, marker, x, i*lineHeight)
+			}
+		})
+	}
+	return ds.scrollPage(iconServicePNG, title, subpages)
+}
+
+// unitMarker returns the pass/fail marker shown for unit on the services page.
+func unitMarker(unit *resources.UnitStatus) string {
+	switch {
+	case unit.Missing():
+		return "MISS"
+	case unit.Failed():
+		if unit.ExitStatus != 0 {
+			return fmt.Sprintf("FAIL %d", unit.ExitStatus)
+		}
+		return "FAIL"
+	case unit.ActiveState == "active":
+		return "OK"
+	case unit.ActiveState == "activating", unit.ActiveState == "reloading":
+		return "START"
+	default:
+		return "OFF"
+	}
+}
+
+func (ds *displayServiceImpl) renderUPSPage() error {
+	snap := ds.sampler.Snapshot()
+	stats, err := sampleValue(&snap.UPS)
+	if err != nil {
+		return fmt.Errorf("getting UPS stats: %w", err)
+	}
+
+	title := "UPS online"
+	switch {
+	case stats.LowBattery():
+		title = "UPS LOW BATT"
+	case stats.OnBattery():
+		title = "UPS on battery"
+	}
+
+	canvas := ds.layout.newCanvas()
+	y := drawHeader(canvas, iconUPSPNG, title)
+
+	if stats.ChargeKnown {
+		pctText := fmt.Sprintf(" %.0f%%", stats.Charge)
+		barW := ds.layout.width - textWidth(pctText) - 2
+		drawProgressBar(canvas, 0, y, barW, stats.Charge)
+		drawText(canvas, pctText, barW+2, y)
+	} else {
+		drawText(canvas, "Charge unknown", 0, y)
+	}
+	y += lineHeight
+
+	runtime := "Runtime ?"
+	if stats.RuntimeKnown {
+		runtime = "Runtime " + formatUptime(stats.Runtime)
+	}
+	drawText(canvas, runtime, 0, y)
+	if stats.LoadKnown {
+		load := fmt.Sprintf("Load %.0f%%", stats.Load)
+		drawText(canvas, load, rightAlignX(canvas, load), y)
+	}
+	y += lineHeight
+
+	drawText(canvas, truncateToFit("Status "+stats.Status, ds.layout.width), 0, y)
+
+	return ds.present(canvas)
+}
+
+func (ds *displayServiceImpl) renderAlertsPage() error {
+	alerts := ds.alerts.Active()
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	// One subpage per alert: row1 title+severity, rows 2–3 wrapped message.
+	subpages := make([]func(draw.Image), len(alerts))
+	for i, alert := range alerts {
+		subpages[i] = func(content draw.Image) {
+			y := 0
+			severity := "WARN"
+			if alert.Severity == AlertCritical {
+				severity = "CRIT"
+			}
+			severityX := rightAlignX(content, severity)
+			drawText(content, truncateToFit(alert.Title, severityX-6), 0, y)
+			drawText(content, severity, severityX, y)
+			y += lineHeight
+
+			for _, line := range wrapText(alert.Message, ds.layout.width, ds.layout.lines-1) {
+				drawText(content, line, 0, y)
+				y += lineHeight
+			}
+		}
+	}
+	return ds.scrollPage(iconAlertPNG, fmt.Sprintf("Alerts (%d)", len(alerts)), subpages)
+}
+
 // renderSplash draws the embedded splash onto the display.
 // Uses the animated GIF on first boot, static PNG on wake.
 func (ds *displayServiceImpl) renderSplash() error {
@@ -606,13 +1169,13 @@ func (ds *displayServiceImpl) renderSplash() error {
 	return ds.oled.DrawImage(img)
 }
 
-// renderAnimatedSplash draws the animated GIF splash once.
+// renderAnimatedSplash plays the animated GIF splash once.
 func (ds *displayServiceImpl) renderAnimatedSplash() error {
 	g, err := gif.DecodeAll(bytes.NewReader(splashGIF))
 	if err != nil {
 		slog.Warn("failed to decode animated splash, falling back to static", "error", err)
 		return ds.renderSplash()
 	}
-	g.LoopCount = 1 // play once (LoopCount -1 makes the DrawGIF loop bound negative)
-	return ds.oled.DrawGIF(g)
+	g.LoopCount = -1 // play once
+	return ds.oled.DrawGIF(ds.ctx, g)
 }
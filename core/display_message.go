@@ -0,0 +1,212 @@
+package core
+
+import (
+	"errors"
+	"image"
+	"image/gif"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// displayMessageQueueSize is how many messages can wait to be shown.
+const displayMessageQueueSize = 16
+
+var (
+	ErrMessageQueueFull = errors.New("display message queue is full")
+	ErrMessageInvalid   = errors.New("message needs exactly one of text, image or GIF, and a duration")
+)
+
+// DisplayMessage is shown on the display in place of the pages for Duration.
+// Exactly one of Text, Image and GIF is set.
+type DisplayMessage struct {
+	Text  string
+	Image image.Image
+	GIF   *gif.GIF
+	// Priority orders the queue; higher is shown first and interrupts a
+	// lower priority message, which resumes afterwards.
+	Priority int
+	Duration time.Duration
+}
+
+func (m *DisplayMessage) valid() bool {
+	set := 0
+	if m.Text != "" {
+		set++
+	}
+	if m.Image != nil {
+		set++
+	}
+	if m.GIF != nil && len(m.GIF.Image) > 0 {
+		set++
+	}
+	return set == 1 && m.Duration > 0
+}
+
+func (ds *displayServiceImpl) ShowMessage(msg DisplayMessage) error {
+	if !msg.valid() {
+		return ErrMessageInvalid
+	}
+
+	ds.mutex.Lock()
+	if len(ds.messages) >= displayMessageQueueSize {
+		ds.mutex.Unlock()
+		return ErrMessageQueueFull
+	}
+	ds.queueMessage(&msg, false)
+	ds.mutex.Unlock()
+
+	ds.signalMessage()
+	return nil
+}
+
+func (ds *displayServiceImpl) ClearMessages() {
+	ds.mutex.Lock()
+	showing := ds.message != nil
+	ds.messages = nil
+	ds.message = nil
+	ds.messageUntil = time.Time{}
+	ds.messageCleared = ds.messageCleared || showing
+	ds.mutex.Unlock()
+
+	if showing {
+		ds.signalMessage()
+	}
+}
+
+// signalMessage tells the display loop that the messages changed.
+func (ds *displayServiceImpl) signalMessage() {
+	select {
+	case ds.messageChan <- struct{}{}:
+	default:
+	}
+}
+
+// queueMessage inserts msg after the messages of the same or higher priority,
+// or before those of the same priority if it is resuming. The caller holds
+// the mutex.
+func (ds *displayServiceImpl) queueMessage(msg *DisplayMessage, resume bool) {
+	i := slices.IndexFunc(ds.messages, func(queued *DisplayMessage) bool {
+		if resume {
+			return queued.Priority <= msg.Priority
+		}
+		return queued.Priority < msg.Priority
+	})
+	if i < 0 {
+		i = len(ds.messages)
+	}
+	ds.messages = slices.Insert(ds.messages, i, msg)
+}
+
+// messageShowing reports whether a message is shown and has not expired.
+func (ds *displayServiceImpl) messageShowing(now time.Time) bool {
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
+	return ds.message != nil && now.Before(ds.messageUntil)
+}
+
+// nextMessage returns the message to show now, or nil if the shown message,
+// if any, stays. A queued message replaces an expired one, or interrupts one
+// of lower priority, which is queued again with the time it has left.
+func (ds *displayServiceImpl) nextMessage(now time.Time) *DisplayMessage {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	showing := ds.message != nil && now.Before(ds.messageUntil)
+	if !showing {
+		ds.message = nil
+	}
+	if len(ds.messages) == 0 || (showing && ds.messages[0].Priority <= ds.message.Priority) {
+		return nil
+	}
+
+	next := ds.messages[0]
+	ds.messages = ds.messages[1:]
+	if showing {
+		interrupted := *ds.message
+		interrupted.Duration = ds.messageUntil.Sub(now)
+		ds.queueMessage(&interrupted, true)
+	}
+	ds.message = next
+	ds.messageCleared = false
+	ds.messageUntil = now.Add(next.Duration)
+	return next
+}
+
+// handleMessage wakes the display, without the splash, and shows the next
+// message if it is due. Returns true if the shown message was cleared and the
+// caller should draw the pages again.
+func (ds *displayServiceImpl) handleMessage(ticker *time.Ticker, sleepTimer *time.Timer) bool {
+	msg := ds.nextMessage(time.Now())
+	if msg == nil {
+		ds.mutex.Lock()
+		cleared := ds.messageCleared
+		ds.messageCleared = false
+		ds.mutex.Unlock()
+		if cleared {
+			ticker.Reset(ds.displayConfig.Interval())
+		}
+		return cleared
+	}
+
+	ds.mutex.Lock()
+	ds.sleeping = false
+	ds.mutex.Unlock()
+
+	if !sleepTimer.Stop() {
+		select {
+		case <-sleepTimer.C:
+		default:
+		}
+	}
+	sleepTimer.Reset(displaySleepTimeout)
+
+	ds.renderMessage(msg)
+
+	ticker.Reset(ds.displayConfig.Interval())
+	select {
+	case <-ticker.C:
+	default:
+	}
+	return false
+}
+
+// renderMessage draws msg: text wrapped below a header, or an image or GIF
+// scaled to the screen. A GIF loops in the background until the display loop
+// draws something else.
+func (ds *displayServiceImpl) renderMessage(msg *DisplayMessage) {
+	ds.stopAnimation()
+
+	var err error
+	switch {
+	case msg.Image != nil:
+		err = ds.oled.DrawImage(msg.Image)
+	case msg.GIF != nil:
+		looped := *msg.GIF
+		looped.LoopCount = 0
+		ds.animation = ds.oled.PlayGIF(ds.ctx, &looped)
+	default:
+		canvas := ds.layout.newCanvas()
+		y := drawHeader(canvas, iconMessagePNG, "Message")
+		for _, line := range wrapText(msg.Text, ds.layout.width, ds.layout.lines) {
+			drawText(canvas, line, 0, y)
+			y += lineHeight
+		}
+		err = ds.present(canvas)
+	}
+	if err != nil {
+		slog.Error("failed to render message", "error", err)
+	}
+}
+
+// stopAnimation stops the GIF message playing, if any.
+func (ds *displayServiceImpl) stopAnimation() {
+	if ds.animation == nil {
+		return
+	}
+	ds.animation.Stop()
+	if err := ds.animation.Wait(); err != nil {
+		slog.Error("failed to play message", "error", err)
+	}
+	ds.animation = nil
+}
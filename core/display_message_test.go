@@ -0,0 +1,181 @@
+package core
+
+import (
+	"context"
+	"image"
+	"image/draw"
+	"image/gif"
+	"testing"
+	"time"
+
+	"github.com/czechbol/lumeon/app/config"
+	"github.com/czechbol/lumeon/core/hardware"
+	hwmock "github.com/czechbol/lumeon/core/hardware/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type DisplayMessageTestSuite struct {
+	suite.Suite
+	display *displayServiceImpl
+	now     time.Time
+}
+
+func TestDisplayMessageTestSuite(t *testing.T) {
+	suite.Run(t, new(DisplayMessageTestSuite))
+}
+
+func (s *DisplayMessageTestSuite) SetupTest() {
+	display, ok := NewDisplayService(
+		&hwmock.OLEDMock{BoundsHandler: fullBounds},
+		&hwmock.SystemMock{},
+		nil,
+		NewAlertManager(),
+		config.NewDisplayConfig(true, 5*time.Second, config.DisplayPanelConfig{}, config.DisplayImageConfig{},
+			config.DisplaySplashConfig{}),
+	).(*displayServiceImpl)
+	s.Require().True(ok)
+	s.display = display
+	s.now = time.Now()
+}
+
+func (s *DisplayMessageTestSuite) show(text string, priority int, d time.Duration) {
+	s.Require().NoError(s.display.ShowMessage(DisplayMessage{Text: text, Priority: priority, Duration: d}))
+}
+
+// next returns the text of the message shown after minutes, or "" if the
+// shown message does not change.
+func (s *DisplayMessageTestSuite) next(minutes int) string {
+	msg := s.display.nextMessage(s.now.Add(time.Duration(minutes) * time.Minute))
+	if msg == nil {
+		return ""
+	}
+	return msg.Text
+}
+
+func (s *DisplayMessageTestSuite) TestPriorityOrder() {
+	s.show("low", 0, time.Minute)
+	s.show("high", 5, time.Minute)
+	s.show("low 2", 0, time.Minute)
+
+	s.Equal("high", s.next(0))
+	s.Empty(s.next(0))
+	s.True(s.display.messageShowing(s.now))
+	s.Equal("low", s.next(1))
+	s.Equal("low 2", s.next(2))
+	s.Empty(s.next(3))
+	s.False(s.display.messageShowing(s.now.Add(3 * time.Minute)))
+}
+
+func (s *DisplayMessageTestSuite) TestInterruptAndResume() {
+	s.show("Rebuilding RAID", 0, 10*time.Minute)
+	s.Equal("Rebuilding RAID", s.next(0))
+
+	// A message of the same priority waits.
+	s.show("Backup finished", 0, time.Minute)
+	s.Empty(s.next(1))
+
+	// A higher priority one interrupts, and the interrupted message resumes
+	// with the time it had left, ahead of the waiting one.
+	s.show("UPS on battery", 1, time.Minute)
+	s.Equal("UPS on battery", s.next(4))
+	s.Equal("Rebuilding RAID", s.next(5))
+	s.Empty(s.next(10))
+	s.Equal("Backup finished", s.next(11))
+}
+
+func (s *DisplayMessageTestSuite) TestClearMessages() {
+	s.show("one", 0, time.Minute)
+	s.show("two", 0, time.Minute)
+	s.Equal("one", s.next(0))
+
+	s.display.ClearMessages()
+	s.False(s.display.messageShowing(s.now))
+	s.Empty(s.next(0))
+}
+
+func (s *DisplayMessageTestSuite) TestClearMessagesRedrawsPages() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	sleepTimer := time.NewTimer(time.Hour)
+	defer sleepTimer.Stop()
+
+	// Clearing with nothing shown leaves the pages alone.
+	s.display.ClearMessages()
+	s.Empty(s.display.messageChan)
+
+	s.show("one", 0, time.Minute)
+	<-s.display.messageChan
+	s.Equal("one", s.next(0))
+
+	// The display loop is told, and draws the pages once.
+	s.display.ClearMessages()
+	s.Len(s.display.messageChan, 1)
+	<-s.display.messageChan
+	s.True(s.display.handleMessage(ticker, sleepTimer))
+	s.False(s.display.handleMessage(ticker, sleepTimer))
+}
+
+func (s *DisplayMessageTestSuite) TestMessageInterruptsScroll() {
+	oled := &hwmock.OLEDMock{
+		BoundsHandler:    fullBounds,
+		DrawImageHandler: func(image.Image) error { return nil },
+	}
+	s.display.oled = oled
+	s.display.ctx = context.Background()
+
+	s.show("urgent", 5, time.Minute)
+
+	// Only the first subpage is drawn, and the message is left for the loop.
+	subpage := func(draw.Image) {}
+	s.Require().NoError(s.display.scrollPage(iconMessagePNG, "Page", []func(draw.Image){subpage, subpage, subpage}))
+	s.Equal(1, oled.DrawImageHandlerCalled)
+	s.Len(s.display.messageChan, 1)
+}
+
+func (s *DisplayMessageTestSuite) TestInvalidMessages() {
+	s.ErrorIs(s.display.ShowMessage(DisplayMessage{Duration: time.Minute}), ErrMessageInvalid)
+	s.ErrorIs(s.display.ShowMessage(DisplayMessage{Text: "no duration"}), ErrMessageInvalid)
+	s.ErrorIs(s.display.ShowMessage(DisplayMessage{
+		Text:     "both",
+		Image:    image.NewGray(image.Rect(0, 0, 1, 1)),
+		Duration: time.Minute,
+	}), ErrMessageInvalid)
+
+	for range displayMessageQueueSize {
+		s.show("spam", 0, time.Minute)
+	}
+	s.ErrorIs(s.display.ShowMessage(DisplayMessage{Text: "one more", Duration: time.Minute}), ErrMessageQueueFull)
+}
+
+func (s *DisplayMessageTestSuite) TestGIFMessageLoops() {
+	var played *gif.GIF
+	animation := &hwmock.AnimationMock{
+		StopHandler: func() {},
+		WaitHandler: func() error { return nil },
+	}
+	oled := &hwmock.OLEDMock{
+		PlayGIFHandler: func(_ context.Context, g *gif.GIF) hardware.Animation {
+			played = g
+			return animation
+		},
+		DrawImageHandler: func(image.Image) error { return nil },
+	}
+	s.display.oled = oled
+	s.display.ctx = context.Background()
+
+	g := &gif.GIF{Image: []*image.Paletted{image.NewPaletted(image.Rect(0, 0, 1, 1), nil)}, LoopCount: -1}
+	s.display.renderMessage(&DisplayMessage{GIF: g, Duration: time.Minute})
+	s.Require().NotNil(played)
+	s.Zero(played.LoopCount, "a GIF message loops until it is replaced")
+	s.Equal(-1, g.LoopCount)
+	s.Zero(animation.StopHandlerCalled)
+
+	// The next message stops the animation before it is drawn.
+	s.display.renderMessage(&DisplayMessage{Text: "next", Duration: time.Minute})
+	s.Equal(1, animation.StopHandlerCalled)
+	s.Equal(1, oled.DrawImageHandlerCalled)
+	s.Nil(s.display.animation)
+
+	s.display.stopAnimation()
+	s.Equal(1, animation.StopHandlerCalled)
+}
@@ -6,6 +6,8 @@ import (
 	"image"
 	"image/draw"
 	_ "image/png" // register PNG decoder
+	"strings"
+	"time"
 	"unicode/utf8"
 
 	bitmapfont "github.com/hajimehoshi/bitmapfont/v3"
@@ -15,26 +17,95 @@ import (
 )
 
 const (
-	canvasW = 128
-	canvasH = 64
-
 	// Layout constants for stat pages.
-	headerHeight = 16                     // icon row height
-	contentH     = canvasH - headerHeight // scrollable content area height (48px, 3 rows)
+	headerHeight = 16 // icon row height
 	iconSize     = 16
 	lineHeight   = 16 // text line height (matches bitmapfont 6×16)
 	barHeight    = 16 // matches lineHeight so bar and text share the same row slot
 	barBorder    = 1
+
+	// minPageLines is the fewest content rows a page is laid out for; a
+	// display with room for fewer steps through them.
+	minPageLines = 3
 )
 
-// newCanvas creates a blank 128×64 monochrome canvas (all black).
-func newCanvas() *image1bit.VerticalLSB {
-	return image1bit.NewVerticalLSB(image.Rect(0, 0, canvasW, canvasH))
+// layout is how pages fit the display. Pages are drawn on a canvas as wide as
+// the display, a header and lines rows below it; a display too short for
+// all the rows, such as a 128×32 one, shows fewer at a time below the header.
+type layout struct {
+	// width and height are the size of the display.
+	width, height int
+	// lines is how many content rows a page has.
+	lines int
+	// rows is how many content rows are shown at a time.
+	rows int
+}
+
+func newLayout(bounds image.Rectangle) layout {
+	rows := max((bounds.Dy()-headerHeight)/lineHeight, 1)
+	return layout{
+		width:  bounds.Dx(),
+		height: bounds.Dy(),
+		lines:  max(rows, minPageLines),
+		rows:   rows,
+	}
+}
+
+// compact reports whether the content rows are stepped through rather than
+// shown together.
+func (l layout) compact() bool {
+	return l.rows < l.lines
+}
+
+// contentHeight is the height of the content area shown below the header.
+func (l layout) contentHeight() int {
+	return l.rows * lineHeight
 }
 
-// newContentCanvas creates a blank 128×48 monochrome canvas for a scrollable subpage content area.
-func newContentCanvas() *image1bit.VerticalLSB {
-	return image1bit.NewVerticalLSB(image.Rect(0, 0, canvasW, contentH))
+// newCanvas creates a blank page canvas (all black): the header and all the
+// content rows.
+func (l layout) newCanvas() *image1bit.VerticalLSB {
+	return image1bit.NewVerticalLSB(image.Rect(0, 0, l.width, headerHeight+l.lines*lineHeight))
+}
+
+// newContentCanvas creates a blank canvas for the content rows of a
+// scrollable subpage.
+func (l layout) newContentCanvas() *image1bit.VerticalLSB {
+	return image1bit.NewVerticalLSB(image.Rect(0, 0, l.width, l.lines*lineHeight))
+}
+
+// windows splits a content canvas into the parts shown at a time: the whole
+// canvas, or on a compact layout its rows up to the last one with anything on
+// it.
+func (l layout) windows(content *image1bit.VerticalLSB) []*image1bit.VerticalLSB {
+	if !l.compact() {
+		return []*image1bit.VerticalLSB{content}
+	}
+	used := 1
+	for row := range l.lines {
+		if !blank(content, image.Rect(0, row*lineHeight, l.width, (row+1)*lineHeight)) {
+			used = row + 1
+		}
+	}
+	var windows []*image1bit.VerticalLSB
+	for y := 0; y < used*lineHeight; y += l.contentHeight() {
+		window := image1bit.NewVerticalLSB(image.Rect(0, 0, l.width, l.contentHeight()))
+		draw.Draw(window, window.Rect, content, image.Pt(0, y), draw.Src)
+		windows = append(windows, window)
+	}
+	return windows
+}
+
+// blank reports whether no pixel of r is lit.
+func blank(img *image1bit.VerticalLSB, r image.Rectangle) bool {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if img.BitAt(x, y) {
+				return false
+			}
+		}
+	}
+	return true
 }
 
 // drawIcon blits a decoded icon image onto the canvas at (x, y).
@@ -126,8 +197,8 @@ func drawHeader(canvas draw.Image, iconData []byte, title string) int {
 }
 
 // rightAlignX returns the x position to right-align text on the canvas.
-func rightAlignX(text string) int {
-	return canvasW - textWidth(text)
+func rightAlignX(canvas image.Image, text string) int {
+	return canvas.Bounds().Max.X - textWidth(text)
 }
 
 // truncateToFit shortens text so its pixel width does not exceed maxPx.
@@ -139,6 +210,34 @@ func truncateToFit(text string, maxPx int) string {
 	return string(runes)
 }
 
+// wrapText breaks text at word boundaries into at most maxLines lines of at
+// most maxPx pixels each. Words longer than a line are cut.
+func wrapText(text string, maxPx, maxLines int) []string {
+	lines := make([]string, 0, maxLines)
+	current := ""
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if textWidth(candidate) <= maxPx {
+			current = candidate
+			continue
+		}
+		if current != "" {
+			lines = append(lines, current)
+			if len(lines) == maxLines {
+				return lines
+			}
+		}
+		current = truncateToFit(word, maxPx)
+	}
+	if current != "" && len(lines) < maxLines {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
 const (
 	bytesPerMB      = 1 << 20
 	mbDisplayThresh = 0.1 // show MB/s above this, KB/s below
@@ -172,3 +271,19 @@ func formatBytes(bytes uint64) string {
 		return fmt.Sprintf("%.0fM", float64(bytes)/(1<<20))
 	}
 }
+
+// formatUptime formats a duration as its two most significant units, e.g.
+// "12d 4h", "4h 13m" or "13m".
+func formatUptime(d time.Duration) string {
+	days := int(d / (24 * time.Hour))
+	hours := int(d / time.Hour % 24)
+	minutes := int(d / time.Minute % 60)
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"context"
+	"image"
+	"testing"
+	"time"
+
+	"github.com/czechbol/lumeon/app/config"
+	hwmock "github.com/czechbol/lumeon/core/hardware/mock"
+	"github.com/stretchr/testify/suite"
+	"periph.io/x/devices/v3/ssd1306/image1bit"
+)
+
+// fullBounds, compactBounds and tallBounds are the sizes of a 128×64, a 128×32
+// and a 128×128 display.
+func fullBounds() image.Rectangle    { return image.Rect(0, 0, 128, 64) }
+func compactBounds() image.Rectangle { return image.Rect(0, 0, 128, 32) }
+func tallBounds() image.Rectangle    { return image.Rect(0, 0, 128, 128) }
+
+type DisplayRenderTestSuite struct {
+	suite.Suite
+	drawn []*image1bit.VerticalLSB
+}
+
+func TestDisplayRenderTestSuite(t *testing.T) {
+	suite.Run(t, new(DisplayRenderTestSuite))
+}
+
+func (s *DisplayRenderTestSuite) newDisplay(bounds func() image.Rectangle) *displayServiceImpl {
+	s.drawn = nil
+	ds, ok := NewDisplayService(
+		&hwmock.OLEDMock{
+			BoundsHandler: bounds,
+			DrawImageHandler: func(img image.Image) error {
+				mono, ok := img.(*image1bit.VerticalLSB)
+				s.Require().True(ok)
+				s.drawn = append(s.drawn, mono)
+				return nil
+			},
+		},
+		&hwmock.SystemMock{},
+		nil,
+		NewAlertManager(),
+		config.NewDisplayConfig(true, 30*time.Millisecond, config.DisplayPanelConfig{}, config.DisplayImageConfig{},
+			config.DisplaySplashConfig{}),
+	).(*displayServiceImpl)
+	s.Require().True(ok)
+	ds.ctx = context.Background()
+	return ds
+}
+
+// rows returns a content canvas of a 128×64 display with text in the given rows.
+func rows(lit ...int) *image1bit.VerticalLSB {
+	content := newLayout(fullBounds()).newContentCanvas()
+	for _, row := range lit {
+		drawText(content, "row", 0, row*lineHeight)
+	}
+	return content
+}
+
+func (s *DisplayRenderTestSuite) TestLayout() {
+	full := newLayout(fullBounds())
+	s.False(full.compact())
+	s.Equal(3, full.lines)
+	s.Equal(3, full.rows)
+	s.Equal(fullBounds(), full.newCanvas().Rect)
+	s.Len(full.windows(rows(0)), 1)
+
+	tall := newLayout(tallBounds())
+	s.False(tall.compact())
+	s.Equal(7, tall.lines)
+	s.Equal(tallBounds(), tall.newCanvas().Rect)
+	s.Equal(image.Rect(0, 0, 128, 112), tall.newContentCanvas().Rect)
+
+	compact := newLayout(compactBounds())
+	s.True(compact.compact())
+	s.Equal(minPageLines, compact.lines)
+	s.Equal(1, compact.rows)
+	s.Equal(lineHeight, compact.contentHeight())
+	s.Len(compact.windows(rows(0, 1, 2)), 3)
+	s.Len(compact.windows(rows(0, 2)), 3, "blank rows between are kept")
+	s.Len(compact.windows(rows(0)), 1, "trailing blank rows are dropped")
+	s.Len(compact.windows(rows()), 1)
+}
+
+func (s *DisplayRenderTestSuite) TestPresent() {
+	canvas := newLayout(fullBounds()).newCanvas()
+	drawHeader(canvas, iconMessagePNG, "Message")
+	for row := range minPageLines {
+		drawText(canvas, "row", 0, headerHeight+row*lineHeight)
+	}
+
+	ds := s.newDisplay(fullBounds)
+	s.Require().NoError(ds.present(canvas))
+	s.Require().Len(s.drawn, 1)
+	s.Equal(canvas, s.drawn[0])
+
+	// A 128×32 display keeps the header and scrolls through the three rows.
+	ds = s.newDisplay(compactBounds)
+	s.Require().NoError(ds.present(canvas))
+	s.Require().Len(s.drawn, 1+2*lineHeight/scrollStep)
+	for _, frame := range s.drawn {
+		s.Equal(compactBounds(), frame.Rect)
+		s.False(blank(frame, image.Rect(0, 0, frame.Rect.Dx(), headerHeight)))
+	}
+	first, last := s.drawn[0], s.drawn[len(s.drawn)-1]
+	for y := range lineHeight {
+		for x := range canvas.Rect.Dx() {
+			s.Require().Equal(canvas.BitAt(x, y+headerHeight), first.BitAt(x, y+headerHeight))
+			s.Require().Equal(canvas.BitAt(x, y+headerHeight+2*lineHeight), last.BitAt(x, y+headerHeight))
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/gif"
+	"log/slog"
+	"os"
+
+	"github.com/czechbol/lumeon/core/hardware"
+)
+
+// screen is a custom splash, wake or shutdown screen: a still image or a GIF,
+// which plays once and leaves its last frame up.
+type screen struct {
+	image image.Image
+	gif   *gif.GIF
+}
+
+// screens are the custom screens from the config; nil ones use the built-in.
+type screens struct {
+	splash   *screen
+	wake     *screen
+	shutdown *screen
+}
+
+// loadScreens loads the configured screen files. A file that cannot be
+// loaded is logged and the built-in screen is used instead.
+func loadScreens(splashImage, wakeImage, shutdownImage string) screens {
+	load := func(name, path string) *screen {
+		if path == "" {
+			return nil
+		}
+		s, err := loadScreen(path)
+		if err != nil {
+			slog.Error("failed to load display image, using the built-in one", "screen", name, "path", path,
+				"error", err)
+			return nil
+		}
+		return s
+	}
+	return screens{
+		splash:   load("splash", splashImage),
+		wake:     load("wake", wakeImage),
+		shutdown: load("shutdown", shutdownImage),
+	}
+}
+
+func loadScreen(path string) (*screen, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, g, err := decodeImage(f)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", path, err)
+	}
+	return &screen{image: img, gif: g}, nil
+}
+
+// renderScreen draws s, playing a GIF once or until ctx is cancelled.
+func (ds *displayServiceImpl) renderScreen(ctx context.Context, s *screen) error {
+	if s.gif != nil {
+		g := *s.gif
+		g.LoopCount = -1
+		return ds.oled.DrawGIF(ctx, &g)
+	}
+	return ds.oled.DrawImage(s.image)
+}
+
+// renderWakeScreen draws the wake screen: the custom wake image, else the
+// custom splash, else the built-in static splash.
+func (ds *displayServiceImpl) renderWakeScreen() error {
+	switch {
+	case ds.screens.wake != nil:
+		return ds.renderScreen(ds.ctx, ds.screens.wake)
+	case ds.screens.splash != nil:
+		return ds.renderScreen(ds.ctx, ds.screens.splash)
+	}
+	return ds.renderSplash()
+}
+
+// renderShutdownScreen draws the custom shutdown image, or a notice that the
+// system is powering off or rebooting. It stays up after lumeond exits.
+func (ds *displayServiceImpl) renderShutdownScreen(ctx context.Context, transition hardware.PowerTransition) error {
+	if ds.screens.shutdown != nil {
+		return ds.renderScreen(ctx, ds.screens.shutdown)
+	}
+
+	title, text := "Shutting down", "Please wait. Do not unplug the power yet."
+	if transition == hardware.PowerReboot {
+		title, text = "Rebooting", "Please wait, the NAS will be back shortly."
+	}
+	canvas := ds.layout.newCanvas()
+	y := drawHeader(canvas, iconPowerPNG, title)
+	for _, line := range wrapText(text, ds.layout.width, ds.layout.lines) {
+		drawText(canvas, line, 0, y)
+		y += lineHeight
+	}
+	return ds.present(canvas)
+}
@@ -0,0 +1,155 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/czechbol/lumeon/app/config"
+	"github.com/czechbol/lumeon/core/hardware"
+	hwmock "github.com/czechbol/lumeon/core/hardware/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var errSystemctl = errors.New("systemctl failed")
+
+type DisplayScreenTestSuite struct {
+	suite.Suite
+	oled       *hwmock.OLEDMock
+	system     *hwmock.SystemMock
+	transition hardware.PowerTransition
+	drawn      []image.Image
+	gifs       []*gif.GIF
+}
+
+func TestDisplayScreenTestSuite(t *testing.T) {
+	suite.Run(t, new(DisplayScreenTestSuite))
+}
+
+func (s *DisplayScreenTestSuite) SetupTest() {
+	s.drawn, s.gifs = nil, nil
+	s.transition = hardware.PowerRunning
+	s.oled = &hwmock.OLEDMock{
+		BoundsHandler: fullBounds,
+		DrawImageHandler: func(img image.Image) error {
+			s.drawn = append(s.drawn, img)
+			return nil
+		},
+		DrawGIFHandler: func(_ context.Context, g *gif.GIF) error {
+			s.gifs = append(s.gifs, g)
+			return nil
+		},
+		ClearHandler: func() error { return nil },
+	}
+	s.system = &hwmock.SystemMock{
+		PowerTransitionHandler: func() (hardware.PowerTransition, error) { return s.transition, nil },
+	}
+}
+
+func (s *DisplayScreenTestSuite) newDisplay(splash config.DisplaySplashConfig) *displayServiceImpl {
+	ds, ok := NewDisplayService(
+		s.oled,
+		s.system,
+		nil,
+		NewAlertManager(),
+		config.NewDisplayConfig(true, 5*time.Second, config.DisplayPanelConfig{}, config.DisplayImageConfig{}, splash),
+	).(*displayServiceImpl)
+	s.Require().True(ok)
+	return ds
+}
+
+// writeFile writes data to a file in a temporary directory and returns its path.
+func (s *DisplayScreenTestSuite) writeFile(name string, data []byte) string {
+	path := filepath.Join(s.T().TempDir(), name)
+	s.Require().NoError(os.WriteFile(path, data, 0o600))
+	return path
+}
+
+// shutdown stops a display whose loop has already exited.
+func (s *DisplayScreenTestSuite) shutdown(ds *displayServiceImpl) {
+	ds.ctx, ds.cancel = context.WithCancel(context.Background())
+	close(ds.shutdownChan)
+	s.Require().NoError(ds.Shutdown(context.Background()))
+}
+
+func (s *DisplayScreenTestSuite) TestLoadScreens() {
+	png := s.writeFile("logo.png", encodePNG(64, 32))
+	animated := s.writeFile("logo.gif", encodeGIF(3))
+
+	loaded := loadScreens(png, animated, filepath.Join(s.T().TempDir(), "missing.png"))
+	s.Require().NotNil(loaded.splash)
+	s.Equal(image.Rect(0, 0, 64, 32), loaded.splash.image.Bounds())
+	s.Require().NotNil(loaded.wake)
+	s.Len(loaded.wake.gif.Image, 3)
+	s.Nil(loaded.shutdown)
+
+	s.Equal(screens{}, loadScreens("", "", ""))
+}
+
+func (s *DisplayScreenTestSuite) TestStartupSplash() {
+	ds := s.newDisplay(config.DisplaySplashConfig{Enabled: false, Duration: time.Hour})
+	s.True(ds.showStartupSplash())
+	s.Empty(s.drawn)
+	s.Empty(s.gifs)
+
+	ds = s.newDisplay(config.DisplaySplashConfig{
+		Enabled: true,
+		Image:   s.writeFile("logo.gif", encodeGIF(2)),
+	})
+	ds.ctx = context.Background()
+	s.True(ds.showStartupSplash())
+	s.Require().Len(s.gifs, 1)
+	s.Equal(-1, s.gifs[0].LoopCount, "a custom splash plays once")
+	s.Empty(s.drawn, "a custom splash replaces the built-in static one")
+}
+
+func (s *DisplayScreenTestSuite) TestWakeScreen() {
+	splash := s.writeFile("splash.png", encodePNG(10, 10))
+	wake := s.writeFile("wake.png", encodePNG(20, 20))
+
+	ds := s.newDisplay(config.DisplaySplashConfig{Enabled: true, Image: splash, WakeImage: wake})
+	s.Require().NoError(ds.renderWakeScreen())
+	ds = s.newDisplay(config.DisplaySplashConfig{Enabled: true, Image: splash})
+	s.Require().NoError(ds.renderWakeScreen())
+	ds = s.newDisplay(config.DisplaySplashConfig{Enabled: true})
+	s.Require().NoError(ds.renderWakeScreen())
+
+	s.Require().Len(s.drawn, 3)
+	s.Equal(20, s.drawn[0].Bounds().Dx())
+	s.Equal(10, s.drawn[1].Bounds().Dx())
+	s.Equal(fullBounds().Dx(), s.drawn[2].Bounds().Dx())
+}
+
+func (s *DisplayScreenTestSuite) TestShutdownClears() {
+	s.shutdown(s.newDisplay(config.DisplaySplashConfig{}))
+	s.Equal(1, s.oled.ClearHandlerCalled)
+	s.Empty(s.drawn)
+
+	// When systemd cannot be asked, blank the display as before.
+	s.system.PowerTransitionHandler = func() (hardware.PowerTransition, error) {
+		return hardware.PowerRunning, errSystemctl
+	}
+	s.shutdown(s.newDisplay(config.DisplaySplashConfig{}))
+	s.Equal(2, s.oled.ClearHandlerCalled)
+}
+
+func (s *DisplayScreenTestSuite) TestShutdownScreen() {
+	for _, transition := range []hardware.PowerTransition{hardware.PowerOff, hardware.PowerReboot} {
+		s.transition = transition
+		s.shutdown(s.newDisplay(config.DisplaySplashConfig{}))
+	}
+	s.Zero(s.oled.ClearHandlerCalled)
+	s.Require().Len(s.drawn, 2)
+	s.NotEqual(s.drawn[0], s.drawn[1], "poweroff and reboot show different notices")
+
+	s.transition = hardware.PowerOff
+	s.shutdown(s.newDisplay(config.DisplaySplashConfig{ShutdownImage: s.writeFile("bye.png", encodePNG(32, 32))}))
+	s.Require().Len(s.drawn, 3)
+	s.Equal(image.Rect(0, 0, 32, 32), s.drawn[2].Bounds())
+	s.Zero(s.oled.ClearHandlerCalled)
+}
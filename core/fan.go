@@ -5,6 +5,8 @@ package core
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"sync"
 	"time"
@@ -14,34 +16,103 @@ import (
 	"github.com/czechbol/lumeon/core/resources"
 )
 
+const (
+	// fanWriteAlert is raised while the fan speed cannot be set.
+	fanWriteAlert = "fan:write"
+	// fanSampleWait is how soon the fan is checked again while the first
+	// temperature samples are awaited.
+	fanSampleWait = time.Second
+)
+
 type FanService interface {
 	IsRunning() bool
 	Start(ctx context.Context) error
 	Shutdown(context.Context) error
+	// SetOverride holds the fan at speed percent for d instead of following
+	// the temperature curves, unless it is hotter than they reach.
+	SetOverride(speed uint8, d time.Duration)
+	// ClearOverride returns the fan to the temperature curves.
+	ClearOverride()
+	// Speed returns the speed the fan was last set to and whether it is
+	// overridden.
+	Speed() (speed uint8, overridden bool)
 }
 
 type fanServiceImpl struct {
 	mutex        sync.RWMutex
 	running      bool
 	fan          hardware.Fan
-	cpu          resources.CPU
-	drives       resources.HDD
+	sampler      Sampler
+	alerts       AlertManager
 	fanConfig    config.FanConfig
 	ctx          context.Context
 	cancel       context.CancelFunc
 	shutdownChan chan struct{}
+	// adjustChan asks the fan loop to apply an override change immediately.
+	adjustChan chan struct{}
+
+	// speed is the speed last set; override and overrideUntil are the active
+	// override, if overrideUntil is in the future. Guarded by mutex.
+	speed         uint8
+	override      uint8
+	overrideUntil time.Time
+
+	// Cumulative drive busy time at the previous fan check, for the I/O boost.
+	prevBusy   map[string]time.Duration
+	prevBusyAt time.Time
 }
 
-func NewFanService(fan hardware.Fan, cpu resources.CPU, drives resources.HDD, fanConfig config.FanConfig) FanService {
+func NewFanService(fan hardware.Fan, sampler Sampler, alerts AlertManager, fanConfig config.FanConfig) FanService {
 	return &fanServiceImpl{
 		fan:          fan,
-		cpu:          cpu,
-		drives:       drives,
+		sampler:      sampler,
+		alerts:       alerts,
 		fanConfig:    fanConfig,
 		shutdownChan: make(chan struct{}),
+		adjustChan:   make(chan struct{}, 1),
 	}
 }
 
+func (fs *fanServiceImpl) SetOverride(speed uint8, d time.Duration) {
+	fs.mutex.Lock()
+	fs.override = min(speed, 100)
+	fs.overrideUntil = time.Now().Add(d)
+	fs.mutex.Unlock()
+
+	slog.Info("fan speed overridden", "speed", speed, "duration", d)
+	fs.adjustNow()
+}
+
+func (fs *fanServiceImpl) ClearOverride() {
+	fs.mutex.Lock()
+	fs.overrideUntil = time.Time{}
+	fs.mutex.Unlock()
+
+	slog.Info("fan override cleared")
+	fs.adjustNow()
+}
+
+func (fs *fanServiceImpl) Speed() (uint8, bool) {
+	fs.mutex.RLock()
+	defer fs.mutex.RUnlock()
+	return fs.speed, time.Now().Before(fs.overrideUntil)
+}
+
+// adjustNow wakes the fan loop without waiting for the next check.
+func (fs *fanServiceImpl) adjustNow() {
+	select {
+	case fs.adjustChan <- struct{}{}:
+	default:
+	}
+}
+
+// activeOverride returns the override speed, if one has not expired.
+func (fs *fanServiceImpl) activeOverride(now time.Time) (uint8, bool) {
+	fs.mutex.RLock()
+	defer fs.mutex.RUnlock()
+	return fs.override, now.Before(fs.overrideUntil)
+}
+
 func (fs *fanServiceImpl) IsRunning() bool {
 	fs.mutex.RLock()
 	defer fs.mutex.RUnlock()
@@ -96,29 +167,78 @@ func (fs *fanServiceImpl) fanLoop() {
 			slog.Error("failed to adjust fan speed", "error", err)
 		}
 
+		// Until the first samples are in, check again soon rather than
+		// waiting a whole tick.
+		var sampled <-chan time.Time
+		if !curvesReady(fs.sampler.Snapshot()) {
+			sampled = time.After(fanSampleWait)
+		}
+
 		select {
 		case <-fs.ctx.Done():
 			slog.Info("stopping fan loop due to context cancellation")
 			return
 		case <-ticker.C:
 			// Continue to the next iteration
+		case <-sampled:
+		case <-fs.adjustChan:
 		}
 	}
 }
 
 func (fs *fanServiceImpl) adjustFanSpeed(currentSpeed uint8) (uint8, error) {
-	tempRequestedByCPU := fs.getCPUFanSpeed()
-	tempRequestedByDrives := fs.getDriveFanSpeed()
+	snap := fs.sampler.Snapshot()
+	override, overridden := fs.activeOverride(time.Now())
+
+	if !curvesReady(snap) {
+		if !overridden {
+			// Right after start the probers have not answered yet; that is
+			// no reason to run the fan at full speed.
+			slog.Debug("waiting for the first temperature samples", "current", currentSpeed)
+			return currentSpeed, nil
+		}
+		slog.Debug("fan speed overridden", "override", override)
+		return fs.setSpeed(currentSpeed, override)
+	}
 
-	speed := max(tempRequestedByCPU, tempRequestedByDrives)
+	cpuSpeed, cpuHot := fs.getCPUFanSpeed(snap)
+	driveSpeed, drivesHot := fs.getDriveFanSpeed(snap)
+	speed := max(cpuSpeed, driveSpeed, fs.getIOBoostFanSpeed(snap))
+	if overridden {
+		// An override can quiet the fan, but not once the CPU or the drives
+		// are hotter than their curves reach, or cannot be read.
+		if (cpuHot || drivesHot) && speed > override {
+			slog.Warn("too hot for the fan override, following the curves", "curves", speed, "override", override)
+		} else {
+			slog.Debug("fan speed overridden", "curves", speed, "override", override)
+			speed = override
+		}
+	}
 
-	if speed != currentSpeed {
+	return fs.setSpeed(currentSpeed, speed)
+}
+
+// setSpeed sets the fan to speed if it is not at it, and returns the speed it
+// is at.
+func (fs *fanServiceImpl) setSpeed(currentSpeed, speed uint8) (uint8, error) {
+	if speed != currentSpeed || fs.boardReset(currentSpeed) {
 		slog.Info("altering fan speed", "speed", speed)
 		if err := fs.fan.SetSpeed(speed); err != nil {
 			slog.Error("Failed to set fan speed", "error", err)
+			fs.alerts.Raise(Alert{
+				Key:      fanWriteAlert,
+				Source:   "fan",
+				Severity: AlertWarning,
+				Title:    "Fan not responding",
+				Message:  fmt.Sprintf("failed to set the fan to %d%%: %v", speed, err),
+			})
 			return currentSpeed, err
 		}
+		fs.alerts.Resolve(fanWriteAlert)
 		currentSpeed = speed
+		fs.mutex.Lock()
+		fs.speed = speed
+		fs.mutex.Unlock()
 	} else {
 		slog.Info("requested fan speed did not change", "current", currentSpeed)
 	}
@@ -126,48 +246,126 @@ func (fs *fanServiceImpl) adjustFanSpeed(currentSpeed uint8) (uint8, error) {
 	return currentSpeed, nil
 }
 
-func (fs *fanServiceImpl) getCPUFanSpeed() uint8 {
+// boardReset reads back the fan speed, where the board allows it, and tells
+// whether the board has lost the speed it was set to, as it does when it
+// resets.
+func (fs *fanServiceImpl) boardReset(currentSpeed uint8) bool {
+	applied, err := fs.fan.Speed()
+	if errors.Is(err, hardware.ErrNotImplemented) {
+		return false
+	}
+	if err != nil {
+		slog.Warn("failed to read back fan speed", "error", err)
+		return false
+	}
+	if applied == currentSpeed {
+		return false
+	}
+	slog.Warn("fan board lost its speed, setting it again", "speed", currentSpeed, "board", applied)
+	return true
+}
+
+// curvesReady tells whether the CPU and the drives have been sampled, or have
+// failed to be, so the curves can be followed.
+func curvesReady(snap *Snapshot) bool {
+	return !errors.Is(sampleError(&snap.CPU), ErrSampleNotReady) &&
+		!errors.Is(sampleError(&snap.Drives), ErrSampleNotReady)
+}
+
+// getCPUFanSpeed returns the speed the CPU curve asks for, and whether the
+// CPU is hotter than the curve reaches or cannot be read.
+func (fs *fanServiceImpl) getCPUFanSpeed(snap *Snapshot) (uint8, bool) {
 	slog.Debug("obtaining fan speed from CPU temp curve")
 
-	temp, err := fs.cpu.GetAverageTemp()
-	if err != nil {
+	if err := sampleError(&snap.CPU); err != nil {
 		slog.Error("Failed to get CPU temperature", "error", err)
-		return 100
+		return 100, true
 	}
-
-	var speed uint8
-	for _, point := range fs.fanConfig.CPUCurve() {
-		if temp < 0 {
-			slog.Warn("CPU temperature is less than zero", "temperature", temp)
-		}
-		if uint8(temp) > point.Temperature {
-			speed = point.Speed
-		} else {
-			break
-		}
+	temp := snap.CPU.Value.AvgTemperature
+	if temp < 0 {
+		slog.Warn("CPU temperature is less than zero", "temperature", temp)
 	}
-	return speed
+	return curveSpeed(fs.fanConfig.CPUCurve(), temp)
 }
 
-func (fs *fanServiceImpl) getDriveFanSpeed() uint8 {
+// getDriveFanSpeed returns the speed the drive curve asks for, and whether
+// the drives are hotter than the curve reaches or cannot be read.
+func (fs *fanServiceImpl) getDriveFanSpeed(snap *Snapshot) (uint8, bool) {
 	slog.Debug("obtaining fan speed from HDD temp curve")
 
-	temp, err := fs.drives.GetAverageTemp()
+	if err := sampleError(&snap.Drives); err != nil {
+		slog.Error("Failed to get drive temperature", "error", err)
+		return 100, true
+	}
+	temp, err := resources.AverageTemperature(snap.Drives.Value)
 	if err != nil {
 		slog.Error("Failed to get drive temperature", "error", err)
-		return 100
+		return 100, true
+	}
+	if temp < 0 {
+		slog.Warn("Drive temperature is less than zero", "temperature", temp)
 	}
+	return curveSpeed(fs.fanConfig.HDDCurve(), temp)
+}
 
+// curveSpeed returns the speed of the hottest point of curve below temp, and
+// whether temp is past the last point.
+func curveSpeed(curve []config.FanCurvePoint, temp float64) (uint8, bool) {
 	var speed uint8
-	for _, point := range fs.fanConfig.HDDCurve() {
-		if temp < 0 {
-			slog.Warn("Drive temperature is less than zero", "temperature", temp)
+	for i, point := range curve {
+		if uint8(temp) <= point.Temperature {
+			return speed, false
 		}
-		if uint8(temp) > point.Temperature {
-			speed = point.Speed
-		} else {
-			break
+		speed = point.Speed
+		if i == len(curve)-1 {
+			return speed, true
+		}
+	}
+	return speed, false
+}
+
+// getIOBoostFanSpeed returns the boost speed while any drive is busier than the
+// configured utilization. Sustained I/O heats drives well before their SMART
+// temperature reflects it. Utilization is measured from the drives' cumulative
+// busy time since the previous fan check, so short bursts are averaged out.
+func (fs *fanServiceImpl) getIOBoostFanSpeed(snap *Snapshot) uint8 {
+	boost := fs.fanConfig.IOBoost()
+	if boost.Utilization == 0 {
+		return 0
+	}
+	if err := sampleError(&snap.DiskIO); err != nil {
+		slog.Error("Failed to get disk I/O stats", "error", err)
+		return 0
+	}
+	if err := sampleError(&snap.Drives); err != nil {
+		slog.Error("Failed to get drive stats", "error", err)
+		return 0
+	}
+
+	busy := make(map[string]time.Duration)
+	for _, stat := range snap.DiskIO.Value {
+		busy[stat.Device] = stat.BusyTime
+	}
+	prevBusy, prevBusyAt := fs.prevBusy, fs.prevBusyAt
+	fs.prevBusy, fs.prevBusyAt = busy, snap.DiskIO.UpdatedAt
+
+	elapsed := snap.DiskIO.UpdatedAt.Sub(prevBusyAt)
+	if prevBusy == nil || elapsed <= 0 {
+		return 0
+	}
+
+	for i := range snap.Drives.Value {
+		drive := &snap.Drives.Value[i]
+		prev, ok := prevBusy[drive.DeviceName]
+		if !ok || busy[drive.DeviceName] < prev {
+			continue
+		}
+		utilization := 100 * float64(busy[drive.DeviceName]-prev) / float64(elapsed)
+		if utilization >= float64(boost.Utilization) {
+			slog.Debug("drive busy, boosting fan", "drive", drive.DisplayName(),
+				"utilization", utilization, "speed", boost.Speed)
+			return boost.Speed
 		}
 	}
-	return speed
+	return 0
 }
@@ -0,0 +1,160 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/czechbol/lumeon/app/config"
+	"github.com/czechbol/lumeon/core/hardware"
+	"github.com/czechbol/lumeon/core/hardware/mock"
+	"github.com/czechbol/lumeon/core/resources"
+	"github.com/stretchr/testify/suite"
+)
+
+type FanServiceTestSuite struct {
+	suite.Suite
+	fan     *mock.FanMock
+	service *fanServiceImpl
+	// board is the speed the fan board reports.
+	board uint8
+}
+
+func TestFanServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(FanServiceTestSuite))
+}
+
+func (s *FanServiceTestSuite) SetupTest() {
+	s.board = 0
+	s.fan = &mock.FanMock{
+		SetSpeedHandler: func(speed uint8) error {
+			s.board = speed
+			return nil
+		},
+		SpeedHandler: func() (uint8, error) { return s.board, nil },
+	}
+	fanConfig := config.NewFanConfig(true, 0x1A, nil, nil, config.FanIOBoost{})
+	s.service, _ = NewFanService(s.fan, &fakeSampler{snap: &Snapshot{}}, NewAlertManager(), fanConfig).(*fanServiceImpl)
+	s.service.SetOverride(40, time.Hour)
+}
+
+func (s *FanServiceTestSuite) TestReassertsAfterReset() {
+	speed, err := s.service.adjustFanSpeed(0)
+	s.Require().NoError(err)
+	s.Equal(uint8(40), speed)
+	s.Equal(1, s.fan.SetSpeedHandlerCalled)
+
+	// Unchanged: the board is only read back.
+	_, err = s.service.adjustFanSpeed(speed)
+	s.Require().NoError(err)
+	s.Equal(1, s.fan.SetSpeedHandlerCalled)
+	s.Equal(1, s.fan.SpeedHandlerCalled)
+
+	// The board reset to its default speed.
+	s.board = 0
+	_, err = s.service.adjustFanSpeed(speed)
+	s.Require().NoError(err)
+	s.Equal(2, s.fan.SetSpeedHandlerCalled)
+	s.Equal(uint8(40), s.board)
+}
+
+func (s *FanServiceTestSuite) TestNoReadBack() {
+	s.fan.SpeedHandler = func() (uint8, error) { return 0, hardware.ErrNotImplemented }
+
+	speed, err := s.service.adjustFanSpeed(0)
+	s.Require().NoError(err)
+	_, err = s.service.adjustFanSpeed(speed)
+	s.Require().NoError(err)
+
+	s.Equal(1, s.fan.SetSpeedHandlerCalled)
+}
+
+func (s *FanServiceTestSuite) TestWaitsForSamples() {
+	s.service.ClearOverride()
+
+	speed, err := s.service.adjustFanSpeed(0)
+
+	s.Require().NoError(err)
+	s.Zero(speed)
+	s.Zero(s.fan.SetSpeedHandlerCalled)
+}
+
+func (s *FanServiceTestSuite) TestFailedSampleRunsFanFull() {
+	s.service.ClearOverride()
+	s.service.sampler = &fakeSampler{snap: &Snapshot{
+		CPU:    Sample[*resources.CPUStats]{Err: errProbe},
+		Drives: Sample[[]resources.HDDStats]{Err: errProbe},
+	}}
+
+	speed, err := s.service.adjustFanSpeed(0)
+
+	s.Require().NoError(err)
+	s.Equal(uint8(100), speed)
+}
+
+// sampled returns a snapshot of the CPU and the drives at the temperatures.
+func sampled(cpuTemperature, driveTemperature float64) *Snapshot {
+	now := time.Now()
+	return &Snapshot{
+		CPU: Sample[*resources.CPUStats]{
+			Value:     &resources.CPUStats{AvgTemperature: cpuTemperature},
+			UpdatedAt: now,
+			Interval:  time.Minute,
+		},
+		Drives: Sample[[]resources.HDDStats]{
+			Value:     []resources.HDDStats{{DeviceName: "sda", Temperature: driveTemperature}},
+			UpdatedAt: now,
+			Interval:  time.Minute,
+		},
+	}
+}
+
+func (s *FanServiceTestSuite) TestOverrideAboveCurves() {
+	s.service.fanConfig = config.NewFanConfig(true, 0x1A,
+		[]config.FanCurvePoint{config.NewFanCurvePoint(50, 30), config.NewFanCurvePoint(70, 100)},
+		[]config.FanCurvePoint{config.NewFanCurvePoint(40, 30), config.NewFanCurvePoint(50, 100)},
+		config.FanIOBoost{})
+	s.service.SetOverride(0, time.Hour)
+
+	// Within the curves, the override quiets the fan.
+	s.service.sampler = &fakeSampler{snap: sampled(60, 45)}
+	speed, err := s.service.adjustFanSpeed(0)
+	s.Require().NoError(err)
+	s.Zero(speed)
+
+	// Past the top of the CPU curve, the curves take over.
+	s.service.sampler = &fakeSampler{snap: sampled(85, 45)}
+	speed, err = s.service.adjustFanSpeed(speed)
+	s.Require().NoError(err)
+	s.Equal(uint8(100), speed)
+	s.Equal(uint8(100), s.board)
+
+	// Past the top of the drive curve, too.
+	s.service.sampler = &fakeSampler{snap: sampled(40, 55)}
+	speed, err = s.service.adjustFanSpeed(0)
+	s.Require().NoError(err)
+	s.Equal(uint8(100), speed)
+}
+
+func (s *FanServiceTestSuite) TestCurveSpeed() {
+	curve := []config.FanCurvePoint{config.NewFanCurvePoint(50, 30), config.NewFanCurvePoint(70, 100)}
+	tests := []struct {
+		temperature float64
+		speed       uint8
+		hot         bool
+	}{
+		{temperature: 40, speed: 0},
+		{temperature: 50, speed: 0},
+		{temperature: 51, speed: 30},
+		{temperature: 70, speed: 30},
+		{temperature: 71, speed: 100, hot: true},
+	}
+	for _, tt := range tests {
+		speed, hot := curveSpeed(curve, tt.temperature)
+		s.Equal(tt.speed, speed, "temperature %v", tt.temperature)
+		s.Equal(tt.hot, hot, "temperature %v", tt.temperature)
+	}
+
+	speed, hot := curveSpeed(nil, 90)
+	s.Zero(speed)
+	s.False(hot)
+}
@@ -6,6 +6,10 @@ const (
 	displayAddress       uint16 = 0x3C
 	daughterboardAddress uint16 = 0x1A
 
+	// Registers of daughterboard firmware that has them.
+	regFanDutyCycle byte = 0x80
+	regFirmware     byte = 0x81
+
 	// Command bytes.
 	cmdEnableDisplay        byte = 0xAF
 	cmdDisableDisplay       byte = 0xAE
@@ -22,6 +26,9 @@ const (
 	cmdHorizontalOffsetBase byte = 0x40
 	cmdSystemHalt           byte = 0xFF
 	cmdWriteData            byte = 0x40
+	cmdActivateScroll       byte = 0x2F
+	cmdDeactivateScroll     byte = 0x2E
+	cmdNop                  byte = 0xE3
 
 	// Misc.
 	displayWidth  int = 128
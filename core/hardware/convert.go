@@ -0,0 +1,244 @@
+package hardware
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"math"
+
+	xdraw "golang.org/x/image/draw"
+	"periph.io/x/devices/v3/ssd1306/image1bit"
+)
+
+// Dither selects how grayscale is reduced to the display's on/off pixels.
+type Dither string
+
+const (
+	// DitherThreshold lights pixels brighter than half intensity. Best for
+	// text and line art.
+	DitherThreshold Dither = "threshold"
+	// DitherFloydSteinberg diffuses the error of each pixel to its
+	// neighbours. Best for photos.
+	DitherFloydSteinberg Dither = "floyd-steinberg"
+	// DitherAtkinson diffuses only part of the error, which keeps more
+	// contrast than Floyd–Steinberg. Good for logos and icons.
+	DitherAtkinson Dither = "atkinson"
+	// DitherBayer compares pixels with an 8×8 ordered matrix. The pattern does
+	// not move between frames, so animations do not shimmer.
+	DitherBayer Dither = "bayer"
+)
+
+// Scale selects how an image that is not the size of the display is scaled.
+type Scale string
+
+const (
+	// ScaleStretch scales the image to the display, ignoring its aspect ratio.
+	ScaleStretch Scale = "stretch"
+	// ScaleFit scales the image to fit inside the display, with black bars.
+	ScaleFit Scale = "fit"
+	// ScaleFill scales the image to cover the display, cropping the edges.
+	ScaleFill Scale = "fill"
+)
+
+// ConvertOptions controls how images are converted for the display. The zero
+// value thresholds, stretches and treats images as linear.
+type ConvertOptions struct {
+	Dither Dither
+	Scale  Scale
+	// Gamma is the gamma the images are encoded with; they are converted to
+	// linear light before dithering. 2.2 suits most images, 0 means 1.
+	Gamma float64
+}
+
+// bayer8 is the 8×8 ordered dither matrix.
+var bayer8 = [8][8]float32{
+	{0, 32, 8, 40, 2, 34, 10, 42},
+	{48, 16, 56, 24, 50, 18, 58, 26},
+	{12, 44, 4, 36, 14, 46, 6, 38},
+	{60, 28, 52, 20, 62, 30, 54, 22},
+	{3, 35, 11, 43, 1, 33, 9, 41},
+	{51, 19, 59, 27, 49, 17, 57, 25},
+	{15, 47, 7, 39, 13, 45, 5, 37},
+	{63, 31, 55, 23, 61, 29, 53, 21},
+}
+
+// diffusion is an error diffusion kernel: where the error of a pixel goes and
+// how much of it.
+type diffusion []struct {
+	dx, dy int
+	weight float32
+}
+
+var (
+	floydSteinberg = diffusion{{1, 0, 7.0 / 16}, {-1, 1, 3.0 / 16}, {0, 1, 5.0 / 16}, {1, 1, 1.0 / 16}}
+	atkinson       = diffusion{{1, 0, 1.0 / 8}, {2, 0, 1.0 / 8}, {-1, 1, 1.0 / 8}, {0, 1, 1.0 / 8}, {1, 1, 1.0 / 8},
+		{0, 2, 1.0 / 8}}
+)
+
+// convert prepares the image for the OLED display format.
+func convert(bounds image.Rectangle, src image.Image, opts ConvertOptions) *image1bit.VerticalLSB {
+	gray := scaleImage(src, bounds, opts.Scale)
+
+	gamma := opts.Gamma
+	if gamma <= 0 {
+		gamma = 1
+	}
+	var linear [256]float32
+	for i := range linear {
+		linear[i] = float32(math.Pow(float64(i)/255, gamma))
+	}
+
+	w, h := bounds.Dx(), bounds.Dy()
+	values := make([]float32, w*h)
+	for y := range h {
+		for x := range w {
+			values[y*w+x] = linear[gray.Pix[y*gray.Stride+x]]
+		}
+	}
+
+	switch opts.Dither {
+	case DitherFloydSteinberg:
+		diffuse(values, w, h, floydSteinberg)
+	case DitherAtkinson:
+		diffuse(values, w, h, atkinson)
+	case DitherBayer:
+		for y := range h {
+			for x := range w {
+				if values[y*w+x] > (bayer8[y%8][x%8]+0.5)/64 {
+					values[y*w+x] = 1
+				} else {
+					values[y*w+x] = 0
+				}
+			}
+		}
+	}
+
+	monoImg := image1bit.NewVerticalLSB(bounds)
+	for y := range h {
+		for x := range w {
+			monoImg.SetBit(bounds.Min.X+x, bounds.Min.Y+y, image1bit.Bit(values[y*w+x] > 0.5))
+		}
+	}
+	return monoImg
+}
+
+// scaleImage draws src onto a black grayscale image the size of bounds,
+// scaling it with a Catmull-Rom filter if it is not already that size.
+func scaleImage(src image.Image, bounds image.Rectangle, mode Scale) *image.Gray {
+	dst := image.NewGray(image.Rectangle{Max: bounds.Size()})
+	srcBounds := src.Bounds()
+	if srcBounds.Empty() {
+		return dst
+	}
+	if srcBounds.Size().Eq(dst.Rect.Size()) {
+		draw.Draw(dst, dst.Rect, src, srcBounds.Min, draw.Over)
+		return dst
+	}
+
+	target := dst.Rect
+	if mode == ScaleFit || mode == ScaleFill {
+		scaleX := float64(dst.Rect.Dx()) / float64(srcBounds.Dx())
+		scaleY := float64(dst.Rect.Dy()) / float64(srcBounds.Dy())
+		scale := min(scaleX, scaleY)
+		if mode == ScaleFill {
+			scale = max(scaleX, scaleY)
+		}
+		size := image.Pt(
+			max(1, int(math.Round(float64(srcBounds.Dx())*scale))),
+			max(1, int(math.Round(float64(srcBounds.Dy())*scale))),
+		)
+		target = image.Rectangle{Max: size}.Add(dst.Rect.Size().Sub(size).Div(2))
+	}
+	xdraw.CatmullRom.Scale(dst, target, src, srcBounds, xdraw.Over, nil)
+	return dst
+}
+
+// diffuse turns values into 0 and 1, spreading the error of each pixel to its
+// unvisited neighbours. Rows are scanned in alternating directions to avoid
+// diagonal artifacts.
+func diffuse(values []float32, w, h int, kernel diffusion) {
+	for y := range h {
+		dir, x := 1, 0
+		if y%2 == 1 {
+			dir, x = -1, w-1
+		}
+		for ; x >= 0 && x < w; x += dir {
+			old := values[y*w+x]
+			var out float32
+			if old > 0.5 {
+				out = 1
+			}
+			values[y*w+x] = out
+
+			diff := old - out
+			for _, k := range kernel {
+				nx, ny := x+k.dx*dir, y+k.dy
+				if nx >= 0 && nx < w && ny < h {
+					values[ny*w+nx] += diff * k.weight
+				}
+			}
+		}
+	}
+}
+
+// monoPalette is the palette of converted GIF frames.
+var monoPalette = color.Palette{color.Black, color.White}
+
+// convertGIF composites the frames of g and converts each for the display.
+func convertGIF(bounds image.Rectangle, g *gif.GIF, opts ConvertOptions) *gif.GIF {
+	// Create a new GIF to store the fully rendered frames
+	newGIF := &gif.GIF{
+		LoopCount: g.LoopCount,
+		Delay:     make([]int, len(g.Image)),
+		Disposal:  make([]byte, len(g.Image)),
+	}
+
+	// Create a canvas to build up the frames
+	canvas := image.NewGray(g.Image[0].Bounds())
+	if g.Config.Width > 0 && g.Config.Height > 0 {
+		canvas = image.NewGray(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	}
+
+	// previous is the canvas before the last frame was drawn, for frames that
+	// are disposed by restoring it.
+	var previous *image.Gray
+	for i, srcImg := range g.Image {
+		// Dispose of the previous frame: clear its area to the background, or
+		// put back what was under it.
+		if i > 0 && i-1 < len(g.Disposal) {
+			last := g.Image[i-1].Bounds()
+			switch g.Disposal[i-1] {
+			case gif.DisposalBackground:
+				draw.Draw(canvas, last, image.Transparent, image.Point{}, draw.Src)
+			case gif.DisposalPrevious:
+				if previous != nil {
+					draw.Draw(canvas, last, previous, last.Min, draw.Src)
+				}
+			}
+		}
+
+		if i < len(g.Disposal) && g.Disposal[i] == gif.DisposalPrevious {
+			if previous == nil {
+				previous = image.NewGray(canvas.Bounds())
+			}
+			copy(previous.Pix, canvas.Pix)
+		}
+
+		// Draw this frame onto the canvas
+		draw.Draw(canvas, srcImg.Bounds(), srcImg, srcImg.Bounds().Min, draw.Over)
+
+		// Convert the composited frame and store it as a two-colour frame
+		palettedImage := image.NewPaletted(bounds, monoPalette)
+		draw.Draw(palettedImage, bounds, convert(bounds, canvas, opts), bounds.Min, draw.Src)
+
+		// Add the new frame to our GIF
+		newGIF.Image = append(newGIF.Image, palettedImage)
+		if i < len(g.Delay) {
+			newGIF.Delay[i] = g.Delay[i]
+		}
+		newGIF.Disposal[i] = gif.DisposalNone // Since each frame is now complete
+	}
+
+	return newGIF
+}
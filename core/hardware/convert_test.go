@@ -0,0 +1,208 @@
+package hardware
+
+import (
+	"flag"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"periph.io/x/devices/v3/ssd1306/image1bit"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden images in testdata")
+
+var screenBounds = image.Rect(0, 0, displayWidth, displayHeight)
+
+type ConvertTestSuite struct {
+	suite.Suite
+}
+
+func TestConvertTestSuite(t *testing.T) {
+	suite.Run(t, new(ConvertTestSuite))
+}
+
+// testImage is a 160×100 image: a gradient on top, and a gray disc with a
+// white rim on a darker gradient below.
+func testImage() image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, 160, 100))
+	for y := range 100 {
+		for x := range 160 {
+			v := uint8(x * 255 / 159)
+			if y >= 50 {
+				dx, dy := x-80, y-75
+				switch d := dx*dx + dy*dy; {
+				case d < 18*18:
+					v = 128
+				case d < 22*22:
+					v = 255
+				default:
+					v /= 2
+				}
+			}
+			img.SetNRGBA(x, y, color.NRGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+	return img
+}
+
+// assertGolden compares img with testdata/name.png, or rewrites it with -update.
+func (s *ConvertTestSuite) assertGolden(name string, img *image1bit.VerticalLSB) {
+	path := filepath.Join("testdata", name+".png")
+	gray := image.NewGray(img.Bounds())
+	for y := range img.Bounds().Dy() {
+		for x := range img.Bounds().Dx() {
+			if img.BitAt(x, y) {
+				gray.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+
+	if *update {
+		f, err := os.Create(path)
+		s.Require().NoError(err)
+		defer f.Close()
+		s.Require().NoError(png.Encode(f, gray))
+		return
+	}
+
+	f, err := os.Open(path)
+	s.Require().NoError(err, "run go test -update to create the golden image")
+	defer f.Close()
+	golden, err := png.Decode(f)
+	s.Require().NoError(err)
+	s.Require().Equal(gray.Bounds(), golden.Bounds(), name)
+
+	diff := 0
+	for y := range gray.Bounds().Dy() {
+		for x := range gray.Bounds().Dx() {
+			if gray.GrayAt(x, y) != color.GrayModel.Convert(golden.At(x, y)) {
+				diff++
+			}
+		}
+	}
+	s.Zero(diff, "%s differs from the golden image in %d pixels", name, diff)
+}
+
+func (s *ConvertTestSuite) TestDitherGolden() {
+	for _, dither := range []Dither{DitherThreshold, DitherFloydSteinberg, DitherAtkinson, DitherBayer} {
+		img := convert(screenBounds, testImage(), ConvertOptions{Dither: dither, Scale: ScaleFit, Gamma: 2.2})
+		s.assertGolden("dither-"+string(dither), img)
+	}
+}
+
+func (s *ConvertTestSuite) TestScaleGolden() {
+	for _, scale := range []Scale{ScaleFit, ScaleFill, ScaleStretch} {
+		img := convert(screenBounds, testImage(), ConvertOptions{Dither: DitherAtkinson, Scale: scale, Gamma: 2.2})
+		s.assertGolden("scale-"+string(scale), img)
+	}
+}
+
+func (s *ConvertTestSuite) TestMonochromeUnchanged() {
+	src := image1bit.NewVerticalLSB(screenBounds)
+	for x := range displayWidth {
+		src.SetBit(x, x%displayHeight, image1bit.On)
+		src.SetBit(x, 10, image1bit.On)
+	}
+
+	for _, dither := range []Dither{"", DitherThreshold, DitherFloydSteinberg, DitherAtkinson, DitherBayer} {
+		img := convert(screenBounds, src, ConvertOptions{Dither: dither, Scale: ScaleFit, Gamma: 2.2})
+		s.Equal(src.Pix, img.Pix, dither)
+	}
+}
+
+func (s *ConvertTestSuite) TestGamma() {
+	lit := func(gamma float64) float64 {
+		src := image.NewGray(screenBounds)
+		for i := range src.Pix {
+			src.Pix[i] = 128
+		}
+		img := convert(screenBounds, src, ConvertOptions{Dither: DitherFloydSteinberg, Gamma: gamma})
+		n := 0
+		for y := range displayHeight {
+			for x := range displayWidth {
+				if img.BitAt(x, y) {
+					n++
+				}
+			}
+		}
+		return float64(n) / float64(displayWidth*displayHeight)
+	}
+
+	// Half gray lights about half the pixels as is, and about a fifth of
+	// them in linear light.
+	s.InDelta(0.5, lit(1), 0.02)
+	s.InDelta(0.22, lit(2.2), 0.02)
+}
+
+func (s *ConvertTestSuite) TestConvertGIF() {
+	palette := color.Palette{color.Black, color.White}
+	frame := image.NewPaletted(image.Rect(0, 0, 32, 32), palette)
+	for i := range frame.Pix {
+		frame.Pix[i] = 1
+	}
+	g := &gif.GIF{
+		Image:     []*image.Paletted{frame, image.NewPaletted(image.Rect(8, 8, 16, 16), palette)},
+		Delay:     []int{5, 7},
+		LoopCount: 2,
+		Config:    image.Config{Width: 32, Height: 32},
+	}
+
+	converted := convertGIF(screenBounds, g, ConvertOptions{Dither: DitherBayer, Scale: ScaleFit})
+	s.Equal(2, converted.LoopCount)
+	s.Equal([]int{5, 7}, converted.Delay)
+	s.Require().Len(converted.Image, 2)
+	for _, img := range converted.Image {
+		s.Equal(screenBounds, img.Bounds())
+		s.Equal(monoPalette, img.Palette)
+	}
+
+	// The square is scaled to 64×64 in the middle, and the second frame
+	// blanks a quarter of it.
+	s.Equal(color.Black, converted.Image[0].At(31, 32))
+	s.Equal(color.White, converted.Image[0].At(32, 32))
+	s.Equal(color.White, converted.Image[0].At(95, 63))
+	s.Equal(color.Black, converted.Image[0].At(96, 32))
+	s.Equal(color.Black, converted.Image[1].At(56, 24))
+	s.Equal(color.White, converted.Image[1].At(90, 50))
+}
+
+func (s *ConvertTestSuite) TestConvertGIFDisposal() {
+	palette := color.Palette{color.Black, color.White}
+	square := func(r image.Rectangle) *image.Paletted {
+		img := image.NewPaletted(r, palette)
+		for i := range img.Pix {
+			img.Pix[i] = 1
+		}
+		return img
+	}
+	// A background square, then a square that is restored away, then one
+	// that is cleared away, then an empty frame.
+	g := &gif.GIF{
+		Image: []*image.Paletted{
+			square(image.Rect(0, 0, 64, 64)),
+			square(image.Rect(64, 0, 96, 32)),
+			square(image.Rect(96, 32, 128, 64)),
+			image.NewPaletted(image.Rect(0, 0, 1, 1), nil),
+		},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalPrevious, gif.DisposalBackground, gif.DisposalNone},
+		Config:   image.Config{Width: 128, Height: 64},
+	}
+
+	converted := convertGIF(screenBounds, g, ConvertOptions{})
+	s.Require().Len(converted.Image, 4)
+	for i, want := range [][3]color.Color{
+		{color.White, color.Black, color.Black},
+		{color.White, color.White, color.Black},
+		{color.White, color.Black, color.White},
+		{color.White, color.Black, color.Black},
+	} {
+		s.Equal(want[0], converted.Image[i].At(10, 10), "frame %d background", i)
+		s.Equal(want[1], converted.Image[i].At(70, 10), "frame %d restored square", i)
+		s.Equal(want[2], converted.Image[i].At(100, 40), "frame %d cleared square", i)
+	}
+}
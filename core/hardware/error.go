@@ -4,15 +4,18 @@ import "errors"
 
 var (
 	ErrNotImplemented = errors.New("not implemented")
+	ErrDeviceNotFound = errors.New("device not found on the I2C bus")
 
 	// Fan related errors.
 	ErrInvalidFanSpeed = errors.New("invalid fan speed")
+	ErrFanNotApplied   = errors.New("fan board did not apply the speed")
 
 	// Display related errors.
 	ErrInvalidImageSize        = errors.New("invalid image size")
 	ErrInvalidMemoryMode       = errors.New("invalid memory mode")
 	ErrInvalidPageStart        = errors.New("invalid page start address")
 	ErrInvalidHorizontalOffset = errors.New("invalid horizontal offset")
+	ErrInvalidPanel            = errors.New("invalid display panel")
 
 	// Button related errors.
 	ErrButtonPinNotFound = errors.New("button GPIO pin not found")
@@ -2,26 +2,127 @@ package hardware
 
 import (
 	"fmt"
+	"log/slog"
+	"sync"
 
 	"github.com/czechbol/lumeon/core/hardware/i2c"
 )
 
 type Fan interface {
 	SetSpeed(speed uint8) error
+	// Speed reads back the speed the board runs the fan at. It returns
+	// ErrNotImplemented if the board's firmware has no registers, or has not
+	// been identified by a SetSpeed yet.
+	Speed() (uint8, error)
+	// Info identifies the board. It is zero until the first SetSpeed.
+	Info() BoardInfo
+}
+
+// BoardInfo identifies the firmware of the daughterboard.
+type BoardInfo struct {
+	// Registers is set if the firmware has registers, which can be read back.
+	// Older firmware only takes a bare speed byte.
+	Registers bool
+	// Firmware is the version the firmware reports, if it has registers.
+	Firmware byte
+}
+
+func (b BoardInfo) String() string {
+	if !b.Registers {
+		return "no registers"
+	}
+	return fmt.Sprintf("firmware %#02x", b.Firmware)
+}
+
+// readBoardInfo asks the board at address for its firmware version. Firmware
+// without registers does not answer, and takes the register byte written to
+// ask as a fan speed, so it must only be called right before a speed is set.
+func readBoardInfo(bus i2c.I2CBus, address uint16) BoardInfo {
+	firmware, err := bus.ReadRegister(address, regFirmware)
+	if err != nil {
+		return BoardInfo{}
+	}
+	return BoardInfo{Registers: true, Firmware: firmware}
 }
 
 type fanImpl struct {
-	bus i2c.I2CBus
+	bus     i2c.I2CBus
+	address uint16
+
+	// info is read by the first SetSpeed. verified is set once the board has
+	// read back a speed it was set to. Guarded by mutex.
+	mutex    sync.Mutex
+	info     *BoardInfo
+	verified bool
 }
 
-func NewFan(bus i2c.I2CBus) Fan {
-	return &fanImpl{bus: bus}
+// NewFan returns the fan of the Argon daughterboard at address, 0 meaning the
+// default 0x1A.
+func NewFan(bus i2c.I2CBus, address uint16) Fan {
+	if address == 0 {
+		address = daughterboardAddress
+	}
+	return &fanImpl{bus: bus, address: address}
 }
 
+// SetSpeed sets the fan speed. Where the firmware has registers, the speed is
+// written to the duty cycle register and read back to check it was applied.
+// The first call asks the board for its firmware, right before the speed
+// that replaces whatever older firmware made of the query.
 func (f *fanImpl) SetSpeed(speed uint8) error {
 	if speed > 100 {
 		return fmt.Errorf("%w: speed is specified in percent: 0 to 100", ErrInvalidFanSpeed)
 	}
 
-	return f.bus.SendData(daughterboardAddress, speed)
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.info == nil {
+		info := readBoardInfo(f.bus, f.address)
+		slog.Info("fan board identified", "address", fmt.Sprintf("%#02x", f.address), "board", info.String())
+		f.info = &info
+	}
+	if !f.info.Registers {
+		return f.bus.SendData(f.address, speed)
+	}
+
+	if err := f.bus.SendData(f.address, regFanDutyCycle, speed); err != nil {
+		return err
+	}
+	applied, err := f.bus.ReadRegister(f.address, regFanDutyCycle)
+	if err != nil {
+		return err
+	}
+	if applied == speed {
+		f.verified = true
+		return nil
+	}
+	if f.verified {
+		return fmt.Errorf("%w: set to %d%%, board reports %d%%", ErrFanNotApplied, speed, applied)
+	}
+	// The board answered the firmware query but does not keep the speed in
+	// the register, so it is older firmware after all.
+	slog.Warn("fan board does not read back the speed, setting it without checking", "board", f.info.String())
+	f.info = &BoardInfo{}
+	return f.bus.SendData(f.address, speed)
+}
+
+func (f *fanImpl) Speed() (uint8, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.info == nil || !f.info.Registers {
+		return 0, ErrNotImplemented
+	}
+	return f.bus.ReadRegister(f.address, regFanDutyCycle)
+}
+
+func (f *fanImpl) Info() BoardInfo {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.info == nil {
+		return BoardInfo{}
+	}
+	return *f.info
 }
@@ -1,12 +1,15 @@
 package hardware
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/czechbol/lumeon/core/hardware/i2c/mock"
 	"github.com/stretchr/testify/suite"
 )
 
+var errNoRegisters = errors.New("no registers")
+
 type FanTestSuite struct {
 	suite.Suite
 	busMock *mock.I2CBus
@@ -15,13 +18,39 @@ type FanTestSuite struct {
 
 func (s *FanTestSuite) SetupTest() {
 	s.busMock = &mock.I2CBus{}
-	s.fan = NewFan(s.busMock)
+	// Firmware without registers, unless a test says otherwise.
+	s.busMock.ReadRegisterHandler = func(uint16, byte) (byte, error) {
+		return 0, errNoRegisters
+	}
+	s.fan = NewFan(s.busMock, 0)
 }
 
 func TestFanTestSuite(t *testing.T) {
 	suite.Run(t, new(FanTestSuite))
 }
 
+// registers makes the board keep the speeds written to its duty cycle
+// register, which reads back as speed(written).
+func (s *FanTestSuite) registers(speed func(written byte) byte) *[][]byte {
+	var writes [][]byte
+	var written byte
+	s.busMock.SendDataHandler = func(_ uint16, data ...byte) error {
+		writes = append(writes, data)
+		if len(data) == 2 && data[0] == regFanDutyCycle {
+			written = data[1]
+		}
+		return nil
+	}
+	s.busMock.ReadRegisterHandler = func(addr uint16, reg byte) (byte, error) {
+		s.Equal(daughterboardAddress, addr)
+		if reg == regFirmware {
+			return 0x12, nil
+		}
+		return speed(written), nil
+	}
+	return &writes
+}
+
 func (s *FanTestSuite) TestSetSpeed() {
 	s.busMock.SendDataHandler = func(addr uint16, data ...byte) error {
 		s.Equal(daughterboardAddress, addr)
@@ -33,6 +62,22 @@ func (s *FanTestSuite) TestSetSpeed() {
 	s.NoError(err)
 
 	s.Equal(1, s.busMock.SendDataHandlerCalled)
+	s.Equal(BoardInfo{}, s.fan.Info())
+	_, err = s.fan.Speed()
+	s.ErrorIs(err, ErrNotImplemented)
+}
+
+func (s *FanTestSuite) TestNotIdentifiedBeforeSetSpeed() {
+	s.registers(func(written byte) byte { return written })
+
+	// Asking for the firmware writes to the board, so only SetSpeed does it.
+	s.Equal(BoardInfo{}, s.fan.Info())
+	_, err := s.fan.Speed()
+	s.ErrorIs(err, ErrNotImplemented)
+	s.Zero(s.busMock.ReadRegisterCalled)
+
+	s.Require().NoError(s.fan.SetSpeed(40))
+	s.Equal(BoardInfo{Registers: true, Firmware: 0x12}, s.fan.Info())
 }
 
 func (s *FanTestSuite) TestSetSpeedInvalid() {
@@ -42,3 +87,52 @@ func (s *FanTestSuite) TestSetSpeedInvalid() {
 
 	s.Equal(0, s.busMock.SendDataHandlerCalled)
 }
+
+func (s *FanTestSuite) TestSetSpeedAddress() {
+	s.fan = NewFan(s.busMock, 0x1B)
+	s.busMock.SendDataHandler = func(addr uint16, _ ...byte) error {
+		s.Equal(uint16(0x1B), addr)
+		return nil
+	}
+
+	s.NoError(s.fan.SetSpeed(50))
+	s.Equal(1, s.busMock.SendDataHandlerCalled)
+}
+
+func (s *FanTestSuite) TestSetSpeedVerified() {
+	writes := s.registers(func(written byte) byte { return written })
+
+	s.Require().NoError(s.fan.SetSpeed(40))
+	s.Equal([][]byte{{regFanDutyCycle, 40}}, *writes)
+	s.Equal(BoardInfo{Registers: true, Firmware: 0x12}, s.fan.Info())
+
+	speed, err := s.fan.Speed()
+	s.Require().NoError(err)
+	s.Equal(uint8(40), speed)
+}
+
+func (s *FanTestSuite) TestSetSpeedNotApplied() {
+	applied := true
+	s.registers(func(written byte) byte {
+		if applied {
+			return written
+		}
+		return 0
+	})
+	s.Require().NoError(s.fan.SetSpeed(40))
+
+	applied = false
+	s.ErrorIs(s.fan.SetSpeed(60), ErrFanNotApplied)
+}
+
+func (s *FanTestSuite) TestSetSpeedFallback() {
+	// The board answers the firmware query but never keeps the speed.
+	writes := s.registers(func(byte) byte { return 0xFF })
+
+	s.Require().NoError(s.fan.SetSpeed(40))
+	s.Equal([][]byte{{regFanDutyCycle, 40}, {40}}, *writes)
+	s.False(s.fan.Info().Registers)
+
+	s.Require().NoError(s.fan.SetSpeed(50))
+	s.Equal([]byte{50}, (*writes)[2])
+}
@@ -0,0 +1,57 @@
+package i2c
+
+import "sync"
+
+// priority orders the transactions waiting for the bus.
+type priority int
+
+const (
+	// priorityFrame is the bulk traffic of the display, which can wait.
+	priorityFrame priority = iota
+	// priorityControl is the fan and power commands, which go before any
+	// frame still waiting.
+	priorityControl
+
+	numPriorities
+)
+
+// arbiter lets one transaction at a time on the bus. Waiting transactions are
+// let on highest priority first, and in the order they came within a
+// priority. A transaction is never interrupted, but a long run of frame
+// writes lets a fan write in between any two of them.
+type arbiter struct {
+	mutex   sync.Mutex
+	busy    bool
+	waiting [numPriorities][]chan struct{}
+}
+
+// acquire waits until the bus is free for a transaction at p.
+func (a *arbiter) acquire(p priority) {
+	a.mutex.Lock()
+	if !a.busy {
+		a.busy = true
+		a.mutex.Unlock()
+		return
+	}
+	turn := make(chan struct{})
+	a.waiting[p] = append(a.waiting[p], turn)
+	a.mutex.Unlock()
+
+	<-turn
+}
+
+// release hands the bus to the next waiting transaction, if any.
+func (a *arbiter) release() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for p := numPriorities - 1; p >= 0; p-- {
+		if len(a.waiting[p]) > 0 {
+			turn := a.waiting[p][0]
+			a.waiting[p] = a.waiting[p][1:]
+			close(turn)
+			return
+		}
+	}
+	a.busy = false
+}
@@ -0,0 +1,64 @@
+package i2c
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ArbiterTestSuite struct {
+	suite.Suite
+	arbiter *arbiter
+}
+
+func TestArbiterTestSuite(t *testing.T) {
+	suite.Run(t, new(ArbiterTestSuite))
+}
+
+func (s *ArbiterTestSuite) SetupTest() {
+	s.arbiter = &arbiter{}
+}
+
+// queue starts a transaction at p that appends name to order, and waits until
+// it is waiting for the bus.
+func (s *ArbiterTestSuite) queue(wg *sync.WaitGroup, order *[]string, p priority, name string) {
+	s.arbiter.mutex.Lock()
+	waiting := len(s.arbiter.waiting[p])
+	s.arbiter.mutex.Unlock()
+
+	wg.Go(func() {
+		s.arbiter.acquire(p)
+		*order = append(*order, name)
+		s.arbiter.release()
+	})
+
+	s.Eventually(func() bool {
+		s.arbiter.mutex.Lock()
+		defer s.arbiter.mutex.Unlock()
+		return len(s.arbiter.waiting[p]) == waiting+1
+	}, time.Second, time.Millisecond)
+}
+
+func (s *ArbiterTestSuite) TestPriorities() {
+	var wg sync.WaitGroup
+	var order []string
+
+	s.arbiter.acquire(priorityFrame)
+	s.queue(&wg, &order, priorityFrame, "frame 1")
+	s.queue(&wg, &order, priorityFrame, "frame 2")
+	s.queue(&wg, &order, priorityControl, "fan")
+	s.arbiter.release()
+	wg.Wait()
+
+	s.Equal([]string{"fan", "frame 1", "frame 2"}, order)
+	s.False(s.arbiter.busy)
+}
+
+func (s *ArbiterTestSuite) TestFree() {
+	s.arbiter.acquire(priorityControl)
+	s.True(s.arbiter.busy)
+	s.arbiter.release()
+	s.False(s.arbiter.busy)
+}
@@ -3,23 +3,58 @@ package i2c
 import (
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"periph.io/x/conn/v3/driver/driverreg"
 	"periph.io/x/conn/v3/i2c"
 	"periph.io/x/conn/v3/i2c/i2creg"
+	"periph.io/x/conn/v3/physic"
 	"periph.io/x/host/v3"
 )
 
 type I2CBus interface {
+	// GetBus returns the bus for the bulk traffic of the display. Its
+	// transactions wait for those of SendData.
 	GetBus() i2c.Bus
 	SendData(addr uint16, bytes ...byte) error
+	// Tx writes w to the device at addr, then reads r from it, as one
+	// transaction. Like SendData, it goes before the traffic of GetBus.
+	Tx(addr uint16, w, r []byte) error
+	// ReadRegister reads register reg of the device at addr.
+	ReadRegister(addr uint16, reg byte) (byte, error)
+	// Stats returns the transactions and errors of each address since the
+	// bus was opened.
+	Stats() map[uint16]AddressStats
+}
+
+// Options configures the bus.
+type Options struct {
+	// Bus is the name or number of the bus; empty means the first one.
+	Bus string
+	// Retries is how many times a failed transaction is tried again.
+	Retries int
+	// Backoff is the wait before the first retry, doubled before each next.
+	Backoff time.Duration
+}
+
+// AddressStats counts the transactions with one device.
+type AddressStats struct {
+	Transactions uint64
+	// Errors is the number of failed attempts, retried or not.
+	Errors uint64
+	// Failures is the number of transactions that failed every attempt.
+	Failures uint64
 }
 
 type i2cBusImpl struct {
-	bus i2c.Bus
+	bus *sharedBus
 }
 
-func NewBus(busName string) (I2CBus, error) {
+func NewBus(opts Options) (I2CBus, error) {
 	if _, err := host.Init(); err != nil {
 		slog.Error("cannot start host")
 		return nil, err
@@ -29,26 +64,32 @@ func NewBus(busName string) (I2CBus, error) {
 		slog.Error("cannot inicialize i2c driver")
 		return nil, err
 	}
-	i2cBus, err := i2creg.Open(busName)
+	i2cBus, err := i2creg.Open(opts.Bus)
 	if err != nil {
 		slog.Error("cannot open i2c bus")
 		slog.Warn("please make sure you enabled i2c in your system")
 		return nil, err
 	}
 
+	return NewBusOn(i2cBus, opts), nil
+}
+
+// NewBusOn shares bus between the drivers like NewBus, without opening a bus
+// of the host. opts.Bus is not used.
+func NewBusOn(bus i2c.Bus, opts Options) I2CBus {
 	return i2cBusImpl{
-		bus: i2cBus,
-	}, nil
+		bus: newSharedBus(newCountingBus(bus), opts),
+	}
 }
 
 func (ib i2cBusImpl) GetBus() i2c.Bus {
-	return ib.bus
+	return priorityBus{sharedBus: ib.bus, priority: priorityFrame}
 }
 
 func (ib i2cBusImpl) SendData(addr uint16, data ...byte) error {
 	slog.Debug(fmt.Sprintf("sending bytes to %x: %v", addr, data))
 
-	device := i2c.Dev{Bus: ib.bus, Addr: addr}
+	device := i2c.Dev{Bus: priorityBus{sharedBus: ib.bus, priority: priorityControl}, Addr: addr}
 
 	_, err := device.Write(data)
 	if err != nil {
@@ -58,3 +99,185 @@ func (ib i2cBusImpl) SendData(addr uint16, data ...byte) error {
 
 	return nil
 }
+
+func (ib i2cBusImpl) Tx(addr uint16, w, r []byte) error {
+	return ib.bus.tx(priorityControl, addr, w, r)
+}
+
+func (ib i2cBusImpl) ReadRegister(addr uint16, reg byte) (byte, error) {
+	value := make([]byte, 1)
+	if err := ib.Tx(addr, []byte{reg}, value); err != nil {
+		return 0, err
+	}
+	slog.Debug("read register", "address", fmt.Sprintf("%#02x", addr), "register", fmt.Sprintf("%#02x", reg),
+		"value", value[0])
+	return value[0], nil
+}
+
+func (ib i2cBusImpl) Stats() map[uint16]AddressStats {
+	return ib.bus.counting.stats()
+}
+
+// sharedBus is the bus shared by the drivers: one transaction at a time,
+// highest priority first, control transactions retried with backoff.
+type sharedBus struct {
+	counting *countingBus
+	arbiter  arbiter
+	retries  int
+	backoff  time.Duration
+}
+
+func newSharedBus(counting *countingBus, opts Options) *sharedBus {
+	return &sharedBus{counting: counting, retries: max(opts.Retries, 0), backoff: opts.Backoff}
+}
+
+// tx does a transaction at p. The bus is held through the retries, so the
+// transactions of a driver stay in order. Frame transactions are not retried:
+// the display redraws after a failed write, and retrying a frame would only
+// keep a fan write waiting.
+func (b *sharedBus) tx(p priority, addr uint16, w, r []byte) error {
+	b.arbiter.acquire(p)
+	defer b.arbiter.release()
+
+	retries := b.retries
+	if p == priorityFrame {
+		retries = 0
+	}
+	backoff := b.backoff
+	for attempt := 0; ; attempt++ {
+		err := b.counting.Tx(addr, w, r)
+		if err == nil {
+			return nil
+		}
+		if attempt == retries {
+			b.counting.failed(addr)
+			return err
+		}
+		slog.Debug("retrying i2c transaction", "address", fmt.Sprintf("%#02x", addr), "attempt", attempt+1,
+			"error", err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// priorityBus is the shared bus as seen by one kind of traffic.
+type priorityBus struct {
+	*sharedBus
+
+	priority priority
+}
+
+func (b priorityBus) String() string {
+	return b.counting.String()
+}
+
+func (b priorityBus) Tx(addr uint16, w, r []byte) error {
+	return b.tx(b.priority, addr, w, r)
+}
+
+func (b priorityBus) SetSpeed(f physic.Frequency) error {
+	return b.counting.SetSpeed(f)
+}
+
+// busStatsInterval is how often bus traffic is logged at debug level.
+const busStatsInterval = time.Minute
+
+// countingBus counts the traffic on the bus and logs its rate at debug level
+// about every busStatsInterval, when there is traffic. It also keeps the
+// counts of each address.
+type countingBus struct {
+	i2c.Bus
+
+	mutex        sync.Mutex
+	bytes        uint64
+	transactions uint64
+	since        time.Time
+	addresses    map[uint16]*AddressStats
+}
+
+func newCountingBus(bus i2c.Bus) *countingBus {
+	return &countingBus{Bus: bus, since: time.Now(), addresses: make(map[uint16]*AddressStats)}
+}
+
+func (b *countingBus) Tx(addr uint16, w, r []byte) error {
+	err := b.Bus.Tx(addr, w, r)
+	b.record(addr, err)
+	// Count the address byte as well as the payload.
+	b.count(len(w)+len(r)+1, time.Now())
+	return err
+}
+
+// record counts an attempted transaction with addr.
+func (b *countingBus) record(addr uint16, err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	stats := b.address(addr)
+	stats.Transactions++
+	if err != nil {
+		stats.Errors++
+	}
+}
+
+// failed counts a transaction with addr that failed every attempt.
+func (b *countingBus) failed(addr uint16) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.address(addr).Failures++
+}
+
+// address returns the counts of addr. The mutex must be held.
+func (b *countingBus) address(addr uint16) *AddressStats {
+	stats, ok := b.addresses[addr]
+	if !ok {
+		stats = &AddressStats{}
+		b.addresses[addr] = stats
+	}
+	return stats
+}
+
+func (b *countingBus) stats() map[uint16]AddressStats {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	stats := make(map[uint16]AddressStats, len(b.addresses))
+	for addr, s := range b.addresses {
+		stats[addr] = *s
+	}
+	return stats
+}
+
+func (b *countingBus) count(n int, now time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.bytes += uint64(n) //nolint:gosec // n is a length, never negative
+	b.transactions++
+
+	elapsed := now.Sub(b.since)
+	if elapsed < busStatsInterval {
+		return
+	}
+	slog.Debug("i2c bus stats",
+		"bytesPerSec", fmt.Sprintf("%.0f", float64(b.bytes)/elapsed.Seconds()),
+		"transactionsPerSec", fmt.Sprintf("%.1f", float64(b.transactions)/elapsed.Seconds()),
+		"errors", b.errorSummary(),
+	)
+	b.bytes, b.transactions, b.since = 0, 0, now
+}
+
+// errorSummary lists the errors and failures of each address that had any,
+// since the bus was opened. The mutex must be held.
+func (b *countingBus) errorSummary() string {
+	var summary []string
+	for _, addr := range slices.Sorted(maps.Keys(b.addresses)) {
+		if stats := b.addresses[addr]; stats.Errors > 0 {
+			summary = append(summary, fmt.Sprintf("%#02x: %d errors, %d failed", addr, stats.Errors, stats.Failures))
+		}
+	}
+	if summary == nil {
+		return "none"
+	}
+	return strings.Join(summary, "; ")
+}
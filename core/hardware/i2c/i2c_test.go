@@ -0,0 +1,125 @@
+package i2c
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"periph.io/x/conn/v3/i2c/i2ctest"
+)
+
+type CountingBusTestSuite struct {
+	suite.Suite
+	bus *countingBus
+}
+
+func TestCountingBusTestSuite(t *testing.T) {
+	suite.Run(t, new(CountingBusTestSuite))
+}
+
+func (s *CountingBusTestSuite) SetupTest() {
+	s.bus = newCountingBus(&i2ctest.Playback{Ops: []i2ctest.IO{
+		{Addr: 0x3C, W: []byte{0x40, 1, 2, 3}},
+		{Addr: 0x1A, W: []byte{0x10}, R: []byte{0x2A, 0x01}},
+	}})
+}
+
+func (s *CountingBusTestSuite) TestCounts() {
+	s.Require().NoError(s.bus.Tx(0x3C, []byte{0x40, 1, 2, 3}, nil))
+	s.Require().NoError(s.bus.Tx(0x1A, []byte{0x10}, make([]byte, 2)))
+
+	s.Equal(uint64(5+4), s.bus.bytes)
+	s.Equal(uint64(2), s.bus.transactions)
+}
+
+func (s *CountingBusTestSuite) TestResetsAfterInterval() {
+	start := s.bus.since
+	s.bus.count(100, start.Add(busStatsInterval/2))
+	s.Equal(uint64(100), s.bus.bytes)
+
+	s.bus.count(100, start.Add(busStatsInterval))
+	s.Zero(s.bus.bytes)
+	s.Zero(s.bus.transactions)
+	s.Equal(start.Add(busStatsInterval), s.bus.since)
+}
+
+var errNack = errors.New("nack")
+
+// flakyBus fails the first fails transactions.
+type flakyBus struct {
+	i2ctest.Record
+
+	fails int
+}
+
+func (b *flakyBus) Tx(addr uint16, w, r []byte) error {
+	if b.fails > 0 {
+		b.fails--
+		return errNack
+	}
+	return b.Record.Tx(addr, w, r)
+}
+
+type SharedBusTestSuite struct {
+	suite.Suite
+	raw *flakyBus
+	bus *sharedBus
+}
+
+func TestSharedBusTestSuite(t *testing.T) {
+	suite.Run(t, new(SharedBusTestSuite))
+}
+
+func (s *SharedBusTestSuite) SetupTest() {
+	s.raw = &flakyBus{}
+	s.bus = newSharedBus(newCountingBus(s.raw), Options{Retries: 2, Backoff: time.Millisecond})
+}
+
+func (s *SharedBusTestSuite) TestRetries() {
+	s.raw.fails = 2
+	bus := i2cBusImpl{bus: s.bus}
+	s.Require().NoError(bus.SendData(0x1A, 50))
+
+	s.Len(s.raw.Ops, 1)
+	s.Equal(map[uint16]AddressStats{0x1A: {Transactions: 3, Errors: 2}}, bus.Stats())
+}
+
+func (s *SharedBusTestSuite) TestFails() {
+	s.raw.fails = 3
+	bus := i2cBusImpl{bus: s.bus}
+	s.Require().ErrorIs(bus.Tx(0x3C, []byte{0x00, 0xAF}, nil), errNack)
+
+	s.Empty(s.raw.Ops)
+	s.Equal(map[uint16]AddressStats{0x3C: {Transactions: 3, Errors: 3, Failures: 1}}, bus.Stats())
+	s.Equal("0x3c: 3 errors, 1 failed", s.bus.counting.errorSummary())
+}
+
+func (s *SharedBusTestSuite) TestFramesNotRetried() {
+	s.raw.fails = 1
+	bus := i2cBusImpl{bus: s.bus}
+	s.Require().ErrorIs(bus.GetBus().Tx(0x3C, []byte{0x40, 0xFF}, nil), errNack)
+	s.Require().NoError(bus.GetBus().Tx(0x3C, []byte{0x40, 0xFF}, nil))
+
+	s.Len(s.raw.Ops, 1)
+	s.Equal(map[uint16]AddressStats{0x3C: {Transactions: 2, Errors: 1, Failures: 1}}, bus.Stats())
+}
+
+func (s *SharedBusTestSuite) TestNoRetries() {
+	s.bus = newSharedBus(newCountingBus(s.raw), Options{})
+	s.raw.fails = 1
+	s.Require().Error(s.bus.tx(priorityControl, 0x1A, []byte{50}, nil))
+	s.Require().NoError(s.bus.tx(priorityControl, 0x1A, []byte{50}, nil))
+
+	s.Equal(AddressStats{Transactions: 2, Errors: 1, Failures: 1}, s.bus.counting.stats()[0x1A])
+}
+
+func (s *SharedBusTestSuite) TestReadRegister() {
+	playback := &i2ctest.Playback{Ops: []i2ctest.IO{{Addr: 0x1A, W: []byte{0x81}, R: []byte{0x12}}}}
+	bus := i2cBusImpl{bus: newSharedBus(newCountingBus(playback), Options{})}
+
+	value, err := bus.ReadRegister(0x1A, 0x81)
+	s.Require().NoError(err)
+	s.Equal(byte(0x12), value)
+	s.NoError(playback.Close())
+}
@@ -11,6 +11,12 @@ type I2CBus struct {
 	GetBusCalled          int
 	SendDataHandler       func(addr uint16, data ...byte) error
 	SendDataHandlerCalled int
+	TxHandler             func(addr uint16, w, r []byte) error
+	TxCalled              int
+	ReadRegisterHandler   func(addr uint16, reg byte) (byte, error)
+	ReadRegisterCalled    int
+	StatsHandler          func() map[uint16]i2c.AddressStats
+	StatsCalled           int
 }
 
 var _ i2c.I2CBus = (*I2CBus)(nil)
@@ -24,3 +30,18 @@ func (m *I2CBus) SendData(addr uint16, data ...byte) error {
 	m.SendDataHandlerCalled++
 	return m.SendDataHandler(addr, data...)
 }
+
+func (m *I2CBus) Tx(addr uint16, w, r []byte) error {
+	m.TxCalled++
+	return m.TxHandler(addr, w, r)
+}
+
+func (m *I2CBus) ReadRegister(addr uint16, reg byte) (byte, error) {
+	m.ReadRegisterCalled++
+	return m.ReadRegisterHandler(addr, reg)
+}
+
+func (m *I2CBus) Stats() map[uint16]i2c.AddressStats {
+	m.StatsCalled++
+	return m.StatsHandler()
+}
@@ -6,6 +6,10 @@ import "github.com/czechbol/lumeon/core/hardware"
 type FanMock struct {
 	SetSpeedHandler       func(speed uint8) error
 	SetSpeedHandlerCalled int
+	SpeedHandler          func() (uint8, error)
+	SpeedHandlerCalled    int
+	InfoHandler           func() hardware.BoardInfo
+	InfoHandlerCalled     int
 }
 
 var _ hardware.Fan = (*FanMock)(nil)
@@ -14,3 +18,13 @@ func (m *FanMock) SetSpeed(speed uint8) error {
 	m.SetSpeedHandlerCalled++
 	return m.SetSpeedHandler(speed)
 }
+
+func (m *FanMock) Speed() (uint8, error) {
+	m.SpeedHandlerCalled++
+	return m.SpeedHandler()
+}
+
+func (m *FanMock) Info() hardware.BoardInfo {
+	m.InfoHandlerCalled++
+	return m.InfoHandler()
+}
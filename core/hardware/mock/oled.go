@@ -1,6 +1,7 @@
 package mock
 
 import (
+	"context"
 	"image"
 	"image/gif"
 
@@ -10,6 +11,8 @@ import (
 
 // OLEDMock defines mocks for OLED.
 type OLEDMock struct {
+	BoundsHandler                  func() image.Rectangle
+	BoundsHandlerCalled            int
 	InvertHandler                  func(blackOnWhite bool) error
 	InvertHandlerCalled            int
 	SetContrastHandler             func(brightness uint8) error
@@ -18,15 +21,17 @@ type OLEDMock struct {
 	ClearHandlerCalled             int
 	DrawImageHandler               func(img image.Image) error
 	DrawImageHandlerCalled         int
-	DrawGIFHandler                 func(gif *gif.GIF) error
+	DrawGIFHandler                 func(ctx context.Context, gif *gif.GIF) error
 	DrawGIFHandlerCalled           int
+	PlayGIFHandler                 func(ctx context.Context, gif *gif.GIF) hardware.Animation
+	PlayGIFHandlerCalled           int
 	DrawTextHandler                func(text string, x, y int) error
 	DrawTextHandlerCalled          int
 	DrawLinesHandler               func(lines []string) error
 	DrawLinesHandlerCalled         int
 	DrawImageWithTextHandler       func(img image.Image, x, y int, text string) error
 	DrawImageWithTextHandlerCalled int
-	DrawGIFWithTextHandler         func(gif *gif.GIF, x, y int, text string) error
+	DrawGIFWithTextHandler         func(ctx context.Context, gif *gif.GIF, x, y int, text string) error
 	DrawGIFWithTextHandlerCalled   int
 	ScrollHandler                  func(direction types.ScrollDirection, rate types.FrameRate, startLine, endLine int) error
 	ScrollHandlerCalled            int
@@ -36,6 +41,11 @@ type OLEDMock struct {
 
 var _ hardware.OLED = (*OLEDMock)(nil)
 
+func (m *OLEDMock) Bounds() image.Rectangle {
+	m.BoundsHandlerCalled++
+	return m.BoundsHandler()
+}
+
 func (m *OLEDMock) Invert(blackOnWhite bool) error {
 	m.InvertHandlerCalled++
 	return m.InvertHandler(blackOnWhite)
@@ -56,9 +66,14 @@ func (m *OLEDMock) DrawImage(img image.Image) error {
 	return m.DrawImageHandler(img)
 }
 
-func (m *OLEDMock) DrawGIF(gif *gif.GIF) error {
+func (m *OLEDMock) DrawGIF(ctx context.Context, gif *gif.GIF) error {
 	m.DrawGIFHandlerCalled++
-	return m.DrawGIFHandler(gif)
+	return m.DrawGIFHandler(ctx, gif)
+}
+
+func (m *OLEDMock) PlayGIF(ctx context.Context, gif *gif.GIF) hardware.Animation {
+	m.PlayGIFHandlerCalled++
+	return m.PlayGIFHandler(ctx, gif)
 }
 
 func (m *OLEDMock) DrawText(text string, x, y int) error {
@@ -76,9 +91,9 @@ func (m *OLEDMock) DrawImageWithText(img image.Image, x, y int, text string) err
 	return m.DrawImageWithTextHandler(img, x, y, text)
 }
 
-func (m *OLEDMock) DrawGIFWithText(gif *gif.GIF, x, y int, text string) error {
+func (m *OLEDMock) DrawGIFWithText(ctx context.Context, gif *gif.GIF, x, y int, text string) error {
 	m.DrawGIFWithTextHandlerCalled++
-	return m.DrawGIFWithTextHandler(gif, x, y, text)
+	return m.DrawGIFWithTextHandler(ctx, gif, x, y, text)
 }
 
 func (m *OLEDMock) Scroll(direction types.ScrollDirection, rate types.FrameRate, startLine, endLine int) error {
@@ -90,3 +105,42 @@ func (m *OLEDMock) StopScroll() error {
 	m.StopScrollHandlerCalled++
 	return m.StopScrollHandler()
 }
+
+// AnimationMock defines mocks for Animation. Done returns DoneChan.
+type AnimationMock struct {
+	PauseHandler        func()
+	PauseHandlerCalled  int
+	ResumeHandler       func()
+	ResumeHandlerCalled int
+	StopHandler         func()
+	StopHandlerCalled   int
+	DoneChan            chan struct{}
+	WaitHandler         func() error
+	WaitHandlerCalled   int
+}
+
+var _ hardware.Animation = (*AnimationMock)(nil)
+
+func (m *AnimationMock) Pause() {
+	m.PauseHandlerCalled++
+	m.PauseHandler()
+}
+
+func (m *AnimationMock) Resume() {
+	m.ResumeHandlerCalled++
+	m.ResumeHandler()
+}
+
+func (m *AnimationMock) Stop() {
+	m.StopHandlerCalled++
+	m.StopHandler()
+}
+
+func (m *AnimationMock) Done() <-chan struct{} {
+	return m.DoneChan
+}
+
+func (m *AnimationMock) Wait() error {
+	m.WaitHandlerCalled++
+	return m.WaitHandler()
+}
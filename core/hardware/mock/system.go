@@ -8,6 +8,9 @@ type SystemMock struct {
 	ShutdownHandlerCalled int
 	HaltHandler           func() error
 	HaltHandlerCalled     int
+
+	PowerTransitionHandler       func() (hardware.PowerTransition, error)
+	PowerTransitionHandlerCalled int
 }
 
 var _ hardware.System = (*SystemMock)(nil)
@@ -21,3 +24,8 @@ func (m *SystemMock) Halt() error {
 	m.HaltHandlerCalled++
 	return m.HaltHandler()
 }
+
+func (m *SystemMock) PowerTransition() (hardware.PowerTransition, error) {
+	m.PowerTransitionHandlerCalled++
+	return m.PowerTransitionHandler()
+}
@@ -1,12 +1,13 @@
 package hardware
 
 import (
+	"context"
+	"fmt"
 	"image"
-	"image/color"
 	"image/draw"
 	"image/gif"
 	"log/slog"
-	"time"
+	"sync"
 	"unicode/utf8"
 
 	"github.com/czechbol/lumeon/core/hardware/i2c"
@@ -14,220 +15,188 @@ import (
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/basicfont"
 	"golang.org/x/image/math/fixed"
-	"periph.io/x/conn/v3/display"
-	"periph.io/x/devices/v3/ssd1306"
+	"periph.io/x/conn/v3"
+	i2clib "periph.io/x/conn/v3/i2c"
 	"periph.io/x/devices/v3/ssd1306/image1bit"
 )
 
 type OLED interface {
+	// Bounds is the size of the display in pixels.
+	Bounds() image.Rectangle
 	Invert(blackOnWhite bool) error
 	SetContrast(brightness uint8) error
 	Clear() error
 	DrawImage(img image.Image) error
-	DrawGIF(gif *gif.GIF) error
+	// DrawGIF plays gif and returns when it ends or ctx is cancelled.
+	DrawGIF(ctx context.Context, gif *gif.GIF) error
+	// PlayGIF plays gif in the background until it ends or is stopped.
+	PlayGIF(ctx context.Context, gif *gif.GIF) Animation
 	DrawText(text string, x, y int) error
 	DrawLines(lines []string) error
 	DrawImageWithText(img image.Image, x, y int, text string) error
-	DrawGIFWithText(gif *gif.GIF, x, y int, text string) error
+	DrawGIFWithText(ctx context.Context, gif *gif.GIF, x, y int, text string) error
 	Scroll(direction types.ScrollDirection, rate types.FrameRate, startLine, endLine int) error
 	StopScroll() error
 }
 
 type oledI2cImpl struct {
-	dev *ssd1306.Dev
-}
-
-func NewOLED(i2cBus i2c.I2CBus) (*oledI2cImpl, error) {
-	slog.Info("Initializing OLED display")
-	dev, err := ssd1306.NewI2C(i2cBus.GetBus(), &ssd1306.Opts{
-		W:                displayWidth,
-		H:                displayHeight,
-		MirrorHorizontal: true,
-	})
-	if err != nil {
+	// conn is the display on the bus; commands and frames are written
+	// straight to it, see flush.
+	conn       conn.Conn
+	panel      Panel
+	controller controller
+	opts       ConvertOptions
+
+	// mutex serializes frames, which an animation draws from its own goroutine.
+	mutex sync.Mutex
+	// shadow mirrors the display RAM when shadowValid is set.
+	shadow      []byte
+	shadowValid bool
+	halted      bool
+}
+
+// NewOLED initializes the display described by panel. Images and GIFs are
+// converted with opts.
+func NewOLED(i2cBus i2c.I2CBus, panel Panel, opts ConvertOptions) (*oledI2cImpl, error) {
+	panel = panel.withDefaults()
+	if err := panel.validate(); err != nil {
+		return nil, err
+	}
+	slog.Info("Initializing OLED display", "controller", panel.Controller, "width", panel.Width,
+		"height", panel.Height, "address", fmt.Sprintf("%#02x", panel.Address))
+
+	o := &oledI2cImpl{
+		conn:       &i2clib.Dev{Bus: i2cBus.GetBus(), Addr: panel.Address},
+		panel:      panel,
+		controller: controllers[panel.Controller],
+		opts:       opts,
+		shadow:     make([]byte, panel.Width*panel.Height/8),
+	}
+	if err := o.command(o.controller.init(panel)...); err != nil {
 		return nil, err
 	}
-	return &oledI2cImpl{dev: dev}, nil
+	return o, nil
+}
+
+// Bounds returns the size of the display in pixels.
+func (o *oledI2cImpl) Bounds() image.Rectangle {
+	return o.panel.Bounds()
 }
 
 func (o *oledI2cImpl) Invert(blackOnWhite bool) error {
 	slog.Debug("Inverting display", "blackOnWhite", blackOnWhite)
-	return o.dev.Invert(blackOnWhite)
+	if blackOnWhite {
+		return o.command(cmdInvertedDisplay)
+	}
+	return o.command(cmdNormalDisplay)
 }
 
 // SetContrast sets the display brightness.
 func (o *oledI2cImpl) SetContrast(brightness uint8) error {
 	slog.Debug("Setting contrast", "brightness", brightness)
-	return o.dev.SetContrast(brightness)
+	return o.command(cmdSetBrightness, brightness)
 }
 
+// Clear turns the display off. It comes back on with the next frame.
 func (o *oledI2cImpl) Clear() error {
 	slog.Debug("Clearing display")
-	return o.dev.Halt()
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if err := o.command(cmdDisableDisplay); err != nil {
+		return err
+	}
+	o.halted = true
+	return nil
 }
 
 func (o *oledI2cImpl) DrawImage(img image.Image) error {
 	slog.Debug("Drawing image")
-	convertedImg := convert(o.dev, img)
-
-	return o.dev.Draw(o.dev.Bounds(), convertedImg, image.Point{})
+	// Pages are drawn 1-bit at the display size and need no conversion.
+	if mono, ok := img.(*image1bit.VerticalLSB); ok && mono.Rect == o.Bounds() {
+		return o.flush(mono)
+	}
+	return o.flush(convert(o.Bounds(), img, o.opts))
 }
 
-func (o *oledI2cImpl) DrawGIF(gif *gif.GIF) error {
-	slog.Debug("Preparing GIF")
-
-	convertedGIF := convertGIF(o.dev, gif)
-
+// DrawGIF plays gif as PlayGIF does and waits for it to end. It returns
+// early, with nil, when ctx is cancelled.
+func (o *oledI2cImpl) DrawGIF(ctx context.Context, gif *gif.GIF) error {
 	slog.Debug("Drawing GIF")
-	// LoopCount: -1 = play once, 0 = loop forever, N = loop N times
-	for i := 0; gif.LoopCount == 0 || i < gif.LoopCount*len(gif.Image); i++ {
-		index := i % len(gif.Image)
-		c := time.After(time.Duration(10*convertedGIF.Delay[index]) * time.Millisecond)
-		img := convertedGIF.Image[index]
-		err := o.dev.Draw(o.dev.Bounds(), img, image.Point{})
-		if err != nil {
-			return err
-		}
-		<-c
-	}
-	return nil
+	return o.PlayGIF(ctx, gif).Wait()
 }
 
 func (o *oledI2cImpl) DrawText(text string, x, y int) error {
 	slog.Debug("Drawing text", "text", text, "x", x, "y", y)
-	img := image1bit.NewVerticalLSB(o.dev.Bounds())
+	img := image1bit.NewVerticalLSB(o.Bounds())
 	addLabel(img, x, y, text)
 
-	return o.dev.Draw(o.dev.Bounds(), img, image.Point{})
+	return o.flush(img)
 }
 
 func (o *oledI2cImpl) DrawLines(lines []string) error {
 	slog.Debug("Drawing lines", "count", len(lines))
-	img := image1bit.NewVerticalLSB(o.dev.Bounds())
+	img := image1bit.NewVerticalLSB(o.Bounds())
 	const lineHeight = 13
 	for i, line := range lines {
 		addLabel(img, 0, lineHeight*i, line)
 	}
-	return o.dev.Draw(o.dev.Bounds(), img, image.Point{})
+	return o.flush(img)
 }
 
 func (o *oledI2cImpl) DrawImageWithText(img image.Image, x, y int, text string) error {
 	slog.Debug("Drawing image with text", "text", text, "x", x, "y", y)
-	convertedImg := convert(o.dev, img)
+	convertedImg := convert(o.Bounds(), img, o.opts)
 	addLabel(convertedImg, x, y, text)
-	return o.dev.Draw(o.dev.Bounds(), convertedImg, image.Point{})
+	return o.flush(convertedImg)
 }
 
-func (o *oledI2cImpl) DrawGIFWithText(gif *gif.GIF, x, y int, text string) error {
-	slog.Debug("Preparing GIF with text", "text", text, "x", x, "y", y)
+// DrawGIFWithText plays gif with text over every frame, as DrawGIF does.
+func (o *oledI2cImpl) DrawGIFWithText(ctx context.Context, gif *gif.GIF, x, y int, text string) error {
+	slog.Debug("Drawing GIF with text", "text", text, "x", x, "y", y)
 
 	// preprocess the GIF to save on resources during rendering
-	convertedGIF := convertGIF(o.dev, gif)
+	convertedGIF := convertGIF(o.Bounds(), gif, o.opts)
 	for i := range convertedGIF.Image {
 		addLabel(convertedGIF.Image[i], x, y, text)
 	}
-
-	slog.Debug("Drawing GIF")
-	// LoopCount: -1 = play once, 0 = loop forever, N = loop N times
-	for i := 0; gif.LoopCount == 0 || i < gif.LoopCount*len(gif.Image); i++ {
-		index := i % len(gif.Image)
-		c := time.After(time.Duration(10*gif.Delay[index]) * time.Millisecond)
-		img := convertedGIF.Image[index]
-		err := o.dev.Draw(o.dev.Bounds(), img, image.Point{})
-		if err != nil {
-			return err
-		}
-		<-c
-	}
-	return nil
+	return o.play(ctx, monoFrames(convertedGIF), convertedGIF.Delay, gif.LoopCount).Wait()
 }
 
+// Scroll scrolls the band of lines [startLine, endLine) in hardware; -1 for
+// endLine means the bottom of the display. Lines must be multiples of 8.
 func (o *oledI2cImpl) Scroll(direction types.ScrollDirection, rate types.FrameRate, startLine, endLine int) error {
 	slog.Debug("Scrolling display", "direction", direction, "rate", rate, "startLine", startLine, "endLine", endLine)
-	return o.dev.Scroll(ssd1306.Orientation(direction), ssd1306.FrameRate(rate), startLine, endLine)
-}
-
-func (o *oledI2cImpl) StopScroll() error {
-	slog.Debug("Stopping scroll")
-	return o.dev.StopScroll()
-}
-
-// resize scales the source image to the given size.
-func resize(src image.Image, size image.Point) *image.NRGBA {
-	srcMax := src.Bounds().Max
-	dst := image.NewNRGBA(image.Rectangle{Max: size})
-	for y := 0; y < size.Y; y++ {
-		sY := (y*srcMax.Y + size.Y/2) / size.Y
-		for x := 0; x < size.X; x++ {
-			dst.Set(x, y, src.At((x*srcMax.X+size.X/2)/size.X, sY))
-		}
+	if !o.controller.scroll {
+		return fmt.Errorf("%w: %s cannot scroll", ErrNotImplemented, o.panel.Controller)
 	}
-	return dst
-}
-
-// convert prepares the image for the OLED display format.
-func convert(disp display.Drawer, src image.Image) *image1bit.VerticalLSB {
-	screenBounds := disp.Bounds()
-	size := screenBounds.Size()
-
-	// Resize the image if necessary
-	if !src.Bounds().Size().Eq(size) {
-		src = resize(src, size)
+	if endLine == -1 {
+		endLine = o.panel.Height
 	}
-
-	// Convert the image to grayscale
-	grayImg := image.NewGray(src.Bounds())
-	draw.Draw(grayImg, src.Bounds(), src, image.Point{}, draw.Src)
-
-	// Convert the grayscale image to monochrome
-	monoImg := image1bit.NewVerticalLSB(screenBounds)
-	for y := 0; y < grayImg.Bounds().Dy(); y++ {
-		for x := 0; x < grayImg.Bounds().Dx(); x++ {
-			grayColor := grayImg.GrayAt(x, y)
-			if grayColor.Y > 128 {
-				monoImg.Set(x, y, color.White)
-			} else {
-				monoImg.Set(x, y, color.Black)
-			}
-		}
+	if startLine < 0 || startLine >= endLine || endLine > o.panel.Height || startLine%8 != 0 || endLine%8 != 0 {
+		return fmt.Errorf("%w: lines %d to %d", ErrInvalidPageStart, startLine, endLine)
 	}
 
-	return monoImg
-}
+	// The controller shifts its RAM while scrolling, so the shadow is stale.
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.shadowValid = false
 
-func convertGIF(disp display.Drawer, g *gif.GIF) *gif.GIF {
-	// Create a new GIF to store the fully rendered frames
-	newGIF := &gif.GIF{
-		LoopCount: g.LoopCount,
-		Delay:     make([]int, len(g.Image)),
-		Disposal:  make([]byte, len(g.Image)),
+	startPage, endPage := byte(startLine/8), byte(endLine/8-1)
+	if direction == types.ScrollLeft || direction == types.ScrollRight {
+		return o.command(byte(direction), 0x00, startPage, byte(rate), endPage, 0x00, 0xFF, cmdActivateScroll)
 	}
+	return o.command(byte(direction), 0x00, startPage, byte(rate), endPage, 0x01, cmdActivateScroll)
+}
 
-	// Create a canvas to build up the frames
-	canvas := image.NewGray(g.Image[0].Bounds())
-
-	for i, srcImg := range g.Image {
-		// Start with a clean canvas if disposal method is 2 (RestoreBGColor)
-		if i > 0 && i-1 < len(g.Disposal) && g.Disposal[i-1] == gif.DisposalBackground {
-			draw.Draw(canvas, canvas.Bounds(), image.Transparent, image.Point{}, draw.Src)
-		}
-
-		// Draw this frame onto the canvas
-		draw.Draw(canvas, srcImg.Bounds(), srcImg, srcImg.Bounds().Min, draw.Over)
-
-		// Create a new paletted image for this frame
-		palettedImage := image.NewPaletted(canvas.Bounds(), srcImg.Palette)
-		draw.Draw(palettedImage, palettedImage.Bounds(), convert(disp, canvas), image.Point{}, draw.Src)
-
-		// Add the new frame to our GIF
-		newGIF.Image = append(newGIF.Image, palettedImage)
-		if i < len(g.Delay) {
-			newGIF.Delay[i] = g.Delay[i]
-		}
-		newGIF.Disposal[i] = gif.DisposalNone // Since each frame is now complete
+func (o *oledI2cImpl) StopScroll() error {
+	slog.Debug("Stopping scroll")
+	if !o.controller.scroll {
+		return nil
 	}
-
-	return newGIF
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.shadowValid = false
+	return o.command(cmdDeactivateScroll)
 }
 
 // addLabel draws text onto the image at the specified coordinates.
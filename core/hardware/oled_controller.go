@@ -0,0 +1,186 @@
+package hardware
+
+import (
+	"fmt"
+	"image"
+	"slices"
+)
+
+// Controller is the chip that drives the OLED panel.
+type Controller string
+
+const (
+	// ControllerSSD1306 is the controller of the Argon EON display and most
+	// 128×64 and 128×32 modules.
+	ControllerSSD1306 Controller = "ssd1306"
+	// ControllerSSD1309 takes the SSD1306 commands but is powered from an
+	// external supply, so its charge pump is left alone.
+	ControllerSSD1309 Controller = "ssd1309"
+	// ControllerSH1106 has 132 columns of RAM, of which the middle 128 are
+	// shown, and only page addressing.
+	ControllerSH1106 Controller = "sh1106"
+)
+
+// sh1106ColumnOffset is the first of the 132 SH1106 RAM columns that is shown.
+const sh1106ColumnOffset = 2
+
+// Panel describes the display module. Zero fields are those of the Argon EON
+// display: an SSD1306, 128×64, at 0x3C.
+type Panel struct {
+	Controller Controller
+	Width      int
+	Height     int
+	// Rotation is 0 or 180 degrees.
+	Rotation int
+	// MirrorHorizontal and MirrorVertical flip the picture, for panels that
+	// are wired or mounted the other way round. The Argon EON display is
+	// mirrored horizontally.
+	MirrorHorizontal bool
+	MirrorVertical   bool
+	Address          uint16
+}
+
+// controller is how a controller chip is set up and written to.
+type controller struct {
+	// init returns the commands that set the controller up for p and turn
+	// the display on.
+	init func(p Panel) []byte
+	// address returns the commands that select columns [start, end) of page
+	// for the data that follows.
+	address func(page, start, end int) []byte
+	// scroll is set if the controller can scroll in hardware.
+	scroll bool
+}
+
+var controllers = map[Controller]controller{
+	ControllerSSD1306: {init: ssd1306Init(true), address: ssd1306Address, scroll: true},
+	ControllerSSD1309: {init: ssd1306Init(false), address: ssd1306Address, scroll: true},
+	ControllerSH1106:  {init: sh1106Init, address: sh1106Address},
+}
+
+// withDefaults fills in the zero fields of p.
+func (p Panel) withDefaults() Panel {
+	if p.Controller == "" {
+		p.Controller = ControllerSSD1306
+	}
+	if p.Width == 0 {
+		p.Width = displayWidth
+	}
+	if p.Height == 0 {
+		p.Height = displayHeight
+	}
+	if p.Address == 0 {
+		p.Address = displayAddress
+	}
+	return p
+}
+
+func (p Panel) validate() error {
+	if _, ok := controllers[p.Controller]; !ok {
+		return fmt.Errorf("%w: unknown controller %q", ErrInvalidPanel, p.Controller)
+	}
+	if p.Width < 8 || p.Width > displayWidth || p.Width%8 != 0 ||
+		p.Height < 16 || p.Height > displayHeight || p.Height%8 != 0 {
+		return fmt.Errorf("%w: unsupported size %dx%d", ErrInvalidPanel, p.Width, p.Height)
+	}
+	if !slices.Contains([]int{0, 180}, p.Rotation) {
+		return fmt.Errorf("%w: rotation must be 0 or 180, not %d", ErrInvalidPanel, p.Rotation)
+	}
+	return nil
+}
+
+// Bounds returns the size of the panel in pixels.
+func (p Panel) Bounds() image.Rectangle {
+	return image.Rect(0, 0, p.Width, p.Height)
+}
+
+// orientation returns the segment remap and COM scan direction commands that
+// put the picture the right way round.
+func (p Panel) orientation() (segmentRemap, comScan byte) {
+	segmentRemap, comScan = 0xA1, 0xC8
+	if p.MirrorHorizontal {
+		segmentRemap ^= 0x01
+	}
+	if p.MirrorVertical {
+		comScan ^= 0x08
+	}
+	if p.Rotation == 180 {
+		segmentRemap ^= 0x01
+		comScan ^= 0x08
+	}
+	return segmentRemap, comScan
+}
+
+// comPins returns the COM pins configuration: 128×32 panels are wired
+// sequentially, taller ones alternately.
+func (p Panel) comPins() byte {
+	if p.Height <= 32 {
+		return 0x02
+	}
+	return 0x12
+}
+
+// ssd1306Init returns the set-up of an SSD1306, or of an SSD1309 without the
+// charge pump. The controller is put in horizontal addressing mode.
+func ssd1306Init(chargePump bool) func(p Panel) []byte {
+	return func(p Panel) []byte {
+		segmentRemap, comScan := p.orientation()
+		cmd := []byte{
+			cmdDisableDisplay,
+			0xD5, 0xF0, // oscillator frequency and clock divide ratio
+			0xA8, byte(p.Height - 1), // multiplex ratio: the number of lines
+			0xD3, 0x00, // display offset
+			0x40, // display start line 0
+			segmentRemap,
+			comScan,
+			0xDA, p.comPins(),
+			cmdSetBrightness, 0xFF,
+			0xD9, 0xF1, // pre-charge period
+			0xDB, 0x40, // VCOMH deselect level
+			cmdDisplayRAMContent,
+			cmdNormalDisplay,
+			cmdDeactivateScroll,
+			cmdSetMemoryMode, 0x00,
+			cmdSetColumnAddress, 0, byte(p.Width - 1),
+			cmdSetPageAddress, 0, byte(p.Height/8 - 1),
+		}
+		if chargePump {
+			cmd = append(cmd, 0x8D, 0x14)
+		}
+		return append(cmd, cmdEnableDisplay)
+	}
+}
+
+func ssd1306Address(page, start, end int) []byte {
+	return []byte{cmdSetColumnAddress, byte(start), byte(end - 1), cmdSetPageAddress, byte(page), byte(page)}
+}
+
+// sh1106Init returns the set-up of an SH1106, which has no addressing modes
+// and its own DC-DC converter.
+func sh1106Init(p Panel) []byte {
+	segmentRemap, comScan := p.orientation()
+	return []byte{
+		cmdDisableDisplay,
+		0xD5, 0x80, // oscillator frequency and clock divide ratio
+		0xA8, byte(p.Height - 1), // multiplex ratio: the number of lines
+		0xD3, 0x00, // display offset
+		0x40,       // display start line 0
+		0xAD, 0x8B, // DC-DC converter on
+		segmentRemap,
+		comScan,
+		0xDA, p.comPins(),
+		cmdSetBrightness, 0xFF,
+		0xD9, 0x22, // pre-charge period
+		0xDB, 0x35, // VCOMH deselect level
+		cmdDisplayRAMContent,
+		cmdNormalDisplay,
+		cmdEnableDisplay,
+	}
+}
+
+// sh1106Address selects the page and the first column; the column advances
+// with each data byte.
+func sh1106Address(page, start, _ int) []byte {
+	col := start + sh1106ColumnOffset
+	return []byte{cmdPageStartAddressBase | byte(page), byte(col & 0x0F), 0x10 | byte(col>>4)}
+}
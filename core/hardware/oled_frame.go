@@ -0,0 +1,110 @@
+package hardware
+
+import (
+	"image/draw"
+	"image/gif"
+	"log/slog"
+
+	"periph.io/x/devices/v3/ssd1306/image1bit"
+)
+
+const (
+	// oledSpanOverhead is roughly what it costs to start a new span: the
+	// address command and the I2C address and control bytes of two
+	// transactions. Unchanged runs shorter than this are resent instead.
+	oledSpanOverhead = 10
+)
+
+// i2c control bytes: the rest of the transaction is commands or data.
+const (
+	oledControlCommand byte = 0x00
+	oledControlData    byte = cmdWriteData
+)
+
+// span is a range of columns [start, end) in one page.
+type span struct {
+	start, end int
+}
+
+// changedSpans returns the column ranges of a page that differ between old
+// and next, merging ranges that are close enough to send together.
+func changedSpans(old, next []byte) []span {
+	var spans []span
+	for col := 0; col < len(next); col++ {
+		if old[col] == next[col] {
+			continue
+		}
+		end := col + 1
+		for end < len(next) && old[end] != next[end] {
+			end++
+		}
+		if n := len(spans); n > 0 && col-spans[n-1].end < oledSpanOverhead {
+			spans[n-1].end = end
+		} else {
+			spans = append(spans, span{start: col, end: end})
+		}
+		col = end
+	}
+	return spans
+}
+
+// flush sends the parts of img that differ from what the display shows: for
+// each changed page only the changed column ranges, using the controller's
+// column and page addressing. Pages that do not change, such as the header
+// while the content below it scrolls, cost nothing.
+func (o *oledI2cImpl) flush(img *image1bit.VerticalLSB) error {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	sent := 0
+	pageBytes := o.panel.Width
+	for page := range o.panel.Height / 8 {
+		start := page * pageBytes
+		old, next := o.shadow[start:start+pageBytes], img.Pix[start:start+pageBytes]
+
+		spans := []span{{start: 0, end: pageBytes}}
+		if o.shadowValid {
+			spans = changedSpans(old, next)
+		}
+		for _, sp := range spans {
+			err := o.command(o.controller.address(page, sp.start, sp.end)...)
+			if err == nil {
+				err = o.conn.Tx(append([]byte{oledControlData}, next[sp.start:sp.end]...), nil)
+			}
+			if err != nil {
+				// Part of the frame may have been written; redraw it all next time.
+				o.shadowValid = false
+				return err
+			}
+			sent += sp.end - sp.start
+		}
+	}
+	copy(o.shadow, img.Pix)
+	o.shadowValid = true
+
+	// Turn the display back on after the new frame is in, so the old one
+	// does not flash up.
+	if o.halted {
+		if err := o.command(cmdEnableDisplay); err != nil {
+			return err
+		}
+		o.halted = false
+	}
+
+	slog.Debug("Flushed frame", "bytes", sent, "of", len(o.shadow))
+	return nil
+}
+
+func (o *oledI2cImpl) command(cmd ...byte) error {
+	return o.conn.Tx(append([]byte{oledControlCommand}, cmd...), nil)
+}
+
+// monoFrames converts the frames of a converted GIF for flush.
+func monoFrames(g *gif.GIF) []*image1bit.VerticalLSB {
+	frames := make([]*image1bit.VerticalLSB, len(g.Image))
+	for i, img := range g.Image {
+		frames[i] = image1bit.NewVerticalLSB(img.Bounds())
+		draw.Draw(frames[i], img.Bounds(), img, img.Bounds().Min, draw.Src)
+	}
+	return frames
+}
@@ -0,0 +1,163 @@
+package hardware
+
+import (
+	"context"
+	"image/gif"
+	"time"
+
+	"periph.io/x/devices/v3/ssd1306/image1bit"
+)
+
+// gifMinDelay is the delay of frames that ask for less than 20ms, as browsers
+// play them.
+const gifMinDelay = 100 * time.Millisecond
+
+// Animation is a GIF playing on the display. Its frames are drawn from a
+// single goroutine; the methods can be called from any goroutine.
+type Animation interface {
+	// Pause holds the current frame until Resume.
+	Pause()
+	Resume()
+	// Stop ends the animation, leaving the current frame up, and waits for it.
+	Stop()
+	// Done is closed when the animation has finished or been stopped.
+	Done() <-chan struct{}
+	// Wait waits for the animation to end. It returns the error of a failed
+	// frame, and nil if the animation finished or was stopped.
+	Wait() error
+}
+
+type gifPlayer struct {
+	cancel context.CancelFunc
+	pause  chan bool
+	done   chan struct{}
+	err    error
+}
+
+// PlayGIF starts playing g and returns at once. The animation loops as
+// g.LoopCount says: 0 loops until it is stopped or ctx is cancelled, -1
+// plays once and N plays N+1 times.
+func (o *oledI2cImpl) PlayGIF(ctx context.Context, g *gif.GIF) Animation {
+	convertedGIF := convertGIF(o.Bounds(), g, o.opts)
+	return o.play(ctx, monoFrames(convertedGIF), convertedGIF.Delay, g.LoopCount)
+}
+
+func (o *oledI2cImpl) play(
+	ctx context.Context,
+	frames []*image1bit.VerticalLSB,
+	delays []int,
+	loopCount int,
+) *gifPlayer {
+	ctx, cancel := context.WithCancel(ctx)
+	p := &gifPlayer{
+		cancel: cancel,
+		pause:  make(chan bool),
+		done:   make(chan struct{}),
+	}
+	go p.run(ctx, o, frames, delays, loopCount)
+	return p
+}
+
+func (p *gifPlayer) run(
+	ctx context.Context,
+	o *oledI2cImpl,
+	frames []*image1bit.VerticalLSB,
+	delays []int,
+	loopCount int,
+) {
+	defer close(p.done)
+	defer p.cancel()
+
+	plays := max(loopCount+1, 1)
+	for play := 0; loopCount == 0 || play < plays; play++ {
+		for i, frame := range frames {
+			if ctx.Err() != nil {
+				return
+			}
+			if err := o.flush(frame); err != nil {
+				p.err = err
+				return
+			}
+			if !p.wait(ctx, frameDelay(delays, i)) {
+				return
+			}
+		}
+	}
+}
+
+// wait shows the current frame for d, longer while paused. It returns false
+// if the animation was stopped.
+func (p *gifPlayer) wait(ctx context.Context, d time.Duration) bool {
+	deadline := time.Now().Add(d)
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-timer.C:
+			return true
+		case paused := <-p.pause:
+			if !paused {
+				continue
+			}
+			remaining := time.Until(deadline)
+			timer.Stop()
+			if !p.waitResume(ctx) {
+				return false
+			}
+			deadline = time.Now().Add(remaining)
+			timer.Reset(remaining)
+		}
+	}
+}
+
+func (p *gifPlayer) waitResume(ctx context.Context) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case paused := <-p.pause:
+			if !paused {
+				return true
+			}
+		}
+	}
+}
+
+func (p *gifPlayer) Pause() {
+	select {
+	case p.pause <- true:
+	case <-p.done:
+	}
+}
+
+func (p *gifPlayer) Resume() {
+	select {
+	case p.pause <- false:
+	case <-p.done:
+	}
+}
+
+func (p *gifPlayer) Stop() {
+	p.cancel()
+	<-p.done
+}
+
+func (p *gifPlayer) Done() <-chan struct{} {
+	return p.done
+}
+
+func (p *gifPlayer) Wait() error {
+	<-p.done
+	return p.err
+}
+
+// frameDelay returns how long frame i is shown.
+func frameDelay(delays []int, i int) time.Duration {
+	if i >= len(delays) || delays[i] < 2 {
+		return gifMinDelay
+	}
+	return time.Duration(delays[i]) * 10 * time.Millisecond
+}
@@ -1,16 +1,19 @@
 package hardware
 
 import (
+	"context"
 	"image"
 	"image/color"
 	"image/gif"
 	"testing"
+	"time"
 
 	"github.com/czechbol/lumeon/core/hardware/i2c/mock"
 	"github.com/czechbol/lumeon/core/hardware/types"
 	"github.com/stretchr/testify/suite"
 	i2clib "periph.io/x/conn/v3/i2c"
 	"periph.io/x/conn/v3/i2c/i2ctest"
+	"periph.io/x/devices/v3/ssd1306/image1bit"
 )
 
 type OLEDTestSuite struct {
@@ -28,7 +31,7 @@ func (s *OLEDTestSuite) SetupTest() {
 		return s.recordBus
 	}
 
-	oled, err := NewOLED(s.busMock)
+	oled, err := NewOLED(s.busMock, Panel{MirrorHorizontal: true}, ConvertOptions{})
 	s.NoError(err)
 	s.oled = oled
 
@@ -69,20 +72,137 @@ func (s *OLEDTestSuite) TestDrawImage() {
 	s.Len(s.recordBus.Ops, 17)
 }
 
-func (s *OLEDTestSuite) TestDrawGIF() {
+func (s *OLEDTestSuite) TestDifferentialUpdates() {
+	img := image1bit.NewVerticalLSB(image.Rect(0, 0, 128, 64))
+	s.Require().NoError(s.oled.DrawImage(img))
+	s.recordBus.Ops = nil
+
+	// The same frame again sends nothing.
+	s.Require().NoError(s.oled.DrawImage(img))
+	s.Empty(s.recordBus.Ops)
+
+	// One pixel sends its column of one page: the address command and one
+	// data byte.
+	img.SetBit(5, 20, image1bit.On)
+	s.Require().NoError(s.oled.DrawImage(img))
+	s.Require().Len(s.recordBus.Ops, 2)
+	s.Equal([]byte{0x00, cmdSetColumnAddress, 5, 5, cmdSetPageAddress, 2, 2}, s.recordBus.Ops[0].W)
+	s.Equal([]byte{cmdWriteData, 1 << 4}, s.recordBus.Ops[1].W)
+	s.recordBus.Ops = nil
+
+	// Changes close together go in one span, far apart in two.
+	img.SetBit(8, 20, image1bit.On)
+	img.SetBit(100, 20, image1bit.On)
+	s.Require().NoError(s.oled.DrawImage(img))
+	s.Require().Len(s.recordBus.Ops, 4)
+	s.Equal([]byte{0x00, cmdSetColumnAddress, 8, 8, cmdSetPageAddress, 2, 2}, s.recordBus.Ops[0].W)
+	s.Equal([]byte{0x00, cmdSetColumnAddress, 100, 100, cmdSetPageAddress, 2, 2}, s.recordBus.Ops[2].W)
+}
+
+func (s *OLEDTestSuite) TestChangedSpans() {
+	old := make([]byte, 128)
+	next := make([]byte, 128)
+	s.Empty(changedSpans(old, next))
+
+	next[0], next[3], next[4], next[50], next[127] = 1, 1, 1, 1, 1
+	s.Equal([]span{{0, 5}, {50, 51}, {127, 128}}, changedSpans(old, next))
+}
+
+func (s *OLEDTestSuite) TestRedrawAfterClear() {
+	img := image1bit.NewVerticalLSB(image.Rect(0, 0, 128, 64))
+	s.Require().NoError(s.oled.DrawImage(img))
+	s.Require().NoError(s.oled.Clear())
+	s.recordBus.Ops = nil
+
+	// Nothing changed, but the display is switched back on.
+	s.Require().NoError(s.oled.DrawImage(img))
+	s.Require().Len(s.recordBus.Ops, 1)
+	s.Equal([]byte{0x00, cmdEnableDisplay}, s.recordBus.Ops[0].W)
+
+	// Scrolling shifts the display RAM, so the next frame is sent in full.
+	s.Require().NoError(s.oled.StopScroll())
+	s.recordBus.Ops = nil
+	s.Require().NoError(s.oled.DrawImage(img))
+	s.Len(s.recordBus.Ops, 16)
+}
+
+// blinkGIF returns a GIF that alternates a black and a white screen, showing
+// each for delay hundredths of a second.
+func blinkGIF(delay, loopCount int) *gif.GIF {
 	palette := color.Palette{color.Black, color.White}
-	g := &gif.GIF{
-		Image: []*image.Paletted{
-			image.NewPaletted(image.Rect(0, 0, 128, 64), palette),
-			image.NewPaletted(image.Rect(0, 0, 128, 64), palette),
-		},
-		Delay:     []int{0, 0},
+	white := image.NewPaletted(image.Rect(0, 0, 128, 64), palette)
+	for i := range white.Pix {
+		white.Pix[i] = 1
+	}
+	return &gif.GIF{
+		Image:     []*image.Paletted{image.NewPaletted(image.Rect(0, 0, 128, 64), palette), white},
+		Delay:     []int{delay, delay},
 		Disposal:  []byte{gif.DisposalNone, gif.DisposalNone},
-		LoopCount: 1,
+		LoopCount: loopCount,
 	}
+}
 
-	err := s.oled.DrawGIF(g)
-	s.NoError(err)
+func (s *OLEDTestSuite) TestDrawGIF() {
+	// Each frame changes every page, which costs an address command and the
+	// data for each of the 8 pages.
+	for _, tc := range []struct {
+		loopCount, frames int
+	}{{-1, 2}, {1, 4}, {2, 6}} {
+		s.oled.shadowValid = false
+		s.recordBus.Ops = nil
+		start := time.Now()
+		s.Require().NoError(s.oled.DrawGIF(context.Background(), blinkGIF(2, tc.loopCount)))
+		s.Len(s.recordBus.Ops, 16*tc.frames, "LoopCount %d", tc.loopCount)
+		s.GreaterOrEqual(time.Since(start), time.Duration(tc.frames)*20*time.Millisecond)
+	}
+}
+
+func (s *OLEDTestSuite) TestDrawGIFCancelled() {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// A GIF that loops forever returns once the context is done.
+	start := time.Now()
+	s.Require().NoError(s.oled.DrawGIF(ctx, blinkGIF(100, 0)))
+	s.Less(time.Since(start), time.Second)
+}
+
+// ops returns how many transactions were recorded, while an animation may
+// still be writing.
+func (s *OLEDTestSuite) ops() int {
+	s.recordBus.Lock()
+	defer s.recordBus.Unlock()
+	return len(s.recordBus.Ops)
+}
+
+func (s *OLEDTestSuite) TestPlayGIF() {
+	s.recordBus.Ops = nil
+	animation := s.oled.PlayGIF(context.Background(), blinkGIF(2, 0))
+
+	s.Eventually(func() bool { return s.ops() >= 32 }, time.Second, 10*time.Millisecond)
+	animation.Pause()
+	paused := s.ops()
+	time.Sleep(50 * time.Millisecond)
+	s.Equal(paused, s.ops(), "no frames are drawn while paused")
+
+	animation.Resume()
+	s.Eventually(func() bool { return s.ops() > paused }, time.Second, 10*time.Millisecond)
+	animation.Stop()
+	s.NoError(animation.Wait())
+	<-animation.Done()
+
+	// Pausing a finished animation does not block.
+	animation.Pause()
+	animation.Resume()
+}
+
+func (s *OLEDTestSuite) TestFrameDelay() {
+	delays := []int{0, 1, 2, 50}
+	s.Equal(gifMinDelay, frameDelay(delays, 0))
+	s.Equal(gifMinDelay, frameDelay(delays, 1))
+	s.Equal(20*time.Millisecond, frameDelay(delays, 2))
+	s.Equal(500*time.Millisecond, frameDelay(delays, 3))
+	s.Equal(gifMinDelay, frameDelay(delays, 4))
 }
 
 func (s *OLEDTestSuite) TestDrawText() {
@@ -102,12 +222,12 @@ func (s *OLEDTestSuite) TestDrawGIFWithText() {
 		Image: []*image.Paletted{
 			image.NewPaletted(image.Rect(0, 0, 128, 64), palette),
 		},
-		Delay:     []int{0},
+		Delay:     []int{2},
 		Disposal:  []byte{gif.DisposalNone},
-		LoopCount: 1,
+		LoopCount: -1,
 	}
 
-	err := s.oled.DrawGIFWithText(g, 0, 0, "Hello")
+	err := s.oled.DrawGIFWithText(context.Background(), g, 0, 0, "Hello")
 	s.NoError(err)
 }
 
@@ -120,3 +240,56 @@ func (s *OLEDTestSuite) TestStopScroll() {
 	err := s.oled.StopScroll()
 	s.NoError(err)
 }
+
+func (s *OLEDTestSuite) TestPanels() {
+	record := &i2ctest.Record{}
+	bus := &mock.I2CBus{GetBusHandler: func() i2clib.Bus { return record }}
+
+	// An SH1106 is addressed by page and column, two columns in.
+	oled, err := NewOLED(bus, Panel{Controller: ControllerSH1106, Height: 32, Address: 0x3D}, ConvertOptions{})
+	s.Require().NoError(err)
+	s.Equal(image.Rect(0, 0, 128, 32), oled.Bounds())
+	s.Equal(uint16(0x3D), record.Ops[0].Addr)
+
+	img := image1bit.NewVerticalLSB(oled.Bounds())
+	s.Require().NoError(oled.DrawImage(img))
+	s.Len(record.Ops, 1+2*4, "four pages")
+	record.Ops = nil
+
+	img.SetBit(5, 20, image1bit.On)
+	s.Require().NoError(oled.DrawImage(img))
+	s.Require().Len(record.Ops, 2)
+	s.Equal([]byte{0x00, cmdPageStartAddressBase | 2, 0x07, 0x10}, record.Ops[0].W)
+	s.ErrorIs(oled.Scroll(types.ScrollLeft, types.FrameRate5, 0, -1), ErrNotImplemented)
+
+	for _, panel := range []Panel{
+		{Controller: "st7567"},
+		{Width: 132},
+		{Height: 8},
+		{Rotation: 90},
+	} {
+		_, err := NewOLED(bus, panel, ConvertOptions{})
+		s.ErrorIs(err, ErrInvalidPanel, "%+v", panel)
+	}
+}
+
+func (s *OLEDTestSuite) TestPanelOrientation() {
+	for _, tc := range []struct {
+		panel                 Panel
+		segmentRemap, comScan byte
+	}{
+		{Panel{}, 0xA1, 0xC8},
+		{Panel{MirrorHorizontal: true}, 0xA0, 0xC8},
+		{Panel{MirrorVertical: true}, 0xA1, 0xC0},
+		{Panel{Rotation: 180}, 0xA0, 0xC0},
+		{Panel{Rotation: 180, MirrorHorizontal: true}, 0xA1, 0xC0},
+	} {
+		segmentRemap, comScan := tc.panel.orientation()
+		s.Equal(tc.segmentRemap, segmentRemap, "%+v", tc.panel)
+		s.Equal(tc.comScan, comScan, "%+v", tc.panel)
+	}
+
+	// The SSD1309 has no charge pump to enable.
+	s.Contains(string(ssd1306Init(true)(Panel{}.withDefaults())), "\x8D\x14")
+	s.NotContains(string(ssd1306Init(false)(Panel{}.withDefaults())), "\x8D\x14")
+}
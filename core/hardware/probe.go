@@ -0,0 +1,53 @@
+package hardware
+
+import (
+	"github.com/czechbol/lumeon/core/hardware/i2c"
+)
+
+// Device is an Argon device that is looked for on the I2C bus.
+type Device struct {
+	Address uint16
+	Found   bool
+}
+
+// Devices is what Probe found on the I2C bus.
+type Devices struct {
+	// Bus is the name of the bus.
+	Bus string
+	// Display is the OLED display module.
+	Display Device
+	// FanBoard is the daughterboard that drives the fan and cuts the power.
+	FanBoard Device
+}
+
+// Probe looks for the display and the fan board at their addresses, 0 meaning
+// the default ones. Neither is changed: the display is sent a no-op command
+// and the fan board is read from, as writing to it would set the fan speed.
+// That includes the firmware query, so the firmware is not identified here.
+func Probe(bus i2c.I2CBus, display, fanBoard uint16) Devices {
+	if display == 0 {
+		display = displayAddress
+	}
+	b := bus.GetBus()
+	devices := Devices{
+		Bus: b.String(),
+		Display: Device{
+			Address: display,
+			Found:   b.Tx(display, []byte{0x00, cmdNop}, nil) == nil,
+		},
+		FanBoard: ProbeFanBoard(bus, fanBoard),
+	}
+	return devices
+}
+
+// ProbeFanBoard looks for the fan board at address, 0 meaning the default one,
+// by reading from it.
+func ProbeFanBoard(bus i2c.I2CBus, address uint16) Device {
+	if address == 0 {
+		address = daughterboardAddress
+	}
+	return Device{
+		Address: address,
+		Found:   bus.Tx(address, nil, make([]byte, 1)) == nil,
+	}
+}
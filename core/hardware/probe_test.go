@@ -0,0 +1,77 @@
+package hardware
+
+import (
+	"testing"
+
+	"github.com/czechbol/lumeon/core/hardware/i2c/mock"
+	"github.com/stretchr/testify/suite"
+	i2clib "periph.io/x/conn/v3/i2c"
+	"periph.io/x/conn/v3/i2c/i2ctest"
+)
+
+type ProbeTestSuite struct {
+	suite.Suite
+	busMock *mock.I2CBus
+}
+
+func TestProbeTestSuite(t *testing.T) {
+	suite.Run(t, new(ProbeTestSuite))
+}
+
+func (s *ProbeTestSuite) probe(ops []i2ctest.IO, display, fanBoard uint16) Devices {
+	playback := &i2ctest.Playback{Ops: ops, DontPanic: true}
+	s.busMock = &mock.I2CBus{
+		GetBusHandler: func() i2clib.Bus { return playback },
+		TxHandler:     playback.Tx,
+		ReadRegisterHandler: func(addr uint16, reg byte) (byte, error) {
+			value := make([]byte, 1)
+			err := playback.Tx(addr, []byte{reg}, value)
+			return value[0], err
+		},
+	}
+	devices := Probe(s.busMock, display, fanBoard)
+	s.NoError(playback.Close())
+	return devices
+}
+
+func (s *ProbeTestSuite) TestFound() {
+	devices := s.probe([]i2ctest.IO{
+		{Addr: 0x3C, W: []byte{0x00, cmdNop}},
+		{Addr: 0x1A, R: []byte{0x00}},
+	}, 0, 0)
+
+	s.Equal(Device{Address: 0x3C, Found: true}, devices.Display)
+	s.Equal(Device{Address: 0x1A, Found: true}, devices.FanBoard)
+	s.Equal("playback", devices.Bus)
+}
+
+func (s *ProbeTestSuite) TestAddresses() {
+	devices := s.probe([]i2ctest.IO{
+		{Addr: 0x3D, W: []byte{0x00, cmdNop}},
+		{Addr: 0x1B, R: []byte{0x00}},
+	}, 0x3D, 0x1B)
+
+	s.True(devices.Display.Found)
+	s.True(devices.FanBoard.Found)
+}
+
+func (s *ProbeTestSuite) TestMissing() {
+	// The display is not there; the fan board is read next.
+	devices := s.probe([]i2ctest.IO{
+		{Addr: 0x1A, R: []byte{0x00}},
+	}, 0, 0)
+
+	s.False(devices.Display.Found)
+	s.True(devices.FanBoard.Found)
+	// Nothing is written to the fan board, not even the firmware query.
+	s.Zero(s.busMock.ReadRegisterCalled)
+	s.Zero(s.busMock.SendDataHandlerCalled)
+}
+
+func (s *ProbeTestSuite) TestProbeFanBoard() {
+	playback := &i2ctest.Playback{Ops: []i2ctest.IO{{Addr: 0x1A, R: []byte{0x00}}}, DontPanic: true}
+	bus := &mock.I2CBus{TxHandler: playback.Tx}
+	s.Equal(Device{Address: 0x1A, Found: true}, ProbeFanBoard(bus, 0))
+	s.Equal(Device{Address: 0x1A}, ProbeFanBoard(bus, 0x1A))
+	s.Zero(bus.SendDataHandlerCalled)
+}
@@ -0,0 +1,164 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"periph.io/x/conn/v3/physic"
+)
+
+// ErrNack is returned for a transaction no simulated device answers.
+var ErrNack = errors.New("sim: no device acknowledged")
+
+// Registers of the simulated fan board, which has the newer firmware.
+const (
+	regFanDutyCycle byte = 0x80
+	regFirmware     byte = 0x81
+	cmdSystemHalt   byte = 0xFF
+
+	// Firmware is the version the simulated fan board reports.
+	Firmware byte = 0x01
+)
+
+// device is a simulated device on the bus.
+type device interface {
+	tx(w, r []byte) error
+}
+
+// Bus is a simulated I2C bus. It implements the periph i2c.Bus, so the real
+// drivers run on it.
+type Bus struct {
+	mutex   sync.Mutex
+	devices map[uint16]device
+}
+
+func newBus() *Bus {
+	return &Bus{devices: make(map[uint16]device)}
+}
+
+// attach puts d on the bus at addr.
+func (b *Bus) attach(addr uint16, d device) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.devices[addr] = d
+}
+
+func (b *Bus) String() string {
+	return "sim"
+}
+
+func (b *Bus) Tx(addr uint16, w, r []byte) error {
+	b.mutex.Lock()
+	d, ok := b.devices[addr]
+	b.mutex.Unlock()
+	if !ok {
+		return fmt.Errorf("%w at %#02x", ErrNack, addr)
+	}
+	return d.tx(w, r)
+}
+
+func (b *Bus) SetSpeed(physic.Frequency) error {
+	return nil
+}
+
+// Board is the simulated Argon daughterboard. It takes a bare speed byte, as
+// older firmware does, or a write to its duty cycle register, and reads back
+// its registers.
+type Board struct {
+	mutex  sync.Mutex
+	duty   uint8
+	halted bool
+}
+
+// Duty returns the fan speed in percent.
+func (b *Board) Duty() uint8 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.duty
+}
+
+// Halted tells whether the board was told to cut the power.
+func (b *Board) Halted() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.halted
+}
+
+// Reset drops the fan speed to 0, as a board that lost power does.
+func (b *Board) Reset() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.duty = 0
+	slog.Info("sim: fan board reset")
+}
+
+func (b *Board) tx(w, r []byte) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch {
+	case len(w) == 1 && len(r) == 0 && w[0] == cmdSystemHalt:
+		b.halted = true
+		slog.Info("sim: fan board halted")
+	case len(w) == 1 && len(r) == 0:
+		b.setDuty(w[0])
+	case len(w) == 2 && w[0] == regFanDutyCycle:
+		b.setDuty(w[1])
+	case len(w) == 1 && len(r) > 0:
+		r[0] = b.register(w[0])
+	case len(w) == 0 && len(r) > 0:
+		r[0] = b.duty
+	default:
+		return fmt.Errorf("%w: fan board does not take % x", ErrNack, w)
+	}
+	return nil
+}
+
+func (b *Board) setDuty(speed uint8) {
+	b.duty = min(speed, 100)
+	slog.Debug("sim: fan speed set", "speed", b.duty)
+}
+
+func (b *Board) register(reg byte) byte {
+	switch reg {
+	case regFanDutyCycle:
+		return b.duty
+	case regFirmware:
+		return Firmware
+	default:
+		return 0
+	}
+}
+
+// Display is the simulated OLED display. It takes any command and data, and
+// counts them.
+type Display struct {
+	mutex     sync.Mutex
+	commands  int
+	dataBytes int
+}
+
+// Written returns the number of command transactions and data bytes the
+// display was sent.
+func (d *Display) Written() (commands, dataBytes int) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.commands, d.dataBytes
+}
+
+func (d *Display) tx(w, r []byte) error {
+	if len(w) == 0 || len(r) > 0 {
+		return fmt.Errorf("%w: the display is write-only", ErrNack)
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if w[0] == 0x40 {
+		d.dataBytes += len(w) - 1
+	} else {
+		d.commands++
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package sim
+
+import (
+	"testing"
+
+	"github.com/czechbol/lumeon/core/hardware"
+	"github.com/czechbol/lumeon/core/hardware/i2c"
+	"github.com/stretchr/testify/suite"
+)
+
+type BusTestSuite struct {
+	suite.Suite
+	sim *Simulator
+	bus i2c.I2CBus
+}
+
+func TestBusTestSuite(t *testing.T) {
+	suite.Run(t, new(BusTestSuite))
+}
+
+func (s *BusTestSuite) SetupTest() {
+	s.sim = New(Options{DisplayAddress: 0x3C, FanAddress: 0x1A, Ambient: 25})
+	s.bus = i2c.NewBusOn(s.sim.Bus, i2c.Options{})
+}
+
+func (s *BusTestSuite) TestProbe() {
+	devices := hardware.Probe(s.bus, 0x3C, 0x1A)
+
+	s.True(devices.Display.Found)
+	s.True(devices.FanBoard.Found)
+	s.Equal(uint8(0), s.sim.Board.Duty())
+	s.Equal("sim", devices.Bus)
+}
+
+func (s *BusTestSuite) TestMissingDevice() {
+	s.sim = New(Options{FanAddress: 0x1A})
+	s.bus = i2c.NewBusOn(s.sim.Bus, i2c.Options{})
+
+	devices := hardware.Probe(s.bus, 0x3C, 0x1A)
+
+	s.False(devices.Display.Found)
+	s.True(devices.FanBoard.Found)
+}
+
+func (s *BusTestSuite) TestFan() {
+	fan := hardware.NewFan(s.bus, 0x1A)
+
+	s.Require().NoError(fan.SetSpeed(55))
+
+	s.Equal(uint8(55), s.sim.Board.Duty())
+	speed, err := fan.Speed()
+	s.Require().NoError(err)
+	s.Equal(uint8(55), speed)
+}
+
+func (s *BusTestSuite) TestReset() {
+	fan := hardware.NewFan(s.bus, 0x1A)
+	s.Require().NoError(fan.SetSpeed(55))
+
+	s.sim.Board.Reset()
+
+	speed, err := fan.Speed()
+	s.Require().NoError(err)
+	s.Zero(speed)
+}
+
+func (s *BusTestSuite) TestHalt() {
+	system := NewSystem(s.bus, 0x1A)
+
+	s.Require().NoError(system.Halt())
+
+	s.True(s.sim.Board.Halted())
+}
+
+func (s *BusTestSuite) TestDisplay() {
+	s.Require().NoError(s.bus.GetBus().Tx(0x3C, []byte{0x00, 0xAF}, nil))
+	s.Require().NoError(s.bus.GetBus().Tx(0x3C, []byte{0x40, 0x01, 0x02, 0x03}, nil))
+
+	commands, dataBytes := s.sim.Display.Written()
+	s.Equal(1, commands)
+	s.Equal(3, dataBytes)
+	s.ErrorIs(s.bus.Tx(0x3C, []byte{0x00}, make([]byte, 1)), ErrNack)
+}
+
+func (s *BusTestSuite) TestThermalFollowsFan() {
+	s.Require().NoError(hardware.NewFan(s.bus, 0x1A).SetSpeed(100))
+
+	s.Equal(uint8(100), s.sim.Thermal.fan())
+}
@@ -0,0 +1,61 @@
+package sim
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/czechbol/lumeon/core/hardware"
+)
+
+// Button is the simulated power button. It is pressed at the scripted times
+// and whenever Press is called.
+type Button struct {
+	presses chan struct{}
+	start   time.Time
+	// script is the time after start of each scripted press still to come.
+	// Only WaitForEvent touches it.
+	script []time.Duration
+}
+
+var _ hardware.Button = (*Button)(nil)
+
+// NewButton returns a button pressed at each time in script after now.
+func NewButton(script []time.Duration) *Button {
+	script = slices.Clone(script)
+	slices.Sort(script)
+	return &Button{
+		presses: make(chan struct{}, 1),
+		start:   time.Now(),
+		script:  script,
+	}
+}
+
+// Press presses the button.
+func (b *Button) Press() {
+	select {
+	case b.presses <- struct{}{}:
+	default:
+	}
+}
+
+// WaitForEvent blocks until the button is pressed or ctx is cancelled. A
+// scripted press that came while nobody was waiting is reported at once.
+func (b *Button) WaitForEvent(ctx context.Context) (hardware.ButtonEvent, error) {
+	var scripted <-chan time.Time
+	if len(b.script) > 0 {
+		timer := time.NewTimer(time.Until(b.start.Add(b.script[0])))
+		defer timer.Stop()
+		scripted = timer.C
+	}
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-b.presses:
+		return hardware.ButtonPress, nil
+	case <-scripted:
+		b.script = b.script[1:]
+		return hardware.ButtonPress, nil
+	}
+}
@@ -0,0 +1,51 @@
+package sim
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/czechbol/lumeon/core/hardware"
+	"github.com/stretchr/testify/suite"
+)
+
+type ButtonTestSuite struct {
+	suite.Suite
+}
+
+func TestButtonTestSuite(t *testing.T) {
+	suite.Run(t, new(ButtonTestSuite))
+}
+
+func (s *ButtonTestSuite) TestScripted() {
+	button := NewButton([]time.Duration{20 * time.Millisecond, 10 * time.Millisecond})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	for range 2 {
+		event, err := button.WaitForEvent(ctx)
+		s.Require().NoError(err)
+		s.Equal(hardware.ButtonPress, event)
+	}
+	s.GreaterOrEqual(time.Since(button.start), 20*time.Millisecond)
+}
+
+func (s *ButtonTestSuite) TestPress() {
+	button := NewButton(nil)
+	button.Press()
+
+	event, err := button.WaitForEvent(context.Background())
+
+	s.Require().NoError(err)
+	s.Equal(hardware.ButtonPress, event)
+}
+
+func (s *ButtonTestSuite) TestCancel() {
+	button := NewButton([]time.Duration{time.Hour})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := button.WaitForEvent(ctx)
+
+	s.ErrorIs(err, context.Canceled)
+}
@@ -0,0 +1,80 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/czechbol/lumeon/core/resources"
+)
+
+const (
+	simCores       = 4
+	simMaxFreqMHz  = 2400
+	simIdleFreqMHz = 1500
+)
+
+// CPU reports the load and the CPU temperature of the thermal model.
+type CPU struct {
+	thermal *Thermal
+}
+
+var _ resources.CPU = (*CPU)(nil)
+
+func (c *CPU) GetAverageTemp(context.Context) (float64, error) {
+	temperature, _ := c.thermal.Temperatures()
+	return temperature, nil
+}
+
+func (c *CPU) GetStats(context.Context) (*resources.CPUStats, error) {
+	temperature, _ := c.thermal.Temperatures()
+	usage := c.thermal.Load() * 100
+	stats := &resources.CPUStats{
+		UsagePercent:   usage,
+		AvgTemperature: temperature,
+		CoreCount:      simCores,
+	}
+	for id := range simCores {
+		stats.Cores = append(stats.Cores, resources.CoreStats{
+			ID:           id,
+			UsagePercent: usage,
+			MaxFrequency: simMaxFreqMHz,
+			CurFrequency: simIdleFreqMHz + (simMaxFreqMHz-simIdleFreqMHz)*usage/100,
+			Governor:     "ondemand",
+		})
+	}
+	return stats, nil
+}
+
+// Drives reports four healthy drives at the drive temperature of the thermal
+// model.
+type Drives struct {
+	thermal *Thermal
+}
+
+var _ resources.HDD = (*Drives)(nil)
+
+func (d *Drives) GetAverageTemp(context.Context) (float64, error) {
+	_, temperature := d.thermal.Temperatures()
+	return temperature, nil
+}
+
+func (d *Drives) GetStats(context.Context) ([]resources.HDDStats, error) {
+	_, temperature := d.thermal.Temperatures()
+	stats := make([]resources.HDDStats, 0, 4)
+	for i, name := range []string{"sda", "sdb", "sdc", "sdd"} {
+		stats = append(stats, resources.HDDStats{
+			DeviceName:   name,
+			Label:        fmt.Sprintf("Bay %d", i+1),
+			Model:        "SIM HDD 4TB",
+			Serial:       fmt.Sprintf("SIM-%04d", i+1),
+			RotationRate: 5400,
+			Temperature:  temperature,
+			TotalSize:    4_000_787_030_016,
+			SmartStatus:  resources.SmartStatus{HealthOK: true},
+		})
+	}
+	return stats, nil
+}
+
+// ScheduleSelfTests does nothing: the simulated drives have no self-tests.
+func (d *Drives) ScheduleSelfTests(context.Context) {}
@@ -0,0 +1,63 @@
+/*
+Package sim simulates the Argon EON hardware, so lumeond runs on machines
+without it, such as a development laptop or CI. The real drivers run on a
+simulated I2C bus with a fan board and a display, the button is pressed on a
+script, and a thermal model turns the fan speed into CPU and drive
+temperatures.
+*/
+package sim
+
+import (
+	"time"
+)
+
+// Options configures the simulation.
+type Options struct {
+	// DisplayAddress and FanAddress are where the display and the fan board
+	// are on the bus; 0 leaves the device out.
+	DisplayAddress uint16
+	FanAddress     uint16
+	// Ambient is the air temperature around the case, in °C.
+	Ambient float64
+	// Load is the CPU load, 0 to 1.
+	Load float64
+	// ButtonPresses are the times after start the button is pressed.
+	ButtonPresses []time.Duration
+}
+
+// Simulator is the simulated hardware.
+type Simulator struct {
+	Bus     *Bus
+	Board   *Board
+	Display *Display
+	Thermal *Thermal
+	Button  *Button
+}
+
+// New starts the simulation.
+func New(opts Options) *Simulator {
+	s := &Simulator{
+		Bus:     newBus(),
+		Board:   &Board{},
+		Display: &Display{},
+		Button:  NewButton(opts.ButtonPresses),
+	}
+	if opts.DisplayAddress != 0 {
+		s.Bus.attach(opts.DisplayAddress, s.Display)
+	}
+	if opts.FanAddress != 0 {
+		s.Bus.attach(opts.FanAddress, s.Board)
+	}
+	s.Thermal = NewThermal(opts.Ambient, opts.Load, s.Board.Duty)
+	return s
+}
+
+// CPU returns the CPU prober of the simulation.
+func (s *Simulator) CPU() *CPU {
+	return &CPU{thermal: s.Thermal}
+}
+
+// Drives returns the drive prober of the simulation.
+func (s *Simulator) Drives() *Drives {
+	return &Drives{thermal: s.Thermal}
+}
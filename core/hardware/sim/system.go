@@ -0,0 +1,37 @@
+package sim
+
+import (
+	"log/slog"
+
+	"github.com/czechbol/lumeon/core/hardware"
+	"github.com/czechbol/lumeon/core/hardware/i2c"
+)
+
+// System stands in for the host: it logs a shutdown instead of doing it, and
+// halts the simulated fan board over the bus.
+type System struct {
+	bus     i2c.I2CBus
+	address uint16
+}
+
+var _ hardware.System = (*System)(nil)
+
+// NewSystem returns the system of the simulated fan board at address.
+func NewSystem(bus i2c.I2CBus, address uint16) *System {
+	return &System{bus: bus, address: address}
+}
+
+func (s *System) Shutdown() error {
+	slog.Warn("sim: shutting down the system")
+	return nil
+}
+
+func (s *System) Halt() error {
+	slog.Warn("sim: halting the system")
+	return s.bus.SendData(s.address, cmdSystemHalt)
+}
+
+// PowerTransition reports that the simulated system keeps running.
+func (s *System) PowerTransition() (hardware.PowerTransition, error) {
+	return hardware.PowerRunning, nil
+}
@@ -0,0 +1,114 @@
+package sim
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// body is something that heats up and is cooled by the air the fan moves.
+type body struct {
+	// power is the heat the body gives off at no load and the heat added at
+	// full load, in watts.
+	idlePower, loadPower float64
+	// conductance is how fast the body loses heat to the air with the fan
+	// off and the conductance the fan adds at full speed, in watts per kelvin.
+	stillConductance, fanConductance float64
+	// capacity is the heat capacity of the body, in joules per kelvin.
+	capacity float64
+}
+
+var (
+	// cpu is the Raspberry Pi SoC under its heatsink: with the fan off it
+	// settles 12.5 °C over ambient at idle and 50 °C at full load, with the
+	// fan at full speed at about a third of that.
+	cpu = body{idlePower: 1, loadPower: 3, stillConductance: 0.08, fanConductance: 0.17, capacity: 40}
+	// drives are the four drives in their cage, which heat up slower.
+	drives = body{idlePower: 3, loadPower: 1, stillConductance: 0.1, fanConductance: 0.2, capacity: 400}
+)
+
+// equilibrium returns the temperature the body settles at over ambient, and
+// its time constant.
+func (b body) equilibrium(load float64, fan uint8) (rise float64, tau time.Duration) {
+	conductance := b.stillConductance + b.fanConductance*float64(fan)/100
+	rise = (b.idlePower + b.loadPower*load) / conductance
+	return rise, time.Duration(b.capacity / conductance * float64(time.Second))
+}
+
+// Thermal is a thermal model of the case. The CPU and the drives heat up with
+// load and are cooled by the fan, each approaching the temperature at which
+// the heat it gives off and the heat the air takes away are the same.
+type Thermal struct {
+	mutex   sync.Mutex
+	ambient float64
+	load    float64
+	fan     func() uint8
+	now     func() time.Time
+	last    time.Time
+	cpu     float64
+	drives  float64
+}
+
+// NewThermal starts the model at ambient °C with the fan off. load is the CPU
+// load, 0 to 1, and fan reads the fan speed in percent.
+func NewThermal(ambient, load float64, fan func() uint8) *Thermal {
+	return newThermal(ambient, load, fan, time.Now)
+}
+
+func newThermal(ambient, load float64, fan func() uint8, now func() time.Time) *Thermal {
+	return &Thermal{
+		ambient: ambient,
+		load:    load,
+		fan:     fan,
+		now:     now,
+		last:    now(),
+		cpu:     ambient,
+		drives:  ambient,
+	}
+}
+
+// Load is the CPU load, 0 to 1.
+func (t *Thermal) Load() float64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.load
+}
+
+// SetLoad changes the CPU load, 0 to 1.
+func (t *Thermal) SetLoad(load float64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.update()
+	t.load = min(max(load, 0), 1)
+}
+
+// Temperatures returns the CPU and drive temperatures in °C.
+func (t *Thermal) Temperatures() (cpuTemperature, driveTemperature float64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.update()
+	return t.cpu, t.drives
+}
+
+// update advances the model to now, with the fan at its current speed for
+// the whole time since the last update. The mutex must be held.
+func (t *Thermal) update() {
+	now := t.now()
+	elapsed := now.Sub(t.last)
+	t.last = now
+	if elapsed <= 0 {
+		return
+	}
+	fan := t.fan()
+	t.cpu = approach(t.cpu, t.ambient, cpu, t.load, fan, elapsed)
+	t.drives = approach(t.drives, t.ambient, drives, t.load, fan, elapsed)
+}
+
+// approach returns the temperature of b after elapsed, starting at
+// temperature. It decays exponentially towards the equilibrium, which is
+// exact for a constant load and fan speed, however long elapsed is.
+func approach(temperature, ambient float64, b body, load float64, fan uint8, elapsed time.Duration) float64 {
+	rise, tau := b.equilibrium(load, fan)
+	target := ambient + rise
+	return target + (temperature-target)*math.Exp(-elapsed.Seconds()/tau.Seconds())
+}
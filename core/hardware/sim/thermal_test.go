@@ -0,0 +1,85 @@
+package sim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ThermalTestSuite struct {
+	suite.Suite
+	now time.Time
+	fan uint8
+}
+
+func TestThermalTestSuite(t *testing.T) {
+	suite.Run(t, new(ThermalTestSuite))
+}
+
+func (s *ThermalTestSuite) SetupTest() {
+	s.now = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.fan = 0
+}
+
+func (s *ThermalTestSuite) thermal(load float64) *Thermal {
+	return newThermal(25, load, func() uint8 { return s.fan }, func() time.Time { return s.now })
+}
+
+// settle returns the temperatures of t after an hour.
+func (s *ThermalTestSuite) settle(t *Thermal) (cpuTemperature, driveTemperature float64) {
+	s.now = s.now.Add(time.Hour)
+	return t.Temperatures()
+}
+
+func (s *ThermalTestSuite) TestStartsAtAmbient() {
+	cpuTemperature, driveTemperature := s.thermal(0.5).Temperatures()
+
+	s.InDelta(25, cpuTemperature, 0.001)
+	s.InDelta(25, driveTemperature, 0.001)
+}
+
+func (s *ThermalTestSuite) TestFanCools() {
+	t := s.thermal(0)
+	stillCPU, stillDrives := s.settle(t)
+	s.InDelta(25+12.5, stillCPU, 0.1)
+
+	s.fan = 100
+	fanCPU, fanDrives := s.settle(t)
+
+	s.Less(fanCPU, stillCPU)
+	s.Less(fanDrives, stillDrives)
+	s.InDelta(25+4, fanCPU, 0.1)
+}
+
+func (s *ThermalTestSuite) TestLoadHeats() {
+	t := s.thermal(0)
+	idle, _ := s.settle(t)
+
+	t.SetLoad(1)
+	loaded, _ := s.settle(t)
+
+	s.Greater(loaded, idle)
+	s.InDelta(25+50, loaded, 0.1)
+}
+
+func (s *ThermalTestSuite) TestHeatsGradually() {
+	t := s.thermal(1)
+
+	s.now = s.now.Add(10 * time.Second)
+	early, _ := t.Temperatures()
+	s.now = s.now.Add(10 * time.Minute)
+	late, _ := t.Temperatures()
+
+	s.Greater(early, 25.0)
+	s.Greater(late, early)
+}
+
+func (s *ThermalTestSuite) TestSetLoadClamps() {
+	t := s.thermal(0)
+
+	t.SetLoad(2)
+	s.InDelta(1, t.Load(), 0)
+	t.SetLoad(-1)
+	s.InDelta(0, t.Load(), 0)
+}
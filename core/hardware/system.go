@@ -4,6 +4,8 @@ import (
 	"context"
 	"log/slog"
 	"os/exec"
+	"strings"
+	"time"
 
 	"github.com/czechbol/lumeon/core/hardware/i2c"
 )
@@ -11,15 +13,38 @@ import (
 type System interface {
 	Shutdown() error
 	Halt() error
+	// PowerTransition reports whether the system is powering off or rebooting.
+	PowerTransition() (PowerTransition, error)
 }
 
+// PowerTransition is what the system as a whole is doing.
+type PowerTransition int
+
+const (
+	PowerRunning PowerTransition = iota
+	PowerOff
+	PowerReboot
+)
+
+// systemctlTimeout bounds systemctl calls, which can be slow while the system
+// is going down.
+const systemctlTimeout = 2 * time.Second
+
 type systemImpl struct {
-	bus i2c.I2CBus
+	bus     i2c.I2CBus
+	address uint16
 }
 
-func NewSystem(bus i2c.I2CBus) System {
+// NewSystem returns the system, powered by the Argon daughterboard at address,
+// 0 meaning the default 0x1A. bus is nil if there is no I2C bus; the system
+// can then be shut down but not halted.
+func NewSystem(bus i2c.I2CBus, address uint16) System {
+	if address == 0 {
+		address = daughterboardAddress
+	}
 	return systemImpl{
-		bus: bus,
+		bus:     bus,
+		address: address,
 	}
 }
 
@@ -31,7 +56,40 @@ func (s systemImpl) Shutdown() error {
 }
 
 func (s systemImpl) Halt() error {
+	if s.bus == nil {
+		return ErrDeviceNotFound
+	}
 	slog.Warn("halting the system")
 
-	return s.bus.SendData(daughterboardAddress, cmdSystemHalt)
+	return s.bus.SendData(s.address, cmdSystemHalt)
+}
+
+// PowerTransition looks for a poweroff or reboot job in the systemd job queue.
+func (s systemImpl) PowerTransition() (PowerTransition, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), systemctlTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "systemctl", "list-jobs", "--plain", "--no-legend").Output()
+	if err != nil {
+		return PowerRunning, err
+	}
+	return parsePowerTransition(string(out)), nil
+}
+
+// parsePowerTransition reads the output of systemctl list-jobs, one job per
+// line: id, unit, type and state.
+func parsePowerTransition(jobs string) PowerTransition {
+	for line := range strings.Lines(jobs) {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[1] {
+		case "reboot.target", "kexec.target", "soft-reboot.target":
+			return PowerReboot
+		case "poweroff.target", "halt.target":
+			return PowerOff
+		}
+	}
+	return PowerRunning
 }
@@ -15,7 +15,7 @@ type SystemTestSuite struct {
 
 func (s *SystemTestSuite) SetupTest() {
 	s.busMock = &mock.I2CBus{}
-	s.system = NewSystem(s.busMock)
+	s.system = NewSystem(s.busMock, 0)
 }
 
 func TestSystemTestSuite(t *testing.T) {
@@ -34,3 +34,15 @@ func (s *SystemTestSuite) TestHalt() {
 
 	s.Equal(1, s.busMock.SendDataHandlerCalled)
 }
+
+func (s *SystemTestSuite) TestHaltWithoutBus() {
+	s.ErrorIs(NewSystem(nil, 0).Halt(), ErrDeviceNotFound)
+}
+
+func (s *SystemTestSuite) TestParsePowerTransition() {
+	s.Equal(PowerRunning, parsePowerTransition(""))
+	s.Equal(PowerRunning, parsePowerTransition("2051 apt-daily.service start running\n"))
+	s.Equal(PowerOff, parsePowerTransition(
+		"1823 lumeond.service stop running\n1820 poweroff.target start waiting\n"))
+	s.Equal(PowerReboot, parsePowerTransition("1801 reboot.target start waiting\n"))
+}
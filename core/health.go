@@ -0,0 +1,578 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/czechbol/lumeon/core/resources"
+)
+
+const healthCheckInterval = time.Minute
+
+// Disk space thresholds, as a percentage of the filesystem in use.
+const (
+	filesystemWarnPercent     = 90
+	filesystemCriticalPercent = 95
+)
+
+// CPU temperature thresholds in °C. The Raspberry Pi firmware starts
+// throttling the clock at 80°C and throttles hard at 85°C.
+const (
+	cpuTemperatureWarn     = 80
+	cpuTemperatureCritical = 85
+)
+
+// driveTemperatureCriticalMargin is how far above the configured maximum a
+// drive has to run before its overheating alert becomes critical.
+const driveTemperatureCriticalMargin = 5
+
+// temperatureHysteresis is how far below its threshold a temperature has to
+// drop before an overheating alert is resolved, so a reading hovering around
+// the threshold does not raise and resolve the alert on every check.
+const temperatureHysteresis = 3
+
+// HealthService periodically evaluates resource probers and raises or
+// resolves alerts for the conditions it finds.
+type HealthService interface {
+	IsRunning() bool
+	Start(ctx context.Context) error
+	Shutdown(ctx context.Context) error
+}
+
+type healthServiceImpl struct {
+	mutex   sync.RWMutex
+	running bool
+	alerts  AlertManager
+	sampler Sampler
+	drives  resources.HDD
+	// driveTemperatureMax is the drive temperature in °C above which drives
+	// are reported as overheating. Zero disables the drive check.
+	driveTemperatureMax float64
+	ctx                 context.Context
+	cancel              context.CancelFunc
+	shutdownChan        chan struct{}
+
+	// driveAlerts holds the alert keys raised by the previous drive check so
+	// that alerts for drives that recovered or disappeared can be resolved.
+	driveAlerts map[string]struct{}
+	// poolAlerts does the same for storage pools.
+	poolAlerts map[string]struct{}
+	// filesystemAlerts does the same for filesystems.
+	filesystemAlerts map[string]struct{}
+	// containerAlerts does the same for containers.
+	containerAlerts map[string]struct{}
+	// systemdAlerts does the same for systemd units.
+	systemdAlerts map[string]struct{}
+	// temperatureAlerts does the same for overheating CPU and drives.
+	temperatureAlerts map[string]struct{}
+	// containerRestarts holds each container's restart count at the previous
+	// check, so restarts between checks are noticed.
+	containerRestarts map[string]int
+}
+
+// NewHealthService creates the health service. Drive, pool, filesystem,
+// container and unit state is read from sampler; drives is only used to schedule SMART self-tests.
+// Drives running hotter than driveTemperatureMax °C are reported as overheating.
+func NewHealthService(
+	alerts AlertManager, sampler Sampler, drives resources.HDD, driveTemperatureMax float64,
+) HealthService {
+	return &healthServiceImpl{
+		alerts:              alerts,
+		sampler:             sampler,
+		drives:              drives,
+		driveTemperatureMax: driveTemperatureMax,
+		shutdownChan:        make(chan struct{}),
+		driveAlerts:         make(map[string]struct{}),
+		poolAlerts:          make(map[string]struct{}),
+		filesystemAlerts:    make(map[string]struct{}),
+		containerAlerts:     make(map[string]struct{}),
+		systemdAlerts:       make(map[string]struct{}),
+		temperatureAlerts:   make(map[string]struct{}),
+		containerRestarts:   make(map[string]int),
+	}
+}
+
+func (hs *healthServiceImpl) IsRunning() bool {
+	hs.mutex.RLock()
+	defer hs.mutex.RUnlock()
+	return hs.running
+}
+
+func (hs *healthServiceImpl) Start(ctx context.Context) error {
+	hs.ctx, hs.cancel = context.WithCancel(ctx)
+	hs.mutex.Lock()
+	if hs.running {
+		hs.mutex.Unlock()
+		return nil
+	}
+	hs.running = true
+	hs.mutex.Unlock()
+
+	slog.Info("starting health loop")
+
+	hs.drives.ScheduleSelfTests(hs.ctx)
+
+	go hs.healthLoop()
+
+	return nil
+}
+
+func (hs *healthServiceImpl) Shutdown(ctx context.Context) error {
+	hs.cancel()
+
+	select {
+	case <-hs.shutdownChan:
+		slog.Info("health loop stopped gracefully")
+	case <-ctx.Done():
+		slog.Warn("shutdown context expired before health loop could stop")
+	}
+
+	hs.mutex.Lock()
+	hs.running = false
+	hs.mutex.Unlock()
+
+	return nil
+}
+
+func (hs *healthServiceImpl) healthLoop() {
+	defer close(hs.shutdownChan)
+
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		hs.checkDrives()
+		hs.checkPools()
+		hs.checkFilesystems()
+		hs.checkCPU()
+		hs.checkTemperatures()
+		hs.checkContainers()
+		hs.checkSystemd()
+
+		select {
+		case <-hs.ctx.Done():
+			slog.Info("stopping health loop due to context cancellation")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkDrives raises a critical alert for drives whose overall SMART status or
+// last self-test failed and a warning for drives whose SMART trend shows degradation.
+func (hs *healthServiceImpl) checkDrives() {
+	sample := &hs.sampler.Snapshot().Drives
+	if err := sampleError(sample); err != nil {
+		// Leave existing alerts untouched; a failed probe says nothing about the drives.
+		slog.Error("health check: failed to get drive stats", "error", err)
+		return
+	}
+	stats := sample.Value
+
+	raised := make(map[string]struct{})
+	for i := range stats {
+		stat := &stats[i]
+		id := stat.Serial
+		if id == "" {
+			id = stat.DeviceName
+		}
+
+		if stat.SelfTest.HasResult && !stat.SelfTest.Passed {
+			key := fmt.Sprintf("drive:%s:selftest", id)
+			hs.alerts.Raise(Alert{
+				Key:      key,
+				Source:   "drive",
+				Severity: AlertCritical,
+				Title:    stat.DisplayName() + " test failed",
+				Message: fmt.Sprintf("%s (%s, serial %s) failed its %s self-test at %dh: %s", stat.DisplayName(),
+					stat.Model, stat.Serial, stat.SelfTest.LastType, stat.SelfTest.Hours, stat.SelfTest.Result),
+			})
+			raised[key] = struct{}{}
+		}
+
+		switch {
+		case !stat.SmartStatus.HealthOK:
+			key := fmt.Sprintf("drive:%s:failed", id)
+			hs.alerts.Raise(Alert{
+				Key:      key,
+				Source:   "drive",
+				Severity: AlertCritical,
+				Title:    stat.DisplayName() + " FAILED",
+				Message: fmt.Sprintf("%s (%s, serial %s) reports SMART overall health FAILED", stat.DisplayName(),
+					stat.Model, stat.Serial),
+			})
+			raised[key] = struct{}{}
+		case stat.SmartStatus.Degraded:
+			key := fmt.Sprintf("drive:%s:degraded", id)
+			hs.alerts.Raise(Alert{
+				Key:      key,
+				Source:   "drive",
+				Severity: AlertWarning,
+				Title:    stat.DisplayName() + " degraded",
+				Message: fmt.Sprintf("%s (%s, serial %s) is degrading: %s", stat.DisplayName(), stat.Model, stat.Serial,
+					strings.Join(stat.SmartStatus.Findings, "; ")),
+			})
+			raised[key] = struct{}{}
+		}
+	}
+
+	hs.driveAlerts = hs.reconcile(hs.driveAlerts, raised)
+}
+
+// poolFailedStates are backend states in which a pool has lost data or is unusable.
+var poolFailedStates = []string{"FAULTED", "UNAVAIL", "SUSPENDED", "inactive"}
+
+// checkPools raises a critical alert for failed pools and a warning for pools
+// that are degraded or have recorded device errors.
+func (hs *healthServiceImpl) checkPools() {
+	sample := &hs.sampler.Snapshot().Pools
+	if err := sampleError(sample); err != nil {
+		slog.Error("health check: failed to get storage pools", "error", err)
+		return
+	}
+	pools := sample.Value
+
+	raised := make(map[string]struct{})
+	for i := range pools {
+		pool := &pools[i]
+		if pool.Healthy {
+			continue
+		}
+
+		key := fmt.Sprintf("pool:%s:%s", pool.Type, pool.Name)
+		alert := Alert{
+			Key:      key,
+			Source:   "pool",
+			Severity: AlertWarning,
+			Title:    pool.Name + " degraded",
+			Message:  poolProblem(pool),
+		}
+		if slices.Contains(poolFailedStates, pool.State) {
+			alert.Severity = AlertCritical
+			alert.Title = pool.Name + " FAILED"
+		}
+		hs.alerts.Raise(alert)
+		raised[key] = struct{}{}
+	}
+
+	hs.poolAlerts = hs.reconcile(hs.poolAlerts, raised)
+}
+
+// checkFilesystems raises a warning for filesystems that are nearly full and
+// a critical alert for those that are almost out of space.
+func (hs *healthServiceImpl) checkFilesystems() {
+	sample := &hs.sampler.Snapshot().Filesystems
+	if err := sampleError(sample); err != nil {
+		slog.Error("health check: failed to get filesystems", "error", err)
+		return
+	}
+	filesystems := sample.Value
+
+	raised := make(map[string]struct{})
+	for i := range filesystems {
+		fs := &filesystems[i]
+		if fs.Unavailable {
+			// Keep the alert of a share that stopped answering until it is back.
+			key := fmt.Sprintf("filesystem:%s:space", fs.Mountpoint)
+			if _, ok := hs.filesystemAlerts[key]; ok {
+				raised[key] = struct{}{}
+			}
+			continue
+		}
+		if fs.UsedPercent < filesystemWarnPercent {
+			continue
+		}
+
+		key := fmt.Sprintf("filesystem:%s:space", fs.Mountpoint)
+		alert := Alert{
+			Key:      key,
+			Source:   "filesystem",
+			Severity: AlertWarning,
+			Title:    fs.Mountpoint + " nearly full",
+			Message: fmt.Sprintf("%s (%s) is %.0f%% full, %s free", fs.Mountpoint, fs.Source, fs.UsedPercent,
+				formatBytes(fs.Available)),
+		}
+		if fs.UsedPercent >= filesystemCriticalPercent {
+			alert.Severity = AlertCritical
+			alert.Title = fs.Mountpoint + " full"
+		}
+		hs.alerts.Raise(alert)
+		raised[key] = struct{}{}
+	}
+
+	hs.filesystemAlerts = hs.reconcile(hs.filesystemAlerts, raised)
+}
+
+// cpuUndervoltageAlert is the alert key for Raspberry Pi undervoltage.
+const cpuUndervoltageAlert = "cpu:undervoltage"
+
+// checkCPU raises a critical alert while the firmware reports undervoltage and
+// a warning if undervoltage occurred since boot. The firmware records every
+// occurrence, so short dips between checks are not missed.
+func (hs *healthServiceImpl) checkCPU() {
+	sample := &hs.sampler.Snapshot().CPU
+	if err := sampleError(sample); err != nil {
+		slog.Error("health check: failed to get cpu stats", "error", err)
+		return
+	}
+	stats := sample.Value
+	if !stats.ThrottleKnown {
+		return
+	}
+
+	switch {
+	case stats.Throttle.Has(resources.ThrottleUndervoltage):
+		hs.alerts.Raise(Alert{
+			Key:      cpuUndervoltageAlert,
+			Source:   "cpu",
+			Severity: AlertCritical,
+			Title:    "Undervoltage",
+			Message:  "Power supply voltage is too low; firmware reports " + stats.Throttle.String(),
+		})
+	case stats.Throttle.Has(resources.ThrottleUndervoltageOccurred):
+		hs.alerts.Raise(Alert{
+			Key:      cpuUndervoltageAlert,
+			Source:   "cpu",
+			Severity: AlertWarning,
+			Title:    "Undervoltage seen",
+			Message:  "Power supply voltage dropped too low since boot; firmware reports " + stats.Throttle.String(),
+		})
+	default:
+		hs.alerts.Resolve(cpuUndervoltageAlert)
+	}
+}
+
+// cpuTemperatureAlert is the alert key for an overheating CPU.
+const cpuTemperatureAlert = "cpu:temperature"
+
+// checkTemperatures raises a warning for a CPU or drive running hotter than
+// its threshold and a critical alert for one running far hotter. The alerts
+// are resolved once the temperature drops temperatureHysteresis below the
+// warning threshold. A failed probe leaves the alerts of that source untouched.
+func (hs *healthServiceImpl) checkTemperatures() {
+	snap := hs.sampler.Snapshot()
+	raised := make(map[string]struct{})
+
+	if sampleError(&snap.CPU) == nil {
+		hs.checkTemperature(raised, temperatureCheck{
+			key:      cpuTemperatureAlert,
+			source:   "cpu",
+			name:     "CPU",
+			temp:     snap.CPU.Value.AvgTemperature,
+			warn:     cpuTemperatureWarn,
+			critical: cpuTemperatureCritical,
+		})
+	} else if _, ok := hs.temperatureAlerts[cpuTemperatureAlert]; ok {
+		raised[cpuTemperatureAlert] = struct{}{}
+	}
+
+	if sampleError(&snap.Drives) == nil {
+		if hs.driveTemperatureMax > 0 {
+			for i := range snap.Drives.Value {
+				stat := &snap.Drives.Value[i]
+				if stat.Temperature <= 0 {
+					continue
+				}
+				id := stat.Serial
+				if id == "" {
+					id = stat.DeviceName
+				}
+				hs.checkTemperature(raised, temperatureCheck{
+					key:      fmt.Sprintf("drive:%s:temperature", id),
+					source:   "drive",
+					name:     stat.DisplayName(),
+					temp:     stat.Temperature,
+					warn:     hs.driveTemperatureMax,
+					critical: hs.driveTemperatureMax + driveTemperatureCriticalMargin,
+				})
+			}
+		}
+	} else {
+		for key := range hs.temperatureAlerts {
+			if key != cpuTemperatureAlert {
+				raised[key] = struct{}{}
+			}
+		}
+	}
+
+	hs.temperatureAlerts = hs.reconcile(hs.temperatureAlerts, raised)
+}
+
+// temperatureCheck is one temperature reading and its thresholds in °C.
+type temperatureCheck struct {
+	key      string
+	source   string
+	name     string
+	temp     float64
+	warn     float64
+	critical float64
+}
+
+// checkTemperature raises the alert for check if it is too hot, or keeps an
+// already raised alert while the temperature is within temperatureHysteresis
+// below the warning threshold, and records the key in raised.
+func (hs *healthServiceImpl) checkTemperature(raised map[string]struct{}, check temperatureCheck) {
+	severity, limit := AlertWarning, check.warn
+	switch {
+	case check.temp > check.critical:
+		severity, limit = AlertCritical, check.critical
+	case check.temp > check.warn:
+	default:
+		// Keep a raised alert as it is until the temperature has dropped far enough.
+		if _, ok := hs.temperatureAlerts[check.key]; ok && check.temp > check.warn-temperatureHysteresis {
+			raised[check.key] = struct{}{}
+		}
+		return
+	}
+
+	hs.alerts.Raise(Alert{
+		Key:      check.key,
+		Source:   check.source,
+		Severity: severity,
+		Title:    check.name + " overheating",
+		Message:  fmt.Sprintf("%s is at %.0f°C, above its %.0f°C limit", check.name, check.temp, limit),
+	})
+	raised[check.key] = struct{}{}
+}
+
+// checkContainers raises a warning for containers whose healthcheck fails and
+// for containers that are restarting or restarted since the previous check.
+func (hs *healthServiceImpl) checkContainers() {
+	sample := &hs.sampler.Snapshot().Containers
+	if err := sampleError(sample); err != nil {
+		slog.Error("health check: failed to get containers", "error", err)
+		return
+	}
+	containers := sample.Value
+
+	raised := make(map[string]struct{})
+	restarts := make(map[string]int, len(containers))
+	for i := range containers {
+		container := &containers[i]
+		restarts[container.Name] = container.RestartCount
+
+		if container.Unhealthy() {
+			key := fmt.Sprintf("container:%s:unhealthy", container.Name)
+			hs.alerts.Raise(Alert{
+				Key:      key,
+				Source:   "container",
+				Severity: AlertWarning,
+				Title:    container.Name + " unhealthy",
+				Message:  fmt.Sprintf("Container %s (%s) is failing its healthcheck", container.Name, container.Image),
+			})
+			raised[key] = struct{}{}
+		}
+
+		prev, seen := hs.containerRestarts[container.Name]
+		if container.State == "restarting" || (seen && container.RestartCount > prev) {
+			key := fmt.Sprintf("container:%s:restarting", container.Name)
+			hs.alerts.Raise(Alert{
+				Key:      key,
+				Source:   "container",
+				Severity: AlertWarning,
+				Title:    container.Name + " restarting",
+				Message: fmt.Sprintf("Container %s (%s) is %s and has restarted %d times", container.Name,
+					container.Image, container.State, container.RestartCount),
+			})
+			raised[key] = struct{}{}
+		}
+	}
+	hs.containerRestarts = restarts
+
+	hs.containerAlerts = hs.reconcile(hs.containerAlerts, raised)
+}
+
+// systemdFailedAlert is the alert key for failed units that are not watched.
+const systemdFailedAlert = "systemd:failed"
+
+// checkSystemd raises a critical alert for watched units that failed, a
+// warning for watched units that do not exist, and a warning while any other
+// unit on the system is failed.
+func (hs *healthServiceImpl) checkSystemd() {
+	sample := &hs.sampler.Snapshot().Systemd
+	if err := sampleError(sample); err != nil {
+		slog.Error("health check: failed to get systemd units", "error", err)
+		return
+	}
+	stats := sample.Value
+
+	raised := make(map[string]struct{})
+	watchedFailed := 0
+	for i := range stats.Units {
+		unit := &stats.Units[i]
+		switch {
+		case unit.Failed():
+			watchedFailed++
+			key := fmt.Sprintf("systemd:%s:failed", unit.Name)
+			hs.alerts.Raise(Alert{
+				Key:      key,
+				Source:   "systemd",
+				Severity: AlertCritical,
+				Title:    unit.Name + " failed",
+				Message: fmt.Sprintf("%s failed (result %s, exit status %d); see journalctl -u %s",
+					unit.Name, unit.Result, unit.ExitStatus, unit.Name),
+			})
+			raised[key] = struct{}{}
+		case unit.Missing():
+			key := fmt.Sprintf("systemd:%s:missing", unit.Name)
+			hs.alerts.Raise(Alert{
+				Key:      key,
+				Source:   "systemd",
+				Severity: AlertWarning,
+				Title:    unit.Name + " missing",
+				Message:  fmt.Sprintf("Watched unit %s does not exist", unit.Name),
+			})
+			raised[key] = struct{}{}
+		}
+	}
+
+	if other := stats.FailedUnits - watchedFailed; other > 0 {
+		hs.alerts.Raise(Alert{
+			Key:      systemdFailedAlert,
+			Source:   "systemd",
+			Severity: AlertWarning,
+			Title:    fmt.Sprintf("%d units failed", other),
+			Message:  fmt.Sprintf("%d systemd units have failed; see systemctl --failed", other),
+		})
+		raised[systemdFailedAlert] = struct{}{}
+	}
+
+	hs.systemdAlerts = hs.reconcile(hs.systemdAlerts, raised)
+}
+
+// reconcile resolves the alerts in prev that were not raised again, and
+// returns raised to be kept as the alerts of the next check.
+func (hs *healthServiceImpl) reconcile(prev, raised map[string]struct{}) map[string]struct{} {
+	for key := range prev {
+		if _, ok := raised[key]; !ok {
+			hs.alerts.Resolve(key)
+		}
+	}
+	return raised
+}
+
+// poolProblem describes why pool is unhealthy, naming its failing devices.
+func poolProblem(pool *resources.Pool) string {
+	var failing []string
+	for _, device := range pool.Devices {
+		if device.Healthy {
+			continue
+		}
+		if device.Errors > 0 {
+			failing = append(failing, fmt.Sprintf("%s %s (%d errors)", device.Name, device.State, device.Errors))
+		} else {
+			failing = append(failing, device.Name+" "+device.State)
+		}
+	}
+
+	msg := fmt.Sprintf("%s pool %s is %s", pool.Type, pool.Name, pool.State)
+	if len(failing) > 0 {
+		msg += ": " + strings.Join(failing, ", ")
+	}
+	return msg
+}
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/czechbol/lumeon/core/resources"
+	"github.com/stretchr/testify/suite"
+)
+
+type HealthServiceTestSuite struct {
+	suite.Suite
+	snap    *Snapshot
+	alerts  AlertManager
+	service *healthServiceImpl
+}
+
+func TestHealthServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(HealthServiceTestSuite))
+}
+
+func (s *HealthServiceTestSuite) SetupTest() {
+	s.snap = &Snapshot{}
+	s.alerts = NewAlertManager()
+	service, ok := NewHealthService(s.alerts, &fakeSampler{snap: s.snap}, nil, 60).(*healthServiceImpl)
+	s.Require().True(ok)
+	s.service = service
+}
+
+func (s *HealthServiceTestSuite) temperatures(cpu, drive float64) {
+	now := time.Now()
+	s.snap.CPU = Sample[*resources.CPUStats]{
+		Value:     &resources.CPUStats{AvgTemperature: cpu},
+		UpdatedAt: now,
+		CheckedAt: now,
+		Interval:  time.Minute,
+	}
+	s.snap.Drives = Sample[[]resources.HDDStats]{
+		Value:     []resources.HDDStats{{DeviceName: "sda", Serial: "S1", Temperature: drive}},
+		UpdatedAt: now,
+		CheckedAt: now,
+		Interval:  time.Minute,
+	}
+	s.service.checkTemperatures()
+}
+
+func (s *HealthServiceTestSuite) severities() map[string]AlertSeverity {
+	severities := make(map[string]AlertSeverity)
+	for _, alert := range s.alerts.Active() {
+		severities[alert.Key] = alert.Severity
+	}
+	return severities
+}
+
+func (s *HealthServiceTestSuite) TestTemperaturesNormal() {
+	s.temperatures(55, 40)
+	s.Empty(s.severities())
+}
+
+func (s *HealthServiceTestSuite) TestTemperaturesEscalate() {
+	s.temperatures(82, 62)
+	s.Equal(map[string]AlertSeverity{
+		cpuTemperatureAlert:    AlertWarning,
+		"drive:S1:temperature": AlertWarning,
+	}, s.severities())
+
+	s.temperatures(90, 70)
+	s.Equal(map[string]AlertSeverity{
+		cpuTemperatureAlert:    AlertCritical,
+		"drive:S1:temperature": AlertCritical,
+	}, s.severities())
+}
+
+func (s *HealthServiceTestSuite) TestTemperaturesResolve() {
+	s.temperatures(82, 62)
+	s.Len(s.severities(), 2)
+
+	// Just below the threshold the alerts are kept.
+	s.temperatures(79, 59)
+	s.Len(s.severities(), 2)
+
+	s.temperatures(76, 56)
+	s.Empty(s.severities())
+}
+
+func (s *HealthServiceTestSuite) TestTemperaturesFailedSampleKeepsAlerts() {
+	s.temperatures(82, 62)
+	s.snap.CPU.Err = errProbe
+	s.snap.Drives.Err = errProbe
+	s.service.checkTemperatures()
+	s.Len(s.severities(), 2)
+}
+
+func (s *HealthServiceTestSuite) TestDriveTemperatureDisabled() {
+	s.service.driveTemperatureMax = 0
+	s.temperatures(50, 70)
+	s.Empty(s.severities())
+}
+
+func (s *HealthServiceTestSuite) TestHotDriveRaisesOneAlert() {
+	s.temperatures(50, 70)
+	s.snap.Drives.Value[0].SmartStatus.HealthOK = true
+	s.service.checkDrives()
+
+	s.Equal(map[string]AlertSeverity{"drive:S1:temperature": AlertCritical}, s.severities())
+}
@@ -13,3 +13,30 @@ var iconNetworkPNG []byte
 
 //go:embed assets/icons/hdd.png
 var iconHDDPNG []byte
+
+//go:embed assets/icons/alert.png
+var iconAlertPNG []byte
+
+//go:embed assets/icons/pool.png
+var iconPoolPNG []byte
+
+//go:embed assets/icons/io.png
+var iconIOPNG []byte
+
+//go:embed assets/icons/system.png
+var iconSystemPNG []byte
+
+//go:embed assets/icons/container.png
+var iconContainerPNG []byte
+
+//go:embed assets/icons/service.png
+var iconServicePNG []byte
+
+//go:embed assets/icons/ups.png
+var iconUPSPNG []byte
+
+//go:embed assets/icons/message.png
+var iconMessagePNG []byte
+
+//go:embed assets/icons/power.png
+var iconPowerPNG []byte
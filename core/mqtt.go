@@ -0,0 +1,640 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/czechbol/lumeon/app/config"
+	"github.com/czechbol/lumeon/core/resources"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+const (
+	mqttQoS = 1
+	// mqttConnectRetryInterval is the wait between attempts to make the first
+	// connection. Once connected, a lost connection is retried with backoff
+	// up to mqttMaxReconnectInterval.
+	mqttConnectRetryInterval = 30 * time.Second
+	mqttMaxReconnectInterval = 2 * time.Minute
+	mqttConnectTimeout       = 10 * time.Second
+	mqttPublishTimeout       = 10 * time.Second
+	mqttDisconnectQuiesce    = 250 // milliseconds
+
+	// mqttFanOverride is how long a fan speed set over MQTT lasts before the
+	// temperature curves take over again.
+	mqttFanOverride = time.Hour
+	// mqttMessageDuration is how long a message sent over MQTT is displayed.
+	mqttMessageDuration = 30 * time.Second
+)
+
+var (
+	ErrMQTTTimeout      = errors.New("timed out waiting for MQTT broker")
+	ErrMQTTCertificates = errors.New("no certificates found in CA file")
+)
+
+// mqttUnsafe matches the characters not used in topic levels and unique IDs.
+var mqttUnsafe = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// MQTTService publishes the sampled state to an MQTT broker as retained JSON,
+// announces it to Home Assistant and accepts commands for the fan and display.
+type MQTTService interface {
+	IsRunning() bool
+	Start(ctx context.Context) error
+	Shutdown(ctx context.Context) error
+}
+
+type mqttServiceImpl struct {
+	mutex        sync.RWMutex
+	running      bool
+	fan          FanService
+	display      DisplayService
+	sampler      Sampler
+	alerts       AlertManager
+	mqttConfig   config.MQTTConfig
+	version      string
+	hostname     string
+	client       mqtt.Client
+	ctx          context.Context
+	cancel       context.CancelFunc
+	shutdownChan chan struct{}
+	// connectedChan and publishChan hand connection and command events to the
+	// publish loop, so only the loop publishes.
+	connectedChan chan struct{}
+	publishChan   chan struct{}
+
+	// discovered holds the discovery topics published on the current
+	// connection. Only used by the publish loop.
+	discovered map[string]struct{}
+}
+
+// NewMQTTService creates the MQTT service. An empty broker disables it. It
+// fails if the configured TLS files cannot be loaded. fan and display are nil
+// if that hardware is missing; their entities and commands are left out.
+func NewMQTTService(
+	fan FanService,
+	display DisplayService,
+	sampler Sampler,
+	alerts AlertManager,
+	mqttConfig config.MQTTConfig,
+	version string,
+) (MQTTService, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		slog.Warn("failed to get hostname for MQTT", "error", err)
+		hostname = "lumeon"
+	}
+	ms := &mqttServiceImpl{
+		fan:           fan,
+		display:       display,
+		sampler:       sampler,
+		alerts:        alerts,
+		mqttConfig:    mqttConfig,
+		version:       version,
+		hostname:      hostname,
+		shutdownChan:  make(chan struct{}),
+		connectedChan: make(chan struct{}, 1),
+		publishChan:   make(chan struct{}, 1),
+		discovered:    make(map[string]struct{}),
+	}
+	if mqttConfig.Broker() == "" {
+		return ms, nil
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(mqttConfig.Broker()).
+		SetClientID(mqttConfig.ClientID()).
+		SetUsername(mqttConfig.Username()).
+		SetPassword(mqttConfig.Password()).
+		SetCleanSession(true).
+		SetOrderMatters(false).
+		SetConnectTimeout(mqttConnectTimeout).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(mqttConnectRetryInterval).
+		SetAutoReconnect(true).
+		SetMaxReconnectInterval(mqttMaxReconnectInterval).
+		// The broker marks the entities unavailable if lumeond goes away.
+		SetWill(ms.topic("availability"), "offline", mqttQoS, true).
+		SetOnConnectHandler(func(mqtt.Client) {
+			select {
+			case ms.connectedChan <- struct{}{}:
+			default:
+			}
+		}).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			slog.Warn("lost connection to MQTT broker, reconnecting", "error", err)
+		})
+
+	tlsConfig, err := mqttTLSConfig(mqttConfig.TLS())
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		opts.SetTLSConfig(tlsConfig)
+	}
+	ms.client = mqtt.NewClient(opts)
+
+	return ms, nil
+}
+
+// mqttTLSConfig builds the TLS configuration, or returns nil when the
+// defaults will do.
+func mqttTLSConfig(tlsSettings config.MQTTTLSConfig) (*tls.Config, error) {
+	if tlsSettings == (config.MQTTTLSConfig{}) {
+		return nil, nil //nolint:nilnil // no custom TLS configuration
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: tlsSettings.Insecure, //nolint:gosec // opt-in for brokers with self-signed certificates
+	}
+	if tlsSettings.CAFile != "" {
+		pem, err := os.ReadFile(tlsSettings.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading MQTT CA file: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s: %w", tlsSettings.CAFile, ErrMQTTCertificates)
+		}
+	}
+	if tlsSettings.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(tlsSettings.CertFile, tlsSettings.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading MQTT client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+func (ms *mqttServiceImpl) IsRunning() bool {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	return ms.running
+}
+
+func (ms *mqttServiceImpl) Start(ctx context.Context) error {
+	ms.ctx, ms.cancel = context.WithCancel(ctx)
+	ms.mutex.Lock()
+	if ms.running {
+		ms.mutex.Unlock()
+		return nil
+	}
+	ms.running = true
+	ms.mutex.Unlock()
+
+	if ms.client == nil {
+		slog.Info("no MQTT broker configured, MQTT disabled")
+		close(ms.shutdownChan)
+		return nil
+	}
+
+	slog.Info("starting MQTT client", "broker", ms.mqttConfig.Broker(), "topic", ms.topic(""))
+
+	// With connect retry the token only completes once connected, so it is
+	// not waited for; the on-connect handler starts publishing.
+	ms.client.Connect()
+
+	go ms.mqttLoop()
+
+	return nil
+}
+
+func (ms *mqttServiceImpl) Shutdown(ctx context.Context) error {
+	ms.cancel()
+
+	select {
+	case <-ms.shutdownChan:
+		slog.Info("MQTT client stopped gracefully")
+	case <-ctx.Done():
+		slog.Warn("shutdown context expired before MQTT client could stop")
+	}
+
+	ms.mutex.Lock()
+	ms.running = false
+	ms.mutex.Unlock()
+
+	return nil
+}
+
+func (ms *mqttServiceImpl) mqttLoop() {
+	defer close(ms.shutdownChan)
+
+	ticker := time.NewTicker(ms.mqttConfig.Interval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ms.ctx.Done():
+			slog.Info("stopping MQTT client due to context cancellation")
+			ms.disconnect()
+			return
+		case <-ms.connectedChan:
+			ms.handleConnect()
+		case <-ms.publishChan:
+			ms.publishState()
+		case <-ticker.C:
+			ms.publishState()
+		}
+	}
+}
+
+// handleConnect subscribes to the command topics and publishes availability,
+// discovery and state. The session is clean, so this is redone on every
+// reconnect.
+func (ms *mqttServiceImpl) handleConnect() {
+	slog.Info("connected to MQTT broker", "broker", ms.mqttConfig.Broker())
+
+	if filters := ms.commandFilters(); len(filters) > 0 {
+		token := ms.client.SubscribeMultiple(filters, func(_ mqtt.Client, msg mqtt.Message) {
+			ms.handleCommand(msg.Topic(), msg.Payload())
+		})
+		if err := ms.wait(token); err != nil {
+			slog.Error("failed to subscribe to MQTT command topics", "error", err)
+		}
+	}
+
+	if err := ms.publish(ms.topic("availability"), []byte("online")); err != nil {
+		slog.Error("failed to publish MQTT availability", "error", err)
+	}
+	clear(ms.discovered)
+	ms.publishState()
+}
+
+// disconnect marks the entities unavailable and closes the connection.
+func (ms *mqttServiceImpl) disconnect() {
+	if ms.client.IsConnectionOpen() {
+		if err := ms.publish(ms.topic("availability"), []byte("offline")); err != nil {
+			slog.Error("failed to publish MQTT availability", "error", err)
+		}
+	}
+	ms.client.Disconnect(mqttDisconnectQuiesce)
+}
+
+// commandFilters returns the command topics of the hardware present.
+func (ms *mqttServiceImpl) commandFilters() map[string]byte {
+	filters := make(map[string]byte)
+	if ms.fan != nil {
+		filters[ms.topic("fan/set")] = mqttQoS
+	}
+	if ms.display != nil {
+		filters[ms.topic("display/wake")] = mqttQoS
+		filters[ms.topic("display/message")] = mqttQoS
+	}
+	return filters
+}
+
+// handleCommand applies a command received on one of the command topics. It
+// is called from the client's goroutines and must not block.
+func (ms *mqttServiceImpl) handleCommand(topic string, payload []byte) {
+	value := strings.TrimSpace(string(payload))
+	slog.Debug("received MQTT command", "topic", topic, "payload", value)
+
+	switch topic {
+	case ms.topic("fan/set"):
+		if value == "" || strings.EqualFold(value, "auto") {
+			ms.fan.ClearOverride()
+		} else {
+			speed, err := strconv.ParseFloat(value, 64)
+			if err != nil || speed < 0 || speed > 100 {
+				slog.Warn("ignoring invalid fan speed from MQTT", "payload", value)
+				return
+			}
+			ms.fan.SetOverride(uint8(math.Round(speed)), mqttFanOverride)
+		}
+		// Report the new override state without waiting for the next interval.
+		select {
+		case ms.publishChan <- struct{}{}:
+		default:
+		}
+	case ms.topic("display/wake"):
+		ms.display.Wake()
+	case ms.topic("display/message"):
+		if value == "" {
+			return
+		}
+		if err := ms.display.ShowMessage(DisplayMessage{Text: value, Duration: mqttMessageDuration}); err != nil {
+			slog.Warn("failed to show message from MQTT", "error", err)
+		}
+	}
+}
+
+// publishState publishes the state topics, and the discovery topics of any
+// entity not yet announced on this connection.
+func (ms *mqttServiceImpl) publishState() {
+	if !ms.client.IsConnectionOpen() {
+		return
+	}
+	snap := ms.sampler.Snapshot()
+
+	if prefix := ms.mqttConfig.DiscoveryPrefix(); prefix != "" {
+		for _, entity := range ms.entities(snap) {
+			topic := prefix + "/" + entity.component + "/" + ms.node() + "/" + entity.object + "/config"
+			if _, ok := ms.discovered[topic]; ok {
+				continue
+			}
+			if err := ms.publishJSON(topic, entity.config); err != nil {
+				slog.Error("failed to publish Home Assistant discovery", "topic", topic, "error", err)
+				continue
+			}
+			ms.discovered[topic] = struct{}{}
+		}
+	}
+
+	if err := ms.publishJSON(ms.topic("state"), ms.state(snap)); err != nil {
+		slog.Error("failed to publish MQTT state", "error", err)
+	}
+	for i := range snap.Drives.Value {
+		drive := &snap.Drives.Value[i]
+		if err := ms.publishJSON(ms.topic("drive/"+driveID(drive)), newMQTTDriveState(drive)); err != nil {
+			slog.Error("failed to publish MQTT drive state", "drive", drive.DisplayName(), "error", err)
+		}
+	}
+	for i := range snap.Filesystems.Value {
+		fs := &snap.Filesystems.Value[i]
+		if fs.Unavailable {
+			continue
+		}
+		if err := ms.publishJSON(ms.topic("filesystem/"+filesystemID(fs)), newMQTTFilesystemState(fs)); err != nil {
+			slog.Error("failed to publish MQTT filesystem state", "mountpoint", fs.Mountpoint, "error", err)
+		}
+	}
+}
+
+func (ms *mqttServiceImpl) publishJSON(topic string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return ms.publish(topic, data)
+}
+
+// publish sends a retained message, so new subscribers get the latest value.
+func (ms *mqttServiceImpl) publish(topic string, payload []byte) error {
+	return ms.wait(ms.client.Publish(topic, mqttQoS, true, payload))
+}
+
+func (ms *mqttServiceImpl) wait(token mqtt.Token) error {
+	if !token.WaitTimeout(mqttPublishTimeout) {
+		return ErrMQTTTimeout
+	}
+	return token.Error()
+}
+
+// node identifies this machine in topics and unique IDs.
+func (ms *mqttServiceImpl) node() string {
+	return mqttSafe(ms.hostname)
+}
+
+// topic returns the topic for suffix under <prefix>/<node>.
+func (ms *mqttServiceImpl) topic(suffix string) string {
+	base := ms.mqttConfig.TopicPrefix() + "/" + ms.node()
+	if suffix == "" {
+		return base
+	}
+	return base + "/" + suffix
+}
+
+// mqttSafe lowercases s and replaces anything but letters, digits, _ and -
+// with _, for use in topic levels and unique IDs.
+func mqttSafe(s string) string {
+	return strings.Trim(mqttUnsafe.ReplaceAllString(strings.ToLower(s), "_"), "_")
+}
+
+// driveID identifies a drive by serial number, so it keeps its entities when
+// moved to another bay.
+func driveID(drive *resources.HDDStats) string {
+	if drive.Serial != "" {
+		return mqttSafe(drive.Serial)
+	}
+	return mqttSafe(drive.DeviceName)
+}
+
+// filesystemID identifies a filesystem by mountpoint; / is "root".
+func filesystemID(fs *resources.FilesystemStats) string {
+	if id := mqttSafe(fs.Mountpoint); id != "" {
+		return id
+	}
+	return "root"
+}
+
+// mqttState is published to <prefix>/<node>/state. Readings that are not
+// available are left out.
+type mqttState struct {
+	CPUTemperature *float64 `json:"cpu_temperature,omitempty"`
+	CPUUsage       *float64 `json:"cpu_usage,omitempty"`
+	MemoryUsage    *float64 `json:"memory_usage,omitempty"`
+	FanSpeed       *uint8   `json:"fan_speed,omitempty"`
+	FanOverride    *bool    `json:"fan_override,omitempty"`
+	Alerts         int      `json:"alerts"`
+}
+
+func (ms *mqttServiceImpl) state(snap *Snapshot) mqttState {
+	var state mqttState
+	if err := sampleError(&snap.CPU); err == nil {
+		state.CPUTemperature = roundPtr(snap.CPU.Value.AvgTemperature)
+		state.CPUUsage = roundPtr(snap.CPU.Value.UsagePercent)
+	}
+	if err := sampleError(&snap.Memory); err == nil {
+		state.MemoryUsage = roundPtr(snap.Memory.Value.UsagePercent)
+	}
+	if ms.fan != nil {
+		speed, override := ms.fan.Speed()
+		state.FanSpeed, state.FanOverride = &speed, &override
+	}
+	state.Alerts = len(ms.alerts.Active())
+	return state
+}
+
+// mqttDriveState is published to <prefix>/<node>/drive/<serial>.
+type mqttDriveState struct {
+	Name                string  `json:"name"`
+	Device              string  `json:"device"`
+	Model               string  `json:"model"`
+	Serial              string  `json:"serial"`
+	Temperature         float64 `json:"temperature"`
+	Health              string  `json:"health"` // PASS, WARN or FAIL, as on the display
+	PowerOnHours        int     `json:"power_on_hours"`
+	ReallocatedSectors  int     `json:"reallocated_sectors"`
+	PendingSectors      int     `json:"pending_sectors"`
+	UncorrectableErrors int     `json:"uncorrectable_errors"`
+}
+
+func newMQTTDriveState(drive *resources.HDDStats) mqttDriveState {
+	return mqttDriveState{
+		Name:                drive.DisplayName(),
+		Device:              drive.DeviceName,
+		Model:               drive.Model,
+		Serial:              drive.Serial,
+		Temperature:         drive.Temperature,
+		Health:              drive.Health(),
+		PowerOnHours:        drive.SmartStatus.PowerOnHours,
+		ReallocatedSectors:  drive.SmartStatus.ReallocatedSectors,
+		PendingSectors:      drive.SmartStatus.PendingSectors,
+		UncorrectableErrors: drive.SmartStatus.UncorrectableErrors,
+	}
+}
+
+// mqttFilesystemState is published to <prefix>/<node>/filesystem/<mountpoint>.
+type mqttFilesystemState struct {
+	Mountpoint  string  `json:"mountpoint"`
+	FsType      string  `json:"fstype"`
+	UsedPercent float64 `json:"used_percent"`
+	Used        uint64  `json:"used"`
+	Available   uint64  `json:"available"`
+	Total       uint64  `json:"total"`
+}
+
+func newMQTTFilesystemState(fs *resources.FilesystemStats) mqttFilesystemState {
+	return mqttFilesystemState{
+		Mountpoint:  fs.Mountpoint,
+		FsType:      fs.FsType,
+		UsedPercent: *roundPtr(fs.UsedPercent),
+		Used:        fs.Used,
+		Available:   fs.Available,
+		Total:       fs.Total,
+	}
+}
+
+// roundPtr rounds v to one decimal place.
+func roundPtr(v float64) *float64 {
+	rounded := math.Round(v*10) / 10
+	return &rounded
+}
+
+// haDevice groups the entities under one device in Home Assistant.
+type haDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model"`
+	SWVersion    string   `json:"sw_version,omitempty"`
+}
+
+// haEntity is a Home Assistant MQTT discovery payload.
+type haEntity struct {
+	Name                string   `json:"name"`
+	UniqueID            string   `json:"unique_id"`
+	StateTopic          string   `json:"state_topic,omitempty"`
+	ValueTemplate       string   `json:"value_template,omitempty"`
+	JSONAttributesTopic string   `json:"json_attributes_topic,omitempty"`
+	CommandTopic        string   `json:"command_topic,omitempty"`
+	PayloadPress        string   `json:"payload_press,omitempty"`
+	UnitOfMeasurement   string   `json:"unit_of_measurement,omitempty"`
+	DeviceClass         string   `json:"device_class,omitempty"`
+	StateClass          string   `json:"state_class,omitempty"`
+	Icon                string   `json:"icon,omitempty"`
+	Min                 *float64 `json:"min,omitempty"`
+	Max                 *float64 `json:"max,omitempty"`
+	Mode                string   `json:"mode,omitempty"`
+	AvailabilityTopic   string   `json:"availability_topic"`
+	Device              haDevice `json:"device"`
+}
+
+// discoveryEntity is an entity announced at
+// <discovery prefix>/<component>/<node>/<object>/config.
+type discoveryEntity struct {
+	component string
+	object    string
+	config    haEntity
+}
+
+// entities lists the Home Assistant entities for snap: the system sensors
+// and controls, and sensors for each drive and filesystem.
+func (ms *mqttServiceImpl) entities(snap *Snapshot) []discoveryEntity {
+	device := haDevice{
+		Identifiers:  []string{"lumeon_" + ms.node()},
+		Name:         ms.hostname,
+		Manufacturer: "Argon40",
+		Model:        "Argon EON",
+		SWVersion:    ms.version,
+	}
+	entity := func(component, object, name string, e haEntity) discoveryEntity {
+		e.Name = name
+		e.UniqueID = "lumeon_" + ms.node() + "_" + object
+		e.AvailabilityTopic = ms.topic("availability")
+		e.Device = device
+		return discoveryEntity{component: component, object: object, config: e}
+	}
+	measurement := func(object, name, stateTopic, field, unit, deviceClass string) discoveryEntity {
+		return entity("sensor", object, name, haEntity{
+			StateTopic:        stateTopic,
+			ValueTemplate:     "{{ value_json." + field + " }}",
+			UnitOfMeasurement: unit,
+			DeviceClass:       deviceClass,
+			StateClass:        "measurement",
+		})
+	}
+	state := ms.topic("state")
+	percentMin, percentMax := 0.0, 100.0
+
+	entities := []discoveryEntity{
+		measurement("cpu_temperature", "CPU temperature", state, "cpu_temperature", "°C", "temperature"),
+		measurement("cpu_usage", "CPU usage", state, "cpu_usage", "%", ""),
+		measurement("memory_usage", "Memory usage", state, "memory_usage", "%", ""),
+		measurement("alerts", "Active alerts", state, "alerts", "", ""),
+	}
+	if ms.fan != nil {
+		entities = append(entities, entity("number", "fan_speed", "Fan speed", haEntity{
+			StateTopic:        state,
+			ValueTemplate:     "{{ value_json.fan_speed }}",
+			CommandTopic:      ms.topic("fan/set"),
+			UnitOfMeasurement: "%",
+			Icon:              "mdi:fan",
+			Min:               &percentMin,
+			Max:               &percentMax,
+			Mode:              "slider",
+		}), entity("button", "fan_auto", "Fan automatic", haEntity{
+			CommandTopic: ms.topic("fan/set"),
+			PayloadPress: "auto",
+			Icon:         "mdi:fan-auto",
+		}))
+	}
+	if ms.display != nil {
+		entities = append(entities, entity("button", "display_wake", "Wake display", haEntity{
+			CommandTopic: ms.topic("display/wake"),
+			PayloadPress: "wake",
+			Icon:         "mdi:monitor",
+		}), entity("text", "display_message", "Display message", haEntity{
+			CommandTopic: ms.topic("display/message"),
+			Icon:         "mdi:message-text",
+		}))
+	}
+
+	for i := range snap.Drives.Value {
+		drive := &snap.Drives.Value[i]
+		id := driveID(drive)
+		topic := ms.topic("drive/" + id)
+		temperature := measurement("drive_"+id+"_temperature", drive.DisplayName()+" temperature",
+			topic, "temperature", "°C", "temperature")
+		temperature.config.JSONAttributesTopic = topic
+		entities = append(entities, temperature, entity("sensor", "drive_"+id+"_health", drive.DisplayName()+" health",
+			haEntity{
+				StateTopic:          topic,
+				ValueTemplate:       "{{ value_json.health }}",
+				JSONAttributesTopic: topic,
+				Icon:                "mdi:harddisk",
+			}))
+	}
+	for i := range snap.Filesystems.Value {
+		fs := &snap.Filesystems.Value[i]
+		id := filesystemID(fs)
+		usage := measurement("filesystem_"+id+"_usage", fs.Mountpoint+" usage",
+			ms.topic("filesystem/"+id), "used_percent", "%", "")
+		usage.config.JSONAttributesTopic = ms.topic("filesystem/" + id)
+		usage.config.Icon = "mdi:harddisk"
+		entities = append(entities, usage)
+	}
+	return entities
+}
@@ -0,0 +1,445 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/czechbol/lumeon/app/config"
+	"github.com/czechbol/lumeon/core/resources"
+	"github.com/eclipse/paho.mqtt.golang/packets"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeBroker is a minimal MQTT 3.1.1 broker for one client. It keeps
+// retained messages and forwards publishes on exact topic subscriptions.
+type fakeBroker struct {
+	listener net.Listener
+	connects chan *packets.ConnectPacket
+
+	mutex    sync.Mutex
+	conns    []net.Conn
+	subs     map[string]net.Conn
+	retained map[string][]byte
+	// subscribed is signalled on every SUBSCRIBE.
+	subscribed chan struct{}
+}
+
+func newFakeBroker(t *testing.T) *fakeBroker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &fakeBroker{
+		listener:   listener,
+		connects:   make(chan *packets.ConnectPacket, 10),
+		subs:       make(map[string]net.Conn),
+		retained:   make(map[string][]byte),
+		subscribed: make(chan struct{}, 10),
+	}
+	t.Cleanup(func() {
+		listener.Close()
+		b.dropClients()
+	})
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			b.mutex.Lock()
+			b.conns = append(b.conns, conn)
+			b.mutex.Unlock()
+			go b.serve(conn)
+		}
+	}()
+	return b
+}
+
+func (b *fakeBroker) url() string {
+	return "tcp://" + b.listener.Addr().String()
+}
+
+func (b *fakeBroker) write(conn net.Conn, packet packets.ControlPacket) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	_ = packet.Write(conn)
+}
+
+func (b *fakeBroker) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		packet, err := packets.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+		switch p := packet.(type) {
+		case *packets.ConnectPacket:
+			b.connects <- p
+			b.write(conn, packets.NewControlPacket(packets.Connack))
+		case *packets.PublishPacket:
+			b.mutex.Lock()
+			if p.Retain {
+				b.retained[p.TopicName] = p.Payload
+			}
+			b.mutex.Unlock()
+			if p.Qos > 0 {
+				ack, _ := packets.NewControlPacket(packets.Puback).(*packets.PubackPacket)
+				ack.MessageID = p.MessageID
+				b.write(conn, ack)
+			}
+		case *packets.SubscribePacket:
+			b.mutex.Lock()
+			for _, topic := range p.Topics {
+				b.subs[topic] = conn
+			}
+			b.mutex.Unlock()
+			ack, _ := packets.NewControlPacket(packets.Suback).(*packets.SubackPacket)
+			ack.MessageID = p.MessageID
+			ack.ReturnCodes = p.Qoss
+			b.write(conn, ack)
+			b.subscribed <- struct{}{}
+		case *packets.PingreqPacket:
+			b.write(conn, packets.NewControlPacket(packets.Pingresp))
+		case *packets.DisconnectPacket:
+			return
+		}
+	}
+}
+
+// send publishes payload to the client subscribed to topic.
+func (b *fakeBroker) send(topic, payload string) {
+	b.mutex.Lock()
+	conn, ok := b.subs[topic]
+	b.mutex.Unlock()
+	if !ok {
+		return
+	}
+	publish, _ := packets.NewControlPacket(packets.Publish).(*packets.PublishPacket)
+	publish.TopicName = topic
+	publish.Payload = []byte(payload)
+	b.write(conn, publish)
+}
+
+// message returns the retained message on topic, if any.
+func (b *fakeBroker) message(topic string) ([]byte, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	payload, ok := b.retained[topic]
+	return payload, ok
+}
+
+// dropClients closes every client connection, as if the broker restarted.
+func (b *fakeBroker) dropClients() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for _, conn := range b.conns {
+		conn.Close()
+	}
+	b.conns = nil
+	b.subs = make(map[string]net.Conn)
+}
+
+// fakeFan records fan overrides.
+type fakeFan struct {
+	FanService
+
+	mutex    sync.Mutex
+	override uint8
+	duration time.Duration
+	cleared  int
+}
+
+func (f *fakeFan) SetOverride(speed uint8, d time.Duration) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.override, f.duration = speed, d
+}
+
+func (f *fakeFan) ClearOverride() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.cleared++
+}
+
+func (f *fakeFan) Speed() (uint8, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return 40, f.duration > 0
+}
+
+// fakeDisplay records wakes and messages. ShowMessage fails with err.
+type fakeDisplay struct {
+	DisplayService
+
+	mutex    sync.Mutex
+	wakes    int
+	messages []DisplayMessage
+	cleared  int
+	err      error
+}
+
+func (d *fakeDisplay) Wake() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.wakes++
+}
+
+func (d *fakeDisplay) ShowMessage(msg DisplayMessage) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.err != nil {
+		return d.err
+	}
+	d.messages = append(d.messages, msg)
+	return nil
+}
+
+func (d *fakeDisplay) ClearMessages() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.cleared++
+}
+
+// fakeSampler always returns the same snapshot.
+type fakeSampler struct {
+	Sampler
+	snap *Snapshot
+}
+
+func (f *fakeSampler) Snapshot() *Snapshot {
+	return f.snap
+}
+
+type MQTTServiceTestSuite struct {
+	suite.Suite
+	broker  *fakeBroker
+	fan     *fakeFan
+	display *fakeDisplay
+	service *mqttServiceImpl
+}
+
+func TestMQTTServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(MQTTServiceTestSuite))
+}
+
+func (s *MQTTServiceTestSuite) SetupTest() {
+	s.broker = newFakeBroker(s.T())
+	s.fan = &fakeFan{}
+	s.display = &fakeDisplay{}
+
+	now := time.Now()
+	snap := &Snapshot{}
+	snap.CPU = Sample[*resources.CPUStats]{
+		Value:     &resources.CPUStats{AvgTemperature: 48.26, UsagePercent: 12.5},
+		UpdatedAt: now,
+		Interval:  time.Minute,
+	}
+	snap.Drives = Sample[[]resources.HDDStats]{
+		Value: []resources.HDDStats{{
+			DeviceName:  "sda",
+			Label:       "Bay 1",
+			Serial:      "WD-123",
+			Temperature: 35,
+			SmartStatus: resources.SmartStatus{HealthOK: true, Degraded: true},
+		}},
+		UpdatedAt: now,
+		Interval:  time.Minute,
+	}
+	snap.Filesystems = Sample[[]resources.FilesystemStats]{
+		Value:     []resources.FilesystemStats{{Mountpoint: "/", UsedPercent: 41.04}, {Mountpoint: "/srv/data"}},
+		UpdatedAt: now,
+		Interval:  time.Minute,
+	}
+
+	alerts := NewAlertManager()
+	alerts.Raise(Alert{Key: "test", Severity: AlertWarning})
+
+	mqttConfig := config.NewMQTTConfig(s.broker.url(), "lumeon-test", "", "", "lumeon", "homeassistant",
+		time.Hour, config.MQTTTLSConfig{})
+	service, err := NewMQTTService(s.fan, s.display, &fakeSampler{snap: snap}, alerts, mqttConfig, "1.2.3")
+	s.Require().NoError(err)
+	s.service, _ = service.(*mqttServiceImpl)
+	s.service.hostname = "My NAS"
+
+	s.Require().NoError(s.service.Start(context.Background()))
+	s.T().Cleanup(func() { s.Require().NoError(s.service.Shutdown(context.Background())) })
+}
+
+// waitConnected waits for the client to connect and subscribe.
+func (s *MQTTServiceTestSuite) waitConnected() *packets.ConnectPacket {
+	var connect *packets.ConnectPacket
+	select {
+	case connect = <-s.broker.connects:
+	case <-time.After(5 * time.Second):
+		s.FailNow("client did not connect")
+	}
+	select {
+	case <-s.broker.subscribed:
+	case <-time.After(5 * time.Second):
+		s.FailNow("client did not subscribe")
+	}
+	return connect
+}
+
+// retained waits for a retained message on topic.
+func (s *MQTTServiceTestSuite) retained(topic string) []byte {
+	var payload []byte
+	s.Require().Eventually(func() bool {
+		var ok bool
+		payload, ok = s.broker.message(topic)
+		return ok
+	}, 5*time.Second, 10*time.Millisecond, "nothing published to %s", topic)
+	return payload
+}
+
+func (s *MQTTServiceTestSuite) TestPublishesStateAndDiscovery() {
+	connect := s.waitConnected()
+	s.Equal("lumeon-test", connect.ClientIdentifier)
+	s.True(connect.WillFlag)
+	s.True(connect.WillRetain)
+	// The will is set up before the test renames the host.
+	hostname, err := os.Hostname()
+	s.Require().NoError(err)
+	s.Equal("lumeon/"+mqttSafe(hostname)+"/availability", connect.WillTopic)
+	s.Equal("offline", string(connect.WillMessage))
+
+	s.Equal("online", string(s.retained("lumeon/my_nas/availability")))
+
+	var state map[string]any
+	s.Require().NoError(json.Unmarshal(s.retained("lumeon/my_nas/state"), &state))
+	s.InDelta(48.3, state["cpu_temperature"], 0.001)
+	s.InDelta(40, state["fan_speed"], 0)
+	s.Equal(false, state["fan_override"])
+	s.InDelta(1, state["alerts"], 0)
+	s.NotContains(state, "memory_usage")
+
+	var drive map[string]any
+	s.Require().NoError(json.Unmarshal(s.retained("lumeon/my_nas/drive/wd-123"), &drive))
+	s.Equal("WARN", drive["health"])
+	s.Equal("Bay 1", drive["name"])
+
+	var fs map[string]any
+	s.Require().NoError(json.Unmarshal(s.retained("lumeon/my_nas/filesystem/root"), &fs))
+	s.InDelta(41, fs["used_percent"], 0)
+	s.retained("lumeon/my_nas/filesystem/srv_data")
+
+	var sensor haEntity
+	s.Require().NoError(json.Unmarshal(s.retained("homeassistant/sensor/my_nas/cpu_temperature/config"), &sensor))
+	s.Equal("lumeon_my_nas_cpu_temperature", sensor.UniqueID)
+	s.Equal("lumeon/my_nas/state", sensor.StateTopic)
+	s.Equal("{{ value_json.cpu_temperature }}", sensor.ValueTemplate)
+	s.Equal("°C", sensor.UnitOfMeasurement)
+	s.Equal("lumeon/my_nas/availability", sensor.AvailabilityTopic)
+	s.Equal([]string{"lumeon_my_nas"}, sensor.Device.Identifiers)
+	s.Equal("1.2.3", sensor.Device.SWVersion)
+
+	var fan haEntity
+	s.Require().NoError(json.Unmarshal(s.retained("homeassistant/number/my_nas/fan_speed/config"), &fan))
+	s.Equal("lumeon/my_nas/fan/set", fan.CommandTopic)
+	s.Require().NotNil(fan.Min)
+	s.Zero(*fan.Min)
+
+	s.retained("homeassistant/sensor/my_nas/drive_wd-123_health/config")
+	s.retained("homeassistant/sensor/my_nas/filesystem_root_usage/config")
+	s.retained("homeassistant/button/my_nas/display_wake/config")
+	s.retained("homeassistant/text/my_nas/display_message/config")
+}
+
+func (s *MQTTServiceTestSuite) TestCommands() {
+	s.waitConnected()
+
+	s.broker.send("lumeon/my_nas/fan/set", "55.4")
+	s.Eventually(func() bool {
+		s.fan.mutex.Lock()
+		defer s.fan.mutex.Unlock()
+		return s.fan.override == 55 && s.fan.duration == mqttFanOverride
+	}, time.Second, 10*time.Millisecond)
+
+	// Invalid speeds are ignored.
+	s.broker.send("lumeon/my_nas/fan/set", "150")
+	s.broker.send("lumeon/my_nas/fan/set", "auto")
+	s.Eventually(func() bool {
+		s.fan.mutex.Lock()
+		defer s.fan.mutex.Unlock()
+		return s.fan.cleared == 1
+	}, time.Second, 10*time.Millisecond)
+	s.fan.mutex.Lock()
+	s.Equal(uint8(55), s.fan.override)
+	s.fan.mutex.Unlock()
+
+	s.broker.send("lumeon/my_nas/display/wake", "wake")
+	s.broker.send("lumeon/my_nas/display/message", "  Backup done  ")
+	s.Eventually(func() bool {
+		s.display.mutex.Lock()
+		defer s.display.mutex.Unlock()
+		return s.display.wakes == 1 && len(s.display.messages) == 1 &&
+			s.display.messages[0].Text == "Backup done" && s.display.messages[0].Duration == mqttMessageDuration
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (s *MQTTServiceTestSuite) TestReconnect() {
+	s.waitConnected()
+	s.retained("lumeon/my_nas/state")
+
+	// After the broker restarts with no retained messages, the client
+	// reconnects, subscribes and publishes everything again.
+	s.broker.mutex.Lock()
+	s.broker.retained = make(map[string][]byte)
+	s.broker.mutex.Unlock()
+	s.broker.dropClients()
+
+	s.waitConnected()
+	s.Equal("online", string(s.retained("lumeon/my_nas/availability")))
+	s.retained("lumeon/my_nas/state")
+	s.retained("homeassistant/sensor/my_nas/cpu_temperature/config")
+
+	s.broker.send("lumeon/my_nas/display/wake", "")
+	s.Eventually(func() bool {
+		s.display.mutex.Lock()
+		defer s.display.mutex.Unlock()
+		return s.display.wakes == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (s *MQTTServiceTestSuite) TestShutdownMarksOffline() {
+	s.waitConnected()
+	s.Equal("online", string(s.retained("lumeon/my_nas/availability")))
+
+	s.Require().NoError(s.service.Shutdown(context.Background()))
+	payload, _ := s.broker.message("lumeon/my_nas/availability")
+	s.Equal("offline", string(payload))
+}
+
+func (s *MQTTServiceTestSuite) TestWithoutHardware() {
+	mqttConfig := config.NewMQTTConfig("", "lumeon-test", "", "", "lumeon", "homeassistant",
+		time.Hour, config.MQTTTLSConfig{})
+	service, err := NewMQTTService(nil, nil, &fakeSampler{snap: &Snapshot{}}, NewAlertManager(), mqttConfig, "1.2.3")
+	s.Require().NoError(err)
+	ms, _ := service.(*mqttServiceImpl)
+	ms.hostname = "My NAS"
+
+	s.Empty(ms.commandFilters())
+	for _, e := range ms.entities(&Snapshot{}) {
+		s.NotContains(e.object, "fan")
+		s.NotContains(e.object, "display")
+	}
+	state, err := json.Marshal(ms.state(&Snapshot{}))
+	s.Require().NoError(err)
+	s.NotContains(string(state), "fan_")
+}
+
+func (s *MQTTServiceTestSuite) TestDriveHealthMatchesDisplay() {
+	drive := resources.HDDStats{
+		DeviceName:  "sda",
+		SmartStatus: resources.SmartStatus{HealthOK: true},
+		SelfTest:    resources.SelfTestStatus{HasResult: true, Passed: false, Result: "Completed: read failure"},
+	}
+
+	s.Equal("FAIL", newMQTTDriveState(&drive).Health)
+}
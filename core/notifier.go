@@ -0,0 +1,290 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/czechbol/lumeon/app/config"
+	"github.com/czechbol/lumeon/core/notify"
+)
+
+const (
+	// notifyQueueSize is how many alert events can wait for delivery to a sink.
+	notifyQueueSize = 64
+	// notifyAttempts is how many times delivery to a sink is tried.
+	notifyAttempts   = 3
+	notifyRetryDelay = 10 * time.Second
+	// notifyRateWindow is the period the rate limit applies to.
+	notifyRateWindow = time.Hour
+)
+
+// NotifierService sends alerts raised and resolved by the AlertManager to
+// the configured notification sinks.
+type NotifierService interface {
+	IsRunning() bool
+	Start(ctx context.Context) error
+	Shutdown(ctx context.Context) error
+}
+
+type notifierServiceImpl struct {
+	mutex        sync.RWMutex
+	running      bool
+	alerts       AlertManager
+	workers      []*sinkWorker
+	notifyConfig config.NotifyConfig
+	hostname     string
+	ctx          context.Context
+	cancel       context.CancelFunc
+	shutdownChan chan struct{}
+
+	// retryDelay is the wait between delivery attempts; shortened in tests.
+	retryDelay time.Duration
+}
+
+// sinkWorker delivers the notifications for one sink from its own queue, so
+// a sink that is down and being retried does not hold up the others. The
+// delivery state is only used by the worker's goroutine.
+type sinkWorker struct {
+	sink  notify.Sink
+	queue chan notify.Alert
+
+	// lastSent is when an alert key was last sent in a given severity and
+	// state, for deduplication.
+	lastSent map[string]time.Time
+	// notified holds the alert keys whose raise was delivered, so a resolve
+	// is only sent where the raise was seen.
+	notified map[string]struct{}
+	// sentAt holds the delivery times within the rate window.
+	sentAt []time.Time
+}
+
+// NewNotifierService creates the notifier. sinks are matched to the
+// configured rules by name.
+func NewNotifierService(alerts AlertManager, sinks []notify.Sink, notifyConfig config.NotifyConfig) NotifierService {
+	hostname, err := os.Hostname()
+	if err != nil {
+		slog.Warn("failed to get hostname for notifications", "error", err)
+		hostname = "lumeon"
+	}
+	workers := make([]*sinkWorker, 0, len(sinks))
+	for _, sink := range sinks {
+		workers = append(workers, &sinkWorker{
+			sink:     sink,
+			queue:    make(chan notify.Alert, notifyQueueSize),
+			lastSent: make(map[string]time.Time),
+			notified: make(map[string]struct{}),
+		})
+	}
+	return &notifierServiceImpl{
+		alerts:       alerts,
+		workers:      workers,
+		notifyConfig: notifyConfig,
+		hostname:     hostname,
+		shutdownChan: make(chan struct{}),
+		retryDelay:   notifyRetryDelay,
+	}
+}
+
+func (ns *notifierServiceImpl) IsRunning() bool {
+	ns.mutex.RLock()
+	defer ns.mutex.RUnlock()
+	return ns.running
+}
+
+func (ns *notifierServiceImpl) Start(ctx context.Context) error {
+	ns.ctx, ns.cancel = context.WithCancel(ctx)
+	ns.mutex.Lock()
+	if ns.running {
+		ns.mutex.Unlock()
+		return nil
+	}
+	ns.running = true
+	ns.mutex.Unlock()
+
+	if len(ns.workers) == 0 {
+		slog.Info("no notification sinks configured, notifications disabled")
+		close(ns.shutdownChan)
+		return nil
+	}
+
+	slog.Info("starting notifier", "sinks", len(ns.workers))
+
+	// Subscribers are called from the goroutine raising the alert, so
+	// delivery happens on the goroutine of each sink.
+	ns.alerts.Subscribe(func(event AlertEvent) {
+		alert := ns.notifyAlert(event, time.Now())
+		for _, worker := range ns.workers {
+			select {
+			case worker.queue <- alert:
+			default:
+				slog.Warn("notification queue full, dropping notification", "sink", worker.sink.Name(),
+					"key", alert.Key)
+			}
+		}
+	})
+
+	var wg sync.WaitGroup
+	for _, worker := range ns.workers {
+		wg.Go(func() { ns.sinkLoop(worker) })
+	}
+	go func() {
+		wg.Wait()
+		slog.Info("stopping notifier due to context cancellation")
+		close(ns.shutdownChan)
+	}()
+
+	return nil
+}
+
+func (ns *notifierServiceImpl) Shutdown(ctx context.Context) error {
+	ns.cancel()
+
+	select {
+	case <-ns.shutdownChan:
+		slog.Info("notifier stopped gracefully")
+	case <-ctx.Done():
+		slog.Warn("shutdown context expired before notifier could stop")
+	}
+
+	ns.mutex.Lock()
+	ns.running = false
+	ns.mutex.Unlock()
+
+	return nil
+}
+
+func (ns *notifierServiceImpl) sinkLoop(worker *sinkWorker) {
+	for {
+		select {
+		case <-ns.ctx.Done():
+			return
+		case alert := <-worker.queue:
+			ns.dispatch(worker, &alert, time.Now())
+		}
+	}
+}
+
+// notifyAlert converts an alert event for the notification templates.
+func (ns *notifierServiceImpl) notifyAlert(event AlertEvent, now time.Time) notify.Alert {
+	alert := notify.Alert{
+		Hostname: ns.hostname,
+		Key:      event.Alert.Key,
+		Source:   event.Alert.Source,
+		Severity: event.Alert.Severity.String(),
+		Title:    event.Alert.Title,
+		Message:  event.Alert.Message,
+		Since:    event.Alert.Since,
+		Resolved: event.Resolved,
+	}
+	if event.Resolved {
+		alert.Duration = now.Sub(event.Alert.Since).Round(time.Second)
+	}
+	return alert
+}
+
+// dispatch delivers alert to the worker's sink if it is routed there, unless
+// it is a duplicate or the sink is over its rate limit.
+func (ns *notifierServiceImpl) dispatch(worker *sinkWorker, alert *notify.Alert, now time.Time) {
+	sink := worker.sink.Name()
+	if !ns.routed(sink, alert) || !ns.allowed(worker, alert, now) {
+		return
+	}
+	if err := ns.deliver(worker.sink, alert); err != nil {
+		slog.Error("failed to send notification", "sink", sink, "key", alert.Key, "error", err)
+		return
+	}
+
+	slog.Debug("notification sent", "sink", sink, "key", alert.Key, "resolved", alert.Resolved)
+	worker.lastSent[dedupKey(alert)] = now
+	worker.sentAt = append(worker.sentAt, now)
+	if alert.Resolved {
+		delete(worker.notified, alert.Key)
+	} else {
+		worker.notified[alert.Key] = struct{}{}
+	}
+}
+
+// routed reports whether a rule sends alert to sink. Without rules every
+// alert goes to every sink.
+func (ns *notifierServiceImpl) routed(sink string, alert *notify.Alert) bool {
+	rules := ns.notifyConfig.Rules()
+	if len(rules) == 0 {
+		return true
+	}
+	for _, rule := range rules {
+		switch {
+		case !slices.Contains(rule.Sinks, sink),
+			len(rule.Sources) > 0 && !slices.Contains(rule.Sources, alert.Source),
+			rule.Critical && !alert.Critical(),
+			alert.Resolved && !rule.Resolved:
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// allowed applies deduplication and the rate limit. A resolve is only sent
+// to sinks that were sent the raise.
+func (ns *notifierServiceImpl) allowed(worker *sinkWorker, alert *notify.Alert, now time.Time) bool {
+	sink := worker.sink.Name()
+	if alert.Resolved {
+		if _, ok := worker.notified[alert.Key]; !ok {
+			return false
+		}
+	}
+
+	if last, ok := worker.lastSent[dedupKey(alert)]; ok && now.Sub(last) < ns.notifyConfig.DedupWindow() {
+		slog.Debug("suppressing repeated notification", "sink", sink, "key", alert.Key)
+		return false
+	}
+
+	limit := ns.notifyConfig.RateLimit()
+	if limit == 0 {
+		return true
+	}
+	recent := slices.DeleteFunc(worker.sentAt, func(t time.Time) bool {
+		return now.Sub(t) >= notifyRateWindow
+	})
+	worker.sentAt = recent
+	if len(recent) >= limit {
+		slog.Warn("notification rate limit reached, dropping notification", "sink", sink, "key", alert.Key,
+			"limit", limit)
+		return false
+	}
+	return true
+}
+
+// deliver sends alert to sink, retrying a failed attempt after a delay.
+func (ns *notifierServiceImpl) deliver(sink notify.Sink, alert *notify.Alert) error {
+	var err error
+	for attempt := 1; attempt <= notifyAttempts; attempt++ {
+		if err = sink.Send(ns.ctx, alert); err == nil {
+			return nil
+		}
+		if attempt == notifyAttempts {
+			break
+		}
+		slog.Warn("failed to send notification, retrying", "sink", sink.Name(), "attempt", attempt, "error", err)
+		select {
+		case <-ns.ctx.Done():
+			return err
+		case <-time.After(ns.retryDelay):
+		}
+	}
+	return err
+}
+
+// dedupKey identifies a notification for deduplication: the same alert in
+// the same severity and state.
+func dedupKey(alert *notify.Alert) string {
+	state := "raised"
+	if alert.Resolved {
+		state = "resolved"
+	}
+	return alert.Key + "|" + alert.Severity + "|" + state
+}
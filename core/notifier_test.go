@@ -0,0 +1,208 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/czechbol/lumeon/app/config"
+	"github.com/czechbol/lumeon/core/notify"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeSink records the alerts it is sent. The first failures sends fail.
+type fakeSink struct {
+	name     string
+	sent     []notify.Alert
+	failures int
+	attempts int
+}
+
+func (f *fakeSink) Name() string {
+	return f.name
+}
+
+func (f *fakeSink) Send(_ context.Context, alert *notify.Alert) error {
+	f.attempts++
+	if f.failures > 0 {
+		f.failures--
+		return errProbe
+	}
+	f.sent = append(f.sent, *alert)
+	return nil
+}
+
+type NotifierServiceTestSuite struct {
+	suite.Suite
+	phone   *fakeSink
+	mail    *fakeSink
+	service *notifierServiceImpl
+	now     time.Time
+}
+
+func TestNotifierServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(NotifierServiceTestSuite))
+}
+
+func (s *NotifierServiceTestSuite) SetupTest() {
+	s.now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	s.newService(nil, 0, 0)
+}
+
+func (s *NotifierServiceTestSuite) newService(rules []config.NotifyRuleConfig, rateLimit int, dedup time.Duration) {
+	s.phone = &fakeSink{name: "phone"}
+	s.mail = &fakeSink{name: "mail"}
+	service, ok := NewNotifierService(
+		NewAlertManager(),
+		[]notify.Sink{s.phone, s.mail},
+		config.NewNotifyConfig(nil, rules, rateLimit, dedup),
+	).(*notifierServiceImpl)
+	s.Require().True(ok)
+	service.ctx = context.Background()
+	service.retryDelay = 0
+	s.service = service
+}
+
+// event dispatches an alert event after minutes.
+func (s *NotifierServiceTestSuite) event(alert Alert, resolved bool, minutes int) {
+	now := s.now.Add(time.Duration(minutes) * time.Minute)
+	if alert.Since.IsZero() {
+		alert.Since = s.now
+	}
+	n := s.service.notifyAlert(AlertEvent{Alert: alert, Resolved: resolved}, now)
+	for _, worker := range s.service.workers {
+		s.service.dispatch(worker, &n, now)
+	}
+}
+
+var (
+	driveFailed = Alert{Key: "drive:WD1:failed", Source: "drive", Severity: AlertCritical, Title: "Bay 1 FAILED"}
+	poolWarning = Alert{Key: "pool:md:md0", Source: "pool", Severity: AlertWarning, Title: "md0 degraded"}
+)
+
+func (s *NotifierServiceTestSuite) TestWithoutRules() {
+	s.event(driveFailed, false, 0)
+	s.event(driveFailed, true, 90)
+
+	s.Require().Len(s.phone.sent, 2)
+	s.Len(s.mail.sent, 2)
+	s.Equal("critical", s.phone.sent[0].Severity)
+	s.False(s.phone.sent[0].Resolved)
+	s.True(s.phone.sent[1].Resolved)
+	s.Equal(90*time.Minute, s.phone.sent[1].Duration)
+}
+
+func (s *NotifierServiceTestSuite) TestRules() {
+	s.newService([]config.NotifyRuleConfig{
+		{Sinks: []string{"phone"}, Critical: true},
+		{Sinks: []string{"mail"}, Sources: []string{"pool"}, Resolved: true},
+	}, 0, 0)
+
+	s.event(driveFailed, false, 0)
+	s.event(poolWarning, false, 0)
+	s.event(driveFailed, true, 1)
+	s.event(poolWarning, true, 1)
+
+	// The phone only gets critical alerts and no resolves.
+	s.Require().Len(s.phone.sent, 1)
+	s.Equal(driveFailed.Key, s.phone.sent[0].Key)
+	// Mail only gets pool alerts, resolves included.
+	s.Require().Len(s.mail.sent, 2)
+	s.Equal(poolWarning.Key, s.mail.sent[0].Key)
+	s.True(s.mail.sent[1].Resolved)
+}
+
+func (s *NotifierServiceTestSuite) TestDeduplication() {
+	s.newService(nil, 0, 15*time.Minute)
+
+	// A flapping alert is only notified once per window.
+	s.event(poolWarning, false, 0)
+	s.event(poolWarning, true, 1)
+	s.event(poolWarning, false, 2)
+	s.event(poolWarning, true, 3)
+	s.Len(s.phone.sent, 2)
+
+	// A change of severity is not a repeat.
+	escalated := poolWarning
+	escalated.Severity = AlertCritical
+	s.event(escalated, false, 4)
+	s.Len(s.phone.sent, 3)
+
+	s.event(poolWarning, false, 20)
+	s.Len(s.phone.sent, 4)
+}
+
+func (s *NotifierServiceTestSuite) TestRateLimit() {
+	s.newService(nil, 2, 0)
+
+	s.event(driveFailed, false, 0)
+	s.event(poolWarning, false, 1)
+	s.event(Alert{Key: "cpu:undervoltage", Source: "cpu", Severity: AlertCritical}, false, 2)
+	s.Len(s.phone.sent, 2)
+
+	// The resolve of a dropped alert is not sent either.
+	s.event(Alert{Key: "cpu:undervoltage", Source: "cpu", Severity: AlertCritical}, true, 61)
+	s.Len(s.phone.sent, 2)
+
+	s.event(driveFailed, true, 61)
+	s.Len(s.phone.sent, 3)
+}
+
+func (s *NotifierServiceTestSuite) TestRetry() {
+	s.phone.failures = 2
+	s.mail.failures = notifyAttempts
+
+	s.event(driveFailed, false, 0)
+	s.Len(s.phone.sent, 1)
+	s.Equal(3, s.phone.attempts)
+	s.Empty(s.mail.sent)
+	s.Equal(notifyAttempts, s.mail.attempts)
+
+	// The mail sink never got the raise, so it does not get the resolve.
+	s.event(driveFailed, true, 1)
+	s.Len(s.phone.sent, 2)
+	s.Empty(s.mail.sent)
+}
+
+// stuckSink blocks every send until the context is cancelled.
+type stuckSink struct{}
+
+func (stuckSink) Name() string {
+	return "stuck"
+}
+
+func (stuckSink) Send(ctx context.Context, _ *notify.Alert) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// chanSink passes the alerts it is sent on a channel.
+type chanSink chan notify.Alert
+
+func (chanSink) Name() string {
+	return "chan"
+}
+
+func (c chanSink) Send(_ context.Context, alert *notify.Alert) error {
+	c <- *alert
+	return nil
+}
+
+func (s *NotifierServiceTestSuite) TestStuckSinkDoesNotBlockOthers() {
+	alerts := NewAlertManager()
+	sent := make(chanSink, 1)
+	service := NewNotifierService(alerts, []notify.Sink{stuckSink{}, sent}, config.NewNotifyConfig(nil, nil, 0, 0))
+	s.Require().NoError(service.Start(context.Background()))
+
+	alerts.Raise(driveFailed)
+	select {
+	case alert := <-sent:
+		s.Equal(driveFailed.Key, alert.Key)
+	case <-time.After(time.Second):
+		s.Fail("alert was not delivered while another sink was stuck")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	s.Require().NoError(service.Shutdown(ctx))
+}
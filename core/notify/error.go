@@ -0,0 +1,9 @@
+package notify
+
+import "errors"
+
+var (
+	ErrUnknownSinkType = errors.New("unknown notification sink type")
+	ErrMissingOption   = errors.New("missing notification sink option")
+	ErrSinkRejected    = errors.New("notification rejected")
+)
@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// Gotify priorities: the Android app shows 4-7 with sound and 8 and above
+// as a persistent, high-importance notification.
+const (
+	gotifyPriorityResolved = 2
+	gotifyPriorityWarning  = 5
+	gotifyPriorityCritical = 8
+)
+
+// gotifySink posts to a Gotify server's message API (https://gotify.net).
+type gotifySink struct {
+	name      string
+	url       string // the server's /message endpoint
+	token     string // application token
+	client    *http.Client
+	templates *Templates
+}
+
+func (s *gotifySink) Name() string {
+	return s.name
+}
+
+func (s *gotifySink) Send(ctx context.Context, alert *Alert) error {
+	title, body, err := s.templates.Render(alert)
+	if err != nil {
+		return err
+	}
+
+	priority := gotifyPriorityWarning
+	switch {
+	case alert.Resolved:
+		priority = gotifyPriorityResolved
+	case alert.Critical():
+		priority = gotifyPriorityCritical
+	}
+	payload, err := json.Marshal(map[string]any{
+		"title":    title,
+		"message":  body,
+		"priority": priority,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", s.token)
+	return post(s.client, req)
+}
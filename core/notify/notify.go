@@ -0,0 +1,178 @@
+/*
+Package notify delivers alerts to external services: generic JSON webhooks,
+ntfy, Gotify and email.
+*/
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	// DefaultTitleTemplate is used when no title template is configured.
+	DefaultTitleTemplate = `[{{.Hostname}}] {{if .Resolved}}Resolved: {{end}}{{.Title}}`
+	// DefaultBodyTemplate is used when no body template is configured.
+	DefaultBodyTemplate = `{{.Message}}{{if .Resolved}}
+
+Resolved after {{.Duration}}.{{end}}`
+
+	// sendTimeout bounds one delivery attempt.
+	sendTimeout = 20 * time.Second
+)
+
+// Alert is an alert being raised or resolved, as seen by notification templates.
+type Alert struct {
+	Hostname string
+	Key      string // stable alert identity, e.g. "drive:WD-WCC4N1234567:failed"
+	Source   string // subsystem, e.g. "drive"
+	Severity string // "warning" or "critical"
+	Title    string
+	Message  string
+	Since    time.Time
+	Resolved bool
+	// Duration is how long the alert was active; only set when Resolved.
+	Duration time.Duration
+}
+
+// Critical reports whether the alert is critical.
+func (a *Alert) Critical() bool {
+	return a.Severity == "critical"
+}
+
+// Sink delivers notifications to one destination.
+type Sink interface {
+	// Name is the sink's configured name, used in rules and logs.
+	Name() string
+	Send(ctx context.Context, alert *Alert) error
+}
+
+// Options configures a sink. Which fields are used depends on Type.
+type Options struct {
+	Name string
+	Type string // "webhook", "ntfy", "gotify" or "smtp"
+	// URL is the webhook URL, the ntfy topic URL or the Gotify server URL.
+	URL string
+	// Token is a bearer token for webhooks and ntfy, or a Gotify application token.
+	Token string
+
+	// SMTP only.
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+	// TLS connects with implicit TLS, usually on port 465. Otherwise STARTTLS
+	// is used when the server offers it.
+	TLS bool
+
+	// TitleTemplate and BodyTemplate are text/template sources executed on an
+	// Alert; empty means the defaults.
+	TitleTemplate string
+	BodyTemplate  string
+}
+
+// New creates the sink described by opts.
+func New(opts Options) (Sink, error) {
+	templates, err := NewTemplates(opts.TitleTemplate, opts.BodyTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("sink %s: %w", opts.Name, err)
+	}
+
+	switch opts.Type {
+	case "webhook", "ntfy", "gotify":
+		if opts.URL == "" {
+			return nil, fmt.Errorf("sink %s: url: %w", opts.Name, ErrMissingOption)
+		}
+	case "smtp":
+		if opts.Host == "" || opts.From == "" || len(opts.To) == 0 {
+			return nil, fmt.Errorf("sink %s: host, from and to: %w", opts.Name, ErrMissingOption)
+		}
+	}
+
+	client := &http.Client{Timeout: sendTimeout}
+	switch opts.Type {
+	case "webhook":
+		return &webhookSink{
+			name:      opts.Name,
+			url:       opts.URL,
+			token:     opts.Token,
+			client:    client,
+			templates: templates,
+		}, nil
+	case "ntfy":
+		return &ntfySink{
+			name:      opts.Name,
+			url:       opts.URL,
+			token:     opts.Token,
+			client:    client,
+			templates: templates,
+		}, nil
+	case "gotify":
+		return &gotifySink{
+			name:      opts.Name,
+			url:       strings.TrimSuffix(opts.URL, "/") + "/message",
+			token:     opts.Token,
+			client:    client,
+			templates: templates,
+		}, nil
+	case "smtp":
+		return newSMTPSink(opts, templates), nil
+	}
+	return nil, fmt.Errorf("sink %s: %q: %w", opts.Name, opts.Type, ErrUnknownSinkType)
+}
+
+// Templates renders the title and body of a notification.
+type Templates struct {
+	title *template.Template
+	body  *template.Template
+}
+
+// NewTemplates parses the title and body templates, using the defaults for empty ones.
+func NewTemplates(title, body string) (*Templates, error) {
+	if title == "" {
+		title = DefaultTitleTemplate
+	}
+	if body == "" {
+		body = DefaultBodyTemplate
+	}
+
+	t := &Templates{}
+	var err error
+	if t.title, err = template.New("title").Option("missingkey=error").Parse(title); err != nil {
+		return nil, fmt.Errorf("parsing title template: %w", err)
+	}
+	if t.body, err = template.New("body").Option("missingkey=error").Parse(body); err != nil {
+		return nil, fmt.Errorf("parsing body template: %w", err)
+	}
+	return t, nil
+}
+
+// Render returns the notification title and body for alert.
+func (t *Templates) Render(alert *Alert) (title, body string, err error) {
+	var sb strings.Builder
+	if err := t.title.Execute(&sb, alert); err != nil {
+		return "", "", fmt.Errorf("rendering title: %w", err)
+	}
+	// Titles end up in HTTP and mail headers, which cannot span lines.
+	title = strings.Join(strings.Fields(sb.String()), " ")
+
+	sb.Reset()
+	if err := t.body.Execute(&sb, alert); err != nil {
+		return "", "", fmt.Errorf("rendering body: %w", err)
+	}
+	return title, sb.String(), nil
+}
+
+// checkResponse returns an error for HTTP responses other than 2xx.
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("status %d: %w", resp.StatusCode, ErrSinkRejected)
+	}
+	return nil
+}
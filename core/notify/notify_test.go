@@ -0,0 +1,166 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type NotifyTestSuite struct {
+	suite.Suite
+
+	server *httptest.Server
+	// requests are the requests received by the server, with their bodies.
+	requests chan request
+	status   int
+}
+
+type request struct {
+	path   string
+	header http.Header
+	body   []byte
+}
+
+func TestNotifyTestSuite(t *testing.T) {
+	suite.Run(t, new(NotifyTestSuite))
+}
+
+func (s *NotifyTestSuite) SetupTest() {
+	s.requests = make(chan request, 10)
+	s.status = http.StatusOK
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		s.NoError(err)
+		s.Equal(http.MethodPost, r.Method)
+		s.requests <- request{path: r.URL.Path, header: r.Header, body: body}
+		w.WriteHeader(s.status)
+	}))
+	s.T().Cleanup(s.server.Close)
+}
+
+func testAlert() *Alert {
+	return &Alert{
+		Hostname: "nas",
+		Key:      "drive:WD123:failed",
+		Source:   "drive",
+		Severity: "critical",
+		Title:    "Bay 2 FAILED",
+		Message:  "Bay 2 (WDC WD40EFRX, serial WD123) reports SMART overall health FAILED",
+		Since:    time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func (s *NotifyTestSuite) send(opts Options, alert *Alert) (request, error) {
+	sink, err := New(opts)
+	s.Require().NoError(err)
+	s.Equal(opts.Name, sink.Name())
+
+	err = sink.Send(context.Background(), alert)
+	select {
+	case req := <-s.requests:
+		return req, err
+	default:
+		return request{}, err
+	}
+}
+
+func (s *NotifyTestSuite) TestWebhook() {
+	opts := Options{Name: "hook", Type: "webhook", URL: s.server.URL + "/hook", Token: "secret"}
+	req, err := s.send(opts, testAlert())
+	s.Require().NoError(err)
+
+	s.Equal("/hook", req.path)
+	s.Equal("Bearer secret", req.header.Get("Authorization"))
+	s.Equal("application/json", req.header.Get("Content-Type"))
+
+	var payload map[string]any
+	s.Require().NoError(json.Unmarshal(req.body, &payload))
+	s.Equal("drive:WD123:failed", payload["key"])
+	s.Equal("critical", payload["severity"])
+	s.Equal(false, payload["resolved"])
+	s.Equal("[nas] Bay 2 FAILED", payload["title"])
+	s.Equal(testAlert().Message, payload["text"])
+}
+
+func (s *NotifyTestSuite) TestWebhookRejected() {
+	s.status = http.StatusForbidden
+	_, err := s.send(Options{Name: "hook", Type: "webhook", URL: s.server.URL}, testAlert())
+	s.ErrorIs(err, ErrSinkRejected)
+}
+
+func (s *NotifyTestSuite) TestNtfy() {
+	opts := Options{Name: "phone", Type: "ntfy", URL: s.server.URL + "/my-nas"}
+	req, err := s.send(opts, testAlert())
+	s.Require().NoError(err)
+
+	s.Equal("/my-nas", req.path)
+	s.Equal("[nas] Bay 2 FAILED", req.header.Get("Title"))
+	s.Equal("urgent", req.header.Get("Priority"))
+	s.Equal("rotating_light,drive", req.header.Get("Tags"))
+	s.Empty(req.header.Get("Authorization"))
+	s.Equal(testAlert().Message, string(req.body))
+
+	resolved := testAlert()
+	resolved.Resolved = true
+	resolved.Duration = 90 * time.Minute
+	req, err = s.send(opts, resolved)
+	s.Require().NoError(err)
+	s.Equal("[nas] Resolved: Bay 2 FAILED", req.header.Get("Title"))
+	s.Equal("low", req.header.Get("Priority"))
+	s.Contains(string(req.body), "Resolved after 1h30m0s.")
+}
+
+func (s *NotifyTestSuite) TestGotify() {
+	warning := testAlert()
+	warning.Severity = "warning"
+	req, err := s.send(Options{Name: "gotify", Type: "gotify", URL: s.server.URL + "/", Token: "app-token"}, warning)
+	s.Require().NoError(err)
+
+	s.Equal("/message", req.path)
+	s.Equal("app-token", req.header.Get("X-Gotify-Key"))
+
+	var payload map[string]any
+	s.Require().NoError(json.Unmarshal(req.body, &payload))
+	s.Equal("[nas] Bay 2 FAILED", payload["title"])
+	s.InDelta(gotifyPriorityWarning, payload["priority"], 0)
+}
+
+func (s *NotifyTestSuite) TestTemplates() {
+	opts := Options{
+		Name:          "phone",
+		Type:          "ntfy",
+		URL:           s.server.URL,
+		TitleTemplate: "{{.Severity}}:\n{{.Source}}",
+		BodyTemplate:  "{{.Key}} since {{.Since.Format \"15:04\"}}",
+	}
+	req, err := s.send(opts, testAlert())
+	s.Require().NoError(err)
+	// Line breaks are removed from titles.
+	s.Equal("critical: drive", req.header.Get("Title"))
+	s.Equal("drive:WD123:failed since 12:00", string(req.body))
+}
+
+func (s *NotifyTestSuite) TestNewErrors() {
+	_, err := New(Options{Name: "x", Type: "pager", URL: "http://localhost"})
+	s.ErrorIs(err, ErrUnknownSinkType)
+
+	_, err = New(Options{Name: "x", Type: "ntfy"})
+	s.ErrorIs(err, ErrMissingOption)
+
+	_, err = New(Options{Name: "x", Type: "smtp", Host: "mail.example.com"})
+	s.ErrorIs(err, ErrMissingOption)
+
+	_, err = New(Options{Name: "x", Type: "webhook", URL: "http://localhost", TitleTemplate: "{{.Title"})
+	s.ErrorContains(err, "title template")
+
+	templates, err := NewTemplates("{{.Nope}}", "")
+	s.Require().NoError(err)
+	_, _, err = templates.Render(testAlert())
+	s.ErrorContains(err, "rendering title")
+}
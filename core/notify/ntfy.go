@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// ntfySink publishes to an ntfy topic (https://ntfy.sh): the body is the
+// message, and title, priority and tags are set with headers.
+type ntfySink struct {
+	name      string
+	url       string // server and topic, e.g. https://ntfy.sh/my-nas
+	token     string
+	client    *http.Client
+	templates *Templates
+}
+
+func (s *ntfySink) Name() string {
+	return s.name
+}
+
+func (s *ntfySink) Send(ctx context.Context, alert *Alert) error {
+	title, body, err := s.templates.Render(alert)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", title)
+	// Tags with emoji names are shown as icons in the ntfy apps.
+	switch {
+	case alert.Resolved:
+		req.Header.Set("Priority", "low")
+		req.Header.Set("Tags", "white_check_mark,"+alert.Source)
+	case alert.Critical():
+		req.Header.Set("Priority", "urgent")
+		req.Header.Set("Tags", "rotating_light,"+alert.Source)
+	default:
+		req.Header.Set("Priority", "high")
+		req.Header.Set("Tags", "warning,"+alert.Source)
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	return post(s.client, req)
+}
@@ -0,0 +1,164 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSMTPPort    = 587
+	defaultSMTPTLSPort = 465
+)
+
+// smtpSink sends each alert as a plain text email.
+type smtpSink struct {
+	name      string
+	host      string
+	addr      string
+	username  string
+	password  string
+	from      string
+	to        []string
+	tls       bool
+	templates *Templates
+	tlsConfig *tls.Config
+}
+
+func newSMTPSink(opts Options, templates *Templates) *smtpSink {
+	port := opts.Port
+	if port == 0 {
+		port = defaultSMTPPort
+		if opts.TLS {
+			port = defaultSMTPTLSPort
+		}
+	}
+	return &smtpSink{
+		name:      opts.Name,
+		host:      opts.Host,
+		addr:      net.JoinHostPort(opts.Host, strconv.Itoa(port)),
+		username:  opts.Username,
+		password:  opts.Password,
+		from:      opts.From,
+		to:        opts.To,
+		tls:       opts.TLS,
+		templates: templates,
+		tlsConfig: &tls.Config{ServerName: opts.Host, MinVersion: tls.VersionTLS12},
+	}
+}
+
+func (s *smtpSink) Name() string {
+	return s.name
+}
+
+func (s *smtpSink) Send(ctx context.Context, alert *Alert) error {
+	title, body, err := s.templates.Render(alert)
+	if err != nil {
+		return err
+	}
+	message, err := s.message(title, body, time.Now())
+	if err != nil {
+		return err
+	}
+
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("connecting to %s: %w", s.addr, err)
+	}
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if !s.tls {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(s.tlsConfig); err != nil {
+				return fmt.Errorf("starting TLS: %w", err)
+			}
+		}
+	}
+	if s.username != "" {
+		// PlainAuth refuses to send the password unencrypted, except to localhost.
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return fmt.Errorf("authenticating: %w", err)
+		}
+	}
+
+	if err := client.Mail(s.from); err != nil {
+		return err
+	}
+	for _, to := range s.to {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("recipient %s: %w", to, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// dial connects to the server, with implicit TLS if configured. The
+// connection's deadline is taken from ctx, since net/smtp has no timeouts.
+func (s *smtpSink) dial(ctx context.Context) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	var conn net.Conn
+	var err error
+	if s.tls {
+		dialer := &tls.Dialer{Config: s.tlsConfig}
+		conn, err = dialer.DialContext(ctx, "tcp", s.addr)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", s.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// message builds a UTF-8 plain text email.
+func (s *smtpSink) message(subject, body string, date time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\n", s.from)
+	fmt.Fprintf(&buf, "To: %s\n", strings.Join(s.to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\n\n")
+
+	w := quotedprintable.NewWriter(&buf)
+	if _, err := w.Write([]byte(body)); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
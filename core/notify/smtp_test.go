@@ -0,0 +1,169 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// smtpMessage is a message received by the fake SMTP server.
+type smtpMessage struct {
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+type SMTPTestSuite struct {
+	suite.Suite
+
+	listener net.Listener
+	messages chan smtpMessage
+}
+
+func TestSMTPTestSuite(t *testing.T) {
+	suite.Run(t, new(SMTPTestSuite))
+}
+
+// SetupTest starts a minimal SMTP server on a local port that accepts
+// AUTH PLAIN and any sender and recipients.
+func (s *SMTPTestSuite) SetupTest() {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	s.Require().NoError(err)
+	s.listener = listener
+	s.T().Cleanup(func() { listener.Close() })
+	s.messages = make(chan smtpMessage, 10)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+}
+
+func (s *SMTPTestSuite) serve(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+	reply("220 localhost ESMTP fake")
+
+	var msg smtpMessage
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			_, credentials, _ := strings.Cut(arg, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(credentials)
+			msg.auth = string(decoded)
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			msg.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			reply("250 OK")
+		case "RCPT":
+			msg.to = append(msg.to, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil || line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			msg.data = data.String()
+			s.messages <- msg
+			reply("250 OK queued")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func (s *SMTPTestSuite) TestSend() {
+	_, port, err := net.SplitHostPort(s.listener.Addr().String())
+	s.Require().NoError(err)
+	portNum, err := strconv.Atoi(port)
+	s.Require().NoError(err)
+
+	sink, err := New(Options{
+		Name:     "mail",
+		Type:     "smtp",
+		Host:     "127.0.0.1",
+		Port:     portNum,
+		Username: "lumeon",
+		Password: "hunter2",
+		From:     "nas@example.com",
+		To:       []string{"admin@example.com", "backup@example.com"},
+	})
+	s.Require().NoError(err)
+
+	alert := testAlert()
+	alert.Title = "Pool tank degraded – résumé"
+	s.Require().NoError(sink.Send(context.Background(), alert))
+
+	var msg smtpMessage
+	select {
+	case msg = <-s.messages:
+	case <-time.After(time.Second):
+		s.FailNow("no message received")
+	}
+
+	s.Equal("\x00lumeon\x00hunter2", msg.auth)
+	s.Equal("nas@example.com", msg.from)
+	s.Equal([]string{"admin@example.com", "backup@example.com"}, msg.to)
+
+	parsed, err := mail.ReadMessage(strings.NewReader(msg.data))
+	s.Require().NoError(err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	s.Require().NoError(err)
+	s.Equal("[nas] Pool tank degraded – résumé", subject)
+	s.Equal("admin@example.com, backup@example.com", parsed.Header.Get("To"))
+	s.Equal("quoted-printable", parsed.Header.Get("Content-Transfer-Encoding"))
+	body, err := io.ReadAll(quotedprintable.NewReader(parsed.Body))
+	s.Require().NoError(err)
+	// The DATA terminator adds a final line break.
+	s.Equal(alert.Message+"\r\n", string(body))
+}
+
+func (s *SMTPTestSuite) TestSendUnreachable() {
+	address := s.listener.Addr().String()
+	s.listener.Close()
+	host, port, err := net.SplitHostPort(address)
+	s.Require().NoError(err)
+	portNum, err := strconv.Atoi(port)
+	s.Require().NoError(err)
+
+	sink, err := New(Options{Name: "mail", Type: "smtp", Host: host, Port: portNum, From: "a@b", To: []string{"c@d"}})
+	s.Require().NoError(err)
+	s.Error(sink.Send(context.Background(), testAlert()))
+}
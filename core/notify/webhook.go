@@ -0,0 +1,81 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// webhookPayload is the JSON body posted to generic webhooks.
+type webhookPayload struct {
+	Hostname string    `json:"hostname"`
+	Key      string    `json:"key"`
+	Source   string    `json:"source"`
+	Severity string    `json:"severity"`
+	Resolved bool      `json:"resolved"`
+	Since    time.Time `json:"since"`
+	// Duration is how long the alert was active, in seconds; 0 unless resolved.
+	Duration float64 `json:"duration"`
+	Title    string  `json:"title"`
+	Message  string  `json:"message"`
+	// Text is the rendered body template.
+	Text string `json:"text"`
+}
+
+// webhookSink posts every alert as JSON, for home automation and chat bridges.
+type webhookSink struct {
+	name      string
+	url       string
+	token     string
+	client    *http.Client
+	templates *Templates
+}
+
+func (s *webhookSink) Name() string {
+	return s.name
+}
+
+func (s *webhookSink) Send(ctx context.Context, alert *Alert) error {
+	title, body, err := s.templates.Render(alert)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(webhookPayload{
+		Hostname: alert.Hostname,
+		Key:      alert.Key,
+		Source:   alert.Source,
+		Severity: alert.Severity,
+		Resolved: alert.Resolved,
+		Since:    alert.Since,
+		Duration: alert.Duration.Seconds(),
+		Title:    title,
+		Message:  alert.Message,
+		Text:     body,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	return post(s.client, req)
+}
+
+// post sends req and discards the response body.
+func post(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting to %s: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp)
+}
@@ -0,0 +1,242 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/czechbol/lumeon/core/resources/dto"
+)
+
+// containerRequestTimeout bounds each API request, so a hung daemon does not
+// stall the sampler.
+const containerRequestTimeout = 5 * time.Second
+
+// containerSockets are tried in order when no socket is configured: Docker,
+// then rootful Podman.
+var containerSockets = []string{"/var/run/docker.sock", "/run/podman/podman.sock"}
+
+// ContainerStats is the state of one container. Usage fields are zero for
+// containers that are not running.
+type ContainerStats struct {
+	ID           string
+	Name         string
+	Image        string
+	State        string // created, running, paused, restarting, exited, dead
+	Health       string // healthy, unhealthy, starting; empty without a healthcheck
+	RestartCount int
+	CPUPercent   float64 // percent of one CPU, like docker stats
+	MemoryUsage  uint64  // bytes, page cache excluded
+	MemoryLimit  uint64  // bytes; the host's memory when the container is unlimited
+}
+
+// Running reports whether the container's processes are running.
+func (c *ContainerStats) Running() bool {
+	return c.State == "running"
+}
+
+// Unhealthy reports whether the container's healthcheck is failing.
+func (c *ContainerStats) Unhealthy() bool {
+	return c.Health == "unhealthy"
+}
+
+type Containers interface {
+	// GetStats returns every container, running or not, sorted by name. It
+	// returns no containers and no error when no container runtime is installed.
+	GetStats(ctx context.Context) ([]ContainerStats, error)
+}
+
+// containerCPU is a container's cumulative CPU counters from its last stats call.
+type containerCPU struct {
+	total  uint64
+	system uint64
+}
+
+type containersImpl struct {
+	socket string
+	client *http.Client
+
+	mu      sync.Mutex
+	prevCPU map[string]containerCPU
+}
+
+// NewContainers creates a prober for the Docker Engine API on socket. Podman
+// serves the same API on its compatibility socket. When socket is empty the
+// default Docker and Podman sockets are tried.
+func NewContainers(socket string) Containers {
+	c := &containersImpl{
+		socket:  socket,
+		prevCPU: make(map[string]containerCPU),
+	}
+	c.client = &http.Client{
+		Timeout: containerRequestTimeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", c.socketPath())
+			},
+		},
+	}
+	return c
+}
+
+// socketPath returns the configured socket, or the first default socket that exists.
+func (c *containersImpl) socketPath() string {
+	if c.socket != "" {
+		return c.socket
+	}
+	for _, socket := range containerSockets {
+		if _, err := os.Stat(socket); err == nil {
+			return socket
+		}
+	}
+	return ""
+}
+
+func (c *containersImpl) GetStats(ctx context.Context) ([]ContainerStats, error) {
+	socket := c.socketPath()
+	if socket == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(socket); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("container socket %s: %w", socket, err)
+	}
+
+	var list []dto.DockerContainer
+	if err := c.get(ctx, "/containers/json?all=true", &list); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := make([]ContainerStats, 0, len(list))
+	current := make(map[string]containerCPU)
+	for _, container := range list {
+		stat := ContainerStats{
+			ID:    container.ID,
+			Name:  containerName(container),
+			Image: container.Image,
+			State: container.State,
+		}
+
+		var inspect dto.DockerContainerInspect
+		if err := c.get(ctx, "/containers/"+url.PathEscape(container.ID)+"/json", &inspect); err != nil {
+			// The container may have been removed since it was listed.
+			slog.Warn("error inspecting container", "container", stat.Name, "error", err)
+		} else {
+			stat.RestartCount = inspect.RestartCount
+			if inspect.State.Health != nil {
+				stat.Health = inspect.State.Health.Status
+			}
+		}
+
+		if stat.Running() {
+			if cpu, ok := c.setContainerUsage(ctx, &stat); ok {
+				current[container.ID] = cpu
+			}
+		}
+		stats = append(stats, stat)
+	}
+	c.prevCPU = current
+
+	slices.SortFunc(stats, func(a, b ContainerStats) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return stats, nil
+}
+
+// setContainerUsage fills the CPU and memory usage of stat. CPU usage is
+// computed from the counters of the previous call, so one-shot stats can be
+// used instead of waiting a second per container for the daemon's own sample.
+func (c *containersImpl) setContainerUsage(ctx context.Context, stat *ContainerStats) (containerCPU, bool) {
+	var usage dto.DockerContainerStats
+	path := "/containers/" + url.PathEscape(stat.ID) + "/stats?stream=false&one-shot=true"
+	if err := c.get(ctx, path, &usage); err != nil {
+		slog.Warn("error getting container stats", "container", stat.Name, "error", err)
+		return containerCPU{}, false
+	}
+
+	cpu := containerCPU{
+		total:  usage.CPUStats.CPUUsage.TotalUsage,
+		system: usage.CPUStats.SystemCPUUsage,
+	}
+	if prev, ok := c.prevCPU[stat.ID]; ok {
+		stat.CPUPercent = containerCPUPercent(prev, cpu, usage.CPUStats.OnlineCPUs)
+	}
+
+	stat.MemoryUsage = containerMemoryUsage(usage.MemoryStats.Usage, usage.MemoryStats.Stats)
+	stat.MemoryLimit = usage.MemoryStats.Limit
+	return cpu, true
+}
+
+// get decodes the JSON response of an API GET request into v.
+func (c *containersImpl) get(ctx context.Context, path string, v any) error {
+	ctx, cancel := context.WithTimeout(ctx, containerRequestTimeout)
+	defer cancel()
+
+	// The host is ignored; requests are dialed to the socket.
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost"+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("requesting %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("requesting %s: status %d: %w", path, resp.StatusCode, ErrContainerAPI)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding %s: %w", path, err)
+	}
+	return nil
+}
+
+// containerName returns the container's name without the leading slash the API adds.
+func containerName(container dto.DockerContainer) string {
+	if len(container.Names) == 0 {
+		return container.ID[:min(len(container.ID), 12)]
+	}
+	return strings.TrimPrefix(container.Names[0], "/")
+}
+
+// containerCPUPercent computes CPU usage between two stats calls the way
+// docker stats does: the container's share of host CPU time, scaled to the
+// number of CPUs so that one busy core is 100%.
+func containerCPUPercent(prev, curr containerCPU, onlineCPUs int) float64 {
+	cpuDelta := counterDelta(prev.total, curr.total)
+	systemDelta := counterDelta(prev.system, curr.system)
+	if cpuDelta == 0 || systemDelta == 0 {
+		return 0
+	}
+	return float64(cpuDelta) / float64(systemDelta) * float64(max(onlineCPUs, 1)) * 100
+}
+
+// containerMemoryUsage subtracts the page cache from usage like docker stats:
+// inactive_file on cgroup v2, total_inactive_file or cache on cgroup v1.
+func containerMemoryUsage(usage uint64, stats map[string]uint64) uint64 {
+	for _, key := range []string{"inactive_file", "total_inactive_file", "cache"} {
+		if cache, ok := stats[key]; ok {
+			if cache < usage {
+				return usage - cache
+			}
+			return usage
+		}
+	}
+	return usage
+}
@@ -0,0 +1,156 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ContainerTestSuite struct {
+	suite.Suite
+
+	socket string
+	server *httptest.Server
+	// cpuTotal is the CPU counter the fake daemon reports for "web".
+	cpuTotal atomic.Uint64
+	// systemTotal is the host CPU counter it reports.
+	systemTotal atomic.Uint64
+}
+
+func TestContainerTestSuite(t *testing.T) {
+	suite.Run(t, new(ContainerTestSuite))
+}
+
+// SetupTest starts a fake Docker Engine API on a Unix socket with a running
+// healthy container, an unhealthy one and a stopped one.
+func (s *ContainerTestSuite) SetupTest() {
+	s.socket = filepath.Join(s.T().TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", s.socket)
+	s.Require().NoError(err)
+
+	s.cpuTotal.Store(1_000_000_000)
+	s.systemTotal.Store(100_000_000_000)
+
+	reply := func(w http.ResponseWriter, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		s.NoError(json.NewEncoder(w).Encode(v))
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /containers/json", func(w http.ResponseWriter, r *http.Request) {
+		s.Equal("true", r.URL.Query().Get("all"))
+		reply(w, []map[string]any{
+			{"Id": "bbb", "Names": []string{"/web"}, "Image": "nginx", "State": "running"},
+			{"Id": "aaa", "Names": []string{"/app"}, "Image": "app:1", "State": "running"},
+			{"Id": "ccc", "Names": []string{"/backup"}, "Image": "restic", "State": "exited"},
+		})
+	})
+	mux.HandleFunc("GET /containers/{id}/json", func(w http.ResponseWriter, r *http.Request) {
+		switch r.PathValue("id") {
+		case "aaa":
+			reply(w, map[string]any{
+				"RestartCount": 3,
+				"State":        map[string]any{"Status": "running", "Health": map[string]any{"Status": "unhealthy"}},
+			})
+		case "bbb":
+			reply(w, map[string]any{
+				"RestartCount": 0,
+				"State":        map[string]any{"Status": "running", "Health": map[string]any{"Status": "healthy"}},
+			})
+		case "ccc":
+			reply(w, map[string]any{"RestartCount": 0, "State": map[string]any{"Status": "exited"}})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	mux.HandleFunc("GET /containers/{id}/stats", func(w http.ResponseWriter, r *http.Request) {
+		s.Equal("false", r.URL.Query().Get("stream"))
+		total := uint64(0)
+		if r.PathValue("id") == "bbb" {
+			total = s.cpuTotal.Load()
+		}
+		reply(w, map[string]any{
+			"cpu_stats": map[string]any{
+				"cpu_usage":        map[string]any{"total_usage": total},
+				"system_cpu_usage": s.systemTotal.Load(),
+				"online_cpus":      4,
+			},
+			"memory_stats": map[string]any{
+				"usage": 300 << 20,
+				"limit": 8 << 30,
+				"stats": map[string]any{"inactive_file": 100 << 20},
+			},
+		})
+	})
+
+	s.server = httptest.NewUnstartedServer(mux)
+	s.server.Listener = listener
+	s.server.Start()
+	s.T().Cleanup(s.server.Close)
+}
+
+func (s *ContainerTestSuite) TestGetStats() {
+	containers := NewContainers(s.socket)
+
+	stats, err := containers.GetStats(context.Background())
+	s.Require().NoError(err)
+	s.Require().Len(stats, 3)
+
+	// Sorted by name, with the API's leading slash removed.
+	s.Equal("app", stats[0].Name)
+	s.Equal("backup", stats[1].Name)
+	s.Equal("web", stats[2].Name)
+
+	s.True(stats[0].Unhealthy())
+	s.Equal(3, stats[0].RestartCount)
+	s.Equal("healthy", stats[2].Health)
+
+	s.False(stats[1].Running())
+	s.Zero(stats[1].MemoryUsage)
+
+	// Page cache is not counted as used memory.
+	s.Equal(uint64(200<<20), stats[2].MemoryUsage)
+	s.Equal(uint64(8<<30), stats[2].MemoryLimit)
+	// CPU usage needs two samples.
+	s.Zero(stats[2].CPUPercent)
+
+	// 0.5s of container CPU time over 10s of host time on 4 CPUs: 20% of one CPU.
+	s.cpuTotal.Add(500_000_000)
+	s.systemTotal.Add(10_000_000_000)
+
+	stats, err = containers.GetStats(context.Background())
+	s.Require().NoError(err)
+	s.InDelta(20.0, stats[2].CPUPercent, 0.001)
+	s.Zero(stats[0].CPUPercent)
+}
+
+func (s *ContainerTestSuite) TestGetStatsWithoutRuntime() {
+	containers := NewContainers(filepath.Join(s.T().TempDir(), "missing.sock"))
+
+	stats, err := containers.GetStats(context.Background())
+	s.NoError(err)
+	s.Empty(stats)
+}
+
+func (s *ContainerTestSuite) TestGetStatsAPIError() {
+	s.server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "server error", http.StatusInternalServerError)
+	})
+
+	_, err := NewContainers(s.socket).GetStats(context.Background())
+	s.ErrorIs(err, ErrContainerAPI)
+}
+
+func (s *ContainerTestSuite) TestContainerMemoryUsage() {
+	// cgroup v1 reports the page cache as "cache".
+	s.Equal(uint64(70), containerMemoryUsage(100, map[string]uint64{"cache": 30}))
+	s.Equal(uint64(100), containerMemoryUsage(100, nil))
+	s.Equal(uint64(100), containerMemoryUsage(100, map[string]uint64{"inactive_file": 150}))
+}
@@ -5,6 +5,7 @@ package resources
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
@@ -12,7 +13,6 @@ import (
 	"strconv"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/shirou/gopsutil/cpu"
 )
@@ -21,7 +21,7 @@ const (
 	thermalZonePath = "/sys/class/thermal"
 	procStatPath    = "/proc/stat"
 	procCPUInfo     = "/proc/cpuinfo"
-	cpuCacheTTL     = 30 * time.Second
+	cpuFreqPath     = "/sys/devices/system/cpu"
 )
 
 type CPUStats struct {
@@ -29,37 +29,40 @@ type CPUStats struct {
 	AvgTemperature float64
 	CoreCount      int
 	Cores          []CoreStats
+	// Throttle is the Raspberry Pi firmware throttling state; it is only
+	// meaningful when ThrottleKnown is set.
+	Throttle      ThrottleFlags
+	ThrottleKnown bool
 }
 
 type CoreStats struct {
 	ID           int
 	UsagePercent float64
 	MaxFrequency float64 // MHz
+	CurFrequency float64 // MHz, 0 if cpufreq is not available
+	Governor     string  // cpufreq scaling governor, empty if not available
 }
 
 type CPU interface {
-	GetAverageTemp() (float64, error)
-	GetStats() (*CPUStats, error)
-	// Poll starts a background goroutine that continuously refreshes the
-	// CPU stats cache so GetStats always returns quickly from cache.
-	// The goroutine stops when ctx is cancelled.
-	Poll(ctx context.Context)
+	GetAverageTemp(ctx context.Context) (float64, error)
+	GetStats(ctx context.Context) (*CPUStats, error)
 }
 
 type cpuImpl struct {
-	mu          sync.RWMutex
-	cachedStats *CPUStats
-	cacheTime   time.Time
-	cacheTTL    time.Duration
+	mu        sync.Mutex
+	prevCores []cpu.TimesStat
+	prevTotal *cpu.TimesStat
+
+	// throttleUnavailable is set once the firmware turned out not to report
+	// throttling, so it is not asked again on every sample.
+	throttleUnavailable bool
 }
 
 func NewCPU() CPU {
-	return &cpuImpl{
-		cacheTTL: cpuCacheTTL,
-	}
+	return &cpuImpl{}
 }
 
-func (c *cpuImpl) GetAverageTemp() (float64, error) {
+func (c *cpuImpl) GetAverageTemp(context.Context) (float64, error) {
 	temps, err := c.getAllTemps()
 	if err != nil {
 		return 0, fmt.Errorf("error getting temperatures: %w", err)
@@ -122,87 +125,120 @@ func readTemperature(path string) (float64, error) {
 	return temp / 1000.0, nil
 }
 
-// Poll starts a goroutine that continuously measures CPU stats and keeps the
-// cache fresh. Each measurement takes ~5 s (cpu.Percent sampling window), so
-// the goroutine naturally throttles itself without an explicit sleep.
-func (c *cpuImpl) Poll(ctx context.Context) {
-	go func() {
-		for {
-			c.mu.RLock()
-			fresh := c.cachedStats != nil && time.Since(c.cacheTime) < c.cacheTTL
-			c.mu.RUnlock()
-
-			if !fresh {
-				if _, err := c.measure(); err != nil {
-					slog.Warn("cpu poller: measurement failed", "error", err)
-				}
-			}
-
-			select {
-			case <-ctx.Done():
-				return
-			case <-time.After(c.cacheTTL / 2):
-			}
-		}
-	}()
-}
-
-// measure takes a fresh CPU sample, updates the cache, and returns the result.
-func (c *cpuImpl) measure() (*CPUStats, error) {
-	percentages, err := cpu.Percent(time.Second*5, true)
+// GetStats returns CPU usage since the previous call together with the
+// current temperature. It does not block: usage is computed from the
+// difference between two /proc/stat samples, so the first call reports 0%.
+func (c *cpuImpl) GetStats(ctx context.Context) (*CPUStats, error) {
+	perCore, err := cpu.Times(true)
 	if err != nil {
 		return nil, err
 	}
-
-	info, err := cpu.Info()
+	total, err := cpu.Times(false)
 	if err != nil {
 		return nil, err
 	}
-
-	var cores []CoreStats
-	for i, percent := range percentages {
-		cores = append(cores, CoreStats{
-			ID:           i,
-			UsagePercent: percent,
-			MaxFrequency: info[i].Mhz,
-		})
+	if len(total) == 0 {
+		return nil, ErrNoCPUTimes
 	}
 
-	avgPercent, err := cpu.Percent(0, false)
+	info, err := cpu.Info()
 	if err != nil {
 		return nil, err
 	}
 
-	avgTemp, err := c.GetAverageTemp()
+	avgTemp, err := c.GetAverageTemp(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	result := &CPUStats{
-		UsagePercent:   avgPercent[0],
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cores := make([]CoreStats, 0, len(perCore))
+	for i, times := range perCore {
+		core := CoreStats{ID: i}
+		if i < len(info) {
+			core.MaxFrequency = info[i].Mhz
+		}
+		core.CurFrequency, core.Governor = readCoreFrequency(i)
+		if i < len(c.prevCores) {
+			core.UsagePercent = cpuBusyPercent(c.prevCores[i], times)
+		}
+		cores = append(cores, core)
+	}
+
+	var usage float64
+	if c.prevTotal != nil {
+		usage = cpuBusyPercent(*c.prevTotal, total[0])
+	}
+
+	c.prevCores = perCore
+	c.prevTotal = &total[0]
+
+	stats := &CPUStats{
+		UsagePercent:   usage,
 		AvgTemperature: avgTemp,
 		CoreCount:      len(cores),
 		Cores:          cores,
 	}
+	c.setThrottle(ctx, stats)
 
-	c.mu.Lock()
-	c.cachedStats = result
-	c.cacheTime = time.Now()
-	c.mu.Unlock()
+	return stats, nil
+}
 
-	stats := *result
-	return &stats, nil
+// setThrottle fills the throttling state of stats. Failing to read it is
+// logged rather than returned so usage and temperature are still reported.
+func (c *cpuImpl) setThrottle(ctx context.Context, stats *CPUStats) {
+	if c.throttleUnavailable {
+		return
+	}
+	flags, err := readThrottled(ctx)
+	if errors.Is(err, ErrThrottleUnavailable) {
+		slog.Debug("firmware throttling state not available", "error", err)
+		c.throttleUnavailable = true
+		return
+	}
+	if err != nil {
+		slog.Warn("error reading throttling state", "error", err)
+		return
+	}
+	stats.Throttle = flags
+	stats.ThrottleKnown = true
 }
 
-// GetStats returns comprehensive CPU statistics, using the cache when fresh.
-func (c *cpuImpl) GetStats() (*CPUStats, error) {
-	c.mu.RLock()
-	if c.cachedStats != nil && time.Since(c.cacheTime) < c.cacheTTL {
-		stats := *c.cachedStats
-		c.mu.RUnlock()
-		return &stats, nil
+// readCoreFrequency returns the current frequency in MHz and the scaling
+// governor of a core from cpufreq. Both are zero values without cpufreq.
+func readCoreFrequency(core int) (float64, string) {
+	dir := filepath.Join(cpuFreqPath, fmt.Sprintf("cpu%d", core), "cpufreq")
+
+	var freq float64
+	if data, err := os.ReadFile(filepath.Join(dir, "scaling_cur_freq")); err == nil {
+		if khz, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64); err == nil {
+			freq = khz / 1000
+		}
+	}
+
+	var governor string
+	if data, err := os.ReadFile(filepath.Join(dir, "scaling_governor")); err == nil {
+		governor = strings.TrimSpace(string(data))
 	}
-	c.mu.RUnlock()
 
-	return c.measure()
+	return freq, governor
+}
+
+// cpuBusyPercent returns the busy share of CPU time between two samples,
+// counting iowait as busy like gopsutil's cpu.Percent.
+func cpuBusyPercent(prev, curr cpu.TimesStat) float64 {
+	busy := func(t cpu.TimesStat) float64 {
+		return t.User + t.System + t.Nice + t.Iowait + t.Irq + t.Softirq + t.Steal
+	}
+	busyDelta := busy(curr) - busy(prev)
+	allDelta := busy(curr) + curr.Idle - busy(prev) - prev.Idle
+	if busyDelta <= 0 {
+		return 0
+	}
+	if allDelta <= 0 {
+		return 100
+	}
+	return min(100, 100*busyDelta/allDelta)
 }
@@ -0,0 +1,61 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/shirou/gopsutil/cpu"
+	"github.com/stretchr/testify/suite"
+)
+
+type CPUTestSuite struct {
+	suite.Suite
+}
+
+func TestCPUTestSuite(t *testing.T) {
+	suite.Run(t, new(CPUTestSuite))
+}
+
+func (s *CPUTestSuite) TestCPUBusyPercent() {
+	prev := cpu.TimesStat{User: 100, System: 50, Idle: 850}
+	curr := cpu.TimesStat{User: 130, System: 60, Iowait: 10, Idle: 900}
+
+	// 50 busy (iowait included) out of 100 elapsed.
+	s.InDelta(50.0, cpuBusyPercent(prev, curr), 0.001)
+}
+
+func (s *CPUTestSuite) TestCPUBusyPercentIdle() {
+	prev := cpu.TimesStat{User: 100, Idle: 850}
+	curr := cpu.TimesStat{User: 100, Idle: 950}
+
+	s.Zero(cpuBusyPercent(prev, curr))
+	// Counters going backwards (e.g. CPU hot-unplugged) never yield a negative value.
+	s.Zero(cpuBusyPercent(curr, prev))
+}
+
+func (s *CPUTestSuite) TestParseThrottledOutput() {
+	flags, err := parseThrottledOutput("throttled=0x50005\n")
+	s.Require().NoError(err)
+	s.True(flags.Has(ThrottleUndervoltage))
+	s.True(flags.Has(ThrottleThrottled))
+	s.False(flags.Has(ThrottleFreqCapped))
+	s.True(flags.Has(ThrottleUndervoltageOccurred))
+	s.True(flags.Has(ThrottleThrottledOccurred))
+	s.True(flags.Active())
+
+	flags, err = parseThrottledOutput("throttled=0x0")
+	s.Require().NoError(err)
+	s.Zero(flags)
+	s.Equal("ok", flags.String())
+
+	_, err = parseThrottledOutput("error=1 error_msg=\"Command not registered\"")
+	s.ErrorIs(err, ErrThrottleUnavailable)
+	_, err = parseThrottledOutput("throttled=zzz")
+	s.Error(err)
+}
+
+func (s *CPUTestSuite) TestThrottleFlagsOccurredOnly() {
+	flags := ThrottleUndervoltageOccurred | ThrottleSoftTempLimitOccurred
+
+	s.False(flags.Active())
+	s.Equal("undervoltage occurred, soft temperature limit occurred", flags.String())
+}
@@ -0,0 +1,146 @@
+package resources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	vcioPath = "/dev/vcio"
+
+	// mailboxTagGetThrottled is the firmware property tag behind vcgencmd get_throttled.
+	mailboxTagGetThrottled = 0x00030046
+	// mailboxResponseOK is set in the request code word when the firmware processed the message.
+	mailboxResponseOK = 0x80000000
+
+	// ioctlReadWrite is the _IOC_READ|_IOC_WRITE direction of an ioctl request code.
+	ioctlReadWrite = 3
+	// vcioIoctlType is the ioctl type of the vcio driver's IOCTL_MBOX_PROPERTY.
+	vcioIoctlType = 100
+)
+
+// ThrottleFlags is the Raspberry Pi firmware's throttled bitmask as reported
+// by vcgencmd get_throttled. The low bits are current conditions; the high
+// bits record that the condition has occurred since boot.
+type ThrottleFlags uint32
+
+const (
+	ThrottleUndervoltage  ThrottleFlags = 1 << 0
+	ThrottleFreqCapped    ThrottleFlags = 1 << 1
+	ThrottleThrottled     ThrottleFlags = 1 << 2
+	ThrottleSoftTempLimit ThrottleFlags = 1 << 3
+
+	ThrottleUndervoltageOccurred  ThrottleFlags = 1 << 16
+	ThrottleFreqCappedOccurred    ThrottleFlags = 1 << 17
+	ThrottleThrottledOccurred     ThrottleFlags = 1 << 18
+	ThrottleSoftTempLimitOccurred ThrottleFlags = 1 << 19
+
+	throttleActiveMask = ThrottleUndervoltage | ThrottleFreqCapped | ThrottleThrottled | ThrottleSoftTempLimit
+)
+
+var throttleFlagNames = []struct {
+	flag ThrottleFlags
+	name string
+}{
+	{ThrottleUndervoltage, "undervoltage"},
+	{ThrottleFreqCapped, "frequency capped"},
+	{ThrottleThrottled, "throttled"},
+	{ThrottleSoftTempLimit, "soft temperature limit"},
+	{ThrottleUndervoltageOccurred, "undervoltage occurred"},
+	{ThrottleFreqCappedOccurred, "frequency capping occurred"},
+	{ThrottleThrottledOccurred, "throttling occurred"},
+	{ThrottleSoftTempLimitOccurred, "soft temperature limit occurred"},
+}
+
+// Has reports whether every bit of flag is set.
+func (f ThrottleFlags) Has(flag ThrottleFlags) bool {
+	return f&flag == flag
+}
+
+// Active reports whether any throttling condition is in effect right now.
+func (f ThrottleFlags) Active() bool {
+	return f&throttleActiveMask != 0
+}
+
+func (f ThrottleFlags) String() string {
+	var names []string
+	for _, n := range throttleFlagNames {
+		if f.Has(n.flag) {
+			names = append(names, n.name)
+		}
+	}
+	if len(names) == 0 {
+		return "ok"
+	}
+	return strings.Join(names, ", ")
+}
+
+// readThrottled returns the firmware throttled bitmask, asking the VideoCore
+// mailbox directly and falling back to vcgencmd. It returns
+// ErrThrottleUnavailable on hardware without the firmware interface.
+func readThrottled(ctx context.Context) (ThrottleFlags, error) {
+	flags, err := readThrottledMailbox()
+	if err == nil {
+		return flags, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, fs.ErrPermission) {
+		return 0, err
+	}
+
+	if _, lookErr := exec.LookPath("vcgencmd"); lookErr != nil {
+		return 0, ErrThrottleUnavailable
+	}
+	output, err := exec.CommandContext(ctx, "vcgencmd", "get_throttled").Output()
+	if err != nil {
+		return 0, fmt.Errorf("executing vcgencmd: %w", err)
+	}
+	return parseThrottledOutput(string(output))
+}
+
+// readThrottledMailbox sends a get-throttled property message through /dev/vcio.
+func readThrottledMailbox() (ThrottleFlags, error) {
+	f, err := os.OpenFile(vcioPath, os.O_RDWR, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	// Property message: total size, request code, then one tag (id, value
+	// buffer size, request/response size, value) and the end tag.
+	msg := [7]uint32{7 * 4, 0, mailboxTagGetThrottled, 4, 0, 0, 0}
+
+	// IOCTL_MBOX_PROPERTY is _IOWR(100, 0, char *); the size field depends on the pointer width.
+	req := uintptr(ioctlReadWrite)<<30 | unsafe.Sizeof(uintptr(0))<<16 | vcioIoctlType<<8
+	// The pointer conversion must stay inside the Syscall call expression.
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, f.Fd(), req,
+		uintptr(unsafe.Pointer(&msg[0]))) //nolint:gosec // the firmware fills msg in place
+	if errno != 0 {
+		return 0, fmt.Errorf("mailbox ioctl: %w", errno)
+	}
+	if msg[1] != mailboxResponseOK {
+		return 0, fmt.Errorf("mailbox response 0x%x: %w", msg[1], ErrThrottleUnavailable)
+	}
+	return ThrottleFlags(msg[5]), nil
+}
+
+// parseThrottledOutput parses vcgencmd output such as "throttled=0x50005".
+func parseThrottledOutput(output string) (ThrottleFlags, error) {
+	value, ok := strings.CutPrefix(strings.TrimSpace(output), "throttled=")
+	if !ok {
+		return 0, fmt.Errorf("unexpected vcgencmd output %q: %w", output, ErrThrottleUnavailable)
+	}
+	flags, err := strconv.ParseUint(value, 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("parsing throttled value %q: %w", value, err)
+	}
+	return ThrottleFlags(flags), nil
+}
@@ -0,0 +1,70 @@
+package resources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type CronTestSuite struct {
+	suite.Suite
+}
+
+func TestCronTestSuite(t *testing.T) {
+	suite.Run(t, new(CronTestSuite))
+}
+
+func (s *CronTestSuite) TestEveryMinute() {
+	c, err := parseCron("* * * * *")
+	s.Require().NoError(err)
+
+	s.True(c.matches(time.Date(2026, 3, 14, 15, 9, 0, 0, time.Local)))
+}
+
+func (s *CronTestSuite) TestWeeklyOnSunday() {
+	c, err := parseCron("0 3 * * 7")
+	s.Require().NoError(err)
+
+	s.True(c.matches(time.Date(2026, 3, 15, 3, 0, 0, 0, time.Local)))  // Sunday
+	s.False(c.matches(time.Date(2026, 3, 15, 3, 1, 0, 0, time.Local))) // wrong minute
+	s.False(c.matches(time.Date(2026, 3, 16, 3, 0, 0, 0, time.Local))) // Monday
+}
+
+func (s *CronTestSuite) TestRangesListsAndSteps() {
+	c, err := parseCron("*/15 1-3,22 * 1-6/2 *")
+	s.Require().NoError(err)
+
+	s.True(c.matches(time.Date(2026, 1, 10, 22, 45, 0, 0, time.Local)))
+	s.True(c.matches(time.Date(2026, 5, 10, 2, 30, 0, 0, time.Local)))
+	s.False(c.matches(time.Date(2026, 2, 10, 2, 30, 0, 0, time.Local))) // even month
+	s.False(c.matches(time.Date(2026, 1, 10, 4, 0, 0, 0, time.Local)))  // hour outside list
+	s.False(c.matches(time.Date(2026, 1, 10, 2, 20, 0, 0, time.Local))) // minute off step
+}
+
+func (s *CronTestSuite) TestDayOfMonthOrDayOfWeek() {
+	// First day of the month or any Saturday.
+	c, err := parseCron("0 0 1 * 6")
+	s.Require().NoError(err)
+
+	s.True(c.matches(time.Date(2026, 4, 1, 0, 0, 0, 0, time.Local)))  // Wednesday the 1st
+	s.True(c.matches(time.Date(2026, 4, 4, 0, 0, 0, 0, time.Local)))  // Saturday the 4th
+	s.False(c.matches(time.Date(2026, 4, 2, 0, 0, 0, 0, time.Local))) // Thursday the 2nd
+}
+
+func (s *CronTestSuite) TestInvalid() {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	} {
+		_, err := parseCron(expr)
+		s.ErrorIs(err, ErrInvalidSchedule, expr)
+	}
+}
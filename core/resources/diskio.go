@@ -0,0 +1,187 @@
+package resources
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	procDiskstatsPath = "/proc/diskstats"
+	sysBlockPath      = "/sys/block"
+	// diskstatsSectorSize is the unit of the sector fields in /proc/diskstats,
+	// regardless of the device's logical sector size.
+	diskstatsSectorSize = 512
+)
+
+// DiskIOStats is the I/O activity of a block device. Counters are cumulative
+// since boot; rates and latencies cover the interval since the previous call.
+type DiskIOStats struct {
+	Device          string
+	ReadBytes       uint64
+	WriteBytes      uint64
+	ReadsCompleted  uint64
+	WritesCompleted uint64
+	BusyTime        time.Duration // cumulative time the device had I/O in flight
+	ReadSpeed       float64       // bytes per second
+	WriteSpeed      float64       // bytes per second
+	ReadIOPS        float64
+	WriteIOPS       float64
+	Utilization     float64 // percent of the interval the device was busy
+	Await           float64 // average milliseconds per completed request, queueing included
+}
+
+type DiskIO interface {
+	GetDeviceStats(ctx context.Context, device string) (*DiskIOStats, error)
+	GetAllDeviceStats(ctx context.Context) (map[string]*DiskIOStats, error)
+}
+
+// diskCounters are the raw /proc/diskstats fields used for rate calculation.
+type diskCounters struct {
+	reads, readSectors, readTicks    uint64
+	writes, writeSectors, writeTicks uint64
+	ioTicks                          uint64
+}
+
+type diskIOImpl struct {
+	mu        sync.Mutex
+	prevStats map[string]diskCounters
+	lastCheck time.Time
+}
+
+func NewDiskIO() DiskIO {
+	return &diskIOImpl{
+		prevStats: make(map[string]diskCounters),
+		lastCheck: time.Now(),
+	}
+}
+
+func (d *diskIOImpl) GetAllDeviceStats(context.Context) (map[string]*DiskIOStats, error) {
+	file, err := os.Open(procDiskstatsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	counters, err := parseDiskstats(file)
+	if err != nil {
+		return nil, fmt.Errorf("parsing diskstats: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(d.lastCheck)
+
+	stats := make(map[string]*DiskIOStats)
+	current := make(map[string]diskCounters)
+	for device, c := range counters {
+		if !isWholeDisk(device) {
+			continue
+		}
+		current[device] = c
+
+		stat := &DiskIOStats{
+			Device:          device,
+			ReadBytes:       c.readSectors * diskstatsSectorSize,
+			WriteBytes:      c.writeSectors * diskstatsSectorSize,
+			ReadsCompleted:  c.reads,
+			WritesCompleted: c.writes,
+			BusyTime:        time.Duration(c.ioTicks) * time.Millisecond, //nolint:gosec // ms since boot fit in int64
+		}
+		if prev, ok := d.prevStats[device]; ok && elapsed > 0 {
+			setDiskIORates(stat, prev, c, elapsed)
+		}
+		stats[device] = stat
+	}
+
+	d.prevStats = current
+	d.lastCheck = now
+
+	return stats, nil
+}
+
+func (d *diskIOImpl) GetDeviceStats(ctx context.Context, device string) (*DiskIOStats, error) {
+	stats, err := d.GetAllDeviceStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if stat, ok := stats[device]; ok {
+		return stat, nil
+	}
+	return nil, fmt.Errorf("block device not found %s: %w", device, ErrDeviceNotFound)
+}
+
+// setDiskIORates fills the per-interval fields of stat from two counter samples.
+func setDiskIORates(stat *DiskIOStats, prev, curr diskCounters, elapsed time.Duration) {
+	seconds := elapsed.Seconds()
+	reads := counterDelta(prev.reads, curr.reads)
+	writes := counterDelta(prev.writes, curr.writes)
+
+	stat.ReadSpeed = float64(counterDelta(prev.readSectors, curr.readSectors)*diskstatsSectorSize) / seconds
+	stat.WriteSpeed = float64(counterDelta(prev.writeSectors, curr.writeSectors)*diskstatsSectorSize) / seconds
+	stat.ReadIOPS = float64(reads) / seconds
+	stat.WriteIOPS = float64(writes) / seconds
+
+	busyMs := float64(counterDelta(prev.ioTicks, curr.ioTicks))
+	stat.Utilization = min(100, 100*busyMs/(seconds*1000))
+
+	if reads+writes > 0 {
+		ticks := counterDelta(prev.readTicks, curr.readTicks) + counterDelta(prev.writeTicks, curr.writeTicks)
+		stat.Await = float64(ticks) / float64(reads+writes)
+	}
+}
+
+// counterDelta returns curr-prev, or 0 if the counter went backwards
+// (device re-attached or a 32-bit counter wrapped).
+func counterDelta(prev, curr uint64) uint64 {
+	if curr < prev {
+		return 0
+	}
+	return curr - prev
+}
+
+// isWholeDisk reports whether device is a disk, md array or device-mapper
+// volume rather than a partition. Loop and RAM devices are skipped.
+func isWholeDisk(device string) bool {
+	for _, prefix := range []string{"loop", "ram", "zram"} {
+		if strings.HasPrefix(device, prefix) {
+			return false
+		}
+	}
+	_, err := os.Stat(filepath.Join(sysBlockPath, device))
+	return err == nil
+}
+
+// parseDiskstats parses /proc/diskstats as documented in the kernel's
+// Documentation/admin-guide/iostats.rst:
+//
+//	8 0 sda 4523 1205 351246 2301 9875 6511 752704 18830 0 11060 21132 ...
+func parseDiskstats(r io.Reader) (map[string]diskCounters, error) {
+	counters := make(map[string]diskCounters)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 14 {
+			continue
+		}
+		counters[fields[2]] = diskCounters{
+			reads:        parseUint64(fields[3]),
+			readSectors:  parseUint64(fields[5]),
+			readTicks:    parseUint64(fields[6]),
+			writes:       parseUint64(fields[7]),
+			writeSectors: parseUint64(fields[9]),
+			writeTicks:   parseUint64(fields[10]),
+			ioTicks:      parseUint64(fields[12]),
+		}
+	}
+	return counters, scanner.Err()
+}
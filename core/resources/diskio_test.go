@@ -0,0 +1,76 @@
+package resources
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type DiskIOTestSuite struct {
+	suite.Suite
+}
+
+func TestDiskIOTestSuite(t *testing.T) {
+	suite.Run(t, new(DiskIOTestSuite))
+}
+
+func (s *DiskIOTestSuite) TestParseDiskstats() {
+	counters, err := parseDiskstats(strings.NewReader(
+		`   8       0 sda 4523 1205 351246 2301 9875 6511 752704 18830 0 11060 21132 0 0 0 0 120 1
+   8       1 sda1 4400 1200 350000 2290 9870 6510 752700 18820 0 11050 21110
+   9       0 md0 100 0 800 0 200 0 1600 0 0 0 0 0 0 0 0
+ 179       0 mmcblk0 short line
+`))
+	s.Require().NoError(err)
+	s.Len(counters, 3)
+	s.Equal(diskCounters{
+		reads:        4523,
+		readSectors:  351246,
+		readTicks:    2301,
+		writes:       9875,
+		writeSectors: 752704,
+		writeTicks:   18830,
+		ioTicks:      11060,
+	}, counters["sda"])
+	s.Equal(uint64(200), counters["md0"].writes)
+}
+
+func (s *DiskIOTestSuite) TestSetDiskIORates() {
+	prev := diskCounters{
+		reads: 100, readSectors: 1000, readTicks: 500,
+		writes: 50, writeSectors: 2000, writeTicks: 300,
+		ioTicks: 1000,
+	}
+	curr := diskCounters{
+		reads: 300, readSectors: 5096, readTicks: 1300,
+		writes: 100, writeSectors: 4048, writeTicks: 700,
+		ioTicks: 1500,
+	}
+
+	stat := &DiskIOStats{}
+	setDiskIORates(stat, prev, curr, 2*time.Second)
+
+	s.InDelta(4096*512/2.0, stat.ReadSpeed, 0.001)
+	s.InDelta(2048*512/2.0, stat.WriteSpeed, 0.001)
+	s.InDelta(100.0, stat.ReadIOPS, 0.001)
+	s.InDelta(25.0, stat.WriteIOPS, 0.001)
+	s.InDelta(25.0, stat.Utilization, 0.001)
+	// (800 + 400) ms over 250 requests.
+	s.InDelta(4.8, stat.Await, 0.001)
+}
+
+func (s *DiskIOTestSuite) TestSetDiskIORatesCounterReset() {
+	prev := diskCounters{reads: 300, readSectors: 5096, ioTicks: 1500}
+	curr := diskCounters{reads: 10, readSectors: 80, ioTicks: 3000}
+
+	stat := &DiskIOStats{}
+	setDiskIORates(stat, prev, curr, time.Second)
+
+	s.Zero(stat.ReadSpeed)
+	s.Zero(stat.ReadIOPS)
+	s.Zero(stat.Await)
+	// Busy time can't exceed the interval.
+	s.InDelta(100.0, stat.Utilization, 0.001)
+}
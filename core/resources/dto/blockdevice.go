@@ -4,17 +4,38 @@ type BlockDevice struct {
 	Name       string       `json:"name"`
 	Size       uint64       `json:"size"`
 	Type       string       `json:"type"`
+	FsType     string       `json:"fstype,omitempty"`
 	MountPoint string       `json:"mountpoint,omitempty"`
 	Children   []BlockChild `json:"children,omitempty"`
 }
 
+// BlockChild is a device stacked on top of its parent: a partition, md array,
+// LVM logical volume or dm-crypt mapping. Stacks nest arbitrarily deep.
 type BlockChild struct {
-	Name       string `json:"name"`
-	Size       uint64 `json:"size"`
-	Type       string `json:"type"`
-	MountPoint string `json:"mountpoint,omitempty"`
+	Name       string       `json:"name"`
+	Size       uint64       `json:"size"`
+	Type       string       `json:"type"`
+	FsType     string       `json:"fstype,omitempty"`
+	MountPoint string       `json:"mountpoint,omitempty"`
+	Children   []BlockChild `json:"children,omitempty"`
 }
 
 type BlockDevices struct {
 	BlockDevices []*BlockDevice `json:"blockdevices"`
 }
+
+type LvmReport struct {
+	Report []struct {
+		VG []LvmVolumeGroup `json:"vg"`
+	} `json:"report"`
+}
+
+type LvmVolumeGroup struct {
+	Name       string `json:"vg_name"`
+	Size       string `json:"vg_size"`
+	Free       string `json:"vg_free"`
+	PVCount    string `json:"pv_count"`
+	LVCount    string `json:"lv_count"`
+	MissingPVs string `json:"vg_missing_pv_count"`
+	Attributes string `json:"vg_attr"`
+}
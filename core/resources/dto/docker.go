@@ -0,0 +1,42 @@
+package dto
+
+// DockerContainer is an entry of the Docker Engine API's GET /containers/json,
+// which Podman also serves on its compatibility socket.
+type DockerContainer struct {
+	ID     string   `json:"Id"`
+	Names  []string `json:"Names"`
+	Image  string   `json:"Image"`
+	State  string   `json:"State"`
+	Status string   `json:"Status"`
+}
+
+// DockerContainerInspect is the subset of GET /containers/{id}/json that the
+// list endpoint does not include.
+type DockerContainerInspect struct {
+	RestartCount int `json:"RestartCount"`
+	State        struct {
+		Status     string `json:"Status"`
+		Restarting bool   `json:"Restarting"`
+		Health     *struct {
+			Status        string `json:"Status"`
+			FailingStreak int    `json:"FailingStreak"`
+		} `json:"Health"`
+	} `json:"State"`
+}
+
+// DockerContainerStats is the subset of GET /containers/{id}/stats used for
+// CPU and memory usage.
+type DockerContainerStats struct {
+	CPUStats struct {
+		CPUUsage struct {
+			TotalUsage uint64 `json:"total_usage"`
+		} `json:"cpu_usage"`
+		SystemCPUUsage uint64 `json:"system_cpu_usage"`
+		OnlineCPUs     int    `json:"online_cpus"`
+	} `json:"cpu_stats"`
+	MemoryStats struct {
+		Usage uint64            `json:"usage"`
+		Limit uint64            `json:"limit"`
+		Stats map[string]uint64 `json:"stats"`
+	} `json:"memory_stats"`
+}
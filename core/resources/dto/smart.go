@@ -122,6 +122,47 @@ type AtaSmartErrorLog struct {
 	} `json:"summary"`
 }
 
+// SelfTestStatus is the state of the current or most recent self-test.
+// Values 0xF0–0xFF (Value>>4 == 15) mean a test is in progress.
+type SelfTestStatus struct {
+	Value            int    `json:"value"`
+	String           string `json:"string"`
+	RemainingPercent int    `json:"remaining_percent,omitempty"`
+	Passed           *bool  `json:"passed,omitempty"`
+}
+
+type AtaSmartData struct {
+	SelfTest struct {
+		Status         SelfTestStatus `json:"status"`
+		PollingMinutes struct {
+			Short    int `json:"short"`
+			Extended int `json:"extended"`
+		} `json:"polling_minutes"`
+	} `json:"self_test"`
+}
+
+type SelfTestLogEntry struct {
+	Type struct {
+		Value  int    `json:"value"`
+		String string `json:"string"`
+	} `json:"type"`
+	Status struct {
+		Value            int    `json:"value"`
+		String           string `json:"string"`
+		RemainingPercent int    `json:"remaining_percent,omitempty"`
+		Passed           bool   `json:"passed"`
+	} `json:"status"`
+	LifetimeHours int `json:"lifetime_hours"`
+}
+
+type AtaSmartSelfTestLog struct {
+	Standard struct {
+		Revision int                `json:"revision"`
+		Table    []SelfTestLogEntry `json:"table"`
+		Count    int                `json:"count"`
+	} `json:"standard"`
+}
+
 type SmartctlOutput struct {
 	JSONFormatVersion []int        `json:"json_format_version"`
 	Smartctl          Smartctl     `json:"smartctl"`
@@ -140,15 +181,17 @@ type SmartctlOutput struct {
 	Trim              struct {
 		Supported bool `json:"supported"`
 	} `json:"trim"`
-	InSmartctlDatabase bool               `json:"in_smartctl_database"`
-	AtaVersion         AtaVersion         `json:"ata_version"`
-	SataVersion        SataVersion        `json:"sata_version"`
-	InterfaceSpeed     InterfaceSpeed     `json:"interface_speed"`
-	SmartSupport       SmartSupport       `json:"smart_support"`
-	SmartStatus        SmartStatus        `json:"smart_status"`
-	AtaSmartAttributes AtaSmartAttributes `json:"ata_smart_attributes"`
-	PowerOnTime        PowerOnTime        `json:"power_on_time"`
-	PowerCycleCount    int                `json:"power_cycle_count"`
-	Temperature        Temperature        `json:"temperature"`
-	AtaSmartErrorLog   AtaSmartErrorLog   `json:"ata_smart_error_log"`
+	InSmartctlDatabase bool                `json:"in_smartctl_database"`
+	AtaVersion         AtaVersion          `json:"ata_version"`
+	SataVersion        SataVersion         `json:"sata_version"`
+	InterfaceSpeed     InterfaceSpeed      `json:"interface_speed"`
+	SmartSupport       SmartSupport        `json:"smart_support"`
+	SmartStatus        SmartStatus         `json:"smart_status"`
+	AtaSmartAttributes AtaSmartAttributes  `json:"ata_smart_attributes"`
+	PowerOnTime        PowerOnTime         `json:"power_on_time"`
+	PowerCycleCount    int                 `json:"power_cycle_count"`
+	Temperature        Temperature         `json:"temperature"`
+	AtaSmartErrorLog   AtaSmartErrorLog    `json:"ata_smart_error_log"`
+	AtaSmartData       AtaSmartData        `json:"ata_smart_data"`
+	AtaSmartSelfTest   AtaSmartSelfTestLog `json:"ata_smart_self_test_log"`
 }
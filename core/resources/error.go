@@ -10,10 +10,33 @@ var (
 	ErrNoValidDeviceStats             = errors.New("no valid device stats found")
 	ErrSmartctlFailed                 = errors.New("smartctl command failed")
 	ErrSmartOutputVersionIncompatible = errors.New("smartctl output version incompatible")
+	ErrInvalidSchedule                = errors.New("invalid schedule")
+	ErrInvalidSelfTestType            = errors.New("invalid self-test type")
+	ErrSelfTestNotStarted             = errors.New("self-test not started")
+
+	// Disk I/O related errors.
+	ErrDeviceNotFound = errors.New("block device not found")
+
+	// Filesystem related errors.
+	ErrFilesystemNotResponding = errors.New("filesystem not responding")
 
 	// Network related errors.
 	ErrInterfaceNotFound = errors.New("interface not found")
 
+	// CPU related errors.
+	ErrNoCPUTimes          = errors.New("no cpu times reported")
+	ErrThrottleUnavailable = errors.New("throttling state not available")
+
+	// Container related errors.
+	ErrContainerAPI = errors.New("container API request failed")
+
+	// UPS related errors.
+	ErrUPSProtocol        = errors.New("unexpected upsd response")
+	ErrUPSVarNotSupported = errors.New("variable not supported by the UPS driver")
+
+	// System related errors.
+	ErrUnexpectedFormat = errors.New("unexpected format")
+
 	// Temperature related errors.
 	ErrTemperatureNotFound = errors.New("temperature not found")
 	ErrNoThermalZones      = errors.New("no thermal zones found")
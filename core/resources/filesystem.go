@@ -0,0 +1,302 @@
+package resources
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	procMountinfoPath  = "/proc/self/mountinfo"
+	filesystemCacheTTL = 10 * time.Second
+	// statfsTimeout bounds how long a single mount may take to answer statfs.
+	// A hard-mounted NFS share whose server is down blocks indefinitely.
+	statfsTimeout = 2 * time.Second
+)
+
+// pseudoFsTypes are filesystems without backing storage. They are skipped
+// unless their mountpoint is explicitly included.
+var pseudoFsTypes = []string{
+	"autofs", "binfmt_misc", "bpf", "cgroup", "cgroup2", "configfs", "debugfs", "devpts", "devtmpfs",
+	"efivarfs", "fuse.gvfsd-fuse", "fuse.portal", "fusectl", "hugetlbfs", "mqueue", "nfsd", "nsfs",
+	"overlay", "proc", "pstore", "ramfs", "rpc_pipefs", "securityfs", "selinuxfs", "squashfs", "sysfs",
+	"tmpfs", "tracefs",
+}
+
+// remoteFsTypes are network filesystems.
+var remoteFsTypes = []string{
+	"9p", "ceph", "cifs", "fuse.glusterfs", "fuse.sshfs", "glusterfs", "nfs", "nfs4", "smb3", "smbfs",
+}
+
+// FilesystemStats is the usage of one mounted filesystem.
+type FilesystemStats struct {
+	Source      string // device or share, e.g. /dev/sda1, tank/data or nas:/export
+	Mountpoint  string
+	FsType      string
+	Remote      bool // network filesystem such as NFS or CIFS
+	Unavailable bool // statfs did not answer in time; usage fields are zero
+	Total       uint64
+	Used        uint64
+	Available   uint64  // bytes available to unprivileged users, excluding root-reserved blocks
+	UsedPercent float64 // Used / (Used + Available), as reported by df
+	Inodes      uint64  // 0 on filesystems without a fixed inode table, e.g. btrfs
+	InodesUsed  uint64
+	InodesFree  uint64
+}
+
+// InodesPercent returns the share of inodes in use, or 0 if the filesystem has no inode limit.
+func (s *FilesystemStats) InodesPercent() float64 {
+	if s.Inodes == 0 {
+		return 0
+	}
+	return 100 * float64(s.InodesUsed) / float64(s.Inodes)
+}
+
+type Filesystems interface {
+	// GetStats returns the usage of every mounted filesystem that passes the
+	// configured filters, in mount order.
+	GetStats(ctx context.Context) ([]FilesystemStats, error)
+}
+
+// FilesystemOptions configures which mounts are reported. Patterns are
+// path.Match globs against the mountpoint; "*" does not match "/".
+type FilesystemOptions struct {
+	// Include, if not empty, limits reporting to matching mountpoints.
+	// An included mountpoint is reported even if it is a pseudo filesystem.
+	Include []string
+	// Exclude hides matching mountpoints.
+	Exclude []string
+}
+
+type filesystemsImpl struct {
+	include []string
+	exclude []string
+
+	mu          sync.RWMutex
+	cachedStats []FilesystemStats
+	cacheTime   time.Time
+	cacheTTL    time.Duration
+
+	// pending holds mountpoints whose statfs call has not returned yet, so a
+	// hung mount is not queried again until it answers.
+	pendingMu sync.Mutex
+	pending   map[string]struct{}
+}
+
+func NewFilesystems(opts FilesystemOptions) Filesystems {
+	return &filesystemsImpl{
+		include:  opts.Include,
+		exclude:  opts.Exclude,
+		cacheTTL: filesystemCacheTTL,
+		pending:  make(map[string]struct{}),
+	}
+}
+
+// mountInfo is one line of /proc/self/mountinfo.
+type mountInfo struct {
+	device     string // major:minor
+	mountpoint string
+	fsType     string
+	source     string
+}
+
+func (f *filesystemsImpl) GetStats(context.Context) ([]FilesystemStats, error) {
+	f.mu.RLock()
+	if f.cachedStats != nil && time.Since(f.cacheTime) < f.cacheTTL {
+		stats := make([]FilesystemStats, len(f.cachedStats))
+		copy(stats, f.cachedStats)
+		f.mu.RUnlock()
+		return stats, nil
+	}
+	f.mu.RUnlock()
+
+	file, err := os.Open(procMountinfoPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening mountinfo: %w", err)
+	}
+	defer file.Close()
+
+	mounts, err := parseMountinfo(file)
+	if err != nil {
+		return nil, fmt.Errorf("parsing mountinfo: %w", err)
+	}
+
+	stats := make([]FilesystemStats, 0, len(mounts))
+	for _, mount := range f.selectMounts(mounts) {
+		stat := FilesystemStats{
+			Source:     mount.source,
+			Mountpoint: mount.mountpoint,
+			FsType:     mount.fsType,
+			Remote:     slices.Contains(remoteFsTypes, mount.fsType),
+		}
+
+		statfs, err := f.statfs(mount.mountpoint)
+		if err != nil {
+			slog.Warn("error getting filesystem usage", "mountpoint", mount.mountpoint, "error", err)
+			stat.Unavailable = true
+			stats = append(stats, stat)
+			continue
+		}
+		if statfs.Blocks == 0 && !f.included(mount.mountpoint) {
+			continue
+		}
+		setFilesystemUsage(&stat, statfs)
+		stats = append(stats, stat)
+	}
+
+	f.mu.Lock()
+	f.cachedStats = stats
+	f.cacheTime = time.Now()
+	f.mu.Unlock()
+
+	result := make([]FilesystemStats, len(stats))
+	copy(result, stats)
+	return result, nil
+}
+
+// selectMounts applies the include and exclude filters and drops pseudo
+// filesystems and repeated mounts of the same filesystem (bind mounts), keeping
+// the first mountpoint.
+func (f *filesystemsImpl) selectMounts(mounts []mountInfo) []mountInfo {
+	seen := make(map[string]struct{})
+	selected := make([]mountInfo, 0, len(mounts))
+	for _, mount := range mounts {
+		if matchesAny(f.exclude, mount.mountpoint) {
+			continue
+		}
+		if len(f.include) > 0 && !f.included(mount.mountpoint) {
+			continue
+		}
+		if slices.Contains(pseudoFsTypes, mount.fsType) && !f.included(mount.mountpoint) {
+			continue
+		}
+		if _, ok := seen[mount.device]; ok {
+			continue
+		}
+		seen[mount.device] = struct{}{}
+		selected = append(selected, mount)
+	}
+	return selected
+}
+
+func (f *filesystemsImpl) included(mountpoint string) bool {
+	return matchesAny(f.include, mountpoint)
+}
+
+func matchesAny(patterns []string, mountpoint string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, mountpoint); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// statfs calls statfs on mountpoint, giving up after statfsTimeout. The call
+// itself cannot be interrupted, so a mount that timed out is skipped until
+// its outstanding call returns.
+func (f *filesystemsImpl) statfs(mountpoint string) (*unix.Statfs_t, error) {
+	f.pendingMu.Lock()
+	if _, ok := f.pending[mountpoint]; ok {
+		f.pendingMu.Unlock()
+		return nil, ErrFilesystemNotResponding
+	}
+	f.pending[mountpoint] = struct{}{}
+	f.pendingMu.Unlock()
+
+	type result struct {
+		stat unix.Statfs_t
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		var r result
+		r.err = unix.Statfs(mountpoint, &r.stat)
+		f.pendingMu.Lock()
+		delete(f.pending, mountpoint)
+		f.pendingMu.Unlock()
+		done <- r
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return nil, r.err
+		}
+		return &r.stat, nil
+	case <-time.After(statfsTimeout):
+		return nil, ErrFilesystemNotResponding
+	}
+}
+
+// setFilesystemUsage fills the usage fields of stat the way df computes them.
+func setFilesystemUsage(stat *FilesystemStats, statfs *unix.Statfs_t) {
+	bsize := uint64(statfs.Bsize) //nolint:gosec // Bsize is a block size, always positive
+	stat.Total = statfs.Blocks * bsize
+	stat.Used = (statfs.Blocks - statfs.Bfree) * bsize
+	stat.Available = statfs.Bavail * bsize
+	if stat.Used+stat.Available > 0 {
+		stat.UsedPercent = 100 * float64(stat.Used) / float64(stat.Used+stat.Available)
+	}
+
+	stat.Inodes = statfs.Files
+	stat.InodesFree = statfs.Ffree
+	if statfs.Files >= statfs.Ffree {
+		stat.InodesUsed = statfs.Files - statfs.Ffree
+	}
+}
+
+// parseMountinfo parses the format described in proc(5):
+//
+//	36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+//
+// The number of optional fields before the "-" separator varies.
+func parseMountinfo(r io.Reader) ([]mountInfo, error) {
+	var mounts []mountInfo
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		sep := slices.Index(fields, "-")
+		if sep < 6 || len(fields) < sep+3 {
+			continue
+		}
+		mounts = append(mounts, mountInfo{
+			device:     fields[2],
+			mountpoint: unescapeMountinfo(fields[4]),
+			fsType:     fields[sep+1],
+			source:     unescapeMountinfo(fields[sep+2]),
+		})
+	}
+	return mounts, scanner.Err()
+}
+
+// unescapeMountinfo decodes the octal escapes (\040 for space etc.) the kernel
+// uses for whitespace and backslashes in mountinfo paths.
+func unescapeMountinfo(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
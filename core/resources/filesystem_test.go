@@ -0,0 +1,101 @@
+package resources
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/sys/unix"
+)
+
+type FilesystemTestSuite struct {
+	suite.Suite
+}
+
+func TestFilesystemTestSuite(t *testing.T) {
+	suite.Run(t, new(FilesystemTestSuite))
+}
+
+const testMountinfo = `22 1 259:2 / / rw,relatime shared:1 - ext4 /dev/nvme0n1p2 rw
+23 22 0:21 / /proc rw,nosuid,nodev,noexec,relatime shared:12 - proc proc rw
+24 22 0:22 / /sys rw,nosuid,nodev,noexec,relatime shared:2 - sysfs sysfs rw
+25 22 0:5 / /dev rw,nosuid shared:8 - devtmpfs udev rw,size=1921388k,nr_inodes=480347,mode=755
+26 25 0:23 / /dev/shm rw,nosuid,nodev shared:9 - tmpfs tmpfs rw
+40 22 259:1 / /boot/firmware rw,relatime shared:31 - vfat /dev/nvme0n1p1 rw,fmask=0022
+41 22 9:0 / /srv/media rw,relatime shared:32 - ext4 /dev/md0 rw,stripe=256
+42 22 9:0 /exports /srv/nfs rw,relatime shared:32 - ext4 /dev/md0 rw,stripe=256
+43 22 0:52 / /mnt/backup\040share rw,relatime shared:33 - cifs //nas/backup\040share rw,vers=3.1.1
+44 22 0:53 / /mnt/nas rw,relatime shared:34 master:5 - nfs4 nas:/export rw,vers=4.2
+45 22 0:54 / /var/lib/docker/overlay2/abc/merged rw,relatime - overlay overlay rw,lowerdir=/x
+`
+
+func (s *FilesystemTestSuite) TestParseMountinfo() {
+	mounts, err := parseMountinfo(strings.NewReader(testMountinfo))
+	s.Require().NoError(err)
+	s.Require().Len(mounts, 11)
+
+	s.Equal(mountInfo{device: "259:2", mountpoint: "/", fsType: "ext4", source: "/dev/nvme0n1p2"}, mounts[0])
+	s.Equal(mountInfo{
+		device:     "0:52",
+		mountpoint: "/mnt/backup share",
+		fsType:     "cifs",
+		source:     "//nas/backup share",
+	}, mounts[8])
+	// Two optional fields before the separator.
+	s.Equal(mountInfo{device: "0:53", mountpoint: "/mnt/nas", fsType: "nfs4", source: "nas:/export"}, mounts[9])
+}
+
+func (s *FilesystemTestSuite) TestUnescapeMountinfo() {
+	s.Equal("/mnt/a b", unescapeMountinfo(`/mnt/a\040b`))
+	s.Equal(`/mnt/a\b`, unescapeMountinfo(`/mnt/a\134b`))
+	s.Equal("/mnt/a\tb\n", unescapeMountinfo(`/mnt/a\011b\012`))
+	s.Equal(`/mnt/x\04`, unescapeMountinfo(`/mnt/x\04`))
+}
+
+func (s *FilesystemTestSuite) mountpoints(opts FilesystemOptions) []string {
+	mounts, err := parseMountinfo(strings.NewReader(testMountinfo))
+	s.Require().NoError(err)
+
+	f, ok := NewFilesystems(opts).(*filesystemsImpl)
+	s.Require().True(ok)
+
+	var result []string
+	for _, mount := range f.selectMounts(mounts) {
+		result = append(result, mount.mountpoint)
+	}
+	return result
+}
+
+func (s *FilesystemTestSuite) TestSelectMountsDefault() {
+	// Pseudo filesystems and the bind mount of md0 are dropped.
+	s.Equal([]string{"/", "/boot/firmware", "/srv/media", "/mnt/backup share", "/mnt/nas"},
+		s.mountpoints(FilesystemOptions{}))
+}
+
+func (s *FilesystemTestSuite) TestSelectMountsExclude() {
+	s.Equal([]string{"/", "/srv/media"},
+		s.mountpoints(FilesystemOptions{Exclude: []string{"/boot/*", "/mnt/*"}}))
+}
+
+func (s *FilesystemTestSuite) TestSelectMountsInclude() {
+	// An explicitly included pseudo filesystem is reported.
+	s.Equal([]string{"/dev/shm", "/mnt/backup share", "/mnt/nas"},
+		s.mountpoints(FilesystemOptions{Include: []string{"/mnt/*", "/dev/shm"}}))
+	s.Equal([]string{"/mnt/nas"},
+		s.mountpoints(FilesystemOptions{Include: []string{"/mnt/*"}, Exclude: []string{"/mnt/backup*"}}))
+}
+
+func (s *FilesystemTestSuite) TestSetFilesystemUsage() {
+	stat := FilesystemStats{}
+	// 100 blocks, 30 free of which 5 are reserved for root.
+	setFilesystemUsage(&stat, &unix.Statfs_t{Bsize: 4096, Blocks: 100, Bfree: 30, Bavail: 25, Files: 50, Ffree: 40})
+
+	s.Equal(uint64(100*4096), stat.Total)
+	s.Equal(uint64(70*4096), stat.Used)
+	s.Equal(uint64(25*4096), stat.Available)
+	s.InDelta(100*70.0/95.0, stat.UsedPercent, 0.001)
+	s.Equal(uint64(50), stat.Inodes)
+	s.Equal(uint64(10), stat.InodesUsed)
+	s.Equal(uint64(40), stat.InodesFree)
+	s.InDelta(20.0, stat.InodesPercent(), 0.001)
+}
@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
@@ -16,7 +17,6 @@ import (
 	"time"
 
 	"github.com/czechbol/lumeon/core/resources/dto"
-	"golang.org/x/sys/unix"
 )
 
 const (
@@ -27,26 +27,60 @@ const (
 	AttrPendingSectors       = 197
 	AttrTotalLBAWritten      = 241
 	hddCacheTTL              = 20 * time.Second
+	diskByPathDir            = "/dev/disk/by-path"
 )
 
 type HDDStats struct {
-	DeviceName  string
-	Temperature float64
-	TotalSize   uint64
-	Partitions  []Partition
-	SmartStatus SmartStatus
+	DeviceName   string
+	Label        string // friendly bay label from config, empty if unmapped
+	ByPath       string // stable /dev/disk/by-path name, e.g. platform-fd500000.pcie-pci-0000:01:00.0-ata-2
+	Model        string
+	Serial       string
+	Firmware     string
+	WWN          string
+	RotationRate int // RPM, 0 for solid state drives
+	Temperature  float64
+	TotalSize    uint64
+	Partitions   []Partition
+	SmartStatus  SmartStatus
+	SelfTest     SelfTestStatus
+}
+
+// DisplayName returns the configured bay label if set, otherwise the kernel device name.
+func (s *HDDStats) DisplayName() string {
+	if s.Label != "" {
+		return s.Label
+	}
+	return s.DeviceName
+}
+
+// Health summarizes the drive's state as shown on the display and published
+// over MQTT: FAIL if SMART reports it failed or its last self-test failed,
+// WARN if it is degrading, PASS otherwise.
+func (s *HDDStats) Health() string {
+	switch {
+	case !s.SmartStatus.HealthOK, s.SelfTest.HasResult && !s.SelfTest.Passed:
+		return "FAIL"
+	case s.SmartStatus.Degraded:
+		return "WARN"
+	default:
+		return "PASS"
+	}
 }
 
 type Partition struct {
 	Name       string
 	Mountpoint string
 	FsType     string
-	Total      uint64
-	Free       uint64
+	Total      uint64 // filesystem size
+	Used       uint64
+	Free       uint64 // available to unprivileged users, excluding root-reserved blocks
 }
 
 type SmartStatus struct {
 	HealthOK            bool
+	Degraded            bool     // tracked counters grew or temperature limit exceeded
+	Findings            []string // human-readable reasons for Degraded
 	PowerOnHours        int
 	PowerCycleCount     int
 	ReallocatedSectors  int
@@ -56,8 +90,11 @@ type SmartStatus struct {
 }
 
 type HDD interface {
-	GetAverageTemp() (float64, error)
-	GetStats() ([]HDDStats, error)
+	GetAverageTemp(ctx context.Context) (float64, error)
+	GetStats(ctx context.Context) ([]HDDStats, error)
+	// ScheduleSelfTests starts a background goroutine that runs the configured
+	// SMART self-test schedules. The goroutine stops when ctx is cancelled.
+	ScheduleSelfTests(ctx context.Context)
 }
 
 type hddImpl struct {
@@ -65,15 +102,48 @@ type hddImpl struct {
 	cachedStats []HDDStats
 	cacheTime   time.Time
 	cacheTTL    time.Duration
+	bays        map[string]string
+	tracker     *smartTracker
+	selfTests   []SelfTestSchedule
+	// startTest starts a self-test on a drive; replaced in tests.
+	startTest func(ctx context.Context, stats *HDDStats, testType SelfTestType) error
+
+	// selfTestResults is the last self-test result seen per serial, used to log each result once.
+	selfTestResults map[string]SelfTestStatus
 }
 
-func NewHDD() HDD {
+// HDDOptions configures the HDD prober.
+type HDDOptions struct {
+	// Bays maps a /dev/disk/by-path name or a drive serial number to a friendly
+	// label such as "Bay 2". Keys are matched case-insensitively.
+	Bays map[string]string
+	// StateDir is where per-drive SMART snapshots are persisted. Empty keeps them in memory only.
+	StateDir string
+	// AcceptAfter makes the SMART counters of a degraded drive its new baseline once they
+	// have not risen for this long, which clears the degradation. Zero keeps the baseline.
+	AcceptAfter time.Duration
+	// SelfTests are the SMART self-test schedules run by ScheduleSelfTests.
+	SelfTests []SelfTestSchedule
+}
+
+func NewHDD(opts HDDOptions) HDD {
+	normalized := make(map[string]string, len(opts.Bays))
+	for key, label := range opts.Bays {
+		normalized[strings.ToLower(key)] = label
+	}
+
 	return &hddImpl{
 		cacheTTL: hddCacheTTL,
+		bays:     normalized,
+		tracker:  newSmartTracker(opts.StateDir, opts.AcceptAfter),
+
+		selfTests:       opts.SelfTests,
+		startTest:       startSelfTest,
+		selfTestResults: make(map[string]SelfTestStatus),
 	}
 }
 
-func (h *hddImpl) getOrRefresh() ([]HDDStats, error) {
+func (h *hddImpl) getOrRefresh(ctx context.Context) ([]HDDStats, error) {
 	h.mu.RLock()
 	if h.cachedStats != nil && time.Since(h.cacheTime) < h.cacheTTL {
 		stats := make([]HDDStats, len(h.cachedStats))
@@ -83,26 +153,43 @@ func (h *hddImpl) getOrRefresh() ([]HDDStats, error) {
 	}
 	h.mu.RUnlock()
 
-	devices, err := getStorageDevices()
+	devices, err := getStorageDevices(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("error getting storage devices: %w", err)
 	}
 
+	byPath := getByPathNames(diskByPathDir)
+
 	stats := make([]HDDStats, 0, len(devices))
 	for _, device := range devices {
-		deviceInfo, err := getDeviceSMARTInfo(device)
+		deviceInfo, err := getDeviceSMARTInfo(ctx, device)
 		if err != nil {
-			slog.Error("error getting stats for device", "device", device, "error", err)
+			slog.Error("error getting stats for device", "device", device.Name,
+				"label", h.bays[strings.ToLower(byPath[device.Name])], "error", err)
 			continue
 		}
 
 		deviceStats := &HDDStats{
-			DeviceName:  device.Name,
-			Temperature: float64(deviceInfo.Temperature.Current),
-			TotalSize:   device.Size,
+			DeviceName:   device.Name,
+			ByPath:       byPath[device.Name],
+			Model:        deviceInfo.ModelName,
+			Serial:       deviceInfo.SerialNumber,
+			Firmware:     deviceInfo.FirmwareVersion,
+			WWN:          formatWWN(deviceInfo.WWN),
+			RotationRate: deviceInfo.RotationRate,
+			Temperature:  float64(deviceInfo.Temperature.Current),
+			TotalSize:    device.Size,
 		}
+		deviceStats.Label = h.lookupLabel(deviceStats)
+		slog.Debug("drive identified", "device", deviceStats.DeviceName, "label", deviceStats.Label,
+			"model", deviceStats.Model, "serial", deviceStats.Serial, "byPath", deviceStats.ByPath)
 
 		populateSMART(deviceStats, deviceInfo)
+		populateSelfTest(deviceStats, deviceInfo)
+		h.logSelfTestResult(deviceStats)
+
+		deviceStats.SmartStatus.Findings = h.tracker.evaluate(deviceStats, deviceInfo)
+		deviceStats.SmartStatus.Degraded = len(deviceStats.SmartStatus.Findings) > 0
 
 		deviceStats.Partitions = populatePartitions(device)
 
@@ -123,13 +210,34 @@ func (h *hddImpl) getOrRefresh() ([]HDDStats, error) {
 	return result, nil
 }
 
-func (h *hddImpl) GetAverageTemp() (float64, error) {
-	stats, err := h.getOrRefresh()
+// lookupLabel resolves the configured bay label for a drive, preferring the
+// by-path name (which follows the physical slot) over the serial number.
+func (h *hddImpl) lookupLabel(stats *HDDStats) string {
+	if stats.ByPath != "" {
+		if label, ok := h.bays[strings.ToLower(stats.ByPath)]; ok {
+			return label
+		}
+	}
+	if stats.Serial != "" {
+		if label, ok := h.bays[strings.ToLower(stats.Serial)]; ok {
+			return label
+		}
+	}
+	return ""
+}
+
+func (h *hddImpl) GetAverageTemp(ctx context.Context) (float64, error) {
+	stats, err := h.getOrRefresh(ctx)
 	if err != nil {
 		slog.Error("error getting storage stats", "error", err)
 		return 0, err
 	}
 
+	return AverageTemperature(stats)
+}
+
+// AverageTemperature returns the mean temperature of the drives that report one.
+func AverageTemperature(stats []HDDStats) (float64, error) {
 	total := 0.0
 	count := 0
 
@@ -150,8 +258,8 @@ func (h *hddImpl) GetAverageTemp() (float64, error) {
 	return averageTemp, nil
 }
 
-func getStorageDevices() ([]*dto.BlockDevice, error) {
-	cmd := exec.CommandContext(context.Background(), "lsblk", "-b", "-J", "-o", "NAME,SIZE,TYPE,MOUNTPOINT")
+func getStorageDevices(ctx context.Context) ([]*dto.BlockDevice, error) {
+	cmd := exec.CommandContext(ctx, "lsblk", "-b", "-J", "-o", "NAME,SIZE,TYPE,FSTYPE,MOUNTPOINT")
 	output, err := cmd.Output()
 	if err != nil {
 		slog.Error("error executing lsblk", "error", err)
@@ -181,7 +289,44 @@ func getStorageDevices() ([]*dto.BlockDevice, error) {
 	return devices, nil
 }
 
-func getDeviceSMARTInfo(device *dto.BlockDevice) (*dto.SmartctlOutput, error) {
+// getByPathNames maps kernel device names (sda) to the names of their by-path links in dir,
+// normally /dev/disk/by-path. Partition links are skipped. Missing udev links yield an empty map.
+func getByPathNames(dir string) map[string]string {
+	names := make(map[string]string)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		slog.Debug("cannot read by-path links", "dir", dir, "error", err)
+		return names
+	}
+
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), "-part") {
+			continue
+		}
+		target, err := filepath.EvalSymlinks(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		device := filepath.Base(target)
+		// Several links may point at the same disk (e.g. usb and usbv2 variants); keep the first.
+		if _, ok := names[device]; !ok {
+			names[device] = entry.Name()
+		}
+	}
+
+	return names
+}
+
+// formatWWN renders the World Wide Name the way smartctl and /dev/disk/by-id do, e.g. 0x5000c500a1b2c3d4.
+func formatWWN(wwn dto.WWN) string {
+	if wwn.Naa == 0 && wwn.Oui == 0 && wwn.ID == 0 {
+		return ""
+	}
+	return fmt.Sprintf("0x%x%06x%09x", wwn.Naa, wwn.Oui, wwn.ID)
+}
+
+func getDeviceSMARTInfo(ctx context.Context, device *dto.BlockDevice) (*dto.SmartctlOutput, error) {
 	cmdDiskType := "sat"
 	if strings.HasPrefix(device.Name, "nvme") {
 		cmdDiskType = "nvme"
@@ -189,7 +334,7 @@ func getDeviceSMARTInfo(device *dto.BlockDevice) (*dto.SmartctlOutput, error) {
 
 	//nolint:gosec // cmdDiskType is hardcoded to "sat" or "nvme"; device.Name is from trusted lsblk output
 	cmd := exec.CommandContext(
-		context.Background(),
+		ctx,
 		"smartctl",
 		"-d",
 		cmdDiskType,
@@ -202,7 +347,9 @@ func getDeviceSMARTInfo(device *dto.BlockDevice) (*dto.SmartctlOutput, error) {
 		"--tolerance=verypermissive",
 		"--nocheck=standby",
 		"--format=brief",
+		"--capabilities",
 		"--log=error",
+		"--log=selftest",
 	)
 	output, err := cmd.Output()
 	var exitErr *exec.ExitError
@@ -233,8 +380,8 @@ func getDeviceSMARTInfo(device *dto.BlockDevice) (*dto.SmartctlOutput, error) {
 	return &smartctlOutput, nil
 }
 
-func (h *hddImpl) GetStats() ([]HDDStats, error) {
-	return h.getOrRefresh()
+func (h *hddImpl) GetStats(ctx context.Context) ([]HDDStats, error) {
+	return h.getOrRefresh(ctx)
 }
 
 func populateSMART(stats *HDDStats, deviceInfo *dto.SmartctlOutput) {
@@ -285,29 +432,38 @@ func getSMARTAttribute(deviceInfo *dto.SmartctlOutput, id int) *dto.Attribute {
 	return nil
 }
 
+// populatePartitions collects the mounted filesystems stacked on a disk, at any
+// depth: plain partitions, md arrays, LVM volumes and dm-crypt mappings. A
+// filesystem spanning several disks (e.g. a RAID1 array) is reported for each of them.
 func populatePartitions(device *dto.BlockDevice) []Partition {
 	partitions := make([]Partition, 0, len(device.Children))
 	for _, part := range device.Children {
-		if part.MountPoint == "" {
-			continue // skip unmounted partitions
-		}
-		var stat unix.Statfs_t
-		err := unix.Statfs(part.MountPoint, &stat)
-		if err != nil {
-			slog.Error("error getting partition stats", "partition", part, "error", err)
-			continue
-		}
+		partitions = appendPartitions(partitions, part)
+	}
 
-		partition := Partition{
-			Name:       part.Name,
-			Mountpoint: part.MountPoint,
-			FsType:     part.Type,
-			Total:      part.Size,
-			Free:       stat.Bfree * uint64(stat.Bsize), //nolint:gosec // Bsize is a block size, always positive
-		}
+	return partitions
+}
 
-		partitions = append(partitions, partition)
+func appendPartitions(partitions []Partition, part dto.BlockChild) []Partition {
+	for _, child := range part.Children {
+		partitions = appendPartitions(partitions, child)
 	}
 
-	return partitions
+	if part.MountPoint == "" {
+		return partitions // skip unmounted devices
+	}
+	total, used, available, err := filesystemUsage(part.MountPoint)
+	if err != nil {
+		slog.Error("error getting partition stats", "partition", part.Name, "error", err)
+		return partitions
+	}
+
+	return append(partitions, Partition{
+		Name:       part.Name,
+		Mountpoint: part.MountPoint,
+		FsType:     part.FsType,
+		Total:      total,
+		Used:       used,
+		Free:       available,
+	})
 }
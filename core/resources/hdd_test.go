@@ -0,0 +1,172 @@
+package resources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/czechbol/lumeon/core/resources/dto"
+	"github.com/stretchr/testify/suite"
+)
+
+type HDDTestSuite struct {
+	suite.Suite
+}
+
+func TestHDDTestSuite(t *testing.T) {
+	suite.Run(t, new(HDDTestSuite))
+}
+
+func (s *HDDTestSuite) TestLookupLabel() {
+	hdd, ok := NewHDD(HDDOptions{Bays: map[string]string{
+		// Viper lowercases config keys, so the labels may arrive either way.
+		"platform-fd500000.pcie-pci-0000:01:00.0-usb-0:2:1.0-scsi-0:0:0:0": "Bay 1",
+		"PLATFORM-FD500000.PCIE-PCI-0000:01:00.0-USB-0:2:1.0-SCSI-0:0:0:1": "Bay 2",
+		"wd-wcc4e1234567": "Spare",
+	}}).(*hddImpl)
+	s.Require().True(ok)
+
+	tests := []struct {
+		name  string
+		stats HDDStats
+		want  string
+	}{
+		{
+			name:  "by-path",
+			stats: HDDStats{ByPath: "platform-fd500000.pcie-pci-0000:01:00.0-usb-0:2:1.0-scsi-0:0:0:0"},
+			want:  "Bay 1",
+		},
+		{
+			name:  "uppercase key",
+			stats: HDDStats{ByPath: "platform-fd500000.pcie-pci-0000:01:00.0-usb-0:2:1.0-scsi-0:0:0:1"},
+			want:  "Bay 2",
+		},
+		{
+			name:  "serial in another case",
+			stats: HDDStats{Serial: "WD-WCC4E1234567"},
+			want:  "Spare",
+		},
+		{
+			name: "by-path before serial",
+			stats: HDDStats{
+				ByPath: "platform-fd500000.pcie-pci-0000:01:00.0-usb-0:2:1.0-scsi-0:0:0:0",
+				Serial: "WD-WCC4E1234567",
+			},
+			want: "Bay 1",
+		},
+		{
+			name:  "serial when by-path is not configured",
+			stats: HDDStats{ByPath: "platform-other", Serial: "WD-WCC4E1234567"},
+			want:  "Spare",
+		},
+		{
+			name:  "unknown",
+			stats: HDDStats{ByPath: "platform-other", Serial: "S1"},
+			want:  "",
+		},
+		{
+			name:  "no identity",
+			stats: HDDStats{},
+			want:  "",
+		},
+	}
+	for _, tc := range tests {
+		s.Run(tc.name, func() {
+			s.Equal(tc.want, hdd.lookupLabel(&tc.stats))
+		})
+	}
+}
+
+func (s *HDDTestSuite) TestFormatWWN() {
+	tests := []struct {
+		name string
+		wwn  dto.WWN
+		want string
+	}{
+		{name: "unknown", wwn: dto.WWN{}, want: ""},
+		{name: "ata", wwn: dto.WWN{Naa: 5, Oui: 0x0014ee, ID: 0x2b1e3c4d5}, want: "0x50014ee2b1e3c4d5"},
+		{name: "padded", wwn: dto.WWN{Naa: 5, Oui: 0xc50, ID: 0x1}, want: "0x5000c50000000001"},
+	}
+	for _, tc := range tests {
+		s.Run(tc.name, func() {
+			s.Equal(tc.want, formatWWN(tc.wwn))
+		})
+	}
+}
+
+func (s *HDDTestSuite) TestGetByPathNames() {
+	devDir := s.T().TempDir()
+	for _, device := range []string{"sda", "sda1", "sdb"} {
+		s.Require().NoError(os.WriteFile(filepath.Join(devDir, device), nil, 0o600))
+	}
+	byPathDir := s.T().TempDir()
+	links := map[string]string{
+		"platform-usb-0:1:1.0-scsi-0:0:0:0":       "sda",
+		"platform-usb-0:1:1.0-scsi-0:0:0:0-part1": "sda1",
+		"platform-usb-0:2:1.0-scsi-0:0:0:0":       "sdb",
+		// A second link to sdb sorts after the first and is ignored.
+		"platform-usbv2-0:2:1.0-scsi-0:0:0:0": "sdb",
+		"platform-dangling":                   "sdz",
+	}
+	for link, device := range links {
+		s.Require().NoError(os.Symlink(filepath.Join(devDir, device), filepath.Join(byPathDir, link)))
+	}
+
+	tests := []struct {
+		name string
+		dir  string
+		want map[string]string
+	}{
+		{
+			name: "links",
+			dir:  byPathDir,
+			want: map[string]string{
+				"sda": "platform-usb-0:1:1.0-scsi-0:0:0:0",
+				"sdb": "platform-usb-0:2:1.0-scsi-0:0:0:0",
+			},
+		},
+		{
+			name: "missing dir",
+			dir:  filepath.Join(byPathDir, "missing"),
+			want: map[string]string{},
+		},
+	}
+	for _, tc := range tests {
+		s.Run(tc.name, func() {
+			s.Equal(tc.want, getByPathNames(tc.dir))
+		})
+	}
+}
+
+func (s *HDDTestSuite) TestHealth() {
+	tests := []struct {
+		name  string
+		stats HDDStats
+		want  string
+	}{
+		{name: "healthy", stats: HDDStats{SmartStatus: SmartStatus{HealthOK: true}}, want: "PASS"},
+		{name: "degraded", stats: HDDStats{SmartStatus: SmartStatus{HealthOK: true, Degraded: true}}, want: "WARN"},
+		{name: "failed", stats: HDDStats{SmartStatus: SmartStatus{Degraded: true}}, want: "FAIL"},
+		{
+			name: "self-test failed",
+			stats: HDDStats{
+				SmartStatus: SmartStatus{HealthOK: true, Degraded: true},
+				SelfTest:    SelfTestStatus{HasResult: true, Passed: false},
+			},
+			want: "FAIL",
+		},
+		{
+			name: "self-test passed",
+			stats: HDDStats{
+				SmartStatus: SmartStatus{HealthOK: true},
+				SelfTest:    SelfTestStatus{HasResult: true, Passed: true},
+			},
+			want: "PASS",
+		},
+	}
+	for _, tc := range tests {
+		s.Run(tc.name, func() {
+			s.Equal(tc.want, tc.stats.Health())
+		})
+	}
+}
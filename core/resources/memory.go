@@ -2,27 +2,70 @@ package resources
 
 import (
 	"bufio"
+	"context"
+	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
 )
 
+const (
+	procMeminfoPath  = "/proc/meminfo"
+	zfsArcstatsPath  = "/proc/spl/kstat/zfs/arcstats"
+	procPressurePath = "/proc/pressure"
+)
+
 // Memory monitoring.
 type MemoryStats struct {
-	Total        uint64
-	Used         uint64
-	Free         uint64
-	Available    uint64
-	SwapTotal    uint64
-	SwapUsed     uint64
-	SwapFree     uint64
-	Buffers      uint64
-	Cached       uint64
+	Total     uint64
+	Used      uint64 // Total - Free - Buffers - Cached - SReclaimable, as reported by free
+	Free      uint64
+	Available uint64
+	SwapTotal uint64
+	SwapUsed  uint64
+	SwapFree  uint64
+	Buffers   uint64
+	Cached    uint64 // page cache, Shmem included
+	// SReclaimable is slab memory the kernel can reclaim, such as the dentry
+	// and inode caches. free counts it as cache.
+	SReclaimable uint64
+	Shmem        uint64 // tmpfs and shared memory; part of Cached but not reclaimable
+	Dirty        uint64 // waiting to be written back to disk
+	Writeback    uint64 // being written back to disk
+	// ZFSArcSize is the size of the ZFS ARC, which the kernel reports as used
+	// rather than cached memory; 0 without ZFS.
+	ZFSArcSize   uint64
 	UsagePercent float64
+	// Pressure is the pressure stall information, nil if the kernel does not provide it.
+	Pressure *PressureStats
+}
+
+// PressureStats is the pressure stall information (PSI) for CPU, memory and
+// I/O as documented in the kernel's Documentation/accounting/psi.rst.
+type PressureStats struct {
+	CPU    Pressure
+	Memory Pressure
+	IO     Pressure
+}
+
+// Pressure is the share of time tasks were stalled on a resource. Some is
+// time at least one task was stalled; Full is time all non-idle tasks were
+// stalled at once. Full is always zero for CPU at the system level.
+type Pressure struct {
+	Some PressureAverages
+	Full PressureAverages
+}
+
+// PressureAverages are the stall percentages averaged over 10, 60 and 300 seconds.
+type PressureAverages struct {
+	Avg10  float64
+	Avg60  float64
+	Avg300 float64
 }
 
 type Memory interface {
-	GetStats() (*MemoryStats, error)
+	GetStats(ctx context.Context) (*MemoryStats, error)
 }
 
 type memoryImpl struct{}
@@ -31,13 +74,31 @@ func NewMemory() Memory {
 	return &memoryImpl{}
 }
 
-func (m *memoryImpl) GetStats() (*MemoryStats, error) {
-	file, err := os.Open("/proc/meminfo")
+func (m *memoryImpl) GetStats(context.Context) (*MemoryStats, error) {
+	file, err := os.Open(procMeminfoPath)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
+	stats, err := parseMeminfo(file)
+	if err != nil {
+		return nil, fmt.Errorf("parsing meminfo: %w", err)
+	}
+
+	if arcstats, err := os.Open(zfsArcstatsPath); err == nil {
+		stats.ZFSArcSize = parseArcSize(arcstats)
+		arcstats.Close()
+	}
+
+	stats.Pressure = readPressure()
+
+	return stats, nil
+}
+
+// parseMeminfo parses /proc/meminfo and derives used memory the way procps
+// free does, counting reclaimable slab as cache.
+func parseMeminfo(r io.Reader) (*MemoryStats, error) {
 	stats := &MemoryStats{}
 	fields := map[string]*uint64{
 		"MemTotal:":     &stats.Total,
@@ -45,11 +106,15 @@ func (m *memoryImpl) GetStats() (*MemoryStats, error) {
 		"MemAvailable:": &stats.Available,
 		"Buffers:":      &stats.Buffers,
 		"Cached:":       &stats.Cached,
+		"SReclaimable:": &stats.SReclaimable,
+		"Shmem:":        &stats.Shmem,
+		"Dirty:":        &stats.Dirty,
+		"Writeback:":    &stats.Writeback,
 		"SwapTotal:":    &stats.SwapTotal,
 		"SwapFree:":     &stats.SwapFree,
 	}
 
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		parts := strings.Fields(scanner.Text())
 		if len(parts) < 2 {
@@ -67,10 +132,98 @@ func (m *memoryImpl) GetStats() (*MemoryStats, error) {
 		}
 		*ptr = value * 1024 // Convert KB to bytes
 	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
 
-	stats.Used = stats.Total - stats.Free - stats.Buffers - stats.Cached
+	// procps falls back to Total - Free when the page cache accounting
+	// exceeds what is not free, which happens briefly under heavy reclaim.
+	if notFree := stats.Total - stats.Free; stats.Buffers+stats.Cached+stats.SReclaimable < notFree {
+		stats.Used = notFree - stats.Buffers - stats.Cached - stats.SReclaimable
+	} else {
+		stats.Used = notFree
+	}
 	stats.SwapUsed = stats.SwapTotal - stats.SwapFree
-	stats.UsagePercent = float64(stats.Used) / float64(stats.Total) * 100
+	if stats.Total > 0 {
+		stats.UsagePercent = float64(stats.Used) / float64(stats.Total) * 100
+	}
 
 	return stats, nil
 }
+
+// parseArcSize returns the current ARC size from the ZFS arcstats kstat:
+//
+//	size                            4    4294967296
+func parseArcSize(r io.Reader) uint64 {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 3 && fields[0] == "size" {
+			return parseUint64(fields[2])
+		}
+	}
+	return 0
+}
+
+// readPressure reads /proc/pressure. It returns nil if the kernel was built
+// without PSI or booted with psi=0, in which case the files are missing or
+// fail to read.
+func readPressure() *PressureStats {
+	stats := &PressureStats{}
+	for name, pressure := range map[string]*Pressure{
+		"cpu":    &stats.CPU,
+		"memory": &stats.Memory,
+		"io":     &stats.IO,
+	} {
+		data, err := os.ReadFile(procPressurePath + "/" + name)
+		if err != nil {
+			return nil
+		}
+		*pressure = parsePressure(string(data))
+	}
+	return stats
+}
+
+// parsePressure parses one /proc/pressure file:
+//
+//	some avg10=0.12 avg60=0.05 avg300=0.01 total=123456
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+func parsePressure(data string) Pressure {
+	var pressure Pressure
+	for line := range strings.Lines(data) {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var averages *PressureAverages
+		switch fields[0] {
+		case "some":
+			averages = &pressure.Some
+		case "full":
+			averages = &pressure.Full
+		default:
+			continue
+		}
+
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				continue
+			}
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			switch key {
+			case "avg10":
+				averages.Avg10 = v
+			case "avg60":
+				averages.Avg60 = v
+			case "avg300":
+				averages.Avg300 = v
+			}
+		}
+	}
+	return pressure
+}
@@ -0,0 +1,84 @@
+package resources
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type MemoryTestSuite struct {
+	suite.Suite
+}
+
+func TestMemoryTestSuite(t *testing.T) {
+	suite.Run(t, new(MemoryTestSuite))
+}
+
+const meminfoSample = `MemTotal:        8000000 kB
+MemFree:         1000000 kB
+MemAvailable:    5000000 kB
+Buffers:          200000 kB
+Cached:          3000000 kB
+SwapCached:            0 kB
+Dirty:              1200 kB
+Writeback:            64 kB
+Shmem:            150000 kB
+SReclaimable:     400000 kB
+SUnreclaim:       100000 kB
+SwapTotal:       2000000 kB
+SwapFree:        1500000 kB
+`
+
+func (s *MemoryTestSuite) TestParseMeminfo() {
+	stats, err := parseMeminfo(strings.NewReader(meminfoSample))
+	s.Require().NoError(err)
+
+	const kb = 1024
+	s.Equal(uint64(8000000*kb), stats.Total)
+	// 8000000 - 1000000 - 200000 - 3000000 - 400000, matching free's "used".
+	s.Equal(uint64(3400000*kb), stats.Used)
+	s.Equal(uint64(400000*kb), stats.SReclaimable)
+	s.Equal(uint64(150000*kb), stats.Shmem)
+	s.Equal(uint64(1200*kb), stats.Dirty)
+	s.Equal(uint64(64*kb), stats.Writeback)
+	s.Equal(uint64(500000*kb), stats.SwapUsed)
+	s.InDelta(42.5, stats.UsagePercent, 0.001)
+}
+
+func (s *MemoryTestSuite) TestParseMeminfoCacheExceedsUsed() {
+	stats, err := parseMeminfo(strings.NewReader(`MemTotal: 1000 kB
+MemFree: 100 kB
+Buffers: 100 kB
+Cached: 800 kB
+SReclaimable: 100 kB
+`))
+	s.Require().NoError(err)
+
+	// Cache accounting exceeds Total - Free; procps reports Total - Free.
+	s.Equal(uint64(900*1024), stats.Used)
+}
+
+func (s *MemoryTestSuite) TestParseArcSize() {
+	arcstats := `13 1 0x01 123 33456 4367212345 81234567890
+name                            type data
+hits                            4    123456
+size                            4    4294967296
+c_min                           4    262144000
+`
+	s.Equal(uint64(4294967296), parseArcSize(strings.NewReader(arcstats)))
+	s.Zero(parseArcSize(strings.NewReader("")))
+}
+
+func (s *MemoryTestSuite) TestParsePressure() {
+	pressure := parsePressure("some avg10=12.50 avg60=4.20 avg300=1.00 total=123456\n" +
+		"full avg10=3.25 avg60=0.80 avg300=0.10 total=2345\n")
+
+	s.Equal(PressureAverages{Avg10: 12.5, Avg60: 4.2, Avg300: 1}, pressure.Some)
+	s.Equal(PressureAverages{Avg10: 3.25, Avg60: 0.8, Avg300: 0.1}, pressure.Full)
+
+	// Kernels before 5.13 have no "full" line for CPU.
+	cpu := parsePressure("some avg10=0.50 avg60=0.20 avg300=0.10 total=99\n")
+	s.InDelta(0.5, cpu.Some.Avg10, 0.001)
+	s.Zero(cpu.Full)
+}
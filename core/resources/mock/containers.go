@@ -0,0 +1,20 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/czechbol/lumeon/core/resources"
+)
+
+// ContainersMock defines mocks for Containers.
+type ContainersMock struct {
+	GetStatsHandler       func(ctx context.Context) ([]resources.ContainerStats, error)
+	GetStatsHandlerCalled int
+}
+
+var _ resources.Containers = (*ContainersMock)(nil)
+
+func (m *ContainersMock) GetStats(ctx context.Context) ([]resources.ContainerStats, error) {
+	m.GetStatsHandlerCalled++
+	return m.GetStatsHandler(ctx)
+}
@@ -8,23 +8,21 @@ import (
 
 // CPUMock defines mocks for CPU.
 type CPUMock struct {
-	GetAverageTempHandler       func() (float64, error)
+	GetAverageTempHandler       func(ctx context.Context) (float64, error)
 	GetAverageTempHandlerCalled int
 
-	GetStatsHandler       func() (*resources.CPUStats, error)
+	GetStatsHandler       func(ctx context.Context) (*resources.CPUStats, error)
 	GetStatsHandlerCalled int
 }
 
 var _ resources.CPU = (*CPUMock)(nil)
 
-func (m *CPUMock) GetAverageTemp() (float64, error) {
+func (m *CPUMock) GetAverageTemp(ctx context.Context) (float64, error) {
 	m.GetAverageTempHandlerCalled++
-	return m.GetAverageTempHandler()
+	return m.GetAverageTempHandler(ctx)
 }
 
-func (m *CPUMock) GetStats() (*resources.CPUStats, error) {
+func (m *CPUMock) GetStats(ctx context.Context) (*resources.CPUStats, error) {
 	m.GetStatsHandlerCalled++
-	return m.GetStatsHandler()
+	return m.GetStatsHandler(ctx)
 }
-
-func (m *CPUMock) Poll(_ context.Context) {}
@@ -0,0 +1,28 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/czechbol/lumeon/core/resources"
+)
+
+// DiskIOMock defines mocks for DiskIO.
+type DiskIOMock struct {
+	GetDeviceStatsHandler       func(ctx context.Context, device string) (*resources.DiskIOStats, error)
+	GetDeviceStatsHandlerCalled int
+
+	GetAllDeviceStatsHandler       func(ctx context.Context) (map[string]*resources.DiskIOStats, error)
+	GetAllDeviceStatsHandlerCalled int
+}
+
+var _ resources.DiskIO = (*DiskIOMock)(nil)
+
+func (m *DiskIOMock) GetDeviceStats(ctx context.Context, device string) (*resources.DiskIOStats, error) {
+	m.GetDeviceStatsHandlerCalled++
+	return m.GetDeviceStatsHandler(ctx, device)
+}
+
+func (m *DiskIOMock) GetAllDeviceStats(ctx context.Context) (map[string]*resources.DiskIOStats, error) {
+	m.GetAllDeviceStatsHandlerCalled++
+	return m.GetAllDeviceStatsHandler(ctx)
+}
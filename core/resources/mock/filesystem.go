@@ -0,0 +1,20 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/czechbol/lumeon/core/resources"
+)
+
+// FilesystemsMock defines mocks for Filesystems.
+type FilesystemsMock struct {
+	GetStatsHandler       func(ctx context.Context) ([]resources.FilesystemStats, error)
+	GetStatsHandlerCalled int
+}
+
+var _ resources.Filesystems = (*FilesystemsMock)(nil)
+
+func (m *FilesystemsMock) GetStats(ctx context.Context) ([]resources.FilesystemStats, error) {
+	m.GetStatsHandlerCalled++
+	return m.GetStatsHandler(ctx)
+}
@@ -1,24 +1,30 @@
 package mock
 
-import "github.com/czechbol/lumeon/core/resources"
+import (
+	"context"
+
+	"github.com/czechbol/lumeon/core/resources"
+)
 
 // HDDMock defines mocks for HDD.
 type HDDMock struct {
-	GetAverageTempHandler       func() (float64, error)
+	GetAverageTempHandler       func(ctx context.Context) (float64, error)
 	GetAverageTempHandlerCalled int
 
-	GetStatsHandler       func() ([]resources.HDDStats, error)
+	GetStatsHandler       func(ctx context.Context) ([]resources.HDDStats, error)
 	GetStatsHandlerCalled int
 }
 
 var _ resources.HDD = (*HDDMock)(nil)
 
-func (m *HDDMock) GetAverageTemp() (float64, error) {
+func (m *HDDMock) GetAverageTemp(ctx context.Context) (float64, error) {
 	m.GetAverageTempHandlerCalled++
-	return m.GetAverageTempHandler()
+	return m.GetAverageTempHandler(ctx)
 }
 
-func (m *HDDMock) GetStats() ([]resources.HDDStats, error) {
+func (m *HDDMock) GetStats(ctx context.Context) ([]resources.HDDStats, error) {
 	m.GetStatsHandlerCalled++
-	return m.GetStatsHandler()
+	return m.GetStatsHandler(ctx)
 }
+
+func (m *HDDMock) ScheduleSelfTests(_ context.Context) {}
@@ -1,16 +1,20 @@
 package mock
 
-import "github.com/czechbol/lumeon/core/resources"
+import (
+	"context"
+
+	"github.com/czechbol/lumeon/core/resources"
+)
 
 // MemoryMock defines mocks for Memory.
 type MemoryMock struct {
-	GetStatsHandler       func() (*resources.MemoryStats, error)
+	GetStatsHandler       func(ctx context.Context) (*resources.MemoryStats, error)
 	GetStatsHandlerCalled int
 }
 
 var _ resources.Memory = (*MemoryMock)(nil)
 
-func (m *MemoryMock) GetStats() (*resources.MemoryStats, error) {
+func (m *MemoryMock) GetStats(ctx context.Context) (*resources.MemoryStats, error) {
 	m.GetStatsHandlerCalled++
-	return m.GetStatsHandler()
+	return m.GetStatsHandler(ctx)
 }
@@ -1,24 +1,28 @@
 package mock
 
-import "github.com/czechbol/lumeon/core/resources"
+import (
+	"context"
+
+	"github.com/czechbol/lumeon/core/resources"
+)
 
 // NetworkMock defines mocks for Network.
 type NetworkMock struct {
-	GetInterfaceStatsHandler       func(iface string) (*resources.NetworkStats, error)
+	GetInterfaceStatsHandler       func(ctx context.Context, iface string) (*resources.NetworkStats, error)
 	GetInterfaceStatsHandlerCalled int
 
-	GetAllInterfaceStatsHandler       func() (map[string]*resources.NetworkStats, error)
+	GetAllInterfaceStatsHandler       func(ctx context.Context) (map[string]*resources.NetworkStats, error)
 	GetAllInterfaceStatsHandlerCalled int
 }
 
 var _ resources.Network = (*NetworkMock)(nil)
 
-func (m *NetworkMock) GetInterfaceStats(iface string) (*resources.NetworkStats, error) {
+func (m *NetworkMock) GetInterfaceStats(ctx context.Context, iface string) (*resources.NetworkStats, error) {
 	m.GetInterfaceStatsHandlerCalled++
-	return m.GetInterfaceStatsHandler(iface)
+	return m.GetInterfaceStatsHandler(ctx, iface)
 }
 
-func (m *NetworkMock) GetAllInterfaceStats() (map[string]*resources.NetworkStats, error) {
+func (m *NetworkMock) GetAllInterfaceStats(ctx context.Context) (map[string]*resources.NetworkStats, error) {
 	m.GetAllInterfaceStatsHandlerCalled++
-	return m.GetAllInterfaceStatsHandler()
+	return m.GetAllInterfaceStatsHandler(ctx)
 }
@@ -0,0 +1,28 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/czechbol/lumeon/core/resources"
+)
+
+// StorageMock defines mocks for Storage.
+type StorageMock struct {
+	GetDevicesHandler       func(ctx context.Context) ([]resources.StackDevice, error)
+	GetDevicesHandlerCalled int
+
+	GetPoolsHandler       func(ctx context.Context) ([]resources.Pool, error)
+	GetPoolsHandlerCalled int
+}
+
+var _ resources.Storage = (*StorageMock)(nil)
+
+func (m *StorageMock) GetDevices(ctx context.Context) ([]resources.StackDevice, error) {
+	m.GetDevicesHandlerCalled++
+	return m.GetDevicesHandler(ctx)
+}
+
+func (m *StorageMock) GetPools(ctx context.Context) ([]resources.Pool, error) {
+	m.GetPoolsHandlerCalled++
+	return m.GetPoolsHandler(ctx)
+}
@@ -0,0 +1,20 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/czechbol/lumeon/core/resources"
+)
+
+// SystemMock defines mocks for System.
+type SystemMock struct {
+	GetStatsHandler       func(ctx context.Context) (*resources.SystemStats, error)
+	GetStatsHandlerCalled int
+}
+
+var _ resources.System = (*SystemMock)(nil)
+
+func (m *SystemMock) GetStats(ctx context.Context) (*resources.SystemStats, error) {
+	m.GetStatsHandlerCalled++
+	return m.GetStatsHandler(ctx)
+}
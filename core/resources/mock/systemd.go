@@ -0,0 +1,20 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/czechbol/lumeon/core/resources"
+)
+
+// SystemdMock defines mocks for Systemd.
+type SystemdMock struct {
+	GetStatsHandler       func(ctx context.Context) (*resources.SystemdStats, error)
+	GetStatsHandlerCalled int
+}
+
+var _ resources.Systemd = (*SystemdMock)(nil)
+
+func (m *SystemdMock) GetStats(ctx context.Context) (*resources.SystemdStats, error) {
+	m.GetStatsHandlerCalled++
+	return m.GetStatsHandler(ctx)
+}
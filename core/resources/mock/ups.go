@@ -0,0 +1,20 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/czechbol/lumeon/core/resources"
+)
+
+// UPSMock defines mocks for UPS.
+type UPSMock struct {
+	GetStatsHandler       func(ctx context.Context) (*resources.UPSStats, error)
+	GetStatsHandlerCalled int
+}
+
+var _ resources.UPS = (*UPSMock)(nil)
+
+func (m *UPSMock) GetStats(ctx context.Context) (*resources.UPSStats, error) {
+	m.GetStatsHandlerCalled++
+	return m.GetStatsHandler(ctx)
+}
@@ -2,10 +2,12 @@ package resources
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -23,11 +25,12 @@ type NetworkStats struct {
 }
 
 type Network interface {
-	GetInterfaceStats(iface string) (*NetworkStats, error)
-	GetAllInterfaceStats() (map[string]*NetworkStats, error)
+	GetInterfaceStats(ctx context.Context, iface string) (*NetworkStats, error)
+	GetAllInterfaceStats(ctx context.Context) (map[string]*NetworkStats, error)
 }
 
 type networkImpl struct {
+	mu        sync.Mutex
 	prevStats map[string]*NetworkStats
 	lastCheck time.Time
 }
@@ -39,7 +42,7 @@ func NewNetwork() Network {
 	}
 }
 
-func (n *networkImpl) GetAllInterfaceStats() (map[string]*NetworkStats, error) {
+func (n *networkImpl) GetAllInterfaceStats(context.Context) (map[string]*NetworkStats, error) {
 	file, err := os.Open("/proc/net/dev")
 	if err != nil {
 		return nil, err
@@ -53,6 +56,9 @@ func (n *networkImpl) GetAllInterfaceStats() (map[string]*NetworkStats, error) {
 	scanner.Scan()
 	scanner.Scan()
 
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
 	now := time.Now()
 	timeDiff := now.Sub(n.lastCheck).Seconds()
 
@@ -89,8 +95,8 @@ func (n *networkImpl) GetAllInterfaceStats() (map[string]*NetworkStats, error) {
 	return stats, nil
 }
 
-func (n *networkImpl) GetInterfaceStats(iface string) (*NetworkStats, error) {
-	stats, err := n.GetAllInterfaceStats()
+func (n *networkImpl) GetInterfaceStats(ctx context.Context, iface string) (*NetworkStats, error) {
+	stats, err := n.GetAllInterfaceStats(ctx)
 	if err != nil {
 		return nil, err
 	}
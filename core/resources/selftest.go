@@ -0,0 +1,268 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/czechbol/lumeon/core/resources/dto"
+)
+
+// SelfTestType is the kind of SMART self-test to run.
+type SelfTestType string
+
+const (
+	SelfTestShort SelfTestType = "short"
+	SelfTestLong  SelfTestType = "long"
+
+	selfTestInProgress    = 0x0F // upper nibble of the self-test status while running
+	smartctlStandbyExit   = 0x02 // smartctl exit bit set when --nocheck=standby skipped the device
+	selfTestDriveWildcard = "*"
+)
+
+// SelfTestSchedule starts a self-test on matching drives whenever its cron expression fires.
+type SelfTestSchedule struct {
+	// Drive is a bay label, serial number, by-path name or kernel device name.
+	// "*" or empty matches every drive.
+	Drive string
+	Type  SelfTestType
+	Cron  string
+	cron  *cronSchedule
+}
+
+// NewSelfTestSchedule validates and builds a schedule, e.g. ("Bay 1", "long", "0 3 * * 0").
+func NewSelfTestSchedule(drive, testType, cron string) (SelfTestSchedule, error) {
+	t := SelfTestType(strings.ToLower(testType))
+	if t != SelfTestShort && t != SelfTestLong {
+		return SelfTestSchedule{}, fmt.Errorf("%w: %q, expected short or long", ErrInvalidSelfTestType, testType)
+	}
+
+	parsed, err := parseCron(cron)
+	if err != nil {
+		return SelfTestSchedule{}, err
+	}
+
+	return SelfTestSchedule{
+		Drive: drive,
+		Type:  t,
+		Cron:  cron,
+		cron:  parsed,
+	}, nil
+}
+
+func (s *SelfTestSchedule) matchesDrive(stats *HDDStats) bool {
+	if s.Drive == "" || s.Drive == selfTestDriveWildcard {
+		return true
+	}
+	for _, id := range []string{stats.Label, stats.Serial, stats.ByPath, stats.DeviceName} {
+		if id != "" && strings.EqualFold(id, s.Drive) {
+			return true
+		}
+	}
+	return false
+}
+
+// SelfTestStatus is the progress of a running self-test and the outcome of the last completed one.
+type SelfTestStatus struct {
+	Running   bool
+	Progress  int  // percent complete while Running
+	HasResult bool // false if the self-test log is empty
+	LastType  string
+	Result    string // e.g. "Completed without error"
+	Passed    bool
+	Hours     int // power-on hours when the last test ran
+}
+
+func populateSelfTest(stats *HDDStats, deviceInfo *dto.SmartctlOutput) {
+	status := deviceInfo.AtaSmartData.SelfTest.Status
+	selfTest := SelfTestStatus{
+		Running: status.Value>>4 == selfTestInProgress,
+	}
+	if selfTest.Running {
+		selfTest.Progress = 100 - status.RemainingPercent
+	}
+
+	// The log is ordered newest first; skip an entry for the test still running.
+	for _, entry := range deviceInfo.AtaSmartSelfTest.Standard.Table {
+		if entry.Status.Value>>4 == selfTestInProgress {
+			continue
+		}
+		selfTest.HasResult = true
+		selfTest.LastType = entry.Type.String
+		selfTest.Result = entry.Status.String
+		selfTest.Passed = entry.Status.Passed
+		selfTest.Hours = entry.LifetimeHours
+		break
+	}
+
+	stats.SelfTest = selfTest
+}
+
+// logSelfTestResult logs a self-test result once, the first time it appears in the log.
+func (h *hddImpl) logSelfTestResult(stats *HDDStats) {
+	if !stats.SelfTest.HasResult || stats.Serial == "" {
+		return
+	}
+
+	h.mu.Lock()
+	previous, seen := h.selfTestResults[stats.Serial]
+	h.selfTestResults[stats.Serial] = stats.SelfTest
+	h.mu.Unlock()
+
+	if !seen || previous.Hours == stats.SelfTest.Hours && previous.Result == stats.SelfTest.Result {
+		return
+	}
+
+	args := []any{
+		"device", stats.DeviceName, "label", stats.Label, "serial", stats.Serial,
+		"type", stats.SelfTest.LastType, "result", stats.SelfTest.Result,
+	}
+	if stats.SelfTest.Passed {
+		slog.Info("SMART self-test finished", args...)
+	} else {
+		slog.Error("SMART self-test failed", args...)
+	}
+}
+
+// ScheduleSelfTests starts a goroutine that checks the configured schedules at
+// the top of every minute and starts due self-tests. The goroutine stops when
+// ctx is cancelled.
+func (h *hddImpl) ScheduleSelfTests(ctx context.Context) {
+	if len(h.selfTests) == 0 {
+		return
+	}
+
+	go func() {
+		for {
+			now := time.Now()
+			next := now.Truncate(time.Minute).Add(time.Minute)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(next.Sub(now)):
+			}
+
+			h.runDueSelfTests(ctx, next)
+		}
+	}()
+}
+
+func (h *hddImpl) runDueSelfTests(ctx context.Context, at time.Time) {
+	var due []SelfTestSchedule
+	for _, schedule := range h.selfTests {
+		if schedule.cron.matches(at) {
+			due = append(due, schedule)
+		}
+	}
+	if len(due) == 0 {
+		return
+	}
+
+	// Drives in standby are skipped by getOrRefresh (smartctl --nocheck=standby), so
+	// only awake drives are considered and no drive is spun up just to be tested.
+	stats, err := h.getOrRefresh(ctx)
+	if err != nil {
+		slog.Error("self-test scheduler: failed to get drive stats", "error", err)
+		return
+	}
+
+	started := false
+	for i := range stats {
+		stat := &stats[i]
+
+		testType, ok := dueSelfTestType(stat, due)
+		if !ok {
+			continue
+		}
+		if stat.SelfTest.Running {
+			slog.Info("skipping scheduled self-test, another test is running", "device", stat.DeviceName,
+				"label", stat.Label, "type", testType, "progress", stat.SelfTest.Progress)
+			continue
+		}
+
+		if err := h.startTest(ctx, stat, testType); err != nil {
+			if errors.Is(err, ErrSelfTestNotStarted) {
+				slog.Info("skipping scheduled self-test", "device", stat.DeviceName, "label", stat.Label,
+					"type", testType, "reason", err)
+			} else {
+				slog.Error("failed to start self-test", "device", stat.DeviceName, "label", stat.Label,
+					"type", testType, "error", err)
+			}
+			continue
+		}
+		slog.Info("started SMART self-test", "device", stat.DeviceName, "label", stat.Label, "type", testType)
+		started = true
+	}
+
+	if started {
+		// Expire the cache so the next read shows the test in progress.
+		h.mu.Lock()
+		h.cacheTime = time.Time{}
+		h.mu.Unlock()
+	}
+}
+
+// dueSelfTestType picks the test to run on a drive when several schedules fire
+// at once. A long test covers everything a short test does, so it wins.
+func dueSelfTestType(stats *HDDStats, due []SelfTestSchedule) (SelfTestType, bool) {
+	var testType SelfTestType
+	for _, schedule := range due {
+		if !schedule.matchesDrive(stats) {
+			continue
+		}
+		if testType == "" || schedule.Type == SelfTestLong {
+			testType = schedule.Type
+		}
+	}
+	return testType, testType != ""
+}
+
+func startSelfTest(ctx context.Context, stats *HDDStats, testType SelfTestType) error {
+	if strings.HasPrefix(stats.DeviceName, "nvme") {
+		return fmt.Errorf("%w: NVMe self-tests are not supported", ErrSelfTestNotStarted)
+	}
+
+	//nolint:gosec // testType is validated to "short" or "long"; DeviceName is from trusted lsblk output
+	cmd := exec.CommandContext(
+		ctx,
+		"smartctl",
+		"-d",
+		"sat",
+		"-j",
+		"--nocheck=standby",
+		"--test="+string(testType),
+		filepath.Join("/dev", stats.DeviceName),
+	)
+	output, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return err
+	}
+
+	return selfTestStartError(output)
+}
+
+// selfTestStartError reads the JSON output of smartctl --test and returns why
+// the test was not started, or nil if it was.
+func selfTestStartError(output []byte) error {
+	var smartctlOutput dto.SmartctlOutput
+	if err := json.Unmarshal(output, &smartctlOutput); err != nil {
+		return err
+	}
+
+	switch status := smartctlOutput.Smartctl.ExitStatus; {
+	case status&smartctlStandbyExit != 0:
+		return fmt.Errorf("%w: drive is in standby", ErrSelfTestNotStarted)
+	case status != 0:
+		return fmt.Errorf("smartctl exit status %d: %w", status, ErrSmartctlFailed)
+	}
+
+	return nil
+}
@@ -0,0 +1,241 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/czechbol/lumeon/core/resources/dto"
+	"github.com/stretchr/testify/suite"
+)
+
+// smartctlSelfTestRunning is the self-test part of smartctl -j output while a
+// short test is 30% done, with an earlier extended test in the log.
+const smartctlSelfTestRunning = `{
+  "ata_smart_data": {
+    "self_test": {"status": {"value": 247, "string": "in progress, 70% remaining", "remaining_percent": 70}}
+  },
+  "ata_smart_self_test_log": {"standard": {"revision": 1, "count": 2, "table": [
+    {"type": {"value": 1, "string": "Short offline"},
+     "status": {"value": 247, "string": "Self-test routine in progress", "remaining_percent": 70},
+     "lifetime_hours": 20112},
+    {"type": {"value": 2, "string": "Extended offline"},
+     "status": {"value": 0, "string": "Completed without error", "passed": true},
+     "lifetime_hours": 20010}
+  ]}}
+}`
+
+// smartctlSelfTestFailed is the self-test part of smartctl -j output after a
+// failed extended test.
+const smartctlSelfTestFailed = `{
+  "ata_smart_data": {
+    "self_test": {"status": {"value": 121, "string": "completed: read failure", "passed": false}}
+  },
+  "ata_smart_self_test_log": {"standard": {"revision": 1, "count": 1, "table": [
+    {"type": {"value": 2, "string": "Extended offline"},
+     "status": {"value": 121, "string": "Completed: read failure", "passed": false},
+     "lifetime_hours": 31000}
+  ]}}
+}`
+
+// smartctlSelfTestNever is the self-test part of smartctl -j output for a
+// drive that was never tested.
+const smartctlSelfTestNever = `{
+  "ata_smart_data": {"self_test": {"status": {"value": 0, "string": "never started", "passed": true}}},
+  "ata_smart_self_test_log": {"standard": {"revision": 1, "count": 0}}
+}`
+
+type SelfTestTestSuite struct {
+	suite.Suite
+}
+
+func TestSelfTestTestSuite(t *testing.T) {
+	suite.Run(t, new(SelfTestTestSuite))
+}
+
+func (s *SelfTestTestSuite) schedule(drive, testType string) SelfTestSchedule {
+	schedule, err := NewSelfTestSchedule(drive, testType, "0 3 * * *")
+	s.Require().NoError(err)
+	return schedule
+}
+
+func (s *SelfTestTestSuite) TestPopulateSelfTest() {
+	tests := []struct {
+		name    string
+		fixture string
+		want    SelfTestStatus
+	}{
+		{
+			name:    "running",
+			fixture: smartctlSelfTestRunning,
+			want: SelfTestStatus{
+				Running:   true,
+				Progress:  30,
+				HasResult: true,
+				LastType:  "Extended offline",
+				Result:    "Completed without error",
+				Passed:    true,
+				Hours:     20010,
+			},
+		},
+		{
+			name:    "failed",
+			fixture: smartctlSelfTestFailed,
+			want: SelfTestStatus{
+				HasResult: true,
+				LastType:  "Extended offline",
+				Result:    "Completed: read failure",
+				Hours:     31000,
+			},
+		},
+		{
+			name:    "never tested",
+			fixture: smartctlSelfTestNever,
+			want:    SelfTestStatus{},
+		},
+	}
+	for _, tc := range tests {
+		s.Run(tc.name, func() {
+			var output dto.SmartctlOutput
+			s.Require().NoError(json.Unmarshal([]byte(tc.fixture), &output))
+
+			var stats HDDStats
+			populateSelfTest(&stats, &output)
+			s.Equal(tc.want, stats.SelfTest)
+		})
+	}
+}
+
+func (s *SelfTestTestSuite) TestMatchesDrive() {
+	drive := &HDDStats{
+		DeviceName: "sda",
+		Label:      "Bay 1",
+		Serial:     "WD-WCC4E1234567",
+		ByPath:     "platform-fd500000.pcie-pci-0000:01:00.0-usb-0:2:1.0-scsi-0:0:0:0",
+	}
+
+	tests := []struct {
+		drive string
+		want  bool
+	}{
+		{drive: "", want: true},
+		{drive: "*", want: true},
+		{drive: "Bay 1", want: true},
+		{drive: "bay 1", want: true},
+		{drive: "wd-wcc4e1234567", want: true},
+		{drive: "platform-fd500000.pcie-pci-0000:01:00.0-usb-0:2:1.0-scsi-0:0:0:0", want: true},
+		{drive: "sda", want: true},
+		{drive: "Bay 2", want: false},
+		{drive: "sdb", want: false},
+	}
+	for _, tc := range tests {
+		s.Run(tc.drive, func() {
+			schedule := s.schedule(tc.drive, "short")
+			s.Equal(tc.want, schedule.matchesDrive(drive))
+		})
+	}
+}
+
+func (s *SelfTestTestSuite) TestDueSelfTestType() {
+	drive := &HDDStats{DeviceName: "sda", Label: "Bay 1"}
+
+	tests := []struct {
+		name   string
+		due    []SelfTestSchedule
+		want   SelfTestType
+		wantOK bool
+	}{
+		{name: "none due", due: nil},
+		{name: "other drive", due: []SelfTestSchedule{s.schedule("Bay 2", "long")}},
+		{
+			name:   "short",
+			due:    []SelfTestSchedule{s.schedule("*", "short")},
+			want:   SelfTestShort,
+			wantOK: true,
+		},
+		{
+			name:   "long wins after short",
+			due:    []SelfTestSchedule{s.schedule("*", "short"), s.schedule("Bay 1", "long")},
+			want:   SelfTestLong,
+			wantOK: true,
+		},
+		{
+			name:   "long wins before short",
+			due:    []SelfTestSchedule{s.schedule("Bay 1", "long"), s.schedule("*", "short")},
+			want:   SelfTestLong,
+			wantOK: true,
+		},
+		{
+			name:   "long for another drive",
+			due:    []SelfTestSchedule{s.schedule("*", "short"), s.schedule("Bay 2", "long")},
+			want:   SelfTestShort,
+			wantOK: true,
+		},
+	}
+	for _, tc := range tests {
+		s.Run(tc.name, func() {
+			testType, ok := dueSelfTestType(drive, tc.due)
+			s.Equal(tc.wantOK, ok)
+			s.Equal(tc.want, testType)
+		})
+	}
+}
+
+func (s *SelfTestTestSuite) TestSelfTestStartError() {
+	tests := []struct {
+		name    string
+		output  string
+		wantErr error
+	}{
+		{name: "started", output: `{"smartctl": {"exit_status": 0}}`},
+		{name: "standby", output: `{"smartctl": {"exit_status": 2}}`, wantErr: ErrSelfTestNotStarted},
+		{name: "failed", output: `{"smartctl": {"exit_status": 4}}`, wantErr: ErrSmartctlFailed},
+	}
+	for _, tc := range tests {
+		s.Run(tc.name, func() {
+			s.ErrorIs(selfTestStartError([]byte(tc.output)), tc.wantErr)
+		})
+	}
+
+	s.Error(selfTestStartError([]byte("not json")))
+}
+
+func (s *SelfTestTestSuite) TestRunDueSelfTests() {
+	at := time.Date(2026, 3, 15, 3, 0, 0, 0, time.Local)
+	hdd, ok := NewHDD(HDDOptions{SelfTests: []SelfTestSchedule{
+		s.schedule("*", "short"),
+		s.schedule("Bay 2", "long"),
+		s.schedule("Bay 4", "long"),
+	}}).(*hddImpl)
+	s.Require().True(ok)
+
+	// Drives in standby are not in the stats, so they are never started.
+	hdd.cachedStats = []HDDStats{
+		{DeviceName: "sda", Label: "Bay 1"},
+		{DeviceName: "sdb", Label: "Bay 2"},
+		{DeviceName: "sdc", Label: "Bay 3", SelfTest: SelfTestStatus{Running: true, Progress: 40}},
+		{DeviceName: "sdd", Label: "Bay 4"},
+	}
+	hdd.cacheTime = time.Now()
+
+	started := make(map[string]SelfTestType)
+	hdd.startTest = func(_ context.Context, stats *HDDStats, testType SelfTestType) error {
+		if stats.DeviceName == "sdd" {
+			// The drive went to standby since the stats were read.
+			return selfTestStartError([]byte(`{"smartctl": {"exit_status": 2}}`))
+		}
+		started[stats.DeviceName] = testType
+		return nil
+	}
+
+	hdd.runDueSelfTests(context.Background(), at)
+	s.Equal(map[string]SelfTestType{"sda": SelfTestShort, "sdb": SelfTestLong}, started)
+	// The cache is expired so the next read shows the tests running.
+	s.True(hdd.cacheTime.IsZero())
+
+	// Nothing is due at another minute.
+	clear(started)
+	hdd.runDueSelfTests(context.Background(), at.Add(time.Minute))
+	s.Empty(started)
+}
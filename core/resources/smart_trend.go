@@ -0,0 +1,271 @@
+package resources
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/czechbol/lumeon/core/resources/dto"
+)
+
+const (
+	AttrReportedUncorrectable = 187
+	AttrUDMACRCErrors         = 199
+
+	smartSnapshotInterval = time.Hour
+	smartSnapshotHistory  = 24 * 30 // one month of hourly snapshots
+)
+
+// trackedAttributes are the SMART counters whose growth predicts failure.
+// Any increase over the drive's baseline marks it as degraded.
+var trackedAttributes = map[int]string{
+	AttrReallocatedSectors:    "reallocated sectors",
+	AttrReportedUncorrectable: "reported uncorrectable errors",
+	AttrPendingSectors:        "pending sectors",
+	AttrUncorrectableSectors:  "offline uncorrectable sectors",
+	AttrUDMACRCErrors:         "UDMA CRC errors",
+}
+
+// SmartSnapshot is a point-in-time record of the tracked SMART counters of a drive.
+type SmartSnapshot struct {
+	Taken       time.Time     `json:"taken"`
+	Attributes  map[int]int64 `json:"attributes"`
+	Temperature float64       `json:"temperature"`
+}
+
+// smartHistory is the persisted per-drive state, keyed by serial number.
+type smartHistory struct {
+	Serial         string          `json:"serial"`
+	Model          string          `json:"model"`
+	Baseline       SmartSnapshot   `json:"baseline"`
+	MaxTemperature float64         `json:"maxTemperature"`
+	Snapshots      []SmartSnapshot `json:"snapshots"`
+	// Increased is when a tracked counter last rose while above the
+	// baseline. It is zero while no counter is above the baseline.
+	Increased time.Time `json:"increased,omitzero"`
+
+	// lastSeen is the most recent evaluation, kept in memory so counter
+	// increases are logged once rather than on every refresh.
+	lastSeen *SmartSnapshot
+}
+
+// smartTracker persists SMART snapshots and derives degradation findings from them.
+type smartTracker struct {
+	mu       sync.Mutex
+	stateDir string
+	// acceptAfter is how long the counters of a degraded drive have to stay
+	// the same before they become its baseline. Zero never accepts them.
+	acceptAfter time.Duration
+	histories   map[string]*smartHistory
+}
+
+func newSmartTracker(stateDir string, acceptAfter time.Duration) *smartTracker {
+	return &smartTracker{
+		stateDir:    stateDir,
+		acceptAfter: acceptAfter,
+		histories:   make(map[string]*smartHistory),
+	}
+}
+
+// evaluate records the current SMART state of the drive and returns the
+// degradation findings relative to its baseline, the first recorded snapshot
+// or the counters last accepted after acceptAfter.
+func (t *smartTracker) evaluate(stats *HDDStats, deviceInfo *dto.SmartctlOutput) []string {
+	if stats.Serial == "" {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current := SmartSnapshot{
+		Taken:       time.Now(),
+		Attributes:  make(map[int]int64, len(trackedAttributes)),
+		Temperature: stats.Temperature,
+	}
+	for id := range trackedAttributes {
+		if attr := getSMARTAttribute(deviceInfo, id); attr != nil {
+			current.Attributes[id] = attr.Raw.Value
+		}
+	}
+
+	history := t.load(stats)
+	if history.Baseline.Attributes == nil {
+		history.Baseline = current
+	}
+
+	findings := t.compare(stats, history, current)
+	changed := t.accept(stats, history, current, findings)
+	if changed {
+		findings = nil
+	}
+	history.lastSeen = &current
+
+	history.MaxTemperature = max(history.MaxTemperature, current.Temperature)
+	previous := history.latest()
+	due := previous == nil || current.Taken.Sub(previous.Taken) >= smartSnapshotInterval
+	if due {
+		history.Snapshots = append(history.Snapshots, current)
+		if len(history.Snapshots) > smartSnapshotHistory {
+			history.Snapshots = history.Snapshots[len(history.Snapshots)-smartSnapshotHistory:]
+		}
+	}
+	if due || changed {
+		if err := t.save(history); err != nil {
+			slog.Error("error saving SMART snapshot", "device", stats.DeviceName, "label", stats.Label, "error", err)
+		}
+	}
+
+	return findings
+}
+
+func (t *smartTracker) compare(stats *HDDStats, history *smartHistory, current SmartSnapshot) []string {
+	ids := make([]int, 0, len(current.Attributes))
+	for id := range current.Attributes {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	var findings []string
+	for _, id := range ids {
+		value := current.Attributes[id]
+		base, ok := history.Baseline.Attributes[id]
+		if !ok || value <= base {
+			continue
+		}
+		findings = append(findings, fmt.Sprintf("%s increased from %d to %d", trackedAttributes[id], base, value))
+
+		if previous := history.lastSeen; previous == nil || value > previous.Attributes[id] {
+			slog.Warn("SMART counter increased", "device", stats.DeviceName, "label", stats.Label,
+				"serial", stats.Serial, "attribute", trackedAttributes[id], "baseline", base, "current", value)
+		}
+	}
+
+	return findings
+}
+
+// accept keeps track of when the counters of a degraded drive last rose, and
+// makes them the baseline once they have not risen for acceptAfter, so a
+// drive is not flagged forever for errors that stopped growing. It reports
+// whether the baseline was moved.
+func (t *smartTracker) accept(stats *HDDStats, history *smartHistory, current SmartSnapshot, findings []string) bool {
+	if len(findings) == 0 {
+		history.Increased = time.Time{}
+		return false
+	}
+	if history.Increased.IsZero() || rose(history.lastSeen, current) {
+		history.Increased = current.Taken
+	}
+	if t.acceptAfter == 0 || current.Taken.Sub(history.Increased) < t.acceptAfter {
+		return false
+	}
+
+	slog.Info("accepting SMART counters as the new baseline", "device", stats.DeviceName, "label", stats.Label,
+		"serial", stats.Serial, "unchangedFor", current.Taken.Sub(history.Increased).Round(time.Hour))
+	history.Baseline = current
+	history.Increased = time.Time{}
+	return true
+}
+
+// rose reports whether a tracked counter is higher in current than in
+// previous. It is false without a previous evaluation, as after a restart.
+func rose(previous *SmartSnapshot, current SmartSnapshot) bool {
+	if previous == nil {
+		return false
+	}
+	for id, value := range current.Attributes {
+		if value > previous.Attributes[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// load returns the cached history for a drive, reading it from disk on first
+// use and starting a new baseline if none exists.
+func (t *smartTracker) load(stats *HDDStats) *smartHistory {
+	if history, ok := t.histories[stats.Serial]; ok {
+		return history
+	}
+
+	history := &smartHistory{}
+	if t.stateDir == "" {
+		history.Serial = stats.Serial
+		history.Model = stats.Model
+		t.histories[stats.Serial] = history
+		return history
+	}
+
+	data, err := os.ReadFile(t.path(stats.Serial))
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, history); err != nil {
+			slog.Error("error parsing SMART history, starting a new baseline", "serial", stats.Serial, "error", err)
+			history = &smartHistory{}
+		}
+	case errors.Is(err, fs.ErrNotExist):
+	default:
+		slog.Error("error reading SMART history", "serial", stats.Serial, "error", err)
+	}
+
+	if history.Serial == "" {
+		slog.Info("recording SMART baseline", "device", stats.DeviceName, "label", stats.Label, "serial", stats.Serial)
+		history.Serial = stats.Serial
+		history.Model = stats.Model
+	}
+	t.histories[stats.Serial] = history
+	return history
+}
+
+func (t *smartTracker) save(history *smartHistory) error {
+	if t.stateDir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(t.stateDir, 0o750); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+
+	// Write atomically so a power cut mid-write does not lose the baseline.
+	tmp := t.path(history.Serial) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, t.path(history.Serial))
+}
+
+// path returns the snapshot file of the drive with serial. Characters other
+// than letters, digits, '.', '_' and '-' are escaped as %XX, so every serial
+// has a file of its own.
+func (t *smartTracker) path(serial string) string {
+	var name strings.Builder
+	for _, c := range []byte(serial) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '.', c == '_', c == '-':
+			name.WriteByte(c)
+		default:
+			fmt.Fprintf(&name, "%%%02X", c)
+		}
+	}
+	return filepath.Join(t.stateDir, "smart-"+name.String()+".json")
+}
+
+func (h *smartHistory) latest() *SmartSnapshot {
+	if len(h.Snapshots) == 0 {
+		return nil
+	}
+	return &h.Snapshots[len(h.Snapshots)-1]
+}
@@ -0,0 +1,141 @@
+package resources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/czechbol/lumeon/core/resources/dto"
+	"github.com/stretchr/testify/suite"
+)
+
+type SmartTrendTestSuite struct {
+	suite.Suite
+	stateDir string
+	tracker  *smartTracker
+}
+
+func (s *SmartTrendTestSuite) SetupTest() {
+	s.stateDir = s.T().TempDir()
+	s.tracker = newSmartTracker(s.stateDir, 0)
+}
+
+func TestSmartTrendTestSuite(t *testing.T) {
+	suite.Run(t, new(SmartTrendTestSuite))
+}
+
+func smartOutput(reallocated, crc int64) *dto.SmartctlOutput {
+	return &dto.SmartctlOutput{
+		AtaSmartAttributes: dto.AtaSmartAttributes{
+			Table: []dto.Attribute{
+				{ID: AttrReallocatedSectors, Raw: dto.Raw{Value: reallocated}},
+				{ID: AttrUDMACRCErrors, Raw: dto.Raw{Value: crc}},
+			},
+		},
+	}
+}
+
+func (s *SmartTrendTestSuite) TestBaselineHasNoFindings() {
+	stats := &HDDStats{DeviceName: "sda", Serial: "SN1", Temperature: 35}
+
+	findings := s.tracker.evaluate(stats, smartOutput(8, 0))
+	s.Empty(findings)
+}
+
+func (s *SmartTrendTestSuite) TestIncreaseIsFlagged() {
+	stats := &HDDStats{DeviceName: "sda", Serial: "SN1", Temperature: 35}
+	s.tracker.evaluate(stats, smartOutput(8, 0))
+
+	findings := s.tracker.evaluate(stats, smartOutput(12, 3))
+	s.Equal([]string{
+		"reallocated sectors increased from 8 to 12",
+		"UDMA CRC errors increased from 0 to 3",
+	}, findings)
+}
+
+func (s *SmartTrendTestSuite) TestBaselinePersistsAcrossRestarts() {
+	stats := &HDDStats{DeviceName: "sda", Serial: "SN/1", Temperature: 35}
+	s.tracker.evaluate(stats, smartOutput(8, 0))
+
+	restarted := newSmartTracker(s.stateDir, 0)
+	findings := restarted.evaluate(stats, smartOutput(9, 0))
+	s.Equal([]string{"reallocated sectors increased from 8 to 9"}, findings)
+}
+
+func (s *SmartTrendTestSuite) TestTemperatureIsNotAFinding() {
+	// Overheating is raised by the health service, not as degradation.
+	stats := &HDDStats{DeviceName: "sda", Serial: "SN1", Temperature: 80}
+
+	s.Empty(s.tracker.evaluate(stats, smartOutput(0, 0)))
+}
+
+func (s *SmartTrendTestSuite) TestSnapshotsAreThrottled() {
+	stats := &HDDStats{DeviceName: "sda", Serial: "SN1", Temperature: 35}
+	s.tracker.evaluate(stats, smartOutput(0, 0))
+	s.tracker.evaluate(stats, smartOutput(0, 0))
+	s.Len(s.tracker.histories["SN1"].Snapshots, 1)
+
+	s.tracker.histories["SN1"].Snapshots[0].Taken = time.Now().Add(-2 * smartSnapshotInterval)
+	s.tracker.evaluate(stats, smartOutput(0, 0))
+	s.Len(s.tracker.histories["SN1"].Snapshots, 2)
+}
+
+func (s *SmartTrendTestSuite) TestNoSerialIsIgnored() {
+	stats := &HDDStats{DeviceName: "sda", Temperature: 80}
+
+	s.Nil(s.tracker.evaluate(stats, smartOutput(0, 0)))
+}
+
+func (s *SmartTrendTestSuite) TestSerialsDoNotCollide() {
+	s.tracker.evaluate(&HDDStats{DeviceName: "sda", Serial: "SN/1"}, smartOutput(8, 0))
+	s.tracker.evaluate(&HDDStats{DeviceName: "sdb", Serial: "SN_1"}, smartOutput(0, 0))
+
+	s.NotEqual(s.tracker.path("SN/1"), s.tracker.path("SN_1"))
+	restarted := newSmartTracker(s.stateDir, 0)
+	findings := restarted.evaluate(&HDDStats{DeviceName: "sda", Serial: "SN/1"}, smartOutput(9, 0))
+	s.Equal([]string{"reallocated sectors increased from 8 to 9"}, findings)
+}
+
+func (s *SmartTrendTestSuite) TestWithoutStateDir() {
+	tracker := newSmartTracker("", 0)
+	stats := &HDDStats{DeviceName: "sda", Serial: "SN1", Temperature: 35}
+	tracker.evaluate(stats, smartOutput(8, 0))
+
+	findings := tracker.evaluate(stats, smartOutput(9, 0))
+	s.Equal([]string{"reallocated sectors increased from 8 to 9"}, findings)
+	s.Equal("SN1", tracker.histories["SN1"].Serial)
+}
+
+func (s *SmartTrendTestSuite) TestAcceptedAfterUnchanged() {
+	s.tracker = newSmartTracker(s.stateDir, 7*24*time.Hour)
+	stats := &HDDStats{DeviceName: "sda", Serial: "SN1", Temperature: 35}
+	s.tracker.evaluate(stats, smartOutput(8, 0))
+	s.NotEmpty(s.tracker.evaluate(stats, smartOutput(9, 0)))
+	history := s.tracker.histories["SN1"]
+	s.False(history.Increased.IsZero())
+
+	// Six days without a further increase keep the drive flagged.
+	history.Increased = time.Now().Add(-6 * 24 * time.Hour)
+	s.NotEmpty(s.tracker.evaluate(stats, smartOutput(9, 0)))
+
+	// A further increase starts the wait again.
+	s.NotEmpty(s.tracker.evaluate(stats, smartOutput(10, 0)))
+	s.WithinDuration(time.Now(), history.Increased, time.Minute)
+
+	// A week without one makes the counters the baseline, also after a restart.
+	history.Increased = time.Now().Add(-8 * 24 * time.Hour)
+	s.Empty(s.tracker.evaluate(stats, smartOutput(10, 0)))
+	s.True(history.Increased.IsZero())
+
+	restarted := newSmartTracker(s.stateDir, 7*24*time.Hour)
+	s.Empty(restarted.evaluate(stats, smartOutput(10, 0)))
+	s.Equal([]string{"reallocated sectors increased from 10 to 11"}, restarted.evaluate(stats, smartOutput(11, 0)))
+}
+
+func (s *SmartTrendTestSuite) TestNeverAcceptedWhenDisabled() {
+	stats := &HDDStats{DeviceName: "sda", Serial: "SN1", Temperature: 35}
+	s.tracker.evaluate(stats, smartOutput(8, 0))
+	s.tracker.evaluate(stats, smartOutput(9, 0))
+
+	s.tracker.histories["SN1"].Increased = time.Now().Add(-365 * 24 * time.Hour)
+	s.NotEmpty(s.tracker.evaluate(stats, smartOutput(9, 0)))
+}
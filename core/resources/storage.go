@@ -0,0 +1,230 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/czechbol/lumeon/core/resources/dto"
+	"golang.org/x/sys/unix"
+)
+
+const storageCacheTTL = 20 * time.Second
+
+// PoolType identifies the volume manager behind a Pool.
+type PoolType string
+
+const (
+	PoolMD    PoolType = "md"
+	PoolLVM   PoolType = "lvm"
+	PoolZFS   PoolType = "zfs"
+	PoolBtrfs PoolType = "btrfs"
+)
+
+// Pool is a storage unit built from one or more block devices: an md array,
+// an LVM volume group, a ZFS pool or a (possibly multi-device) btrfs filesystem.
+type Pool struct {
+	Name         string
+	Type         PoolType
+	Level        string // raid1, raid5, mirror, raidz2, ...; empty if not applicable
+	State        string // as reported by the backend, e.g. "active, degraded" or "ONLINE"
+	Healthy      bool
+	Devices      []PoolDevice
+	SyncAction   string  // resync, recovery, check, reshape, scrub or resilver; empty when idle
+	SyncProgress float64 // percent complete while SyncAction is set
+	Errors       uint64  // I/O and checksum errors summed over all devices
+	Mountpoint   string  // where the pool's filesystem is mounted, empty if not mounted
+	Total        uint64  // bytes, 0 if unknown
+	Used         uint64
+	Available    uint64
+}
+
+// PoolDevice is a member of a Pool.
+type PoolDevice struct {
+	Name    string
+	State   string
+	Healthy bool
+	Errors  uint64
+}
+
+// StackDevice is a node in the block device stack: a disk, partition, md
+// array, LVM logical volume or dm-crypt mapping, with the devices built on it.
+type StackDevice struct {
+	Name       string
+	Type       string // disk, part, raid1, lvm, crypt, ...
+	FsType     string
+	Mountpoint string
+	Size       uint64
+	Children   []StackDevice
+}
+
+type Storage interface {
+	// GetDevices returns the block device stacks, one tree per disk.
+	GetDevices(ctx context.Context) ([]StackDevice, error)
+	// GetPools returns all md arrays, LVM volume groups, ZFS pools and btrfs filesystems.
+	GetPools(ctx context.Context) ([]Pool, error)
+}
+
+type storageImpl struct {
+	mu          sync.RWMutex
+	cachedPools []Pool
+	cacheTime   time.Time
+	cacheTTL    time.Duration
+}
+
+func NewStorage() Storage {
+	return &storageImpl{
+		cacheTTL: storageCacheTTL,
+	}
+}
+
+func (s *storageImpl) GetDevices(ctx context.Context) ([]StackDevice, error) {
+	cmd := exec.CommandContext(ctx, "lsblk", "-b", "-J", "-o", "NAME,SIZE,TYPE,FSTYPE,MOUNTPOINT")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("executing lsblk: %w", err)
+	}
+
+	var lsblkOutput dto.BlockDevices
+	if err := json.Unmarshal(output, &lsblkOutput); err != nil {
+		return nil, fmt.Errorf("parsing lsblk output: %w", err)
+	}
+
+	devices := make([]StackDevice, 0, len(lsblkOutput.BlockDevices))
+	for _, device := range lsblkOutput.BlockDevices {
+		devices = append(devices, StackDevice{
+			Name:       device.Name,
+			Type:       device.Type,
+			FsType:     device.FsType,
+			Mountpoint: device.MountPoint,
+			Size:       device.Size,
+			Children:   stackChildren(device.Children),
+		})
+	}
+	return devices, nil
+}
+
+func stackChildren(children []dto.BlockChild) []StackDevice {
+	if len(children) == 0 {
+		return nil
+	}
+	devices := make([]StackDevice, 0, len(children))
+	for _, child := range children {
+		devices = append(devices, StackDevice{
+			Name:       child.Name,
+			Type:       child.Type,
+			FsType:     child.FsType,
+			Mountpoint: child.MountPoint,
+			Size:       child.Size,
+			Children:   stackChildren(child.Children),
+		})
+	}
+	return devices
+}
+
+func (s *storageImpl) GetPools(ctx context.Context) ([]Pool, error) {
+	s.mu.RLock()
+	if s.cachedPools != nil && time.Since(s.cacheTime) < s.cacheTTL {
+		pools := make([]Pool, len(s.cachedPools))
+		copy(pools, s.cachedPools)
+		s.mu.RUnlock()
+		return pools, nil
+	}
+	s.mu.RUnlock()
+
+	devices, err := s.GetDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pools []Pool
+	pools = append(pools, getMDPools(devices)...)
+	pools = append(pools, getLVMPools(ctx, devices)...)
+	pools = append(pools, getZFSPools(ctx)...)
+	pools = append(pools, getBtrfsPools(ctx, devices)...)
+
+	sort.SliceStable(pools, func(i, j int) bool {
+		return pools[i].Name < pools[j].Name
+	})
+
+	s.mu.Lock()
+	s.cachedPools = pools
+	s.cacheTime = time.Now()
+	s.mu.Unlock()
+
+	result := make([]Pool, len(pools))
+	copy(result, pools)
+	return result, nil
+}
+
+// findStackDevice returns the first device named name anywhere in the stacks.
+// Devices spanning several disks appear under each of them; all copies are equal.
+func findStackDevice(devices []StackDevice, name string) *StackDevice {
+	for i := range devices {
+		if devices[i].Name == name {
+			return &devices[i]
+		}
+		if found := findStackDevice(devices[i].Children, name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// firstMountpoint returns the first mountpoint found on device or anything stacked on it.
+func firstMountpoint(device *StackDevice) string {
+	if device.Mountpoint != "" {
+		return device.Mountpoint
+	}
+	for i := range device.Children {
+		if mountpoint := firstMountpoint(&device.Children[i]); mountpoint != "" {
+			return mountpoint
+		}
+	}
+	return ""
+}
+
+// filesystemUsage returns the size, used and available bytes of the filesystem
+// mounted at mountpoint. Available excludes blocks reserved for root, so
+// used+available can be less than total.
+func filesystemUsage(mountpoint string) (total, used, available uint64, err error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(mountpoint, &stat); err != nil {
+		return 0, 0, 0, err
+	}
+
+	bsize := uint64(stat.Bsize) //nolint:gosec // Bsize is a block size, always positive
+	total = stat.Blocks * bsize
+	used = (stat.Blocks - stat.Bfree) * bsize
+	available = stat.Bavail * bsize
+	return total, used, available, nil
+}
+
+// setPoolUsage fills the usage fields of pool from its mounted filesystem, if any.
+func setPoolUsage(pool *Pool) {
+	if pool.Mountpoint == "" {
+		return
+	}
+	total, used, available, err := filesystemUsage(pool.Mountpoint)
+	if err != nil {
+		slog.Error("error getting pool filesystem usage", "pool", pool.Name, "mountpoint", pool.Mountpoint,
+			"error", err)
+		return
+	}
+	pool.Total, pool.Used, pool.Available = total, used, available
+}
+
+// toolAvailable reports whether an optional storage tool is installed.
+// Missing tools simply mean that kind of pool is not in use.
+func toolAvailable(name string) bool {
+	if _, err := exec.LookPath(name); err != nil {
+		slog.Debug("storage tool not installed, skipping", "tool", name)
+		return false
+	}
+	return true
+}
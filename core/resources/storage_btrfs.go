@@ -0,0 +1,107 @@
+package resources
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log/slog"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// getBtrfsPools reports every mounted btrfs filesystem with its device error counters.
+func getBtrfsPools(ctx context.Context, devices []StackDevice) []Pool {
+	mountpoints := btrfsMountpoints(devices, nil)
+	if len(mountpoints) == 0 || !toolAvailable("btrfs") {
+		return nil
+	}
+
+	pools := make([]Pool, 0, len(mountpoints))
+	for _, mountpoint := range mountpoints {
+		// btrfs exits 64 when any error counter is non-zero; the output is still valid.
+		output, err := exec.CommandContext(ctx, "btrfs", "device", "stats", mountpoint).Output()
+		var exitErr *exec.ExitError
+		if err != nil && !errors.As(err, &exitErr) {
+			slog.Error("error executing btrfs device stats", "mountpoint", mountpoint, "error", err)
+			continue
+		}
+
+		pool := parseBtrfsDeviceStats(string(output))
+		pool.Name = mountpoint
+		pool.Mountpoint = mountpoint
+		setPoolUsage(&pool)
+		pools = append(pools, pool)
+	}
+	return pools
+}
+
+// btrfsMountpoints collects the distinct mountpoints of btrfs filesystems in the stacks.
+func btrfsMountpoints(devices []StackDevice, seen []string) []string {
+	for i := range devices {
+		device := &devices[i]
+		if device.FsType == "btrfs" && device.Mountpoint != "" && !slices.Contains(seen, device.Mountpoint) {
+			seen = append(seen, device.Mountpoint)
+		}
+		seen = btrfsMountpoints(device.Children, seen)
+	}
+	return seen
+}
+
+// parseBtrfsDeviceStats parses lines such as "[/dev/sda1].write_io_errs   0".
+func parseBtrfsDeviceStats(data string) Pool {
+	pool := Pool{
+		Type:    PoolBtrfs,
+		Healthy: true,
+	}
+
+	index := make(map[string]int)
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || !strings.HasPrefix(fields[0], "[") {
+			continue
+		}
+		name, _, ok := strings.Cut(strings.TrimPrefix(fields[0], "["), "].")
+		if !ok {
+			continue
+		}
+
+		i, ok := index[name]
+		if !ok {
+			i = len(pool.Devices)
+			index[name] = i
+			// A device that has gone away is reported by devid instead of path.
+			missing := !strings.HasPrefix(name, "/dev/")
+			state := "ok"
+			if missing {
+				state = "missing"
+				pool.Healthy = false
+			}
+			pool.Devices = append(pool.Devices, PoolDevice{
+				Name:    strings.TrimPrefix(name, "/dev/"),
+				State:   state,
+				Healthy: !missing,
+			})
+		}
+
+		count, _ := strconv.ParseUint(fields[1], 10, 64)
+		if count > 0 {
+			pool.Devices[i].Errors += count
+			pool.Devices[i].Healthy = false
+			pool.Devices[i].State = "errors"
+			pool.Errors += count
+			pool.Healthy = false
+		}
+	}
+
+	pool.State = "ok"
+	switch {
+	case !pool.Healthy && pool.Errors > 0:
+		pool.State = "errors"
+	case !pool.Healthy:
+		pool.State = "degraded"
+	}
+	return pool
+}
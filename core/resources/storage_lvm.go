@@ -0,0 +1,83 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/czechbol/lumeon/core/resources/dto"
+)
+
+// getLVMPools reports LVM volume groups. Size and usage are allocation within
+// the group, not filesystem usage of its logical volumes.
+func getLVMPools(ctx context.Context, devices []StackDevice) []Pool {
+	if !toolAvailable("vgs") {
+		return nil
+	}
+
+	output, err := exec.CommandContext(ctx, "vgs", "--reportformat", "json", "--units", "b",
+		"--nosuffix", "-o", "vg_name,vg_size,vg_free,pv_count,lv_count,vg_missing_pv_count,vg_attr").Output()
+	if err != nil {
+		slog.Error("error executing vgs", "error", err)
+		return nil
+	}
+
+	var report dto.LvmReport
+	if err := json.Unmarshal(output, &report); err != nil {
+		slog.Error("error unmarshalling vgs output", "error", err)
+		return nil
+	}
+
+	var pools []Pool
+	for _, r := range report.Report {
+		for _, vg := range r.VG {
+			pools = append(pools, lvmPool(vg, devices))
+		}
+	}
+	return pools
+}
+
+func lvmPool(vg dto.LvmVolumeGroup, devices []StackDevice) Pool {
+	size, _ := strconv.ParseUint(vg.Size, 10, 64)
+	free, _ := strconv.ParseUint(vg.Free, 10, 64)
+	missing, _ := strconv.Atoi(vg.MissingPVs)
+	// The fourth vg_attr character is 'p' when physical volumes are missing.
+	partial := len(vg.Attributes) > 3 && vg.Attributes[3] == 'p'
+
+	pool := Pool{
+		Name:      vg.Name,
+		Type:      PoolLVM,
+		State:     "ok",
+		Healthy:   missing == 0 && !partial,
+		Total:     size,
+		Used:      size - free,
+		Available: free,
+	}
+	if !pool.Healthy {
+		pool.State = "partial"
+	}
+
+	// Physical volumes are the devices directly below this group's logical
+	// volumes (named "<vg>-<lv>" by device-mapper) in the stacks.
+	prefix := strings.ReplaceAll(vg.Name, "-", "--") + "-"
+	for _, name := range lvmPhysicalVolumes(devices, prefix, "", nil) {
+		pool.Devices = append(pool.Devices, PoolDevice{Name: name, State: "ok", Healthy: true})
+	}
+	return pool
+}
+
+func lvmPhysicalVolumes(devices []StackDevice, prefix, parent string, found []string) []string {
+	for i := range devices {
+		device := &devices[i]
+		if device.Type == "lvm" && strings.HasPrefix(device.Name, prefix) && parent != "" &&
+			!slices.Contains(found, parent) {
+			found = append(found, parent)
+		}
+		found = lvmPhysicalVolumes(device.Children, prefix, device.Name, found)
+	}
+	return found
+}
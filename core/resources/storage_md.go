@@ -0,0 +1,141 @@
+package resources
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const procMdstatPath = "/proc/mdstat"
+
+var (
+	// mdMemberRe matches an array member such as "sda1[0]" or "sdc1[2](F)".
+	mdMemberRe = regexp.MustCompile(`^([^\[]+)\[\d+\](\([A-Z]\))*$`)
+	// mdCountRe matches the "[2/1]" member count on the status line.
+	mdCountRe = regexp.MustCompile(`\[(\d+)/(\d+)\]`)
+	// mdSyncRe matches a progress line such as "resync = 12.6% (...)".
+	mdSyncRe = regexp.MustCompile(`(resync|recovery|check|reshape|repair)\s*=\s*([\d.]+)%`)
+)
+
+// getMDPools reports the md software RAID arrays from /proc/mdstat.
+func getMDPools(devices []StackDevice) []Pool {
+	data, err := os.ReadFile(procMdstatPath)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			slog.Error("error reading mdstat", "error", err)
+		}
+		return nil
+	}
+
+	pools := parseMdstat(string(data))
+	for i := range pools {
+		if device := findStackDevice(devices, pools[i].Name); device != nil {
+			pools[i].Mountpoint = firstMountpoint(device)
+			setPoolUsage(&pools[i])
+		}
+	}
+	return pools
+}
+
+// parseMdstat parses the contents of /proc/mdstat.
+func parseMdstat(data string) []Pool {
+	var pools []Pool
+	var current *Pool
+	var expected, active int
+
+	finish := func() {
+		if current == nil {
+			return
+		}
+		degraded := active < expected
+		for _, device := range current.Devices {
+			if device.State == "faulty" {
+				degraded = true
+			}
+		}
+		current.Healthy = strings.HasPrefix(current.State, "active") && !degraded
+		if degraded {
+			current.State += ", degraded"
+		}
+		pools = append(pools, *current)
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if name, rest, ok := strings.Cut(line, " : "); ok && strings.HasPrefix(name, "md") {
+			finish()
+			current = parseMdArrayLine(strings.TrimSpace(name), rest)
+			expected, active = 0, 0
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		if m := mdCountRe.FindStringSubmatch(line); m != nil && strings.Contains(line, "blocks") {
+			expected, _ = strconv.Atoi(m[1])
+			active, _ = strconv.Atoi(m[2])
+		}
+		if m := mdSyncRe.FindStringSubmatch(line); m != nil {
+			current.SyncAction = m[1]
+			current.SyncProgress, _ = strconv.ParseFloat(m[2], 64)
+		}
+		if strings.TrimSpace(line) == "" {
+			finish()
+		}
+	}
+	finish()
+
+	return pools
+}
+
+// parseMdArrayLine parses the part after "mdX : ", e.g. "active raid1 sdb1[1] sda1[0](F)".
+func parseMdArrayLine(name, rest string) *Pool {
+	pool := &Pool{
+		Name: name,
+		Type: PoolMD,
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return pool
+	}
+	pool.State = fields[0]
+	fields = fields[1:]
+
+	// "(read-only)" and "(auto-read-only)" follow the state.
+	for len(fields) > 0 && strings.HasPrefix(fields[0], "(") {
+		pool.State += " " + fields[0]
+		fields = fields[1:]
+	}
+	if len(fields) > 0 && !mdMemberRe.MatchString(fields[0]) {
+		pool.Level = fields[0]
+		fields = fields[1:]
+	}
+
+	for _, field := range fields {
+		m := mdMemberRe.FindStringSubmatch(field)
+		if m == nil {
+			continue
+		}
+		device := PoolDevice{Name: m[1], State: "active", Healthy: true}
+		switch {
+		case strings.Contains(field, "(F)"):
+			device.State = "faulty"
+			device.Healthy = false
+		case strings.Contains(field, "(S)"):
+			device.State = "spare"
+		}
+		pool.Devices = append(pool.Devices, device)
+	}
+
+	return pool
+}
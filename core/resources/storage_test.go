@@ -0,0 +1,149 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type StorageTestSuite struct {
+	suite.Suite
+}
+
+func TestStorageTestSuite(t *testing.T) {
+	suite.Run(t, new(StorageTestSuite))
+}
+
+const mdstatResync = `Personalities : [raid1] [raid6] [raid5] [raid4]
+md1 : active raid5 sde1[3] sdd1[1](F) sdc1[0]
+      1953258496 blocks super 1.2 level 5, 512k chunk, algorithm 2 [3/2] [U_U]
+      bitmap: 2/8 pages [8KB], 65536KB chunk
+
+md0 : active raid1 sdb1[1] sda1[0]
+      976630464 blocks super 1.2 [2/2] [UU]
+      [==>..................]  resync = 12.6% (123456789/976630464) finish=80.1min speed=177476K/sec
+      bitmap: 0/8 pages [0KB], 65536KB chunk
+
+md127 : inactive sdf[0](S)
+      976631512 blocks super 1.2
+
+unused devices: <none>
+`
+
+func (s *StorageTestSuite) TestParseMdstat() {
+	pools := parseMdstat(mdstatResync)
+	s.Require().Len(pools, 3)
+
+	md1 := pools[0]
+	s.Equal("md1", md1.Name)
+	s.Equal("raid5", md1.Level)
+	s.Equal("active, degraded", md1.State)
+	s.False(md1.Healthy)
+	s.Equal([]PoolDevice{
+		{Name: "sde1", State: "active", Healthy: true},
+		{Name: "sdd1", State: "faulty", Healthy: false},
+		{Name: "sdc1", State: "active", Healthy: true},
+	}, md1.Devices)
+
+	md0 := pools[1]
+	s.Equal("md0", md0.Name)
+	s.Equal("raid1", md0.Level)
+	s.True(md0.Healthy)
+	s.Equal("resync", md0.SyncAction)
+	s.InDelta(12.6, md0.SyncProgress, 0.001)
+	s.Len(md0.Devices, 2)
+
+	md127 := pools[2]
+	s.Equal("inactive", md127.State)
+	s.Empty(md127.Level)
+	s.False(md127.Healthy)
+	s.Equal("spare", md127.Devices[0].State)
+}
+
+const zpoolStatusScrub = `  pool: tank
+ state: DEGRADED
+status: One or more devices has experienced an unrecoverable error.
+  scan: scrub in progress since Sun Jul 25 16:07:49 2026
+	1.23T scanned at 1.2G/s, 456G issued at 456M/s, 3.21T total
+	0B repaired, 14.20% done, 01:23:45 to go
+config:
+
+	NAME        STATE     READ WRITE CKSUM
+	tank        DEGRADED     0     0     0
+	  raidz1-0  DEGRADED     0     0     0
+	    sda     ONLINE       0     0     0
+	    sdb     FAULTED      3     1     7
+	    sdc     ONLINE       0     0     0
+	logs
+	  sdd       ONLINE       0     0     0
+
+errors: No known data errors
+`
+
+func (s *StorageTestSuite) TestParseZpool() {
+	pools := parseZpoolList("tank\tDEGRADED\t3000\t1000\t2000\n")
+	s.Require().Len(pools, 1)
+	pool := pools[0]
+	s.False(pool.Healthy)
+	s.Equal(uint64(3000), pool.Total)
+	s.Equal(uint64(1000), pool.Used)
+	s.Equal(uint64(2000), pool.Available)
+
+	parseZpoolStatus(&pool, zpoolStatusScrub)
+	s.Equal("raidz1", pool.Level)
+	s.Equal("scrub", pool.SyncAction)
+	s.InDelta(14.2, pool.SyncProgress, 0.001)
+	s.Equal(uint64(11), pool.Errors)
+	s.Equal([]PoolDevice{
+		{Name: "sda", State: "ONLINE", Healthy: true},
+		{Name: "sdb", State: "FAULTED", Healthy: false, Errors: 11},
+		{Name: "sdc", State: "ONLINE", Healthy: true},
+		{Name: "sdd", State: "ONLINE", Healthy: true},
+	}, pool.Devices)
+}
+
+func (s *StorageTestSuite) TestParseBtrfsDeviceStats() {
+	pool := parseBtrfsDeviceStats(`[/dev/sda1].write_io_errs    0
+[/dev/sda1].read_io_errs     0
+[/dev/sda1].flush_io_errs    0
+[/dev/sda1].corruption_errs  0
+[/dev/sda1].generation_errs  0
+[/dev/sdb1].write_io_errs    2
+[/dev/sdb1].read_io_errs     0
+[/dev/sdb1].flush_io_errs    0
+[/dev/sdb1].corruption_errs  5
+[/dev/sdb1].generation_errs  0
+`)
+	s.False(pool.Healthy)
+	s.Equal("errors", pool.State)
+	s.Equal(uint64(7), pool.Errors)
+	s.Equal([]PoolDevice{
+		{Name: "sda1", State: "ok", Healthy: true},
+		{Name: "sdb1", State: "errors", Healthy: false, Errors: 7},
+	}, pool.Devices)
+}
+
+func (s *StorageTestSuite) TestLVMPhysicalVolumes() {
+	devices := []StackDevice{
+		{Name: "sda", Type: "disk", Children: []StackDevice{
+			{Name: "sda1", Type: "part", Children: []StackDevice{
+				{Name: "md0", Type: "raid1", Children: []StackDevice{
+					{Name: "data--vg-media", Type: "lvm", Mountpoint: "/srv/media"},
+				}},
+			}},
+		}},
+		{Name: "sdb", Type: "disk", Children: []StackDevice{
+			{Name: "sdb1", Type: "part", Children: []StackDevice{
+				{Name: "md0", Type: "raid1", Children: []StackDevice{
+					{Name: "data--vg-media", Type: "lvm", Mountpoint: "/srv/media"},
+				}},
+			}},
+			{Name: "sdb2", Type: "part", Children: []StackDevice{
+				{Name: "other-root", Type: "lvm"},
+			}},
+		}},
+	}
+
+	s.Equal([]string{"md0"}, lvmPhysicalVolumes(devices, "data--vg-", "", nil))
+	s.Equal("/srv/media", firstMountpoint(findStackDevice(devices, "md0")))
+}
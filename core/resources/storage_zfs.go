@@ -0,0 +1,116 @@
+package resources
+
+import (
+	"bufio"
+	"context"
+	"log/slog"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// zfsScanRe matches the scan line of a running scrub or resilver.
+	zfsScanRe = regexp.MustCompile(`scan:\s+(scrub|resilver) in progress`)
+	// zfsDoneRe matches the progress figure on the scan continuation lines.
+	zfsDoneRe = regexp.MustCompile(`([\d.]+)% done`)
+	// zfsVdevRe matches the names of grouping vdevs in the config table.
+	zfsVdevRe = regexp.MustCompile(`^(mirror|raidz\d?|draid\d?[^-]*|spare|replacing)-\d+$`)
+)
+
+// getZFSPools reports ZFS pools via zpool list and zpool status.
+func getZFSPools(ctx context.Context) []Pool {
+	if !toolAvailable("zpool") {
+		return nil
+	}
+
+	output, err := exec.CommandContext(ctx,
+		"zpool", "list", "-H", "-p", "-o", "name,health,size,alloc,free").Output()
+	if err != nil {
+		slog.Error("error executing zpool list", "error", err)
+		return nil
+	}
+
+	pools := parseZpoolList(string(output))
+	for i := range pools {
+		//nolint:gosec // pool name comes from zpool list output
+		status, err := exec.CommandContext(ctx, "zpool", "status", "-p", pools[i].Name).Output()
+		if err != nil {
+			slog.Error("error executing zpool status", "pool", pools[i].Name, "error", err)
+			continue
+		}
+		parseZpoolStatus(&pools[i], string(status))
+	}
+	return pools
+}
+
+// parseZpoolList parses tab-separated "name health size alloc free" lines.
+func parseZpoolList(data string) []Pool {
+	var pools []Pool
+	for line := range strings.SplitSeq(strings.TrimSpace(data), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 5 {
+			continue
+		}
+		pool := Pool{
+			Name:    fields[0],
+			Type:    PoolZFS,
+			State:   fields[1],
+			Healthy: fields[1] == "ONLINE",
+		}
+		pool.Total, _ = strconv.ParseUint(fields[2], 10, 64)
+		pool.Used, _ = strconv.ParseUint(fields[3], 10, 64)
+		pool.Available, _ = strconv.ParseUint(fields[4], 10, 64)
+		pools = append(pools, pool)
+	}
+	return pools
+}
+
+// parseZpoolStatus fills scan progress, RAID level and per-device errors from zpool status -p.
+func parseZpoolStatus(pool *Pool, data string) {
+	inConfig := false
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+
+		if m := zfsScanRe.FindStringSubmatch(line); m != nil {
+			pool.SyncAction = m[1]
+		}
+		if m := zfsDoneRe.FindStringSubmatch(line); m != nil && pool.SyncAction != "" {
+			pool.SyncProgress, _ = strconv.ParseFloat(m[1], 64)
+		}
+
+		switch {
+		case len(fields) == 5 && fields[0] == "NAME" && fields[1] == "STATE":
+			inConfig = true
+			continue
+		case strings.HasPrefix(line, "errors:"):
+			inConfig = false
+		}
+		if !inConfig || len(fields) < 5 || fields[0] == pool.Name {
+			continue
+		}
+
+		if zfsVdevRe.MatchString(fields[0]) {
+			if pool.Level == "" {
+				pool.Level, _, _ = strings.Cut(fields[0], "-")
+			}
+			continue
+		}
+
+		var errs uint64
+		for _, field := range fields[2:5] {
+			n, _ := strconv.ParseUint(field, 10, 64)
+			errs += n
+		}
+		pool.Errors += errs
+		pool.Devices = append(pool.Devices, PoolDevice{
+			Name:    fields[0],
+			State:   fields[1],
+			Healthy: fields[1] == "ONLINE" && errs == 0,
+			Errors:  errs,
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package resources
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	procLoadavgPath   = "/proc/loadavg"
+	procUptimePath    = "/proc/uptime"
+	procOSReleasePath = "/proc/sys/kernel/osrelease"
+	procPath          = "/proc"
+
+	// clockTicksPerSecond is USER_HZ, the unit of the CPU time fields in
+	// /proc/[pid]/stat. It is 100 on every Linux architecture.
+	clockTicksPerSecond = 100
+	// systemTopProcesses is how many processes are reported per ranking.
+	systemTopProcesses = 5
+)
+
+// SystemStats is an overview of the host: identity, uptime, load and the
+// processes using the most CPU and memory.
+type SystemStats struct {
+	Hostname     string
+	Kernel       string
+	Uptime       time.Duration
+	Load1        float64
+	Load5        float64
+	Load15       float64
+	RunningProcs int // runnable scheduling entities
+	TotalProcs   int // all scheduling entities, threads included
+	// TopCPU are the processes with the highest CPU usage since the previous
+	// call, busiest first. It is empty on the first call.
+	TopCPU []ProcessStats
+	// TopMemory are the processes with the largest resident set, largest first.
+	TopMemory []ProcessStats
+}
+
+type ProcessStats struct {
+	PID        int
+	Name       string
+	CPUPercent float64 // percent of one CPU, like top; can exceed 100 for multithreaded processes
+	RSS        uint64  // resident set size in bytes
+}
+
+type System interface {
+	GetStats(ctx context.Context) (*SystemStats, error)
+}
+
+// processKey identifies a process across calls. The start time guards
+// against a PID being reused by a new process.
+type processKey struct {
+	pid       int
+	startTime uint64
+}
+
+// processSample is the part of /proc/[pid]/stat the prober uses.
+type processSample struct {
+	pid       int
+	name      string
+	cpuTicks  uint64 // utime + stime
+	startTime uint64
+	rssPages  uint64
+}
+
+type systemImpl struct {
+	mu        sync.Mutex
+	prevTicks map[processKey]uint64
+	lastCheck time.Time
+}
+
+func NewSystem() System {
+	return &systemImpl{
+		prevTicks: make(map[processKey]uint64),
+	}
+}
+
+// GetStats returns the system overview. Process CPU usage is computed from
+// the difference since the previous call, so it must only be called by the Sampler.
+func (s *systemImpl) GetStats(context.Context) (*SystemStats, error) {
+	stats := &SystemStats{}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("getting hostname: %w", err)
+	}
+	stats.Hostname = hostname
+
+	if data, err := os.ReadFile(procOSReleasePath); err == nil {
+		stats.Kernel = strings.TrimSpace(string(data))
+	}
+
+	data, err := os.ReadFile(procUptimePath)
+	if err != nil {
+		return nil, fmt.Errorf("reading uptime: %w", err)
+	}
+	if stats.Uptime, err = parseUptime(string(data)); err != nil {
+		return nil, err
+	}
+
+	data, err = os.ReadFile(procLoadavgPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading load average: %w", err)
+	}
+	if err := parseLoadavg(string(data), stats); err != nil {
+		return nil, err
+	}
+
+	s.setTopProcesses(stats, readProcesses())
+
+	return stats, nil
+}
+
+// setTopProcesses ranks samples by CPU usage since the previous call and by
+// resident memory.
+func (s *systemImpl) setTopProcesses(stats *SystemStats, samples []processSample) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(s.lastCheck).Seconds()
+	first := s.lastCheck.IsZero()
+
+	pageSize := uint64(os.Getpagesize()) //nolint:gosec // page size is positive
+	current := make(map[processKey]uint64, len(samples))
+	processes := make([]ProcessStats, 0, len(samples))
+	for _, sample := range samples {
+		key := processKey{pid: sample.pid, startTime: sample.startTime}
+		current[key] = sample.cpuTicks
+
+		process := ProcessStats{
+			PID:  sample.pid,
+			Name: sample.name,
+			RSS:  sample.rssPages * pageSize,
+		}
+		if prev, ok := s.prevTicks[key]; ok && !first && elapsed > 0 {
+			ticks := counterDelta(prev, sample.cpuTicks)
+			process.CPUPercent = 100 * float64(ticks) / clockTicksPerSecond / elapsed
+		}
+		processes = append(processes, process)
+	}
+
+	s.prevTicks = current
+	s.lastCheck = now
+
+	if !first {
+		stats.TopCPU = topProcesses(processes, func(a, b ProcessStats) int {
+			return cmp.Compare(b.CPUPercent, a.CPUPercent)
+		})
+	}
+	stats.TopMemory = topProcesses(processes, func(a, b ProcessStats) int {
+		return cmp.Compare(b.RSS, a.RSS)
+	})
+}
+
+// topProcesses returns the first systemTopProcesses of processes in cmp
+// order, leaving processes unchanged. Ties are broken by PID so the ranking is stable.
+func topProcesses(processes []ProcessStats, compare func(a, b ProcessStats) int) []ProcessStats {
+	sorted := slices.Clone(processes)
+	slices.SortFunc(sorted, func(a, b ProcessStats) int {
+		if c := compare(a, b); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.PID, b.PID)
+	})
+	return sorted[:min(len(sorted), systemTopProcesses)]
+}
+
+// readProcesses reads /proc/[pid]/stat for every process. Processes that
+// exit while being read are skipped.
+func readProcesses() []processSample {
+	entries, err := os.ReadDir(procPath)
+	if err != nil {
+		return nil
+	}
+
+	samples := make([]processSample, 0, len(entries))
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(procPath, entry.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		sample, ok := parseProcessStat(string(data))
+		if !ok {
+			continue
+		}
+		sample.pid = pid
+		samples = append(samples, sample)
+	}
+	return samples
+}
+
+// parseProcessStat parses /proc/[pid]/stat as described in proc(5):
+//
+//	1234 (my prog) S 1 1234 1234 0 -1 4194560 310 0 0 0 25 12 0 0 20 0 1 0 2894 11235328 702 ...
+//
+// The command name is in parentheses and may itself contain spaces and
+// parentheses, so the fields are counted from the last ')'.
+func parseProcessStat(line string) (processSample, bool) {
+	open := strings.IndexByte(line, '(')
+	closing := strings.LastIndexByte(line, ')')
+	if open < 0 || closing < open {
+		return processSample{}, false
+	}
+
+	// fields[0] is field 3 (state) in proc(5) numbering.
+	fields := strings.Fields(line[closing+1:])
+	if len(fields) < 22 {
+		return processSample{}, false
+	}
+
+	return processSample{
+		name:      line[open+1 : closing],
+		cpuTicks:  parseUint64(fields[11]) + parseUint64(fields[12]),
+		startTime: parseUint64(fields[19]),
+		rssPages:  parseUint64(fields[21]),
+	}, true
+}
+
+// parseUptime parses /proc/uptime: "350735.47 234388.90".
+func parseUptime(data string) (time.Duration, error) {
+	fields := strings.Fields(data)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("parsing uptime %q: %w", data, ErrUnexpectedFormat)
+	}
+	seconds, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing uptime %q: %w", fields[0], err)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// parseLoadavg parses /proc/loadavg: "0.52 0.48 0.41 2/213 12345".
+func parseLoadavg(data string, stats *SystemStats) error {
+	fields := strings.Fields(data)
+	if len(fields) < 4 {
+		return fmt.Errorf("parsing load average %q: %w", data, ErrUnexpectedFormat)
+	}
+
+	loads := make([]float64, 3)
+	for i := range loads {
+		load, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return fmt.Errorf("parsing load average %q: %w", fields[i], err)
+		}
+		loads[i] = load
+	}
+	stats.Load1, stats.Load5, stats.Load15 = loads[0], loads[1], loads[2]
+
+	running, total, ok := strings.Cut(fields[3], "/")
+	if !ok {
+		return fmt.Errorf("parsing process counts %q: %w", fields[3], ErrUnexpectedFormat)
+	}
+	stats.RunningProcs, _ = strconv.Atoi(running)
+	stats.TotalProcs, _ = strconv.Atoi(total)
+	return nil
+}
@@ -0,0 +1,85 @@
+package resources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type SystemTestSuite struct {
+	suite.Suite
+}
+
+func TestSystemTestSuite(t *testing.T) {
+	suite.Run(t, new(SystemTestSuite))
+}
+
+func (s *SystemTestSuite) TestParseProcessStat() {
+	line := "1234 (my (odd) prog) S 1 1234 1234 0 -1 4194560 310 0 0 0 25 12 0 0 20 0 1 0 2894 11235328 702 " +
+		"18446744073709551615 1 1 0 0 0 0 0 0 0 0 0 17 2 0 0 0 0 0\n"
+
+	sample, ok := parseProcessStat(line)
+	s.Require().True(ok)
+	s.Equal("my (odd) prog", sample.name)
+	s.Equal(uint64(37), sample.cpuTicks)
+	s.Equal(uint64(2894), sample.startTime)
+	s.Equal(uint64(702), sample.rssPages)
+
+	_, ok = parseProcessStat("1234 (truncated) S 1 2")
+	s.False(ok)
+	_, ok = parseProcessStat("garbage")
+	s.False(ok)
+}
+
+func (s *SystemTestSuite) TestParseLoadavg() {
+	var stats SystemStats
+	s.Require().NoError(parseLoadavg("0.52 0.48 0.41 2/213 12345\n", &stats))
+
+	s.InDelta(0.52, stats.Load1, 0.001)
+	s.InDelta(0.48, stats.Load5, 0.001)
+	s.InDelta(0.41, stats.Load15, 0.001)
+	s.Equal(2, stats.RunningProcs)
+	s.Equal(213, stats.TotalProcs)
+
+	s.ErrorIs(parseLoadavg("0.52 0.48", &stats), ErrUnexpectedFormat)
+	s.Error(parseLoadavg("x 0.48 0.41 2/213 1", &stats))
+}
+
+func (s *SystemTestSuite) TestParseUptime() {
+	uptime, err := parseUptime("350735.47 234388.90\n")
+	s.Require().NoError(err)
+	s.Equal(350735470*time.Millisecond, uptime)
+
+	_, err = parseUptime("")
+	s.ErrorIs(err, ErrUnexpectedFormat)
+}
+
+func (s *SystemTestSuite) TestTopProcesses() {
+	sys := &systemImpl{prevTicks: make(map[processKey]uint64)}
+
+	first := &SystemStats{}
+	sys.setTopProcesses(first, []processSample{
+		{pid: 1, name: "init", cpuTicks: 100, startTime: 1, rssPages: 10},
+		{pid: 2, name: "busy", cpuTicks: 500, startTime: 5, rssPages: 5},
+		{pid: 3, name: "big", cpuTicks: 50, startTime: 7, rssPages: 1000},
+	})
+	// CPU usage needs two samples; memory is ranked right away.
+	s.Empty(first.TopCPU)
+	s.Require().Len(first.TopMemory, 3)
+	s.Equal("big", first.TopMemory[0].Name)
+
+	sys.lastCheck = time.Now().Add(-time.Second)
+	second := &SystemStats{}
+	sys.setTopProcesses(second, []processSample{
+		{pid: 1, name: "init", cpuTicks: 101, startTime: 1, rssPages: 10},
+		{pid: 2, name: "busy", cpuTicks: 550, startTime: 5, rssPages: 5},
+		// PID 3 was reused by a new process; its ticks are not compared.
+		{pid: 3, name: "new", cpuTicks: 900, startTime: 90, rssPages: 1},
+	})
+	s.Require().Len(second.TopCPU, 3)
+	s.Equal("busy", second.TopCPU[0].Name)
+	s.InDelta(50, second.TopCPU[0].CPUPercent, 1)
+	s.Equal("init", second.TopCPU[1].Name)
+	s.Zero(second.TopCPU[2].CPUPercent)
+}
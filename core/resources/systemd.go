@@ -0,0 +1,230 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	systemdBusName       = "org.freedesktop.systemd1"
+	systemdObjectPath    = "/org/freedesktop/systemd1"
+	systemdManagerIface  = "org.freedesktop.systemd1.Manager"
+	systemdUnitIface     = "org.freedesktop.systemd1.Unit"
+	dbusPropertiesGet    = "org.freedesktop.DBus.Properties.Get"
+	dbusPropertiesGetAll = "org.freedesktop.DBus.Properties.GetAll"
+)
+
+// UnitStatus is the state of one systemd unit as shown by systemctl status.
+type UnitStatus struct {
+	Name        string
+	Description string
+	LoadState   string // loaded, not-found, masked, ...
+	ActiveState string // active, inactive, failed, activating, deactivating, reloading
+	SubState    string // unit type specific, e.g. running, exited, dead, waiting
+	// Result is how the unit last finished, e.g. success, exit-code, signal,
+	// timeout. Only services and timers report it.
+	Result string
+	// ExitStatus is the exit status of a service's main process the last time it exited.
+	ExitStatus int
+}
+
+// Failed reports whether systemd considers the unit failed.
+func (u *UnitStatus) Failed() bool {
+	return u.ActiveState == "failed"
+}
+
+// Missing reports whether the unit file does not exist.
+func (u *UnitStatus) Missing() bool {
+	return u.LoadState == "not-found"
+}
+
+// SystemdStats is the state of the watched units and of the system as a whole.
+type SystemdStats struct {
+	// Units are the configured units, in configuration order.
+	Units []UnitStatus
+	// FailedUnits is the number of failed units on the system, watched or not.
+	FailedUnits int
+}
+
+type Systemd interface {
+	GetStats(ctx context.Context) (*SystemdStats, error)
+}
+
+// systemdConn is the part of the systemd D-Bus API used by the prober.
+type systemdConn interface {
+	// FailedUnits returns the manager's NFailedUnits property.
+	FailedUnits(ctx context.Context) (uint32, error)
+	// UnitProperties returns the properties of unit's Unit interface and of
+	// its type-specific interface, such as Service, merged.
+	UnitProperties(ctx context.Context, unit string) (map[string]dbus.Variant, error)
+	Close() error
+}
+
+type systemdImpl struct {
+	units []string
+	// connect opens the bus connection; replaced in tests.
+	connect func(ctx context.Context) (systemdConn, error)
+
+	mu   sync.Mutex
+	conn systemdConn
+}
+
+// NewSystemd creates a prober for units over the system D-Bus. Unit names
+// without a type suffix are taken to be services.
+func NewSystemd(units []string) Systemd {
+	return &systemdImpl{
+		units:   normalizeUnitNames(units),
+		connect: connectSystemd,
+	}
+}
+
+func (s *systemdImpl) GetStats(ctx context.Context) (*SystemdStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		conn, err := s.connect(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to systemd: %w", err)
+		}
+		s.conn = conn
+	}
+
+	stats, err := s.readStats(ctx)
+	if err != nil {
+		// Reconnect on the next call; systemd may have been re-executed.
+		_ = s.conn.Close()
+		s.conn = nil
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (s *systemdImpl) readStats(ctx context.Context) (*SystemdStats, error) {
+	failed, err := s.conn.FailedUnits(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting failed unit count: %w", err)
+	}
+
+	stats := &SystemdStats{
+		FailedUnits: int(failed),
+		Units:       make([]UnitStatus, 0, len(s.units)),
+	}
+	for _, unit := range s.units {
+		props, err := s.conn.UnitProperties(ctx, unit)
+		if err != nil {
+			return nil, fmt.Errorf("getting unit %s: %w", unit, err)
+		}
+		stats.Units = append(stats.Units, unitStatus(unit, props))
+	}
+	return stats, nil
+}
+
+// unitStatus builds a UnitStatus from D-Bus unit properties.
+func unitStatus(name string, props map[string]dbus.Variant) UnitStatus {
+	str := func(key string) string {
+		if v, ok := props[key]; ok {
+			if s, ok := v.Value().(string); ok {
+				return s
+			}
+		}
+		return ""
+	}
+
+	status := UnitStatus{
+		Name:        name,
+		Description: str("Description"),
+		LoadState:   str("LoadState"),
+		ActiveState: str("ActiveState"),
+		SubState:    str("SubState"),
+		Result:      str("Result"),
+	}
+	if v, ok := props["ExecMainStatus"]; ok {
+		if code, ok := v.Value().(int32); ok {
+			status.ExitStatus = int(code)
+		}
+	}
+	return status
+}
+
+// normalizeUnitNames adds the .service suffix to names without a unit type.
+func normalizeUnitNames(units []string) []string {
+	names := make([]string, 0, len(units))
+	for _, unit := range units {
+		if path.Ext(unit) == "" {
+			unit += ".service"
+		}
+		names = append(names, unit)
+	}
+	return names
+}
+
+// dbusSystemdConn talks to systemd on the system bus.
+type dbusSystemdConn struct {
+	conn    *dbus.Conn
+	manager dbus.BusObject
+}
+
+// connectSystemd connects to the system bus. The connection is closed when
+// ctx is cancelled.
+func connectSystemd(ctx context.Context) (systemdConn, error) {
+	conn, err := dbus.ConnectSystemBus(dbus.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return &dbusSystemdConn{
+		conn:    conn,
+		manager: conn.Object(systemdBusName, systemdObjectPath),
+	}, nil
+}
+
+func (c *dbusSystemdConn) FailedUnits(ctx context.Context) (uint32, error) {
+	var v dbus.Variant
+	err := c.manager.CallWithContext(ctx, dbusPropertiesGet, 0, systemdManagerIface, "NFailedUnits").Store(&v)
+	if err != nil {
+		return 0, err
+	}
+	failed, ok := v.Value().(uint32)
+	if !ok {
+		return 0, fmt.Errorf("NFailedUnits is %s: %w", v.Signature(), ErrUnexpectedFormat)
+	}
+	return failed, nil
+}
+
+func (c *dbusSystemdConn) UnitProperties(ctx context.Context, unit string) (map[string]dbus.Variant, error) {
+	// LoadUnit, unlike GetUnit, also works for units that are not loaded,
+	// such as a stopped service nothing depends on.
+	var unitPath dbus.ObjectPath
+	if err := c.manager.CallWithContext(ctx, systemdManagerIface+".LoadUnit", 0, unit).Store(&unitPath); err != nil {
+		return nil, err
+	}
+	obj := c.conn.Object(systemdBusName, unitPath)
+
+	props := make(map[string]dbus.Variant)
+	if err := obj.CallWithContext(ctx, dbusPropertiesGetAll, 0, systemdUnitIface).Store(&props); err != nil {
+		return nil, err
+	}
+
+	// The type-specific interface is named after the unit type, e.g. Service or Timer.
+	unitType := strings.TrimPrefix(path.Ext(unit), ".")
+	if unitType == "" {
+		return props, nil
+	}
+	typeIface := systemdBusName + "." + strings.ToUpper(unitType[:1]) + unitType[1:]
+	var typeProps map[string]dbus.Variant
+	if err := obj.CallWithContext(ctx, dbusPropertiesGetAll, 0, typeIface).Store(&typeProps); err != nil {
+		return nil, err
+	}
+	maps.Copy(props, typeProps)
+	return props, nil
+}
+
+func (c *dbusSystemdConn) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,139 @@
+package resources
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/stretchr/testify/suite"
+)
+
+var errBusGone = errors.New("connection closed")
+
+// fakeSystemdConn serves unit properties from a map instead of the system bus.
+type fakeSystemdConn struct {
+	failed uint32
+	units  map[string]map[string]dbus.Variant
+	err    error
+	closed bool
+}
+
+func (f *fakeSystemdConn) FailedUnits(context.Context) (uint32, error) {
+	return f.failed, f.err
+}
+
+func (f *fakeSystemdConn) UnitProperties(_ context.Context, unit string) (map[string]dbus.Variant, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	props, ok := f.units[unit]
+	if !ok {
+		// systemd loads a placeholder for units without a unit file.
+		return map[string]dbus.Variant{
+			"LoadState":   dbus.MakeVariant("not-found"),
+			"ActiveState": dbus.MakeVariant("inactive"),
+			"SubState":    dbus.MakeVariant("dead"),
+		}, nil
+	}
+	return props, nil
+}
+
+func (f *fakeSystemdConn) Close() error {
+	f.closed = true
+	return nil
+}
+
+type SystemdTestSuite struct {
+	suite.Suite
+
+	conn     *fakeSystemdConn
+	connects int
+	systemd  *systemdImpl
+}
+
+func TestSystemdTestSuite(t *testing.T) {
+	suite.Run(t, new(SystemdTestSuite))
+}
+
+func (s *SystemdTestSuite) SetupTest() {
+	s.conn = &fakeSystemdConn{
+		failed: 2,
+		units: map[string]map[string]dbus.Variant{
+			"smbd.service": {
+				"Description":    dbus.MakeVariant("Samba SMB Daemon"),
+				"LoadState":      dbus.MakeVariant("loaded"),
+				"ActiveState":    dbus.MakeVariant("active"),
+				"SubState":       dbus.MakeVariant("running"),
+				"Result":         dbus.MakeVariant("success"),
+				"ExecMainStatus": dbus.MakeVariant(int32(0)),
+			},
+			"backup.service": {
+				"LoadState":      dbus.MakeVariant("loaded"),
+				"ActiveState":    dbus.MakeVariant("failed"),
+				"SubState":       dbus.MakeVariant("failed"),
+				"Result":         dbus.MakeVariant("exit-code"),
+				"ExecMainStatus": dbus.MakeVariant(int32(3)),
+			},
+			"backup.timer": {
+				"LoadState":   dbus.MakeVariant("loaded"),
+				"ActiveState": dbus.MakeVariant("active"),
+				"SubState":    dbus.MakeVariant("waiting"),
+				"Result":      dbus.MakeVariant("success"),
+			},
+		},
+	}
+	s.connects = 0
+
+	systemd, ok := NewSystemd([]string{"smbd", "backup.service", "backup.timer", "nfs-server"}).(*systemdImpl)
+	s.Require().True(ok)
+	systemd.connect = func(context.Context) (systemdConn, error) {
+		s.connects++
+		return s.conn, nil
+	}
+	s.systemd = systemd
+}
+
+func (s *SystemdTestSuite) TestGetStats() {
+	stats, err := s.systemd.GetStats(context.Background())
+	s.Require().NoError(err)
+
+	s.Equal(2, stats.FailedUnits)
+	s.Require().Len(stats.Units, 4)
+
+	smbd := stats.Units[0]
+	s.Equal("smbd.service", smbd.Name)
+	s.Equal("Samba SMB Daemon", smbd.Description)
+	s.Equal("running", smbd.SubState)
+	s.False(smbd.Failed())
+
+	backup := stats.Units[1]
+	s.True(backup.Failed())
+	s.Equal("exit-code", backup.Result)
+	s.Equal(3, backup.ExitStatus)
+
+	s.Equal("waiting", stats.Units[2].SubState)
+
+	s.Equal("nfs-server.service", stats.Units[3].Name)
+	s.True(stats.Units[3].Missing())
+}
+
+func (s *SystemdTestSuite) TestReconnectsAfterError() {
+	_, err := s.systemd.GetStats(context.Background())
+	s.Require().NoError(err)
+
+	s.conn.err = errBusGone
+	_, err = s.systemd.GetStats(context.Background())
+	s.ErrorIs(err, errBusGone)
+	s.True(s.conn.closed)
+
+	s.conn.err = nil
+	_, err = s.systemd.GetStats(context.Background())
+	s.NoError(err)
+	s.Equal(2, s.connects)
+}
+
+func (s *SystemdTestSuite) TestNormalizeUnitNames() {
+	s.Equal([]string{"smbd.service", "backup.timer", "srv-data.mount"},
+		normalizeUnitNames([]string{"smbd", "backup.timer", "srv-data.mount"}))
+}
@@ -0,0 +1,211 @@
+package resources
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultUPSPort is the port upsd listens on.
+	defaultUPSPort = "3493"
+	// upsTimeout bounds connecting to upsd and each request.
+	upsTimeout = 5 * time.Second
+)
+
+// UPSStats is the state of a UPS as reported by NUT's upsd.
+type UPSStats struct {
+	Name string
+	// Status is the raw ups.status value, a space-separated list of flags
+	// such as "OL CHRG" or "OB DISCHRG LB".
+	Status       string
+	Charge       float64 // battery.charge, percent
+	ChargeKnown  bool
+	Runtime      time.Duration // battery.runtime, estimated time left on battery
+	RuntimeKnown bool
+	Load         float64 // ups.load, percent of rated output
+	LoadKnown    bool
+}
+
+// HasStatus reports whether flag, e.g. "OB", is set in Status.
+func (s *UPSStats) HasStatus(flag string) bool {
+	return slices.Contains(strings.Fields(s.Status), flag)
+}
+
+// OnBattery reports whether the UPS is running on battery.
+func (s *UPSStats) OnBattery() bool {
+	return s.HasStatus("OB")
+}
+
+// LowBattery reports whether the UPS considers its battery critically low.
+func (s *UPSStats) LowBattery() bool {
+	return s.HasStatus("LB")
+}
+
+// ForcedShutdown reports whether the NUT primary has ordered all systems
+// powered by the UPS to shut down.
+func (s *UPSStats) ForcedShutdown() bool {
+	return s.HasStatus("FSD")
+}
+
+type UPS interface {
+	// GetStats returns the UPS state, or nil and no error when no UPS is configured.
+	GetStats(ctx context.Context) (*UPSStats, error)
+}
+
+// UPSOptions configures the NUT client.
+type UPSOptions struct {
+	// Address is the host[:port] of upsd; empty disables the client.
+	Address string
+	// Name is the UPS name as configured in ups.conf.
+	Name string
+}
+
+type upsImpl struct {
+	address string
+	name    string
+}
+
+// NewUPS creates a client for the UPS opts.Name on the upsd at opts.Address.
+func NewUPS(opts UPSOptions) UPS {
+	address := opts.Address
+	if address != "" {
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			address = net.JoinHostPort(address, defaultUPSPort)
+		}
+	}
+	return &upsImpl{
+		address: address,
+		name:    opts.Name,
+	}
+}
+
+// GetStats opens a connection to upsd for each poll, so a restarted upsd
+// does not leave a dead connection behind. Cancelling ctx closes the
+// connection.
+func (u *upsImpl) GetStats(ctx context.Context) (*UPSStats, error) {
+	if u.address == "" {
+		return nil, nil
+	}
+
+	dialer := net.Dialer{Timeout: upsTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", u.address)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to upsd: %w", err)
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	client := &nutClient{conn: conn, reader: bufio.NewReader(conn)}
+	defer client.logout()
+
+	stats := &UPSStats{Name: u.name}
+	if stats.Status, err = client.getVar(u.name, "ups.status"); err != nil {
+		return nil, err
+	}
+
+	optional := []struct {
+		name  string
+		value *float64
+		known *bool
+	}{
+		{"battery.charge", &stats.Charge, &stats.ChargeKnown},
+		{"ups.load", &stats.Load, &stats.LoadKnown},
+	}
+	for _, v := range optional {
+		*v.value, *v.known, err = client.getFloat(u.name, v.name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var runtime float64
+	if runtime, stats.RuntimeKnown, err = client.getFloat(u.name, "battery.runtime"); err != nil {
+		return nil, err
+	}
+	stats.Runtime = time.Duration(runtime * float64(time.Second))
+
+	return stats, nil
+}
+
+// nutClient speaks the upsd network protocol described in NUT's
+// docs/net-protocol.txt: one request per line, one response line each.
+type nutClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// getVar returns a variable's value:
+//
+//	GET VAR ups battery.charge
+//	VAR ups battery.charge "100"
+func (c *nutClient) getVar(ups, name string) (string, error) {
+	response, err := c.request(fmt.Sprintf("GET VAR %s %s", ups, name))
+	if err != nil {
+		return "", err
+	}
+
+	prefix := fmt.Sprintf("VAR %s %s ", ups, name)
+	quoted, ok := strings.CutPrefix(response, prefix)
+	if !ok {
+		return "", fmt.Errorf("unexpected response %q: %w", response, ErrUPSProtocol)
+	}
+	value, err := strconv.Unquote(quoted)
+	if err != nil {
+		return "", fmt.Errorf("unexpected value %q: %w", quoted, ErrUPSProtocol)
+	}
+	return value, nil
+}
+
+// getFloat returns a numeric variable. A variable the driver does not
+// provide is reported as not known rather than as an error.
+func (c *nutClient) getFloat(ups, name string) (float64, bool, error) {
+	value, err := c.getVar(ups, name)
+	if err != nil {
+		if errors.Is(err, ErrUPSVarNotSupported) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("parsing %s %q: %w", name, value, err)
+	}
+	return f, true, nil
+}
+
+// request sends one line and returns the response line. "ERR <code>"
+// responses are returned as errors wrapping ErrUPSProtocol, or
+// ErrUPSVarNotSupported for a variable the driver does not provide.
+func (c *nutClient) request(line string) (string, error) {
+	if err := c.conn.SetDeadline(time.Now().Add(upsTimeout)); err != nil {
+		return "", err
+	}
+	if _, err := fmt.Fprintf(c.conn, "%s\n", line); err != nil {
+		return "", fmt.Errorf("sending %q: %w", line, err)
+	}
+	response, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("reading response to %q: %w", line, err)
+	}
+	response = strings.TrimRight(response, "\r\n")
+	if code, ok := strings.CutPrefix(response, "ERR "); ok {
+		if code == "VAR-NOT-SUPPORTED" {
+			return "", fmt.Errorf("%s: %w", line, ErrUPSVarNotSupported)
+		}
+		return "", fmt.Errorf("%s: %s: %w", line, code, ErrUPSProtocol)
+	}
+	return response, nil
+}
+
+// logout ends the session politely; upsd would otherwise log a dropped connection.
+func (c *nutClient) logout() {
+	_, _ = c.request("LOGOUT")
+}
@@ -0,0 +1,165 @@
+package resources
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type UPSTestSuite struct {
+	suite.Suite
+
+	listener net.Listener
+	mu       sync.Mutex
+	// vars are the variables the fake upsd serves for the UPS "ups".
+	vars map[string]string
+	// logouts counts sessions that ended with LOGOUT.
+	logouts int
+}
+
+func TestUPSTestSuite(t *testing.T) {
+	suite.Run(t, new(UPSTestSuite))
+}
+
+// SetupTest starts a fake upsd on a local port serving one UPS on battery.
+func (s *UPSTestSuite) SetupTest() {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	s.Require().NoError(err)
+	s.listener = listener
+	s.T().Cleanup(func() { listener.Close() })
+
+	s.vars = map[string]string{
+		"ups.status":      "OB DISCHRG",
+		"battery.charge":  "87",
+		"battery.runtime": "1380",
+		"ups.load":        "23.5",
+	}
+	s.logouts = 0
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+}
+
+// serve answers GET VAR and LOGOUT like upsd does.
+func (s *UPSTestSuite) serve(conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		switch {
+		case len(fields) == 1 && fields[0] == "LOGOUT":
+			s.mu.Lock()
+			s.logouts++
+			s.mu.Unlock()
+			fmt.Fprint(conn, "OK Goodbye\n")
+			return
+		case len(fields) == 4 && fields[0] == "GET" && fields[1] == "VAR":
+			if fields[2] != "ups" {
+				fmt.Fprint(conn, "ERR UNKNOWN-UPS\n")
+				continue
+			}
+			s.mu.Lock()
+			value, ok := s.vars[fields[3]]
+			s.mu.Unlock()
+			if !ok {
+				fmt.Fprint(conn, "ERR VAR-NOT-SUPPORTED\n")
+				continue
+			}
+			fmt.Fprintf(conn, "VAR %s %s %q\n", fields[2], fields[3], value)
+		default:
+			fmt.Fprint(conn, "ERR UNKNOWN-COMMAND\n")
+		}
+	}
+}
+
+func (s *UPSTestSuite) TestGetStats() {
+	ups := NewUPS(UPSOptions{Address: s.listener.Addr().String(), Name: "ups"})
+
+	stats, err := ups.GetStats(context.Background())
+	s.Require().NoError(err)
+	s.Equal("ups", stats.Name)
+	s.Equal("OB DISCHRG", stats.Status)
+	s.True(stats.OnBattery())
+	s.False(stats.LowBattery())
+	s.False(stats.ForcedShutdown())
+	s.True(stats.ChargeKnown)
+	s.InDelta(87.0, stats.Charge, 0.001)
+	s.True(stats.RuntimeKnown)
+	s.Equal(23*time.Minute, stats.Runtime)
+	s.True(stats.LoadKnown)
+	s.InDelta(23.5, stats.Load, 0.001)
+
+	s.Eventually(func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.logouts == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (s *UPSTestSuite) TestGetStatsUnsupportedVariables() {
+	s.mu.Lock()
+	s.vars = map[string]string{"ups.status": "OL CHRG LB"}
+	s.mu.Unlock()
+
+	stats, err := NewUPS(UPSOptions{Address: s.listener.Addr().String(), Name: "ups"}).GetStats(context.Background())
+	s.Require().NoError(err)
+	s.False(stats.OnBattery())
+	s.True(stats.LowBattery())
+	s.False(stats.ChargeKnown)
+	s.False(stats.RuntimeKnown)
+	s.False(stats.LoadKnown)
+}
+
+func (s *UPSTestSuite) TestGetStatsUnknownUPS() {
+	_, err := NewUPS(UPSOptions{Address: s.listener.Addr().String(), Name: "other"}).GetStats(context.Background())
+	s.ErrorIs(err, ErrUPSProtocol)
+	s.ErrorContains(err, "UNKNOWN-UPS")
+}
+
+func (s *UPSTestSuite) TestGetStatsInvalidValue() {
+	s.mu.Lock()
+	s.vars["battery.charge"] = "n/a"
+	s.mu.Unlock()
+
+	_, err := NewUPS(UPSOptions{Address: s.listener.Addr().String(), Name: "ups"}).GetStats(context.Background())
+	s.ErrorContains(err, "battery.charge")
+}
+
+func (s *UPSTestSuite) TestGetStatsDisabled() {
+	stats, err := NewUPS(UPSOptions{Name: "ups"}).GetStats(context.Background())
+	s.NoError(err)
+	s.Nil(stats)
+}
+
+func (s *UPSTestSuite) TestGetStatsUnreachable() {
+	address := s.listener.Addr().String()
+	s.listener.Close()
+
+	_, err := NewUPS(UPSOptions{Address: address, Name: "ups"}).GetStats(context.Background())
+	s.Error(err)
+}
+
+func (s *UPSTestSuite) TestDefaultPort() {
+	ups, ok := NewUPS(UPSOptions{Address: "nas.local", Name: "ups"}).(*upsImpl)
+	s.Require().True(ok)
+	s.Equal("nas.local:3493", ups.address)
+
+	ups, ok = NewUPS(UPSOptions{Address: "[::1]:4000", Name: "ups"}).(*upsImpl)
+	s.Require().True(ok)
+	s.Equal("[::1]:4000", ups.address)
+}
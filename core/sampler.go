@@ -0,0 +1,286 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/czechbol/lumeon/app/config"
+	"github.com/czechbol/lumeon/core/resources"
+)
+
+// sampleStaleAfter is how many missed intervals make a sample stale.
+const sampleStaleAfter = 3
+
+var (
+	ErrSampleNotReady = errors.New("no sample taken yet")
+	ErrSampleStale    = errors.New("sample is stale")
+)
+
+// Sample is the latest reading of one resource prober.
+type Sample[T any] struct {
+	// Value is the last successful reading; it is kept when a later sample fails.
+	Value T
+	// Err is the error of the most recent attempt, nil if it succeeded.
+	Err error
+	// UpdatedAt is when Value was read; zero until the first success.
+	UpdatedAt time.Time
+	// CheckedAt is when the prober was last called, successfully or not.
+	CheckedAt time.Time
+	// Interval is how often the prober is sampled.
+	Interval time.Duration
+}
+
+// Ready reports whether the sample holds a value.
+func (s *Sample[T]) Ready() bool {
+	return !s.UpdatedAt.IsZero()
+}
+
+// Stale reports whether the value is missing or older than a few sampling intervals.
+func (s *Sample[T]) Stale(now time.Time) bool {
+	return !s.Ready() || now.Sub(s.UpdatedAt) > sampleStaleAfter*s.Interval
+}
+
+// sampleError returns why sample should not be acted on: the last attempt
+// failed, no value has been read yet, or the value is stale.
+func sampleError[T any](sample *Sample[T]) error {
+	switch {
+	case sample.Err != nil:
+		return sample.Err
+	case !sample.Ready():
+		return ErrSampleNotReady
+	case sample.Stale(time.Now()):
+		return fmt.Errorf("last updated %s ago: %w", time.Since(sample.UpdatedAt).Round(time.Second), ErrSampleStale)
+	}
+	return nil
+}
+
+// sampleValue returns the last value read by the prober, even if a later
+// attempt failed, or an error if it has never succeeded.
+func sampleValue[T any](sample *Sample[T]) (T, error) {
+	if !sample.Ready() {
+		var zero T
+		if sample.Err != nil {
+			return zero, sample.Err
+		}
+		return zero, ErrSampleNotReady
+	}
+	return sample.Value, nil
+}
+
+// Snapshot is an immutable set of the latest resource readings. A new
+// Snapshot is published whenever any prober is sampled. Consumers must not
+// modify it or anything it references.
+type Snapshot struct {
+	CPU         Sample[*resources.CPUStats]
+	Memory      Sample[*resources.MemoryStats]
+	Network     Sample[map[string]*resources.NetworkStats]
+	DiskIO      Sample[map[string]*resources.DiskIOStats]
+	Drives      Sample[[]resources.HDDStats]
+	Filesystems Sample[[]resources.FilesystemStats]
+	Pools       Sample[[]resources.Pool]
+	System      Sample[*resources.SystemStats]
+	Containers  Sample[[]resources.ContainerStats]
+	Systemd     Sample[*resources.SystemdStats]
+	UPS         Sample[*resources.UPSStats]
+}
+
+// Sampler polls every resource prober on its own schedule and publishes
+// the results as snapshots. It is the only caller of the probers, so rate
+// calculations do not depend on how many consumers there are.
+type Sampler interface {
+	IsRunning() bool
+	Start(ctx context.Context) error
+	Shutdown(ctx context.Context) error
+	// Snapshot returns the latest snapshot without locking. It never returns nil.
+	Snapshot() *Snapshot
+}
+
+// Probers groups the resource probers read by the Sampler.
+type Probers struct {
+	CPU         resources.CPU
+	Memory      resources.Memory
+	Network     resources.Network
+	DiskIO      resources.DiskIO
+	Drives      resources.HDD
+	Filesystems resources.Filesystems
+	Storage     resources.Storage
+	System      resources.System
+	Containers  resources.Containers
+	Systemd     resources.Systemd
+	UPS         resources.UPS
+}
+
+type samplerImpl struct {
+	mutex        sync.RWMutex
+	running      bool
+	probers      Probers
+	intervals    config.SamplerIntervals
+	ctx          context.Context
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
+	shutdownChan chan struct{}
+
+	snapshot atomic.Pointer[Snapshot]
+	// publishMu serializes publishers; readers never take it.
+	publishMu sync.Mutex
+}
+
+func NewSampler(probers Probers, samplerConfig config.SamplerConfig) Sampler {
+	intervals := samplerConfig.Intervals()
+	s := &samplerImpl{
+		probers:      probers,
+		intervals:    intervals,
+		shutdownChan: make(chan struct{}),
+	}
+
+	initial := &Snapshot{}
+	initial.CPU.Interval = intervals.CPU
+	initial.Memory.Interval = intervals.Memory
+	initial.Network.Interval = intervals.Network
+	initial.DiskIO.Interval = intervals.DiskIO
+	initial.Drives.Interval = intervals.Drives
+	initial.Filesystems.Interval = intervals.Filesystems
+	initial.Pools.Interval = intervals.Pools
+	initial.System.Interval = intervals.System
+	initial.Containers.Interval = intervals.Containers
+	initial.Systemd.Interval = intervals.Systemd
+	initial.UPS.Interval = intervals.UPS
+	s.snapshot.Store(initial)
+
+	return s
+}
+
+func (s *samplerImpl) IsRunning() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.running
+}
+
+func (s *samplerImpl) Start(ctx context.Context) error {
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	s.mutex.Lock()
+	if s.running {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.running = true
+	s.mutex.Unlock()
+
+	slog.Info("starting sampler")
+
+	p := s.probers
+	startSampling(s, "cpu", s.intervals.CPU, p.CPU.GetStats,
+		func(snap *Snapshot) *Sample[*resources.CPUStats] { return &snap.CPU })
+	startSampling(s, "memory", s.intervals.Memory, p.Memory.GetStats,
+		func(snap *Snapshot) *Sample[*resources.MemoryStats] { return &snap.Memory })
+	startSampling(s, "network", s.intervals.Network, p.Network.GetAllInterfaceStats,
+		func(snap *Snapshot) *Sample[map[string]*resources.NetworkStats] { return &snap.Network })
+	startSampling(s, "diskio", s.intervals.DiskIO, p.DiskIO.GetAllDeviceStats,
+		func(snap *Snapshot) *Sample[map[string]*resources.DiskIOStats] { return &snap.DiskIO })
+	startSampling(s, "drives", s.intervals.Drives, p.Drives.GetStats,
+		func(snap *Snapshot) *Sample[[]resources.HDDStats] { return &snap.Drives })
+	startSampling(s, "filesystems", s.intervals.Filesystems, p.Filesystems.GetStats,
+		func(snap *Snapshot) *Sample[[]resources.FilesystemStats] { return &snap.Filesystems })
+	startSampling(s, "pools", s.intervals.Pools, p.Storage.GetPools,
+		func(snap *Snapshot) *Sample[[]resources.Pool] { return &snap.Pools })
+	startSampling(s, "system", s.intervals.System, p.System.GetStats,
+		func(snap *Snapshot) *Sample[*resources.SystemStats] { return &snap.System })
+	startSampling(s, "containers", s.intervals.Containers, p.Containers.GetStats,
+		func(snap *Snapshot) *Sample[[]resources.ContainerStats] { return &snap.Containers })
+	startSampling(s, "systemd", s.intervals.Systemd, p.Systemd.GetStats,
+		func(snap *Snapshot) *Sample[*resources.SystemdStats] { return &snap.Systemd })
+	startSampling(s, "ups", s.intervals.UPS, p.UPS.GetStats,
+		func(snap *Snapshot) *Sample[*resources.UPSStats] { return &snap.UPS })
+
+	go func() {
+		s.wg.Wait()
+		close(s.shutdownChan)
+	}()
+
+	return nil
+}
+
+func (s *samplerImpl) Shutdown(ctx context.Context) error {
+	s.cancel()
+
+	select {
+	case <-s.shutdownChan:
+		slog.Info("sampler stopped gracefully")
+	case <-ctx.Done():
+		slog.Warn("shutdown context expired before sampler could stop")
+	}
+
+	s.mutex.Lock()
+	s.running = false
+	s.mutex.Unlock()
+
+	return nil
+}
+
+func (s *samplerImpl) Snapshot() *Snapshot {
+	return s.snapshot.Load()
+}
+
+// publish stores a copy of the current snapshot with update applied.
+func (s *samplerImpl) publish(update func(snap *Snapshot)) {
+	s.publishMu.Lock()
+	defer s.publishMu.Unlock()
+
+	next := *s.snapshot.Load()
+	update(&next)
+	s.snapshot.Store(&next)
+}
+
+// startSampling runs probe every interval in its own goroutine, so a slow
+// prober (smartctl on many drives) does not delay the others. The first
+// sample is taken immediately. probe is given the sampler's context, which
+// interrupts it on shutdown.
+func startSampling[T any](
+	s *samplerImpl,
+	name string,
+	interval time.Duration,
+	probe func(ctx context.Context) (T, error),
+	field func(snap *Snapshot) *Sample[T],
+) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			value, err := probe(s.ctx)
+			now := time.Now()
+			if s.ctx.Err() != nil {
+				// Interrupted by the shutdown; the error says nothing
+				// about the resource.
+				return
+			}
+			if err != nil {
+				slog.Warn("sampling failed", "prober", name, "error", err)
+			}
+
+			s.publish(func(snap *Snapshot) {
+				sample := field(snap)
+				sample.Err = err
+				sample.CheckedAt = now
+				if err == nil {
+					sample.Value = value
+					sample.UpdatedAt = now
+				}
+			})
+
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,206 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/czechbol/lumeon/app/config"
+	"github.com/czechbol/lumeon/core/resources"
+	"github.com/czechbol/lumeon/core/resources/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var errProbe = errors.New("probe failed")
+
+type SamplerTestSuite struct {
+	suite.Suite
+	memoryFails atomic.Bool
+	sampler     Sampler
+}
+
+func TestSamplerTestSuite(t *testing.T) {
+	suite.Run(t, new(SamplerTestSuite))
+}
+
+func (s *SamplerTestSuite) SetupTest() {
+	s.memoryFails.Store(false)
+
+	var cpuCalls atomic.Int64
+	s.sampler = NewSampler(Probers{
+		CPU: &mock.CPUMock{GetStatsHandler: func(context.Context) (*resources.CPUStats, error) {
+			return &resources.CPUStats{UsagePercent: float64(cpuCalls.Add(1))}, nil
+		}},
+		Memory: &mock.MemoryMock{GetStatsHandler: func(context.Context) (*resources.MemoryStats, error) {
+			if s.memoryFails.Load() {
+				return nil, errProbe
+			}
+			return &resources.MemoryStats{Total: 1024}, nil
+		}},
+		Network: &mock.NetworkMock{
+			GetAllInterfaceStatsHandler: func(context.Context) (map[string]*resources.NetworkStats, error) {
+				return map[string]*resources.NetworkStats{"eth0": {Interface: "eth0"}}, nil
+			},
+		},
+		DiskIO: &mock.DiskIOMock{
+			GetAllDeviceStatsHandler: func(context.Context) (map[string]*resources.DiskIOStats, error) {
+				return nil, nil
+			},
+		},
+		Drives: &mock.HDDMock{GetStatsHandler: func(context.Context) ([]resources.HDDStats, error) {
+			return nil, errProbe
+		}},
+		Filesystems: &mock.FilesystemsMock{GetStatsHandler: func(context.Context) ([]resources.FilesystemStats, error) {
+			return nil, nil
+		}},
+		Storage: &mock.StorageMock{GetPoolsHandler: func(context.Context) ([]resources.Pool, error) {
+			return nil, nil
+		}},
+		System: &mock.SystemMock{GetStatsHandler: func(context.Context) (*resources.SystemStats, error) {
+			return &resources.SystemStats{}, nil
+		}},
+		Containers: &mock.ContainersMock{GetStatsHandler: func(context.Context) ([]resources.ContainerStats, error) {
+			return nil, nil
+		}},
+		Systemd: &mock.SystemdMock{GetStatsHandler: func(context.Context) (*resources.SystemdStats, error) {
+			return &resources.SystemdStats{}, nil
+		}},
+		UPS: &mock.UPSMock{GetStatsHandler: func(context.Context) (*resources.UPSStats, error) {
+			return nil, nil
+		}},
+	}, config.NewSamplerConfig(config.SamplerIntervals{
+		CPU:         10 * time.Millisecond,
+		Memory:      10 * time.Millisecond,
+		Network:     time.Hour,
+		DiskIO:      time.Hour,
+		Drives:      time.Hour,
+		Filesystems: time.Hour,
+		Pools:       time.Hour,
+		System:      time.Hour,
+		Containers:  time.Hour,
+		Systemd:     time.Hour,
+		UPS:         time.Hour,
+	}))
+}
+
+func (s *SamplerTestSuite) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Require().NoError(s.sampler.Start(ctx))
+	s.T().Cleanup(func() {
+		cancel()
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), time.Second)
+		defer shutdownCancel()
+		s.NoError(s.sampler.Shutdown(shutdownCtx))
+	})
+}
+
+func (s *SamplerTestSuite) TestSnapshotBeforeStart() {
+	snap := s.sampler.Snapshot()
+	s.Require().NotNil(snap)
+	s.False(snap.CPU.Ready())
+	s.True(snap.CPU.Stale(time.Now()))
+	s.Equal(10*time.Millisecond, snap.CPU.Interval)
+
+	_, err := sampleValue(&snap.CPU)
+	s.ErrorIs(err, ErrSampleNotReady)
+	s.ErrorIs(sampleError(&snap.CPU), ErrSampleNotReady)
+}
+
+func (s *SamplerTestSuite) TestSamplesEachProber() {
+	s.start()
+
+	s.Eventually(func() bool {
+		snap := s.sampler.Snapshot()
+		return snap.CPU.Ready() && snap.CPU.Value.UsagePercent >= 3
+	}, time.Second, 5*time.Millisecond, "cpu is sampled repeatedly")
+
+	snap := s.sampler.Snapshot()
+	s.True(snap.Network.Ready())
+	s.Contains(snap.Network.Value, "eth0")
+	s.NoError(sampleError(&snap.Network))
+
+	// A prober that never succeeded reports its error.
+	s.False(snap.Drives.Ready())
+	s.ErrorIs(snap.Drives.Err, errProbe)
+	_, err := sampleValue(&snap.Drives)
+	s.ErrorIs(err, errProbe)
+}
+
+func (s *SamplerTestSuite) TestFailedSampleKeepsLastValue() {
+	s.start()
+
+	s.Eventually(func() bool {
+		return s.sampler.Snapshot().Memory.Ready()
+	}, time.Second, 5*time.Millisecond)
+	updatedAt := s.sampler.Snapshot().Memory.UpdatedAt
+
+	s.memoryFails.Store(true)
+	s.Eventually(func() bool {
+		return s.sampler.Snapshot().Memory.Err != nil
+	}, time.Second, 5*time.Millisecond)
+
+	snap := s.sampler.Snapshot()
+	s.ErrorIs(snap.Memory.Err, errProbe)
+	s.True(snap.Memory.CheckedAt.After(snap.Memory.UpdatedAt))
+	s.False(snap.Memory.UpdatedAt.Before(updatedAt))
+
+	// The display keeps showing the last value; the fan treats it as unusable.
+	stats, err := sampleValue(&snap.Memory)
+	s.Require().NoError(err)
+	s.Equal(uint64(1024), stats.Total)
+	s.ErrorIs(sampleError(&snap.Memory), errProbe)
+}
+
+func (s *SamplerTestSuite) TestSnapshotsAreIndependent() {
+	s.start()
+
+	s.Eventually(func() bool {
+		return s.sampler.Snapshot().CPU.Ready()
+	}, time.Second, 5*time.Millisecond)
+	first := s.sampler.Snapshot()
+	firstUsage := first.CPU.Value.UsagePercent
+
+	s.Eventually(func() bool {
+		return s.sampler.Snapshot().CPU.Value.UsagePercent > firstUsage
+	}, time.Second, 5*time.Millisecond)
+
+	// Publishing a new snapshot leaves earlier ones untouched.
+	s.InDelta(firstUsage, first.CPU.Value.UsagePercent, 0)
+}
+
+func (s *SamplerTestSuite) TestStale() {
+	now := time.Now()
+	sample := Sample[int]{Value: 1, UpdatedAt: now.Add(-time.Minute), Interval: 10 * time.Second}
+	s.True(sample.Stale(now))
+	s.ErrorIs(sampleError(&sample), ErrSampleStale)
+
+	sample.UpdatedAt = now.Add(-25 * time.Second)
+	s.False(sample.Stale(now))
+}
+
+func (s *SamplerTestSuite) TestShutdownInterruptsProbe() {
+	entered := make(chan struct{})
+	sampler, _ := s.sampler.(*samplerImpl)
+	sampler.probers.UPS = &mock.UPSMock{GetStatsHandler: func(ctx context.Context) (*resources.UPSStats, error) {
+		close(entered)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Require().NoError(s.sampler.Start(ctx))
+	<-entered
+	cancel()
+
+	start := time.Now()
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	s.Require().NoError(s.sampler.Shutdown(shutdownCtx))
+
+	s.Less(time.Since(start), time.Second)
+	s.Require().NoError(shutdownCtx.Err())
+	// The interrupted probe is not published as a failure.
+	s.NoError(s.sampler.Snapshot().UPS.Err)
+}
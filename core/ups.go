@@ -0,0 +1,254 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/czechbol/lumeon/app/config"
+	"github.com/czechbol/lumeon/core/hardware"
+	"github.com/czechbol/lumeon/core/resources"
+)
+
+const (
+	upsCheckInterval = 5 * time.Second
+	// upsDeadTime is how long upsd may not answer while the UPS is on
+	// battery before the battery is assumed to be running out, as upsmon's
+	// DEADTIME.
+	upsDeadTime = 15 * time.Second
+)
+
+const (
+	upsAlertOnBattery   = "ups:onbattery"
+	upsAlertShutdown    = "ups:shutdown"
+	upsAlertUnreachable = "ups:unreachable"
+)
+
+// UPSService shuts the system down cleanly when the UPS battery is about to
+// run out, and raises alerts while running on battery.
+type UPSService interface {
+	IsRunning() bool
+	Start(ctx context.Context) error
+	Shutdown(ctx context.Context) error
+}
+
+type upsServiceImpl struct {
+	mutex        sync.RWMutex
+	running      bool
+	system       hardware.System
+	sampler      Sampler
+	alerts       AlertManager
+	upsConfig    config.UPSConfig
+	ctx          context.Context
+	cancel       context.CancelFunc
+	shutdownChan chan struct{}
+
+	// onBatterySince is when the UPS was first seen on battery; zero while on line power.
+	onBatterySince time.Time
+	// shutdownIssued is set once the system shutdown succeeded, so it is not repeated.
+	shutdownIssued bool
+}
+
+func NewUPSService(
+	system hardware.System,
+	sampler Sampler,
+	alerts AlertManager,
+	upsConfig config.UPSConfig,
+) UPSService {
+	return &upsServiceImpl{
+		system:       system,
+		sampler:      sampler,
+		alerts:       alerts,
+		upsConfig:    upsConfig,
+		shutdownChan: make(chan struct{}),
+	}
+}
+
+func (us *upsServiceImpl) IsRunning() bool {
+	us.mutex.RLock()
+	defer us.mutex.RUnlock()
+	return us.running
+}
+
+func (us *upsServiceImpl) Start(ctx context.Context) error {
+	us.ctx, us.cancel = context.WithCancel(ctx)
+	us.mutex.Lock()
+	if us.running {
+		us.mutex.Unlock()
+		return nil
+	}
+	us.running = true
+	us.mutex.Unlock()
+
+	slog.Info("starting UPS monitor")
+
+	go us.upsLoop()
+
+	return nil
+}
+
+func (us *upsServiceImpl) Shutdown(ctx context.Context) error {
+	us.cancel()
+
+	select {
+	case <-us.shutdownChan:
+		slog.Info("UPS monitor stopped gracefully")
+	case <-ctx.Done():
+		slog.Warn("shutdown context expired before UPS monitor could stop")
+	}
+
+	us.mutex.Lock()
+	us.running = false
+	us.mutex.Unlock()
+
+	return nil
+}
+
+func (us *upsServiceImpl) upsLoop() {
+	defer close(us.shutdownChan)
+
+	ticker := time.NewTicker(upsCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		us.checkUPS()
+
+		select {
+		case <-us.ctx.Done():
+			slog.Info("stopping UPS monitor due to context cancellation")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkUPS evaluates the latest UPS sample.
+func (us *upsServiceImpl) checkUPS() {
+	if us.upsConfig.Address() == "" {
+		return
+	}
+	us.checkSample(&us.sampler.Snapshot().UPS, time.Now())
+}
+
+// checkSample evaluates sample. A failed or stale sample is not acted on
+// while the UPS was last seen on line power. If it was last seen on battery,
+// the system is shut down once upsd has not answered for upsDeadTime: the
+// battery may be running out unseen.
+func (us *upsServiceImpl) checkSample(sample *Sample[*resources.UPSStats], now time.Time) {
+	if err := sampleError(sample); err != nil {
+		if sample.Ready() || sample.Err != nil {
+			us.alerts.Raise(Alert{
+				Key:      upsAlertUnreachable,
+				Source:   "ups",
+				Severity: AlertWarning,
+				Title:    "UPS unreachable",
+				Message:  fmt.Sprintf("cannot read UPS %s from %s: %v", us.upsConfig.Name(), us.upsConfig.Address(), err),
+			})
+		}
+		if !us.onBatterySince.IsZero() {
+			us.evaluateLost(sample.UpdatedAt, now)
+		}
+		return
+	}
+	us.alerts.Resolve(upsAlertUnreachable)
+
+	us.evaluate(sample.Value, now)
+}
+
+// evaluateLost shuts the system down when upsd, last seen reporting the UPS
+// on battery at lastSeen, has not answered for upsDeadTime, or the UPS has
+// been on battery for the configured time.
+func (us *upsServiceImpl) evaluateLost(lastSeen, now time.Time) {
+	lost := now.Sub(lastSeen)
+	onBattery := now.Sub(us.onBatterySince)
+	after := us.upsConfig.ShutdownAfter()
+
+	var reason string
+	switch {
+	case lost >= upsDeadTime:
+		reason = fmt.Sprintf("the UPS is on battery and has not answered for %s", lost.Round(time.Second))
+	case after > 0 && onBattery >= after:
+		reason = fmt.Sprintf("on battery for %s", onBattery.Round(time.Second))
+	default:
+		return
+	}
+	us.shutdown(us.upsConfig.Name(), reason)
+}
+
+// evaluate raises or resolves the on-battery alert and shuts the system down
+// when stats meet one of the shutdown conditions.
+func (us *upsServiceImpl) evaluate(stats *resources.UPSStats, now time.Time) {
+	if !stats.OnBattery() && !stats.ForcedShutdown() {
+		if !us.onBatterySince.IsZero() {
+			slog.Info("UPS back on line power", "ups", stats.Name)
+		}
+		us.onBatterySince = time.Time{}
+		us.shutdownIssued = false
+		us.alerts.Resolve(upsAlertOnBattery)
+		us.alerts.Resolve(upsAlertShutdown)
+		return
+	}
+
+	if us.onBatterySince.IsZero() {
+		us.onBatterySince = now
+	}
+	onBattery := now.Sub(us.onBatterySince)
+
+	if stats.OnBattery() {
+		us.alerts.Raise(Alert{
+			Key:      upsAlertOnBattery,
+			Source:   "ups",
+			Severity: AlertWarning,
+			Title:    "UPS on battery",
+			Message: fmt.Sprintf("UPS %s on battery for %s, status %s",
+				stats.Name, onBattery.Round(time.Second), stats.Status),
+		})
+	}
+
+	if reason := us.shutdownReason(stats, onBattery); reason != "" {
+		us.shutdown(stats.Name, reason)
+	}
+}
+
+// shutdown shuts the system down for reason, unless it already did.
+func (us *upsServiceImpl) shutdown(ups, reason string) {
+	if us.shutdownIssued {
+		return
+	}
+
+	us.alerts.Raise(Alert{
+		Key:      upsAlertShutdown,
+		Source:   "ups",
+		Severity: AlertCritical,
+		Title:    "UPS shutdown",
+		Message:  fmt.Sprintf("shutting down: %s", reason),
+	})
+	slog.Warn("shutting down on UPS battery", "ups", ups, "reason", reason)
+	if err := us.system.Shutdown(); err != nil {
+		// Try again on the next check.
+		slog.Error("failed to shut down the system", "error", err)
+		return
+	}
+	us.shutdownIssued = true
+}
+
+// shutdownReason returns why the system should shut down, or an empty string
+// if it can keep running on battery.
+func (us *upsServiceImpl) shutdownReason(stats *resources.UPSStats, onBattery time.Duration) string {
+	threshold := us.upsConfig.ShutdownCharge()
+	after := us.upsConfig.ShutdownAfter()
+
+	switch {
+	case stats.ForcedShutdown():
+		return "the UPS primary requested a forced shutdown"
+	case stats.LowBattery():
+		return "the UPS reports a low battery"
+	case threshold > 0 && stats.ChargeKnown && stats.Charge <= float64(threshold):
+		return fmt.Sprintf("battery charge %.0f%% is at or below %d%%", stats.Charge, threshold)
+	case after > 0 && onBattery >= after:
+		return fmt.Sprintf("on battery for %s", onBattery.Round(time.Second))
+	}
+	return ""
+}
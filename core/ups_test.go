@@ -0,0 +1,161 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/czechbol/lumeon/app/config"
+	hwmock "github.com/czechbol/lumeon/core/hardware/mock"
+	"github.com/czechbol/lumeon/core/resources"
+	"github.com/stretchr/testify/suite"
+)
+
+var errShutdown = errors.New("shutdown failed")
+
+type UPSServiceTestSuite struct {
+	suite.Suite
+	system      *hwmock.SystemMock
+	shutdownErr error
+	alerts      AlertManager
+	service     *upsServiceImpl
+	now         time.Time
+}
+
+func TestUPSServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(UPSServiceTestSuite))
+}
+
+func (s *UPSServiceTestSuite) SetupTest() {
+	s.shutdownErr = nil
+	s.system = &hwmock.SystemMock{ShutdownHandler: func() error { return s.shutdownErr }}
+	s.alerts = NewAlertManager()
+	s.newService(10*time.Minute, 20)
+	s.now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+}
+
+func (s *UPSServiceTestSuite) newService(shutdownAfter time.Duration, shutdownCharge uint8) {
+	upsConfig := config.NewUPSConfig("localhost:3493", "ups", shutdownAfter, shutdownCharge)
+	service, ok := NewUPSService(s.system, nil, s.alerts, upsConfig).(*upsServiceImpl)
+	s.Require().True(ok)
+	s.service = service
+}
+
+func (s *UPSServiceTestSuite) evaluate(status string, charge float64, after time.Duration) {
+	s.service.evaluate(&resources.UPSStats{
+		Name:        "ups",
+		Status:      status,
+		Charge:      charge,
+		ChargeKnown: true,
+	}, s.now.Add(after))
+}
+
+func (s *UPSServiceTestSuite) activeKeys() []string {
+	var keys []string
+	for _, alert := range s.alerts.Active() {
+		keys = append(keys, alert.Key)
+	}
+	return keys
+}
+
+func (s *UPSServiceTestSuite) TestOnLinePower() {
+	s.evaluate("OL CHRG", 100, 0)
+	s.Zero(s.system.ShutdownHandlerCalled)
+	s.Empty(s.activeKeys())
+}
+
+func (s *UPSServiceTestSuite) TestShutdownAfterGracePeriod() {
+	s.evaluate("OB DISCHRG", 90, 0)
+	s.Equal([]string{upsAlertOnBattery}, s.activeKeys())
+
+	s.evaluate("OB DISCHRG", 80, 9*time.Minute)
+	s.Zero(s.system.ShutdownHandlerCalled)
+
+	s.evaluate("OB DISCHRG", 70, 10*time.Minute)
+	s.Equal(1, s.system.ShutdownHandlerCalled)
+	s.ElementsMatch([]string{upsAlertOnBattery, upsAlertShutdown}, s.activeKeys())
+
+	// Shutdown is only requested once.
+	s.evaluate("OB DISCHRG", 65, 11*time.Minute)
+	s.Equal(1, s.system.ShutdownHandlerCalled)
+}
+
+func (s *UPSServiceTestSuite) TestShutdownOnCharge() {
+	s.evaluate("OB DISCHRG", 21, 0)
+	s.Zero(s.system.ShutdownHandlerCalled)
+
+	s.evaluate("OB DISCHRG", 20, time.Minute)
+	s.Equal(1, s.system.ShutdownHandlerCalled)
+}
+
+func (s *UPSServiceTestSuite) TestShutdownOnLowBattery() {
+	s.newService(0, 0)
+
+	s.evaluate("OB DISCHRG", 50, 0)
+	s.evaluate("OB DISCHRG", 5, time.Hour)
+	s.Zero(s.system.ShutdownHandlerCalled)
+
+	s.evaluate("OB DISCHRG LB", 5, time.Hour)
+	s.Equal(1, s.system.ShutdownHandlerCalled)
+}
+
+func (s *UPSServiceTestSuite) TestShutdownOnForcedShutdown() {
+	s.evaluate("OL FSD", 100, 0)
+	s.Equal(1, s.system.ShutdownHandlerCalled)
+	s.Equal([]string{upsAlertShutdown}, s.activeKeys())
+}
+
+func (s *UPSServiceTestSuite) TestPowerRestored() {
+	s.evaluate("OB DISCHRG", 90, 0)
+	s.evaluate("OL CHRG", 90, 5*time.Minute)
+	s.Empty(s.activeKeys())
+
+	// The grace period starts over on the next outage.
+	s.evaluate("OB DISCHRG", 90, 12*time.Minute)
+	s.Zero(s.system.ShutdownHandlerCalled)
+}
+
+func (s *UPSServiceTestSuite) TestShutdownRetriedOnError() {
+	s.shutdownErr = errShutdown
+	s.evaluate("OB DISCHRG LB", 5, 0)
+	s.Equal(1, s.system.ShutdownHandlerCalled)
+
+	s.shutdownErr = nil
+	s.evaluate("OB DISCHRG LB", 5, upsCheckInterval)
+	s.Equal(2, s.system.ShutdownHandlerCalled)
+}
+
+// unreachable checks a sample of upsd failing to answer, after last answering
+// at s.now.
+func (s *UPSServiceTestSuite) unreachable(after time.Duration) {
+	s.service.checkSample(&Sample[*resources.UPSStats]{
+		Value:     &resources.UPSStats{Name: "ups", Status: "OB DISCHRG"},
+		Err:       errProbe,
+		UpdatedAt: s.now,
+		Interval:  upsCheckInterval,
+	}, s.now.Add(after))
+}
+
+func (s *UPSServiceTestSuite) TestShutdownOnBatteryUnreachable() {
+	s.evaluate("OB DISCHRG", 90, 0)
+
+	s.unreachable(upsCheckInterval)
+	s.Zero(s.system.ShutdownHandlerCalled)
+	s.ElementsMatch([]string{upsAlertOnBattery, upsAlertUnreachable}, s.activeKeys())
+
+	s.unreachable(upsDeadTime)
+	s.Equal(1, s.system.ShutdownHandlerCalled)
+	s.Contains(s.activeKeys(), upsAlertShutdown)
+
+	s.unreachable(upsDeadTime + upsCheckInterval)
+	s.Equal(1, s.system.ShutdownHandlerCalled)
+}
+
+func (s *UPSServiceTestSuite) TestOnLineUnreachable() {
+	s.evaluate("OL CHRG", 100, 0)
+
+	s.unreachable(time.Hour)
+
+	s.Zero(s.system.ShutdownHandlerCalled)
+	s.Equal([]string{upsAlertUnreachable}, s.activeKeys())
+}